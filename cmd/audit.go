@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/minamijoyo/myaws/myaws"
+)
+
+func init() {
+	RootCmd.AddCommand(newAuditCmd())
+}
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Manage the audit log of mutating API calls",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(
+		newAuditLsCmd(),
+	)
+
+	return cmd
+}
+
+func newAuditLsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List records of the audit log",
+		RunE:  runAuditLsCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("fields", "F", "Time Identity Profile Region API Resources Result", "Output fields list separated by space")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
+
+	viper.BindPFlag("audit.ls.fields", flags.Lookup("fields"))
+	viper.BindPFlag("audit.ls.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "audit.ls")
+
+	return cmd
+}
+
+func runAuditLsCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	options := myaws.AuditLsOptions{
+		Fields:     getFields("audit.ls.fields"),
+		ListFields: viper.GetBool("audit.ls.list-fields"),
+		Query:      getQueryOptions("audit.ls"),
+	}
+
+	return client.AuditLs(options)
+}
@@ -44,6 +44,7 @@ func newAutoscalingLsCmd() *cobra.Command {
 	flags.BoolP("all", "a", false, "List all autoscaling groups (by default, list autoscaling groups only having at least 1 attached instance)")
 
 	viper.BindPFlag("autoscaling.ls.all", flags.Lookup("all"))
+	addTargetFlags(cmd, "autoscaling.ls")
 
 	return cmd
 }
@@ -55,7 +56,8 @@ func runAutoscalingLsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.AutoscalingLsOptions{
-		All: viper.GetBool("autoscaling.ls.all"),
+		All:     viper.GetBool("autoscaling.ls.all"),
+		Targets: getTargetOptions("autoscaling.ls"),
 	}
 
 	return client.AutoscalingLs(options)
@@ -145,7 +147,7 @@ func runAutoscalingDetachCmd(cmd *cobra.Command, args []string) error {
 
 func newAutoscalingUpdateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "update AUTO_SCALING_GROUP_NAME",
+		Use:   "update [AUTO_SCALING_GROUP_NAME]",
 		Short: "Update autoscaling group",
 		RunE:  runAutoscalingUpdateCmd,
 	}
@@ -153,9 +155,11 @@ func newAutoscalingUpdateCmd() *cobra.Command {
 	flags := cmd.Flags()
 	flags.Int64P("desired-capacity", "c", -1, "The number of EC2 instances that should be running in the Auto Scaling group.")
 	flags.BoolP("wait", "w", false, "Wait until desired capacity instances are InService")
+	flags.BoolP("select", "i", false, "Choose an autoscaling group interactively")
 
 	viper.BindPFlag("autoscaling.update.desired-capacity", flags.Lookup("desired-capacity"))
 	viper.BindPFlag("autoscaling.update.wait", flags.Lookup("wait"))
+	viper.BindPFlag("autoscaling.update.select", flags.Lookup("select"))
 
 	return cmd
 }
@@ -166,9 +170,14 @@ func runAutoscalingUpdateCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
-	if len(args) == 0 {
+	selectGroup := viper.GetBool("autoscaling.update.select")
+	if len(args) == 0 && !selectGroup {
 		return errors.New("AUTO_SCALING_GROUP_NAME is required")
 	}
+	var asgName string
+	if len(args) > 0 {
+		asgName = args[0]
+	}
 
 	desiredCapacity := viper.GetInt64("autoscaling.update.desired-capacity")
 	if desiredCapacity == -1 {
@@ -176,9 +185,10 @@ func runAutoscalingUpdateCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.AutoscalingUpdateOptions{
-		AsgName:         args[0],
+		AsgName:         asgName,
 		DesiredCapacity: desiredCapacity,
 		Wait:            viper.GetBool("autoscaling.update.wait"),
+		Select:          selectGroup,
 	}
 
 	return client.AutoscalingUpdate(options)
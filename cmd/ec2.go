@@ -33,6 +33,9 @@ func newEC2Cmd() *cobra.Command {
 		newEC2StartCmd(),
 		newEC2StopCmd(),
 		newEC2SSHCmd(),
+		newEC2CpCmd(),
+		newEC2SessionCmd(),
+		newEC2PortForwardCmd(),
 	)
 
 	return cmd
@@ -51,13 +54,17 @@ func newEC2SLsCmd() *cobra.Command {
 	flags.StringP("filter-tag", "t", "",
 		"Filter instances by tag, such as \"Name:app-production\". The value of tag is assumed to be a partial match",
 	)
-	flags.StringP("fields", "F", "InstanceId InstanceType PublicIpAddress PrivateIpAddress AvailabilityZone StateName LaunchTime Tag:Name Tag:Service 'Tag:In Charge'", "Output fields list separated by space")
+	flags.StringP("fields", "F", "SnapshotId SnapshotStartTime Description Tag:Name", "Output fields list separated by space")
 	flags.StringP("domain", "D", "", "Please enter the domain you wish to search")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 	viper.BindPFlag("ec2.sls.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2.sls.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2.sls.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("ec2.sls.fields", flags.Lookup("fields"))
 	viper.BindPFlag("ec2.sls.domain", flags.Lookup("domain"))
+	viper.BindPFlag("ec2.sls.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "ec2.sls")
+	addTargetFlags(cmd, "ec2.sls")
 
 	return cmd
 }
@@ -69,11 +76,14 @@ func runEC2SLsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2SLsOptions{
-		All:       viper.GetBool("ec2.sls.all"),
-		Quiet:     viper.GetBool("ec2.sls.quiet"),
-		FilterTag: viper.GetString("ec2.sls.filter-tag"),
-		Fields:    viper.GetStringSlice("ec2.sls.fields"),
-		Domain:    viper.GetStringSlice("ec2.sls.domain"),
+		All:        viper.GetBool("ec2.sls.all"),
+		Quiet:      viper.GetBool("ec2.sls.quiet"),
+		FilterTag:  viper.GetString("ec2.sls.filter-tag"),
+		Fields:     getFields("ec2.sls.fields"),
+		Domain:     viper.GetStringSlice("ec2.sls.domain"),
+		ListFields: viper.GetBool("ec2.sls.list-fields"),
+		Query:      getQueryOptions("ec2.sls"),
+		Targets:    getTargetOptions("ec2.sls"),
 	}
 
 	return client.EC2SLs(options)
@@ -92,13 +102,17 @@ func newEC2ALsCmd() *cobra.Command {
 	flags.StringP("filter-tag", "t", "",
 		"Filter instances by tag, such as \"Name:app-production\". The value of tag is assumed to be a partial match",
 	)
-	flags.StringP("fields", "F", "InstanceId InstanceType PublicIpAddress PrivateIpAddress AvailabilityZone StateName LaunchTime Tag:Name Tag:Service 'Tag:In Charge'", "Output fields list separated by space")
+	flags.StringP("fields", "F", "ImageId AmiName CreationDate Tag:Name", "Output fields list separated by space")
 	flags.StringP("domain", "D", "", "Please enter the domain you wish to search")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 	viper.BindPFlag("ec2.als.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2.als.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2.als.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("ec2.als.fields", flags.Lookup("fields"))
 	viper.BindPFlag("ec2.als.domain", flags.Lookup("domain"))
+	viper.BindPFlag("ec2.als.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "ec2.als")
+	addTargetFlags(cmd, "ec2.als")
 
 	return cmd
 }
@@ -110,11 +124,14 @@ func runEC2ALsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2ALsOptions{
-		All:       viper.GetBool("ec2.als.all"),
-		Quiet:     viper.GetBool("ec2.als.quiet"),
-		FilterTag: viper.GetString("ec2.als.filter-tag"),
-		Fields:    viper.GetStringSlice("ec2.als.fields"),
-		Domain:    viper.GetStringSlice("ec2.als.domain"),
+		All:        viper.GetBool("ec2.als.all"),
+		Quiet:      viper.GetBool("ec2.als.quiet"),
+		FilterTag:  viper.GetString("ec2.als.filter-tag"),
+		Fields:     getFields("ec2.als.fields"),
+		Domain:     viper.GetStringSlice("ec2.als.domain"),
+		ListFields: viper.GetBool("ec2.als.list-fields"),
+		Query:      getQueryOptions("ec2.als"),
+		Targets:    getTargetOptions("ec2.als"),
 	}
 
 	return client.EC2ALs(options)
@@ -133,13 +150,17 @@ func newEC2ILsCmd() *cobra.Command {
 	flags.StringP("filter-tag", "t", "",
 		"Filter instances by tag, such as \"Name:app-production\". The value of tag is assumed to be a partial match",
 	)
-	flags.StringP("fields", "F", "InstanceId InstanceType PublicIpAddress PrivateIpAddress AvailabilityZone StateName LaunchTime Tag:Name Tag:Service 'Tag:In Charge'", "Output fields list separated by space")
+	flags.StringP("fields", "F", "PublicIp AllocationId InstanceId PrivateIpAddress AssociationId Tag:Name", "Output fields list separated by space")
 	flags.StringP("domain", "D", "", "Please enter the domain you wish to search")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 	viper.BindPFlag("ec2.ils.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2.ils.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2.ils.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("ec2.ils.fields", flags.Lookup("fields"))
 	viper.BindPFlag("ec2.ils.domain", flags.Lookup("domain"))
+	viper.BindPFlag("ec2.ils.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "ec2.ils")
+	addTargetFlags(cmd, "ec2.ils")
 
 	return cmd
 }
@@ -151,11 +172,14 @@ func runEC2ILsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2ILsOptions{
-		All:       viper.GetBool("ec2.ils.all"),
-		Quiet:     viper.GetBool("ec2.ils.quiet"),
-		FilterTag: viper.GetString("ec2.ils.filter-tag"),
-		Fields:    viper.GetStringSlice("ec2.ils.fields"),
-		Domain:    viper.GetStringSlice("ec2.ils.domain"),
+		All:        viper.GetBool("ec2.ils.all"),
+		Quiet:      viper.GetBool("ec2.ils.quiet"),
+		FilterTag:  viper.GetString("ec2.ils.filter-tag"),
+		Fields:     getFields("ec2.ils.fields"),
+		Domain:     viper.GetStringSlice("ec2.ils.domain"),
+		ListFields: viper.GetBool("ec2.ils.list-fields"),
+		Query:      getQueryOptions("ec2.ils"),
+		Targets:    getTargetOptions("ec2.ils"),
 	}
 
 	return client.EC2ILs(options)
@@ -174,13 +198,17 @@ func newEC2VLsCmd() *cobra.Command {
 	flags.StringP("filter-tag", "t", "",
 		"Filter instances by tag, such as \"Name:app-production\". The value of tag is assumed to be a partial match",
 	)
-	flags.StringP("fields", "F", "InstanceId InstanceType PublicIpAddress PrivateIpAddress AvailabilityZone StateName LaunchTime Tag:Name Tag:Service 'Tag:In Charge'", "Output fields list separated by space")
+	flags.StringP("fields", "F", "VolumeId VolumeType Size InstanceId Device Tag:Name", "Output fields list separated by space")
 	flags.StringP("domain", "D", "", "Please enter the domain you wish to search")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 	viper.BindPFlag("ec2.vls.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2.vls.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2.vls.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("ec2.vls.fields", flags.Lookup("fields"))
 	viper.BindPFlag("ec2.vls.domain", flags.Lookup("domain"))
+	viper.BindPFlag("ec2.vls.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "ec2.vls")
+	addTargetFlags(cmd, "ec2.vls")
 
 	return cmd
 }
@@ -192,11 +220,14 @@ func runEC2VLsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2VLsOptions{
-		All:       viper.GetBool("ec2.vls.all"),
-		Quiet:     viper.GetBool("ec2.vls.quiet"),
-		FilterTag: viper.GetString("ec2.vls.filter-tag"),
-		Fields:    viper.GetStringSlice("ec2.vls.fields"),
-		Domain:    viper.GetStringSlice("ec2.vls.domain"),
+		All:        viper.GetBool("ec2.vls.all"),
+		Quiet:      viper.GetBool("ec2.vls.quiet"),
+		FilterTag:  viper.GetString("ec2.vls.filter-tag"),
+		Fields:     getFields("ec2.vls.fields"),
+		Domain:     viper.GetStringSlice("ec2.vls.domain"),
+		ListFields: viper.GetBool("ec2.vls.list-fields"),
+		Query:      getQueryOptions("ec2.vls"),
+		Targets:    getTargetOptions("ec2.vls"),
 	}
 
 	return client.EC2VLs(options)
@@ -217,11 +248,15 @@ func newEC2LsCmd() *cobra.Command {
 	)
 	flags.StringP("fields", "F", "InstanceId InstanceType PublicIpAddress PrivateIpAddress AvailabilityZone StateName LaunchTime Tag:Name Tag:Service 'Tag:In Charge'", "Output fields list separated by space")
 	flags.StringP("domain", "D", "", "Please enter the domain you wish to search")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 	viper.BindPFlag("ec2.ls.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2.ls.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2.ls.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("ec2.ls.fields", flags.Lookup("fields"))
 	viper.BindPFlag("ec2.ls.domain", flags.Lookup("domain"))
+	viper.BindPFlag("ec2.ls.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "ec2.ls")
+	addTargetFlags(cmd, "ec2.ls")
 
 	return cmd
 }
@@ -233,11 +268,14 @@ func runEC2LsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2LsOptions{
-		All:       viper.GetBool("ec2.ls.all"),
-		Quiet:     viper.GetBool("ec2.ls.quiet"),
-		FilterTag: viper.GetString("ec2.ls.filter-tag"),
-		Fields:    viper.GetStringSlice("ec2.ls.fields"),
-		Domain:    viper.GetStringSlice("ec2.ls.domain"),
+		All:        viper.GetBool("ec2.ls.all"),
+		Quiet:      viper.GetBool("ec2.ls.quiet"),
+		FilterTag:  viper.GetString("ec2.ls.filter-tag"),
+		Fields:     getFields("ec2.ls.fields"),
+		Domain:     viper.GetStringSlice("ec2.ls.domain"),
+		ListFields: viper.GetBool("ec2.ls.list-fields"),
+		Query:      getQueryOptions("ec2.ls"),
+		Targets:    getTargetOptions("ec2.ls"),
 	}
 
 	return client.EC2Ls(options)
@@ -245,15 +283,17 @@ func runEC2LsCmd(cmd *cobra.Command, args []string) error {
 
 func newEC2StartCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "start INSTANCE_ID [...]",
+		Use:   "start [INSTANCE_ID...]",
 		Short: "Start EC2 instances",
 		RunE:  runEC2StartCmd,
 	}
 
 	flags := cmd.Flags()
 	flags.BoolP("wait", "w", false, "Wait until instance running")
+	flags.BoolP("select", "i", false, "Choose stopped instances interactively")
 
 	viper.BindPFlag("ec2.start.wait", flags.Lookup("wait"))
+	viper.BindPFlag("ec2.start.select", flags.Lookup("select"))
 
 	return cmd
 }
@@ -264,7 +304,8 @@ func runEC2StartCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
-	if len(args) == 0 {
+	selectInstances := viper.GetBool("ec2.start.select")
+	if len(args) == 0 && !selectInstances {
 		return errors.New("INSTANCE_ID is required")
 	}
 	instanceIds := aws.StringSlice(args)
@@ -272,6 +313,7 @@ func runEC2StartCmd(cmd *cobra.Command, args []string) error {
 	options := myaws.EC2StartOptions{
 		InstanceIds: instanceIds,
 		Wait:        viper.GetBool("ec2.start.wait"),
+		Select:      selectInstances,
 	}
 
 	return client.EC2Start(options)
@@ -279,15 +321,17 @@ func runEC2StartCmd(cmd *cobra.Command, args []string) error {
 
 func newEC2StopCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "stop INSTANCE_ID [...]",
+		Use:   "stop [INSTANCE_ID...]",
 		Short: "Stop EC2 instances",
 		RunE:  runEC2StopCmd,
 	}
 
 	flags := cmd.Flags()
 	flags.BoolP("wait", "w", false, "Wait until instance stopped")
+	flags.BoolP("select", "i", false, "Choose running instances interactively")
 
 	viper.BindPFlag("ec2.stop.wait", flags.Lookup("wait"))
+	viper.BindPFlag("ec2.stop.select", flags.Lookup("select"))
 
 	return cmd
 }
@@ -298,7 +342,8 @@ func runEC2StopCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
-	if len(args) == 0 {
+	selectInstances := viper.GetBool("ec2.stop.select")
+	if len(args) == 0 && !selectInstances {
 		return errors.New("INSTANCE_ID is required")
 	}
 	instanceIds := aws.StringSlice(args)
@@ -306,6 +351,7 @@ func runEC2StopCmd(cmd *cobra.Command, args []string) error {
 	options := myaws.EC2StopOptions{
 		InstanceIds: instanceIds,
 		Wait:        viper.GetBool("ec2.stop.wait"),
+		Select:      selectInstances,
 	}
 
 	return client.EC2Stop(options)
@@ -315,16 +361,62 @@ func newEC2SSHCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ssh [USER@]INSTANCE_NAME [COMMAND...]",
 		Short: "SSH to EC2 instances",
-		RunE:  runEC2SSHCmd,
+		Long: `SSH to EC2 instances
+
+The INSTANCE_NAME is a value of the Name tag. With --select, it can be omitted
+and matching instances are chosen interactively.
+
+Defaults of the user, port and identity files are read from ~/.ssh/config,
+where a Host pattern is matched against the Name tag, the instance ID and the
+IP address. Host keys are verified with ~/.ssh/known_hosts. Keys in ssh-agent
+are used if SSH_AUTH_SOCK is set.
+
+With --jump, instances are connected through a jump host by their private IP.
+-L and -R forward ports like ssh, and --no-command (-N) only forwards them
+without starting a shell.
+
+The exit status of the remote shell or command is used as the exit code. For
+multiple hosts, it's the largest one. As with ssh, it's 255 if the connection
+failed.`,
+		RunE: runEC2SSHCmd,
 	}
 
 	flags := cmd.Flags()
 	flags.StringP("login-name", "l", "", "Login username")
-	flags.StringP("identity-file", "i", "~/.ssh/id_rsa", "SSH private key file")
+	flags.StringP("identity-file", "i", "", "SSH private key file (default: IdentityFile in ~/.ssh/config or ~/.ssh/id_*)")
+	flags.StringP("port", "p", "", "Port to connect to (default: Port in ~/.ssh/config or 22)")
+	flags.BoolP("accept-new", "", false, "Add host keys of unknown hosts to ~/.ssh/known_hosts")
+	flags.BoolP("forward-agent", "A", false, "Enable forwarding of ssh-agent")
+	flags.StringP("jump", "J", "", "Connect through a jump host specified by [USER@]NAME, where NAME is a Name tag or TAG:VALUE")
+	flags.StringArrayP("local-forward", "L", []string{}, "Forward a local port to the remote side by [bind_address:]port:host:hostport")
+	flags.StringArrayP("remote-forward", "R", []string{}, "Forward a remote port to the local side by [bind_address:]port:host:hostport")
+	flags.BoolP("no-command", "N", false, "Only forward ports without starting a shell")
+	flags.DurationP("keepalive", "", 0, "Interval of keepalive messages (default: ServerAliveInterval in ~/.ssh/config or 30s)")
 	flags.BoolP("private", "", false, "Use private IP to connect")
+	// -i is used by --identity-file as well as ssh.
+	flags.BoolP("select", "", false, "Choose instances interactively if multiple instances are found")
+	flags.IntP("parallel", "", 1, "Number of hosts to execute the command at once")
+	flags.StringP("output-mode", "", myaws.SSHOutputGroup, "How to print output of the command on hosts ("+strings.Join(myaws.SSHOutputs, "|")+")")
+	flags.BoolP("fail-fast", "", false, "Stop executing the command after the first failure")
+	flags.BoolP("no-pty", "", false, "Execute the command without a pseudo terminal")
+	flags.DurationP("timeout", "", 0, "Timeout of the command per host, such as 30s (0 means no timeout)")
 
 	viper.BindPFlag("ec2.ssh.login-name", flags.Lookup("login-name"))
+	viper.BindPFlag("ec2.ssh.select", flags.Lookup("select"))
+	viper.BindPFlag("ec2.ssh.parallel", flags.Lookup("parallel"))
+	viper.BindPFlag("ec2.ssh.output-mode", flags.Lookup("output-mode"))
+	viper.BindPFlag("ec2.ssh.fail-fast", flags.Lookup("fail-fast"))
+	viper.BindPFlag("ec2.ssh.no-pty", flags.Lookup("no-pty"))
+	viper.BindPFlag("ec2.ssh.timeout", flags.Lookup("timeout"))
 	viper.BindPFlag("ec2.ssh.identity-file", flags.Lookup("identity-file"))
+	viper.BindPFlag("ec2.ssh.port", flags.Lookup("port"))
+	viper.BindPFlag("ec2.ssh.accept-new", flags.Lookup("accept-new"))
+	viper.BindPFlag("ec2.ssh.forward-agent", flags.Lookup("forward-agent"))
+	viper.BindPFlag("ec2.ssh.jump", flags.Lookup("jump"))
+	viper.BindPFlag("ec2.ssh.local-forward", flags.Lookup("local-forward"))
+	viper.BindPFlag("ec2.ssh.remote-forward", flags.Lookup("remote-forward"))
+	viper.BindPFlag("ec2.ssh.no-command", flags.Lookup("no-command"))
+	viper.BindPFlag("ec2.ssh.keepalive", flags.Lookup("keepalive"))
 	viper.BindPFlag("ec2.ssh.private", flags.Lookup("private"))
 
 	return cmd
@@ -336,9 +428,13 @@ func runEC2SSHCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
-	if len(args) == 0 {
+	selectInstances := viper.GetBool("ec2.ssh.select")
+	if len(args) == 0 && !selectInstances {
 		return errors.New("Instance name is required")
 	}
+	if len(args) == 0 {
+		args = []string{""}
+	}
 
 	var loginName, instanceName string
 	if strings.Contains(args[0], "@") {
@@ -351,18 +447,190 @@ func runEC2SSHCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	filterTag := "Name:" + instanceName
+	if instanceName == "" {
+		filterTag = ""
+	}
 
 	var command string
 	if len(args) >= 2 {
 		command = strings.Join(args[1:], " ")
 	}
 	options := myaws.EC2SSHOptions{
-		FilterTag:    filterTag,
-		LoginName:    loginName,
-		IdentityFile: viper.GetString("ec2.ssh.identity-file"),
-		Private:      viper.GetBool("ec2.ssh.private"),
-		Command:      command,
+		FilterTag:      filterTag,
+		LoginName:      loginName,
+		IdentityFile:   viper.GetString("ec2.ssh.identity-file"),
+		Private:        viper.GetBool("ec2.ssh.private"),
+		Command:        command,
+		Select:         selectInstances,
+		Parallel:       viper.GetInt("ec2.ssh.parallel"),
+		OutputMode:     viper.GetString("ec2.ssh.output-mode"),
+		FailFast:       viper.GetBool("ec2.ssh.fail-fast"),
+		NoPty:          viper.GetBool("ec2.ssh.no-pty"),
+		Timeout:        viper.GetDuration("ec2.ssh.timeout"),
+		Port:           viper.GetString("ec2.ssh.port"),
+		AcceptNew:      viper.GetBool("ec2.ssh.accept-new"),
+		ForwardAgent:   viper.GetBool("ec2.ssh.forward-agent"),
+		Jump:           viper.GetString("ec2.ssh.jump"),
+		LocalForwards:  viper.GetStringSlice("ec2.ssh.local-forward"),
+		RemoteForwards: viper.GetStringSlice("ec2.ssh.remote-forward"),
+		NoCommand:      viper.GetBool("ec2.ssh.no-command"),
+		KeepAlive:      viper.GetDuration("ec2.ssh.keepalive"),
 	}
 
 	return client.EC2SSH(options)
 }
+
+func newEC2CpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cp SOURCE DESTINATION",
+		Short: "Copy files to or from EC2 instances",
+		Long: `Copy files to or from EC2 instances
+
+Either SOURCE or DESTINATION is a remote path in the form of [USER@]NAME:PATH,
+where NAME is a value of the Name tag. Files are copied by scp to or from all
+matching instances. When copying from multiple instances, files of each
+instance are copied into a directory named by its instance ID under
+DESTINATION.
+
+Authentication and host key verification are the same as ec2 ssh.`,
+		RunE: runEC2CpCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("recursive", "r", false, "Copy directories recursively")
+	flags.StringP("login-name", "l", "", "Login username")
+	flags.StringP("identity-file", "i", "", "SSH private key file (default: IdentityFile in ~/.ssh/config or ~/.ssh/id_*)")
+	flags.StringP("port", "P", "", "Port to connect to (default: Port in ~/.ssh/config or 22)")
+	flags.BoolP("private", "", false, "Use private IP to connect")
+	flags.BoolP("accept-new", "", false, "Add host keys of unknown hosts to ~/.ssh/known_hosts")
+	flags.StringP("jump", "J", "", "Connect through a jump host specified by [USER@]NAME, where NAME is a Name tag or TAG:VALUE")
+	flags.IntP("parallel", "", 1, "Number of hosts to copy at once")
+	flags.BoolP("quiet", "q", false, "Don't print progress")
+	flags.BoolP("select", "", false, "Choose instances interactively if multiple instances are found")
+
+	viper.BindPFlag("ec2.cp.recursive", flags.Lookup("recursive"))
+	viper.BindPFlag("ec2.cp.login-name", flags.Lookup("login-name"))
+	viper.BindPFlag("ec2.cp.identity-file", flags.Lookup("identity-file"))
+	viper.BindPFlag("ec2.cp.port", flags.Lookup("port"))
+	viper.BindPFlag("ec2.cp.private", flags.Lookup("private"))
+	viper.BindPFlag("ec2.cp.accept-new", flags.Lookup("accept-new"))
+	viper.BindPFlag("ec2.cp.jump", flags.Lookup("jump"))
+	viper.BindPFlag("ec2.cp.parallel", flags.Lookup("parallel"))
+	viper.BindPFlag("ec2.cp.quiet", flags.Lookup("quiet"))
+	viper.BindPFlag("ec2.cp.select", flags.Lookup("select"))
+
+	return cmd
+}
+
+func runEC2CpCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 2 {
+		return errors.New("SOURCE and DESTINATION are required")
+	}
+
+	options := myaws.EC2CpOptions{
+		Source:       args[0],
+		Destination:  args[1],
+		Recursive:    viper.GetBool("ec2.cp.recursive"),
+		LoginName:    viper.GetString("ec2.cp.login-name"),
+		IdentityFile: viper.GetString("ec2.cp.identity-file"),
+		Port:         viper.GetString("ec2.cp.port"),
+		Private:      viper.GetBool("ec2.cp.private"),
+		AcceptNew:    viper.GetBool("ec2.cp.accept-new"),
+		Jump:         viper.GetString("ec2.cp.jump"),
+		Parallel:     viper.GetInt("ec2.cp.parallel"),
+		Quiet:        viper.GetBool("ec2.cp.quiet"),
+		Select:       viper.GetBool("ec2.cp.select"),
+	}
+
+	return client.EC2Cp(options)
+}
+
+func newEC2SessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session INSTANCE_NAME",
+		Short: "Start a shell session on an EC2 instance by Session Manager",
+		Long: `Start a shell session on an EC2 instance by Session Manager
+
+The INSTANCE_NAME is a value of the Name tag. With --select, it can be omitted
+and a matching instance is chosen interactively. Unlike ec2 ssh, it requires
+neither SSH keys nor inbound ports, but the SSM agent on the instance and
+ssm:StartSession permission.`,
+		RunE: runEC2SessionCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("select", "", false, "Choose an instance interactively if multiple instances are found")
+
+	viper.BindPFlag("ec2.session.select", flags.Lookup("select"))
+
+	return cmd
+}
+
+func runEC2SessionCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	selectInstance := viper.GetBool("ec2.session.select")
+	if len(args) == 0 && !selectInstance {
+		return errors.New("Instance name is required")
+	}
+
+	filterTag := ""
+	if len(args) > 0 {
+		filterTag = "Name:" + args[0]
+	}
+
+	options := myaws.EC2SessionOptions{
+		FilterTag: filterTag,
+		Select:    selectInstance,
+	}
+
+	return client.EC2Session(options)
+}
+
+func newEC2PortForwardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "port-forward INSTANCE_NAME LOCAL:[HOST:]REMOTE",
+		Short: "Forward a local port to an EC2 instance by Session Manager",
+		Long: `Forward a local port to an EC2 instance by Session Manager
+
+The INSTANCE_NAME is a value of the Name tag. LOCAL:REMOTE forwards
+localhost:LOCAL to the port REMOTE of the instance, and LOCAL:HOST:REMOTE
+forwards it to HOST:REMOTE through the instance, such as an RDS endpoint in
+the VPC. Ports are forwarded until interrupted.`,
+		RunE: runEC2PortForwardCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("select", "", false, "Choose an instance interactively if multiple instances are found")
+
+	viper.BindPFlag("ec2.port-forward.select", flags.Lookup("select"))
+
+	return cmd
+}
+
+func runEC2PortForwardCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 2 {
+		return errors.New("INSTANCE_NAME and LOCAL:[HOST:]REMOTE are required")
+	}
+
+	options := myaws.EC2PortForwardOptions{
+		FilterTag: "Name:" + args[0],
+		Ports:     args[1],
+		Select:    viper.GetBool("ec2.port-forward.select"),
+	}
+
+	return client.EC2PortForward(options)
+}
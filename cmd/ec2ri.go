@@ -38,10 +38,13 @@ func newEC2RILsCmd() *cobra.Command {
 	flags.BoolP("all", "a", false, "List all reserved instances (by default, list active reserved instances only)")
 	flags.BoolP("quiet", "q", false, "Only display ReservedInstanceIDs")
 	flags.StringP("fields", "F", "ReservedInstancesId State Scope AvailabilityZone InstanceType InstanceCount Duration Start End", "Output fields list separated by space")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 
 	viper.BindPFlag("ec2ri.ls.all", flags.Lookup("all"))
 	viper.BindPFlag("ec2ri.ls.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("ec2ri.ls.fields", flags.Lookup("fields"))
+	viper.BindPFlag("ec2ri.ls.list-fields", flags.Lookup("list-fields"))
+	addTargetFlags(cmd, "ec2ri.ls")
 
 	return cmd
 }
@@ -53,9 +56,11 @@ func runEC2RILsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.EC2RILsOptions{
-		All:    viper.GetBool("ec2ri.ls.all"),
-		Quiet:  viper.GetBool("ec2ri.ls.quiet"),
-		Fields: viper.GetStringSlice("ec2ri.ls.fields"),
+		All:        viper.GetBool("ec2ri.ls.all"),
+		Quiet:      viper.GetBool("ec2ri.ls.quiet"),
+		Fields:     getFields("ec2ri.ls.fields"),
+		ListFields: viper.GetBool("ec2ri.ls.list-fields"),
+		Targets:    getTargetOptions("ec2ri.ls"),
 	}
 
 	return client.EC2RILs(options)
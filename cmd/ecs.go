@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -89,6 +90,9 @@ func newECSNodeLsCmd() *cobra.Command {
 
 	viper.BindPFlag("ecs.node.ls.print-header", flags.Lookup("print-header"))
 
+	addMaxItemsFlag(cmd, "ecs.node.ls")
+	addTargetFlags(cmd, "ecs.node.ls")
+
 	return cmd
 }
 
@@ -105,6 +109,8 @@ func runECSNodeLsCmd(cmd *cobra.Command, args []string) error {
 	options := myaws.ECSNodeLsOptions{
 		Cluster:     args[0],
 		PrintHeader: viper.GetBool("ecs.node.ls.print-header"),
+		MaxItems:    viper.GetInt("ecs.node.ls.max-items"),
+		Targets:     getTargetOptions("ecs.node.ls"),
 	}
 	return client.ECSNodeLs(options)
 }
@@ -204,7 +210,7 @@ func runECSNodeDrainCmd(cmd *cobra.Command, args []string) error {
 func newECSNodeRenewCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "renew CLUSTER",
-		Short: "Renew ECS nodes (container instances) with blue-grean deployment",
+		Short: "Renew ECS nodes (container instances) with blue-grean deployment or rolling update",
 		RunE:  runECSNodeRenewCmd,
 	}
 
@@ -214,9 +220,19 @@ func newECSNodeRenewCmd() *cobra.Command {
 	// Note that this is a total timeout, and indivisual wait operations can
 	// timeout in shorter amount of time.
 	flags.Int64P("timeout", "t", 3600, "Number of secconds to wait before timeout")
+	flags.StringP("strategy", "", myaws.ECSNodeRenewStrategyBlueGreen, "Renew strategy ("+strings.Join(myaws.ECSNodeRenewStrategies, "|")+")")
+	flags.Int64P("batch-size", "", 1, "Number of old nodes replaced at a time in the rolling strategy")
+	flags.Int64P("max-surge", "", 1, "Maximum number of nodes launched above the desired capacity in the rolling strategy")
+	flags.BoolP("resume", "", false, "Resume an interrupted renew from the last phase recorded in $HOME/.myaws/state/ecs-node-renew")
+	flags.BoolP("rollback", "", false, "Roll back an interrupted renew. Restore the original capacity, clear scale in protection and terminate the new nodes")
 
 	viper.BindPFlag("ecs.node.renew.asg-name", flags.Lookup("asg-name"))
 	viper.BindPFlag("ecs.node.renew.timeout", flags.Lookup("timeout"))
+	viper.BindPFlag("ecs.node.renew.strategy", flags.Lookup("strategy"))
+	viper.BindPFlag("ecs.node.renew.batch-size", flags.Lookup("batch-size"))
+	viper.BindPFlag("ecs.node.renew.max-surge", flags.Lookup("max-surge"))
+	viper.BindPFlag("ecs.node.renew.resume", flags.Lookup("resume"))
+	viper.BindPFlag("ecs.node.renew.rollback", flags.Lookup("rollback"))
 
 	return cmd
 }
@@ -239,9 +255,14 @@ func runECSNodeRenewCmd(cmd *cobra.Command, args []string) error {
 	timeout := time.Duration(viper.GetInt64("ecs.node.renew.timeout")) * time.Second
 
 	options := myaws.ECSNodeRenewOptions{
-		Cluster: args[0],
-		AsgName: asgName,
-		Timeout: timeout,
+		Cluster:   args[0],
+		AsgName:   asgName,
+		Timeout:   timeout,
+		Strategy:  viper.GetString("ecs.node.renew.strategy"),
+		BatchSize: viper.GetInt64("ecs.node.renew.batch-size"),
+		MaxSurge:  viper.GetInt64("ecs.node.renew.max-surge"),
+		Resume:    viper.GetBool("ecs.node.renew.resume"),
+		Rollback:  viper.GetBool("ecs.node.renew.rollback"),
 	}
 
 	return client.ECSNodeRenew(options)
@@ -275,6 +296,8 @@ func newECSServiceLsCmd() *cobra.Command {
 	flags.BoolP("print-header", "H", false, "Print Header")
 
 	viper.BindPFlag("ecs.service.ls.print-header", flags.Lookup("print-header"))
+	addQueryFlags(cmd, "ecs.service.ls")
+	addTargetFlags(cmd, "ecs.service.ls")
 
 	return cmd
 }
@@ -292,6 +315,8 @@ func runECSServiceLsCmd(cmd *cobra.Command, args []string) error {
 	options := myaws.ECSServiceLsOptions{
 		Cluster:     args[0],
 		PrintHeader: viper.GetBool("ecs.service.ls.print-header"),
+		Query:       getQueryOptions("ecs.service.ls"),
+		Targets:     getTargetOptions("ecs.service.ls"),
 	}
 	return client.ECSServiceLs(options)
 }
@@ -314,12 +339,14 @@ func newECSServiceUpdateCmd() *cobra.Command {
 	flags.Int64P("timeout", "t", 600, "Number of secconds to wait before timeout")
 
 	flags.BoolP("force", "f", false, "Force new deployment")
+	flags.BoolP("select", "i", false, "Choose a service interactively")
 
 	viper.BindPFlag("ecs.service.update.service", flags.Lookup("service"))
 	viper.BindPFlag("ecs.service.update.desired-capacity", flags.Lookup("desired-capacity"))
 	viper.BindPFlag("ecs.service.update.wait", flags.Lookup("wait"))
 	viper.BindPFlag("ecs.service.update.timeout", flags.Lookup("timeout"))
 	viper.BindPFlag("ecs.service.update.force", flags.Lookup("force"))
+	viper.BindPFlag("ecs.service.update.select", flags.Lookup("select"))
 	return cmd
 }
 
@@ -334,7 +361,8 @@ func runECSServiceUpdateCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	service := viper.GetString("ecs.service.update.service")
-	if len(service) == 0 {
+	selectService := viper.GetBool("ecs.service.update.select")
+	if len(service) == 0 && !selectService {
 		return errors.New("--service is required")
 	}
 
@@ -356,6 +384,7 @@ func runECSServiceUpdateCmd(cmd *cobra.Command, args []string) error {
 		Wait:         viper.GetBool("ecs.service.update.wait"),
 		Timeout:      timeout,
 		Force:        viper.GetBool("ecs.service.update.force"),
+		Select:       selectService,
 	}
 	return client.ECSServiceUpdate(options)
 }
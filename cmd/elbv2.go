@@ -92,6 +92,7 @@ func newELBV2LsCmd() *cobra.Command {
 	viper.BindPFlag("elbv2.ls.filter-tag", flags.Lookup("filter-tag"))
 	viper.BindPFlag("elbv2.ls.fields", flags.Lookup("fields"))
 	viper.BindPFlag("elbv2.ls.domain", flags.Lookup("domain"))
+	addTargetFlags(cmd, "elbv2.ls")
 
 	return cmd
 }
@@ -108,6 +109,7 @@ func runELBV2LsCmd(cmd *cobra.Command, args []string) error {
 		FilterTag: viper.GetString("elbv2.ls.filter-tag"),
 		Fields:    viper.GetStringSlice("elbv2.ls.fields"),
 		Domain:    viper.GetStringSlice("elbv2.ls.domain"),
+		Targets:   getTargetOptions("elbv2.ls"),
 	}
 
 	return client.ELBV2Ls(options)
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/minamijoyo/myaws/myaws"
 )
@@ -51,6 +52,8 @@ func newIAMUserLsCmd() *cobra.Command {
 		RunE:  runIAMUserLsCmd,
 	}
 
+	addMaxItemsFlag(cmd, "iam.user.ls")
+
 	return cmd
 }
 
@@ -60,7 +63,10 @@ func runIAMUserLsCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
-	return client.IAMUserLs()
+	options := myaws.IAMUserLsOptions{
+		MaxItems: viper.GetInt("iam.user.ls.max-items"),
+	}
+	return client.IAMUserLs(options)
 }
 
 func newIAMUserResetPasswordCmd() *cobra.Command {
@@ -38,9 +38,13 @@ func newRDSLsCmd() *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolP("quiet", "q", false, "Only display DBInstanceIdentifier")
 	flags.StringP("fields", "F", "DBInstanceClass Engine AllocatedStorage StorageTypeIops InstanceCreateTime DBInstanceIdentifier ReadReplicaSource", "Output fields list separated by space")
+	flags.BoolP("list-fields", "", false, "List available fields and exit")
 
 	viper.BindPFlag("rds.ls.quiet", flags.Lookup("quiet"))
 	viper.BindPFlag("rds.ls.fields", flags.Lookup("fields"))
+	viper.BindPFlag("rds.ls.list-fields", flags.Lookup("list-fields"))
+	addQueryFlags(cmd, "rds.ls")
+	addTargetFlags(cmd, "rds.ls")
 
 	return cmd
 }
@@ -52,8 +56,11 @@ func runRDSLsCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.RDSLsOptions{
-		Quiet:  viper.GetBool("rds.ls.quiet"),
-		Fields: viper.GetStringSlice("rds.ls.fields"),
+		Quiet:      viper.GetBool("rds.ls.quiet"),
+		Fields:     getFields("rds.ls.fields"),
+		ListFields: viper.GetBool("rds.ls.list-fields"),
+		Query:      getQueryOptions("rds.ls"),
+		Targets:    getTargetOptions("rds.ls"),
 	}
 
 	return client.RDSLs(options)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,12 +29,32 @@ func init() {
 	RootCmd.PersistentFlags().StringP("timezone", "", "Local", "Time zone, such as UTC, Asia/Tokyo")
 	RootCmd.PersistentFlags().BoolP("humanize", "", true, "Use Human friendly format for time")
 	RootCmd.PersistentFlags().BoolP("debug", "", false, "Enable debug mode")
+	RootCmd.PersistentFlags().StringP("output", "o", myaws.OutputFormatTSV, "Output format ("+strings.Join(myaws.OutputFormats, "|")+")")
+	RootCmd.PersistentFlags().StringP("role-arn", "", "", "ARN of an IAM role to assume")
+	RootCmd.PersistentFlags().StringP("external-id", "", "", "External ID to assume the role")
+	RootCmd.PersistentFlags().StringP("mfa-serial", "", "", "Serial number or ARN of an MFA device to assume the role of --role-arn. The token code is prompted")
+	RootCmd.PersistentFlags().StringP("endpoint-url", "", "", "Override the endpoint URL of all AWS services, such as http://localhost:4566")
+	RootCmd.PersistentFlags().BoolP("no-verify-ssl", "", false, "Disable SSL certificate verification")
+	RootCmd.PersistentFlags().BoolP("dry-run", "", false, "Print a plan of API calls which would be made by mutating commands without calling them")
+	RootCmd.PersistentFlags().BoolP("yes", "y", false, "Skip confirmation prompts of destructive operations")
+	RootCmd.PersistentFlags().StringP("audit-log", "", "", "Path of the audit log of mutating API calls (default $HOME/.myaws/audit.jsonl)")
+	RootCmd.PersistentFlags().BoolP("no-audit", "", false, "Disable the audit log")
 
 	viper.BindPFlag("profile", RootCmd.PersistentFlags().Lookup("profile"))
 	viper.BindPFlag("region", RootCmd.PersistentFlags().Lookup("region"))
 	viper.BindPFlag("timezone", RootCmd.PersistentFlags().Lookup("timezone"))
 	viper.BindPFlag("humanize", RootCmd.PersistentFlags().Lookup("humanize"))
 	viper.BindPFlag("debug", RootCmd.PersistentFlags().Lookup("debug"))
+	viper.BindPFlag("output", RootCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("role-arn", RootCmd.PersistentFlags().Lookup("role-arn"))
+	viper.BindPFlag("external-id", RootCmd.PersistentFlags().Lookup("external-id"))
+	viper.BindPFlag("mfa-serial", RootCmd.PersistentFlags().Lookup("mfa-serial"))
+	viper.BindPFlag("endpoint-url", RootCmd.PersistentFlags().Lookup("endpoint-url"))
+	viper.BindPFlag("no-verify-ssl", RootCmd.PersistentFlags().Lookup("no-verify-ssl"))
+	viper.BindPFlag("dry-run", RootCmd.PersistentFlags().Lookup("dry-run"))
+	viper.BindPFlag("yes", RootCmd.PersistentFlags().Lookup("yes"))
+	viper.BindPFlag("audit.path", RootCmd.PersistentFlags().Lookup("audit-log"))
+	viper.BindPFlag("audit.disabled", RootCmd.PersistentFlags().Lookup("no-audit"))
 
 }
 
@@ -50,6 +71,19 @@ func initConfig() {
 }
 
 func newClient() (*myaws.Client, error) {
+	return newClientWithCredentials(credentialOptions())
+}
+
+// credentialOptions returns options of the base credentials of the client.
+func credentialOptions() myaws.CredentialOptions {
+	return myaws.CredentialOptions{
+		RoleArn:    viper.GetString("role-arn"),
+		ExternalID: viper.GetString("external-id"),
+		MFASerial:  viper.GetString("mfa-serial"),
+	}
+}
+
+func newClientWithCredentials(credential myaws.CredentialOptions) (*myaws.Client, error) {
 	return myaws.NewClient(
 		os.Stdin,
 		os.Stdout,
@@ -59,5 +93,147 @@ func newClient() (*myaws.Client, error) {
 		viper.GetString("timezone"),
 		viper.GetBool("humanize"),
 		viper.GetBool("debug"),
+		viper.GetString("output"),
+		credential,
+		myaws.EndpointOptions{
+			URL:         viper.GetString("endpoint-url"),
+			Services:    viper.GetStringMapString("endpoints"),
+			NoVerifySSL: viper.GetBool("no-verify-ssl"),
+		},
+		viper.GetBool("dry-run"),
+		myaws.GuardOptions{
+			Yes:               viper.GetBool("yes"),
+			ProtectedTags:     viper.GetStringSlice("protected.tags"),
+			ProtectedNames:    viper.GetStringSlice("protected.names"),
+			ProtectedSSMPaths: viper.GetStringSlice("protected.ssm-paths"),
+		},
+		myaws.AuditOptions{
+			Path:     viper.GetString("audit.path"),
+			Disabled: viper.GetBool("audit.disabled"),
+			Command:  auditCommand(),
+		},
+	)
+}
+
+// auditCommand returns the command line recorded in the audit log, where
+// secret arguments are redacted.
+func auditCommand() string {
+	cmd, _, err := RootCmd.Find(os.Args[1:])
+	if err != nil {
+		cmd = RootCmd
+	}
+	// Flags of the command include persistent flags of parents once parsed.
+	flags := cmd.Flags()
+	return myaws.RedactAuditCommand(cmd.CommandPath(), os.Args, func(name string) bool {
+		f := flags.Lookup(name)
+		if len(name) == 1 {
+			f = flags.ShorthandLookup(name)
+		}
+		return f != nil && f.NoOptDefVal == ""
+	})
+}
+
+// getFields returns a list of output fields. A value given by a flag is a
+// string separated by spaces, and a field containing spaces such as
+// 'Tag:In Charge' can be quoted. A value in the config file can also be a list.
+func getFields(key string) []string {
+	if s, ok := viper.Get(key).(string); ok {
+		return splitFields(s)
+	}
+	return viper.GetStringSlice(key)
+}
+
+// splitFields splits a string by spaces outside of single or double quotes.
+func splitFields(s string) []string {
+	fields := []string{}
+	var current strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// addQueryFlags adds flags for client-side filtering and sorting to a ls
+// command and binds them to viper keys under a given prefix.
+func addQueryFlags(cmd *cobra.Command, prefix string) {
+	flags := cmd.Flags()
+	flags.StringP("where", "", "",
+		"Filter results by an expression, such as 'StateName == \"running\" && Tag:Env =~ \"prod\" && LaunchTime < 30d'",
 	)
+	flags.StringP("sort-by", "", "", "Sort results by a field")
+	flags.BoolP("reverse", "", false, "Reverse the sort order")
+	flags.IntP("limit", "", 0, "Maximum number of results (0 means unlimited)")
+
+	viper.BindPFlag(prefix+".where", flags.Lookup("where"))
+	viper.BindPFlag(prefix+".sort-by", flags.Lookup("sort-by"))
+	viper.BindPFlag(prefix+".reverse", flags.Lookup("reverse"))
+	viper.BindPFlag(prefix+".limit", flags.Lookup("limit"))
+
+	addMaxItemsFlag(cmd, prefix)
+}
+
+// addMaxItemsFlag adds a flag to cap the number of resources fetched from the
+// API to a ls command and binds it to a viper key under a given prefix.
+func addMaxItemsFlag(cmd *cobra.Command, prefix string) {
+	flags := cmd.Flags()
+	flags.IntP("max-items", "", 0, "Maximum number of resources fetched from the API (0 means unlimited)")
+
+	viper.BindPFlag(prefix+".max-items", flags.Lookup("max-items"))
+}
+
+// addTargetFlags adds flags to query multiple profiles and regions to a ls
+// command and binds them to viper keys under a given prefix.
+func addTargetFlags(cmd *cobra.Command, prefix string) {
+	flags := cmd.Flags()
+	flags.StringSliceP("profiles", "", []string{}, "AWS profiles to query concurrently, such as \"dev,prod\"")
+	flags.StringSliceP("regions", "", []string{}, "AWS regions to query concurrently, such as \"us-east-1,ap-northeast-1\" or \"all\"")
+	flags.IntP("parallel", "", 8, "Maximum number of profiles and regions queried concurrently")
+
+	viper.BindPFlag(prefix+".profiles", flags.Lookup("profiles"))
+	viper.BindPFlag(prefix+".regions", flags.Lookup("regions"))
+	viper.BindPFlag(prefix+".parallel", flags.Lookup("parallel"))
+}
+
+// getTargetOptions returns options to query multiple profiles and regions
+// bound by addTargetFlags.
+func getTargetOptions(prefix string) myaws.TargetOptions {
+	return myaws.TargetOptions{
+		Profiles: viper.GetStringSlice(prefix + ".profiles"),
+		Regions:  viper.GetStringSlice(prefix + ".regions"),
+		Parallel: viper.GetInt(prefix + ".parallel"),
+	}
+}
+
+// getQueryOptions returns options for client-side filtering and sorting
+// bound by addQueryFlags.
+func getQueryOptions(prefix string) myaws.QueryOptions {
+	return myaws.QueryOptions{
+		Where:    viper.GetString(prefix + ".where"),
+		SortBy:   viper.GetString(prefix + ".sort-by"),
+		Reverse:  viper.GetBool(prefix + ".reverse"),
+		Limit:    viper.GetInt(prefix + ".limit"),
+		MaxItems: viper.GetInt(prefix + ".max-items"),
+	}
 }
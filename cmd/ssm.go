@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -24,6 +27,7 @@ func newSSMCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		newSSMParameterCmd(),
+		newSSMRunCmd(),
 	)
 
 	return cmd
@@ -44,6 +48,14 @@ func newSSMParameterCmd() *cobra.Command {
 		newSSMParameterLsCmd(),
 		newSSMParameterEnvCmd(),
 		newSSMParameterDelCmd(),
+		newSSMParameterTreeCmd(),
+		newSSMParameterDiffCmd(),
+		newSSMParameterCpCmd(),
+		newSSMParameterImportCmd(),
+		newSSMParameterExportCmd(),
+		newSSMParameterHistoryCmd(),
+		newSSMParameterLabelCmd(),
+		newSSMParameterRollbackCmd(),
 	)
 
 	return cmd
@@ -87,13 +99,20 @@ func newSSMParameterGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get NAME [...]",
 		Short: "Get SSM parameter",
-		RunE:  runSSMParameterGetCmd,
+		Long: `Get SSM parameter
+
+NAME can have a version or a label as NAME:VERSION or NAME:LABEL to get an old
+value. With --select, parameters are chosen interactively. A given NAME is used
+as a prefix of candidates.`,
+		RunE: runSSMParameterGetCmd,
 	}
 
 	flags := cmd.Flags()
 	flags.BoolP("with-decryption", "d", true, "with KMS decryption")
+	flags.BoolP("select", "i", false, "Choose parameters interactively")
 
 	viper.BindPFlag("ssm.parameter.get.with-decryption", flags.Lookup("with-decryption"))
+	viper.BindPFlag("ssm.parameter.get.select", flags.Lookup("select"))
 	return cmd
 }
 
@@ -103,6 +122,23 @@ func runSSMParameterGetCmd(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "newClient failed:")
 	}
 
+	selectParameters := viper.GetBool("ssm.parameter.get.select")
+	if selectParameters {
+		if len(args) >= 2 {
+			return errors.New("only one NAME can be given with --select")
+		}
+		var path string
+		if len(args) == 1 {
+			path = args[0]
+		}
+		options := myaws.SSMParameterGetOptions{
+			WithDecryption: viper.GetBool("ssm.parameter.get.with-decryption"),
+			Select:         true,
+			Path:           path,
+		}
+		return client.SSMParameterGet(options)
+	}
+
 	if len(args) == 0 {
 		return errors.New("NAME is required")
 	}
@@ -179,8 +215,8 @@ func runSSMParameterEnvCmd(cmd *cobra.Command, args []string) error {
 
 func newSSMParameterDelCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "del NAME",
-		Short: "Delete SSM parameter",
+		Use:   "del NAME...",
+		Short: "Delete SSM parameters",
 		RunE:  runSSMParameterDelCmd,
 	}
 
@@ -198,8 +234,385 @@ func runSSMParameterDelCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	options := myaws.SSMParameterDelOptions{
-		Name: args[0],
+		Names: args,
 	}
 
 	return client.SSMParameterDel(options)
 }
+
+func newSSMParameterTreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree PATH",
+		Short: "Print a hierarchy of SSM parameters under the path",
+		RunE:  runSSMParameterTreeCmd,
+	}
+
+	return cmd
+}
+
+func runSSMParameterTreeCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 1 {
+		return errors.New("PATH is required")
+	}
+
+	options := myaws.SSMParameterTreeOptions{
+		Path: args[0],
+	}
+
+	return client.SSMParameterTree(options)
+}
+
+func newSSMParameterDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff SOURCE_PATH DESTINATION_PATH",
+		Short: "Compare SSM parameters under two paths",
+		Long: `Compare SSM parameters under two paths
+
+Parameters are compared by names relative to the paths. Names missing on
+either side and differences of types and values are printed, and it exits
+with 1 if there are any differences. Values of SecureString are masked.`,
+		RunE: runSSMParameterDiffCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("src-profile", "", "", "AWS profile of SOURCE_PATH (default: --profile)")
+	flags.StringP("dst-profile", "", "", "AWS profile of DESTINATION_PATH (default: --profile)")
+
+	viper.BindPFlag("ssm.parameter.diff.src-profile", flags.Lookup("src-profile"))
+	viper.BindPFlag("ssm.parameter.diff.dst-profile", flags.Lookup("dst-profile"))
+	return cmd
+}
+
+func runSSMParameterDiffCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 2 {
+		return errors.New("SOURCE_PATH and DESTINATION_PATH are required")
+	}
+
+	options := myaws.SSMParameterDiffOptions{
+		Source:      args[0],
+		Destination: args[1],
+		SrcProfile:  viper.GetString("ssm.parameter.diff.src-profile"),
+		DstProfile:  viper.GetString("ssm.parameter.diff.dst-profile"),
+	}
+
+	return client.SSMParameterDiff(options)
+}
+
+func newSSMParameterCpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cp SOURCE DESTINATION",
+		Short: "Copy SSM parameters",
+		Long: `Copy SSM parameters
+
+Copy a parameter SOURCE to DESTINATION. If DESTINATION ends with a slash, the
+parameter is copied into the path. With --recursive, all parameters under the
+path SOURCE are copied under the path DESTINATION.
+
+Types, KMS key IDs, descriptions and tiers are kept. With --src-profile and
+--dst-profile, parameters are copied between accounts, where a customer
+managed KMS key must be accessible from the destination account.`,
+		RunE: runSSMParameterCpCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("recursive", "r", false, "Copy all parameters under the path")
+	flags.StringP("src-profile", "", "", "AWS profile of SOURCE (default: --profile)")
+	flags.StringP("dst-profile", "", "", "AWS profile of DESTINATION (default: --profile)")
+
+	viper.BindPFlag("ssm.parameter.cp.recursive", flags.Lookup("recursive"))
+	viper.BindPFlag("ssm.parameter.cp.src-profile", flags.Lookup("src-profile"))
+	viper.BindPFlag("ssm.parameter.cp.dst-profile", flags.Lookup("dst-profile"))
+	return cmd
+}
+
+func runSSMParameterCpCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 2 {
+		return errors.New("SOURCE and DESTINATION are required")
+	}
+
+	options := myaws.SSMParameterCpOptions{
+		Source:      args[0],
+		Destination: args[1],
+		Recursive:   viper.GetBool("ssm.parameter.cp.recursive"),
+		SrcProfile:  viper.GetString("ssm.parameter.cp.src-profile"),
+		DstProfile:  viper.GetString("ssm.parameter.cp.dst-profile"),
+	}
+
+	return client.SSMParameterCp(options)
+}
+
+func newSSMParameterImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import SSM parameters from a dotenv, JSON or YAML file",
+		Long: `Import SSM parameters from a dotenv, JSON or YAML file
+
+Keys in FILE are put under --prefix. Nested keys of JSON and YAML are mapped
+to the hierarchy separated by slashes, and lists are StringList. A key can
+have its own type and KMS key ID:
+
+  dotenv:  # myaws type=SecureString key-id=alias/app
+           DB_PASSWORD=secret
+  JSON:    {"DB_PASSWORD": {"Value": "secret", "Type": "SecureString", "KeyId": "alias/app"}}
+
+A plan of changes compared to current parameters is printed before writing,
+and unchanged parameters are skipped. The format is detected by the extension
+of FILE unless --format is given.`,
+		RunE: runSSMParameterImportCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("prefix", "", "", "A path to put parameters under (required)")
+	flags.StringP("format", "", "", "A format of FILE: "+strings.Join(myaws.SSMParameterFormats, "|"))
+	flags.StringP("type", "", "", "A default type of parameters (default: String)")
+	flags.StringP("key-id", "k", "", "A default KMS key ID of SecureString")
+
+	viper.BindPFlag("ssm.parameter.import.prefix", flags.Lookup("prefix"))
+	viper.BindPFlag("ssm.parameter.import.format", flags.Lookup("format"))
+	viper.BindPFlag("ssm.parameter.import.type", flags.Lookup("type"))
+	viper.BindPFlag("ssm.parameter.import.key-id", flags.Lookup("key-id"))
+	return cmd
+}
+
+func runSSMParameterImportCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 1 {
+		return errors.New("FILE is required")
+	}
+	prefix := viper.GetString("ssm.parameter.import.prefix")
+	if prefix == "" {
+		return errors.New("--prefix is required")
+	}
+
+	options := myaws.SSMParameterImportOptions{
+		File:   args[0],
+		Format: viper.GetString("ssm.parameter.import.format"),
+		Prefix: prefix,
+		Type:   viper.GetString("ssm.parameter.import.type"),
+		KeyID:  viper.GetString("ssm.parameter.import.key-id"),
+	}
+
+	return client.SSMParameterImport(options)
+}
+
+func newSSMParameterExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export PATH",
+		Short: "Export SSM parameters under the path as dotenv, JSON or YAML",
+		Long: `Export SSM parameters under the path as dotenv, JSON or YAML
+
+Values are decrypted, and types and KMS key IDs are kept in the same way as
+import reads them, so the output can be imported again.`,
+		RunE: runSSMParameterExportCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("format", "", "dotenv", "An output format: "+strings.Join(myaws.SSMParameterFormats, "|"))
+
+	viper.BindPFlag("ssm.parameter.export.format", flags.Lookup("format"))
+	return cmd
+}
+
+func runSSMParameterExportCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 1 {
+		return errors.New("PATH is required")
+	}
+
+	options := myaws.SSMParameterExportOptions{
+		Path:   args[0],
+		Format: viper.GetString("ssm.parameter.export.format"),
+	}
+
+	return client.SSMParameterExport(options)
+}
+
+func newSSMParameterHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history NAME",
+		Short: "Print versions of a SSM parameter",
+		RunE:  runSSMParameterHistoryCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("with-decryption", "d", false, "Show values of SecureString")
+
+	viper.BindPFlag("ssm.parameter.history.with-decryption", flags.Lookup("with-decryption"))
+	return cmd
+}
+
+func runSSMParameterHistoryCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 1 {
+		return errors.New("NAME is required")
+	}
+
+	options := myaws.SSMParameterHistoryOptions{
+		Name:           args[0],
+		WithDecryption: viper.GetBool("ssm.parameter.history.with-decryption"),
+	}
+
+	return client.SSMParameterHistory(options)
+}
+
+func newSSMParameterLabelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label NAME VERSION LABEL...",
+		Short: "Attach labels to a version of a SSM parameter",
+		Long: `Attach labels to a version of a SSM parameter
+
+A label attached to another version of the parameter is moved to VERSION.
+A labeled version can be read by get NAME:LABEL.`,
+		RunE: runSSMParameterLabelCmd,
+	}
+
+	return cmd
+}
+
+func runSSMParameterLabelCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) < 3 {
+		return errors.New("NAME, VERSION and LABEL are required")
+	}
+	version, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errors.Errorf("VERSION must be a number: %s", args[1])
+	}
+
+	options := myaws.SSMParameterLabelOptions{
+		Name:    args[0],
+		Version: version,
+		Labels:  args[2:],
+	}
+
+	return client.SSMParameterLabel(options)
+}
+
+func newSSMParameterRollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback NAME --to VERSION",
+		Short: "Roll back a SSM parameter to an old version",
+		Long: `Roll back a SSM parameter to an old version
+
+The value of VERSION is put as a new version, so the rollback itself can be
+rolled back. Types, KMS key IDs, descriptions and tiers of VERSION are kept.`,
+		RunE: runSSMParameterRollbackCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.Int64P("to", "", 0, "A version to roll back to (required)")
+
+	viper.BindPFlag("ssm.parameter.rollback.to", flags.Lookup("to"))
+	return cmd
+}
+
+func runSSMParameterRollbackCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) != 1 {
+		return errors.New("NAME is required")
+	}
+	to := viper.GetInt64("ssm.parameter.rollback.to")
+	if to <= 0 {
+		return errors.New("--to is required")
+	}
+
+	options := myaws.SSMParameterRollbackOptions{
+		Name: args[0],
+		To:   to,
+	}
+
+	return client.SSMParameterRollback(options)
+}
+
+func newSSMRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run -- COMMAND...",
+		Short: "Run a shell command on EC2 instances by SSM Run Command",
+		Long: `Run a shell command on EC2 instances by SSM Run Command
+
+The command is run by the AWS-RunShellScript document on running instances
+matching --filter-tag. Output of each instance is printed when it completes,
+and a summary of the results is printed at the end. SSM truncates stdout to
+24,000 characters and stderr to 8,000 characters. Interrupting it cancels the
+command.
+
+The command is sent in batches of 50 instances, which run at the same time,
+and --max-concurrency and --max-errors apply to each batch.`,
+		RunE: runSSMRunCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("filter-tag", "t", "",
+		"Filter instances by tag, such as \"Name:app-production\". The value of tag is assumed to be a partial match",
+	)
+	flags.DurationP("timeout", "", 0, "Timeout of the command per instance, such as 10m (default: 1h)")
+	flags.StringP("max-concurrency", "", "", "Number or percentage of instances to run the command at once, such as 10 or 10% (default: 50)")
+	flags.StringP("max-errors", "", "", "Number or percentage of errors to stop running the command on the rest, such as 1 or 10% (default: 0)")
+
+	viper.BindPFlag("ssm.run.filter-tag", flags.Lookup("filter-tag"))
+	viper.BindPFlag("ssm.run.timeout", flags.Lookup("timeout"))
+	viper.BindPFlag("ssm.run.max-concurrency", flags.Lookup("max-concurrency"))
+	viper.BindPFlag("ssm.run.max-errors", flags.Lookup("max-errors"))
+
+	return cmd
+}
+
+func runSSMRunCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) == 0 {
+		return errors.New("COMMAND is required")
+	}
+	filterTag := viper.GetString("ssm.run.filter-tag")
+	if filterTag == "" {
+		return errors.New("--filter-tag is required")
+	}
+
+	options := myaws.SSMRunOptions{
+		FilterTag:      filterTag,
+		Command:        strings.Join(args, " "),
+		Timeout:        viper.GetDuration("ssm.run.timeout"),
+		MaxConcurrency: viper.GetString("ssm.run.max-concurrency"),
+		MaxErrors:      viper.GetString("ssm.run.max-errors"),
+	}
+
+	return client.SSMRun(options)
+}
@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/minamijoyo/myaws/myaws"
 )
 
 func init() {
@@ -20,6 +26,10 @@ func newSTSCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		newSTSIDCmd(),
+		newSTSAssumeRoleCmd(),
+		newSTSSessionTokenCmd(),
+		newSTSEnvCmd(),
+		newSTSExecCmd(),
 	)
 
 	return cmd
@@ -43,3 +53,140 @@ func runSTSIDCmd(cmd *cobra.Command, args []string) error {
 
 	return client.STSID()
 }
+
+func newSTSAssumeRoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "assume-role ROLE_ARN",
+		Short: "Assume a role and print temporary credentials",
+		RunE:  runSTSAssumeRoleCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("role-session-name", "", "", "An identifier of the session (default myaws-TIMESTAMP)")
+	flags.DurationP("duration", "", time.Hour, "Duration of the session")
+	flags.StringP("format", "", myaws.STSFormatEnv, "Output format ("+strings.Join(myaws.STSFormats, "|")+")")
+
+	viper.BindPFlag("sts.assume-role.role-session-name", flags.Lookup("role-session-name"))
+	viper.BindPFlag("sts.assume-role.duration", flags.Lookup("duration"))
+	viper.BindPFlag("sts.assume-role.format", flags.Lookup("format"))
+
+	return cmd
+}
+
+func runSTSAssumeRoleCmd(cmd *cobra.Command, args []string) error {
+	client, err := newSTSClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	if len(args) == 0 {
+		return errors.New("ROLE_ARN is required")
+	}
+
+	options := myaws.STSAssumeRoleOptions{
+		RoleArn:         args[0],
+		RoleSessionName: viper.GetString("sts.assume-role.role-session-name"),
+		ExternalID:      viper.GetString("external-id"),
+		MFASerial:       viper.GetString("mfa-serial"),
+		Duration:        viper.GetDuration("sts.assume-role.duration"),
+		Format:          viper.GetString("sts.assume-role.format"),
+	}
+
+	return client.STSAssumeRole(options)
+}
+
+func newSTSSessionTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session-token",
+		Short: "Get a session token and print temporary credentials",
+		RunE:  runSTSSessionTokenCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolP("mfa", "", false, "Authenticate with MFA. The device is detected from the current IAM user unless --mfa-serial is given")
+	flags.DurationP("duration", "", 12*time.Hour, "Duration of the session")
+	flags.StringP("format", "", myaws.STSFormatEnv, "Output format ("+strings.Join(myaws.STSFormats, "|")+")")
+
+	viper.BindPFlag("sts.session-token.mfa", flags.Lookup("mfa"))
+	viper.BindPFlag("sts.session-token.duration", flags.Lookup("duration"))
+	viper.BindPFlag("sts.session-token.format", flags.Lookup("format"))
+
+	return cmd
+}
+
+func runSTSSessionTokenCmd(cmd *cobra.Command, args []string) error {
+	client, err := newSTSClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	options := myaws.STSSessionTokenOptions{
+		MFA:       viper.GetBool("sts.session-token.mfa"),
+		MFASerial: viper.GetString("mfa-serial"),
+		Duration:  viper.GetDuration("sts.session-token.duration"),
+		Format:    viper.GetString("sts.session-token.format"),
+	}
+
+	return client.STSSessionToken(options)
+}
+
+func newSTSEnvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print current credentials as environment variables",
+		RunE:  runSTSEnvCmd,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("format", "", myaws.STSFormatEnv, "Output format ("+strings.Join(myaws.STSFormats, "|")+")")
+
+	viper.BindPFlag("sts.env.format", flags.Lookup("format"))
+
+	return cmd
+}
+
+func runSTSEnvCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	options := myaws.STSEnvOptions{
+		Format: viper.GetString("sts.env.format"),
+	}
+
+	return client.STSEnv(options)
+}
+
+func newSTSExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec -- COMMAND [ARGS...]",
+		Short: "Run a command with current credentials",
+		RunE:  runSTSExecCmd,
+	}
+
+	return cmd
+}
+
+func runSTSExecCmd(cmd *cobra.Command, args []string) error {
+	client, err := newClient()
+	if err != nil {
+		return errors.Wrap(err, "newClient failed:")
+	}
+
+	options := myaws.STSExecOptions{
+		Command: args,
+	}
+
+	return client.STSExec(options)
+}
+
+// newSTSClient creates a client for the sts commands, which pass --mfa-serial
+// to their own API calls unless the base credentials assume a role.
+func newSTSClient() (*myaws.Client, error) {
+	credential := credentialOptions()
+	if credential.RoleArn == "" {
+		credential.MFASerial = ""
+	}
+	return newClientWithCredentials(credential)
+}
@@ -1,21 +1,33 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/viper"
 
 	"github.com/minamijoyo/myaws/cmd"
+	"github.com/minamijoyo/myaws/myaws"
 )
 
 func main() {
 	if err := cmd.RootCmd.Execute(); err != nil {
+		code := 1
+		var exitErr *myaws.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+			if exitErr.Err == nil {
+				// The error has already been reported.
+				os.Exit(code)
+			}
+		}
+
 		if viper.GetBool("debug") {
 			fmt.Fprintf(os.Stderr, "%+v\n", err)
 		} else {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 		}
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
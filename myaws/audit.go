@@ -0,0 +1,265 @@
+package myaws
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// AuditOptions customize the audit log of mutating API calls.
+type AuditOptions struct {
+	// Path is a path of the audit log. Defaults to $HOME/.myaws/audit.jsonl.
+	Path string
+	// Disabled disables the audit log.
+	Disabled bool
+	// Command is the command line recorded in the audit log.
+	Command string
+}
+
+// AuditRecord is a record of a mutating API call in the audit log.
+type AuditRecord struct {
+	Time       time.Time              `json:"time"`
+	Identity   string                 `json:"identity"`
+	Account    string                 `json:"account"`
+	Profile    string                 `json:"profile"`
+	Region     string                 `json:"region"`
+	Command    string                 `json:"command"`
+	API        string                 `json:"api"`
+	Resources  []string               `json:"resources"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Result     string                 `json:"result"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Results of API calls in the audit log.
+const (
+	auditResultSuccess = "success"
+	auditResultError   = "error"
+)
+
+// auditRedactedKeys are keys of parameters which may hold secrets.
+var auditRedactedKeys = map[string]bool{
+	"Value":           true,
+	"Password":        true,
+	"OldPassword":     true,
+	"NewPassword":     true,
+	"SecretAccessKey": true,
+	"SessionToken":    true,
+}
+
+// auditRedacted replaces secrets in the audit log.
+const auditRedacted = "***"
+
+// auditPath returns a path of the audit log.
+func (options AuditOptions) auditPath() (string, error) {
+	if options.Path != "" {
+		return options.Path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory:")
+	}
+	return filepath.Join(home, ".myaws", "audit.jsonl"), nil
+}
+
+// auditCall appends a record of a mutating API call to the audit log.
+// The input is the input struct of the API, and its secrets are redacted.
+// It's a no-op in dry-run mode or if the audit log is disabled. A failure of
+// writing the log is reported to stderr, but doesn't fail the command.
+func (client *Client) auditCall(api string, input interface{}, resources []string, callErr error) {
+	if client.dryRun || client.audit.Disabled || client.audit.Path == "" {
+		return
+	}
+
+	record := AuditRecord{
+		Time:      time.Now().UTC(),
+		Profile:   client.profile,
+		Region:    client.region,
+		Command:   client.audit.Command,
+		API:       api,
+		Resources: resources,
+		Result:    auditResultSuccess,
+	}
+	if record.Resources == nil {
+		record.Resources = []string{}
+	}
+	if callErr != nil {
+		record.Result = auditResultError
+		record.Error = callErr.Error()
+	}
+
+	identity := client.auditIdentity()
+	record.Identity = aws.StringValue(identity.Arn)
+	record.Account = aws.StringValue(identity.Account)
+
+	record.Parameters = redactAuditParameters(input)
+
+	if err := appendAuditRecord(client.audit.Path, record); err != nil {
+		fmt.Fprintf(client.stderr, "audit: %s\n", err)
+	}
+}
+
+// auditResources returns a list of resource IDs recorded in the audit log.
+func auditResources(id string, ids []*string) []string {
+	return append([]string{id}, aws.StringValueSlice(ids)...)
+}
+
+// auditIdentity returns the caller identity. It's called once per client,
+// and an empty identity is cached if failed.
+func (client *Client) auditIdentity() *sts.GetCallerIdentityOutput {
+	client.auditCallerOnce.Do(func() {
+		client.auditCaller = &sts.GetCallerIdentityOutput{}
+		if response, err := client.STS.GetCallerIdentity(&sts.GetCallerIdentityInput{}); err == nil {
+			client.auditCaller = response
+		}
+	})
+	return client.auditCaller
+}
+
+// redactAuditParameters converts an input struct of an API to a map, and
+// replaces values of secret keys. Null parameters are omitted.
+func redactAuditParameters(input interface{}) map[string]interface{} {
+	parameters := map[string]interface{}{}
+	b, err := json.Marshal(input)
+	if err != nil {
+		return parameters
+	}
+	if err := json.Unmarshal(b, &parameters); err != nil {
+		return parameters
+	}
+	redactAuditValue(parameters)
+	return parameters
+}
+
+func redactAuditValue(v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if child == nil {
+				delete(value, k)
+				continue
+			}
+			if _, ok := child.(string); ok && auditRedactedKeys[k] {
+				value[k] = auditRedacted
+				continue
+			}
+			redactAuditValue(child)
+		}
+	case []interface{}:
+		for _, child := range value {
+			redactAuditValue(child)
+		}
+	}
+}
+
+// auditSecretArgs are indexes of positional arguments which hold secrets by
+// command path.
+var auditSecretArgs = map[string][]int{
+	"myaws ssm parameter put": {1},
+}
+
+// RedactAuditCommand returns a command line recorded in the audit log, where
+// secret arguments of the command are replaced by their positions. args are
+// the command line including the program name, and hasValue returns true if
+// a flag takes a value from the next argument.
+func RedactAuditCommand(commandPath string, args []string, hasValue func(name string) bool) string {
+	secrets := map[int]bool{}
+	for _, i := range auditSecretArgs[commandPath] {
+		secrets[i] = true
+	}
+	// Positional arguments start after the command path.
+	depth := len(strings.Fields(commandPath))
+
+	redacted := []string{}
+	position := 0
+	skipValue, noFlags := false, false
+	for i, arg := range args {
+		switch {
+		case i == 0:
+			position++
+		case skipValue:
+			skipValue = false
+		case !noFlags && arg == "--":
+			noFlags = true
+		case !noFlags && strings.HasPrefix(arg, "-") && len(arg) > 1:
+			name := strings.TrimLeft(arg, "-")
+			// A value can be given as --name=value or -nvalue.
+			short := !strings.HasPrefix(arg, "--")
+			if !strings.Contains(name, "=") && (!short || len(name) == 1) {
+				skipValue = hasValue(name)
+			}
+		default:
+			if secrets[position-depth] {
+				arg = auditRedacted
+			}
+			position++
+		}
+		redacted = append(redacted, arg)
+	}
+	return strings.Join(redacted, " ")
+}
+
+// appendAuditRecord appends a record to the audit log as a line of JSON.
+func appendAuditRecord(path string, record AuditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create audit log directory:")
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit record:")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit log:")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write audit log:")
+	}
+	return nil
+}
+
+// readAuditRecords reads all records of the audit log. It returns an empty
+// list if the log doesn't exist.
+func readAuditRecords(path string) ([]*AuditRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*AuditRecord{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to open audit log:")
+	}
+	defer f.Close()
+
+	records := []*AuditRecord{}
+	scanner := bufio.NewScanner(f)
+	// A record may be longer than the default limit of 64KB.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		record := &AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse audit log %s at line %d:", path, line)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read audit log:")
+	}
+	return records, nil
+}
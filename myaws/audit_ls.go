@@ -0,0 +1,101 @@
+package myaws
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// AuditLsOptions customize the behavior of the Ls command.
+type AuditLsOptions struct {
+	Fields     []string
+	ListFields bool
+	Query      QueryOptions
+}
+
+// auditRecordFields is a registry of output fields for audit records.
+var auditRecordFields = newFieldRegistry(AuditRecord{}, "Time", []field{
+	{"Time", formatAuditTime},
+	{"Identity", formatAuditIdentity},
+	{"Profile", formatAuditProfile},
+	{"Region", formatAuditRegion},
+	{"API", formatAuditAPI},
+	{"Resources", formatAuditResources},
+	{"Result", formatAuditResult},
+	{"Parameters", formatAuditParameters},
+})
+
+// AuditLs lists records of the audit log.
+func (client *Client) AuditLs(options AuditLsOptions) error {
+	if options.ListFields {
+		return client.printFields(auditRecordFields)
+	}
+
+	if err := auditRecordFields.validate(options.Fields); err != nil {
+		return err
+	}
+
+	q, err := auditRecordFields.compileQuery(options.Query)
+	if err != nil {
+		return err
+	}
+
+	records, err := readAuditRecords(client.audit.Path)
+	if err != nil {
+		return err
+	}
+
+	resources := []interface{}{}
+	for _, record := range records {
+		resources = append(resources, record)
+	}
+
+	rows := auditRecordFields.formatRows(client, options.Fields, q.apply(client, resources))
+	return client.printRows(options.Fields, rows, false)
+}
+
+func formatAuditTime(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return client.FormatTime(&record.Time)
+}
+
+func formatAuditIdentity(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return record.Identity
+}
+
+func formatAuditProfile(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return record.Profile
+}
+
+func formatAuditRegion(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return record.Region
+}
+
+func formatAuditAPI(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return record.API
+}
+
+func formatAuditResources(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	return strings.Join(record.Resources, ",")
+}
+
+func formatAuditResult(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	if record.Error != "" {
+		return record.Result + ": " + record.Error
+	}
+	return record.Result
+}
+
+func formatAuditParameters(client *Client, resource interface{}) string {
+	record := resource.(*AuditRecord)
+	b, err := json.Marshal(record.Parameters)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,254 @@
+package myaws
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newAuditTestClient(t *testing.T, services Services) (*Client, string) {
+	t.Helper()
+	services.STS = newFakeSTS()
+	client, _ := newTestClient(t, services)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	client.audit = AuditOptions{Path: path, Command: "myaws ssm parameter put /app/db/password ***"}
+	client.profile = "dev"
+	return client, path
+}
+
+func TestAuditCall(t *testing.T) {
+	client, path := newAuditTestClient(t, Services{SSM: newFakeSSM("/app/old", "1")})
+
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "/app/db/password", Value: "s3cret"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	client.audit.Command = "myaws ssm parameter del /app/old /app/missing"
+	if err := client.SSMParameterDel(SSMParameterDelOptions{Names: []string{"/app/old", "/app/missing"}}); err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %s", err)
+	}
+	if strings.Contains(string(b), "s3cret") {
+		t.Errorf("secrets should be redacted:\n%s", b)
+	}
+
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3:\n%s", len(records), b)
+	}
+
+	put := records[0]
+	if put.API != "SSM.PutParameter" || put.Result != auditResultSuccess || put.Profile != "dev" || put.Region != testRegion {
+		t.Errorf("unexpected record: %+v", put)
+	}
+	if put.Identity != "arn:aws:iam::"+testAccountID+":user/alice" || put.Account != testAccountID {
+		t.Errorf("unexpected identity: %s (%s)", put.Identity, put.Account)
+	}
+	if _, ok := put.Parameters["KeyId"]; ok {
+		t.Errorf("null parameters should be omitted: %v", put.Parameters)
+	}
+	if put.Parameters["Name"] != "/app/db/password" || put.Parameters["Value"] != auditRedacted {
+		t.Errorf("unexpected parameters: %v", put.Parameters)
+	}
+	if put.Command != "myaws ssm parameter put /app/db/password ***" {
+		t.Errorf("unexpected command: %s", put.Command)
+	}
+
+	if records[1].Result != auditResultSuccess || strings.Join(records[1].Resources, ",") != "/app/old" {
+		t.Errorf("unexpected record: %+v", records[1])
+	}
+	if records[2].Result != auditResultError || !strings.Contains(records[2].Error, "ParameterNotFound") {
+		t.Errorf("unexpected record: %+v", records[2])
+	}
+}
+
+func TestAuditCallRegionFromEnv(t *testing.T) {
+	setTestCredentialsEnv(t)
+	old, ok := os.LookupEnv("AWS_DEFAULT_REGION")
+	os.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("AWS_DEFAULT_REGION", old)
+		} else {
+			os.Unsetenv("AWS_DEFAULT_REGION")
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, err := NewClient(nil, &bytes.Buffer{}, &bytes.Buffer{}, "", "", "UTC", false, false, OutputFormatTSV, CredentialOptions{}, EndpointOptions{}, false, GuardOptions{Yes: true}, AuditOptions{Path: path})
+	if err != nil {
+		t.Fatalf("NewClient failed: %s", err)
+	}
+	client.SSM = newFakeSSM()
+	client.STS = newFakeSTS()
+
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "foo", Value: "bar"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(records) != 1 || records[0].Region != "eu-west-1" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestAuditCallConcurrently(t *testing.T) {
+	client, path := newAuditTestClient(t, Services{})
+
+	// Sessions of port forwarding are audited concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.auditCall("SSM.StartSession", struct{}{}, []string{"i-0001"}, nil)
+		}()
+	}
+	wg.Wait()
+
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(records) != 10 {
+		t.Fatalf("got %d records, want 10", len(records))
+	}
+	for _, r := range records {
+		if r.Account != testAccountID {
+			t.Errorf("unexpected identity: %+v", r)
+		}
+	}
+}
+
+func TestRedactAuditCommand(t *testing.T) {
+	cases := []struct {
+		desc        string
+		commandPath string
+		args        string
+		want        string
+	}{
+		{
+			desc:        "value",
+			commandPath: "myaws ssm parameter put",
+			args:        "myaws ssm parameter put /app/prod/db/password s3cret",
+			want:        "myaws ssm parameter put /app/prod/db/password ***",
+		},
+		{
+			desc:        "short value",
+			commandPath: "myaws ssm parameter put",
+			args:        "myaws --profile prod ssm parameter put -k alias/prod /app/prod prod",
+			want:        "myaws --profile prod ssm parameter put -k alias/prod /app/prod ***",
+		},
+		{
+			desc:        "flags with values",
+			commandPath: "myaws ssm parameter put",
+			args:        "myaws ssm parameter put --key-id=alias/app --debug /app/1 1",
+			want:        "myaws ssm parameter put --key-id=alias/app --debug /app/1 ***",
+		},
+		{
+			desc:        "after --",
+			commandPath: "myaws ssm parameter put",
+			args:        "myaws ssm parameter put -- /app/flag -1",
+			want:        "myaws ssm parameter put -- /app/flag ***",
+		},
+		{
+			desc:        "no secrets",
+			commandPath: "myaws ssm parameter del",
+			args:        "myaws ssm parameter del /app/1 1",
+			want:        "myaws ssm parameter del /app/1 1",
+		},
+	}
+
+	// --debug is a boolean flag, and others take values.
+	hasValue := func(name string) bool { return name != "debug" }
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := RedactAuditCommand(tc.commandPath, strings.Fields(tc.args), hasValue)
+			if got != tc.want {
+				t.Errorf("got = %q, want = %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuditCallSkipped(t *testing.T) {
+	cases := []struct {
+		desc    string
+		dryRun  bool
+		disable bool
+	}{
+		{desc: "dry-run", dryRun: true},
+		{desc: "disabled", disable: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, path := newAuditTestClient(t, Services{SSM: newFakeSSM()})
+			client.dryRun = tc.dryRun
+			client.audit.Disabled = tc.disable
+
+			if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "foo", Value: "bar"}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("audit log should not be written: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuditLs(t *testing.T) {
+	client, _ := newAuditTestClient(t, Services{SSM: newFakeSSM("foo", "1", "bar", "2")})
+
+	if err := client.SSMParameterDel(SSMParameterDelOptions{Names: []string{"foo", "baz"}}); err == nil {
+		t.Fatal("expected an error, but got nil")
+	}
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "bar", Value: "3"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	stdout := &strings.Builder{}
+	client.stdout = stdout
+	err := client.AuditLs(AuditLsOptions{
+		Fields: []string{"API", "Resources", "Result"},
+		Query:  QueryOptions{Where: `API =~ "Delete"`},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "SSM.DeleteParameter\tfoo\tsuccess\n" +
+		"SSM.DeleteParameter\tbaz\terror: ParameterNotFound: \n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestAuditTerminateSession(t *testing.T) {
+	client, path := newAuditTestClient(t, Services{SSM: newFakeSSM()})
+
+	client.terminateSSMSession("alice-1")
+
+	records, err := readAuditRecords(path)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.API != "SSM.TerminateSession" || r.Result != auditResultSuccess || strings.Join(r.Resources, ",") != "alice-1" {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
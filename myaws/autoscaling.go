@@ -1,6 +1,9 @@
 package myaws
 
 import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/pkg/errors"
 )
@@ -21,3 +24,50 @@ func (client *Client) getAutoScalingGroupDesiredCapacity(asgName string) (int64,
 
 	return *desiredCapacity, nil
 }
+
+// getAutoScalingGroupInstances is a helper function which returns instances
+// of the specific AutoScalingGroup.
+func (client *Client) getAutoScalingGroupInstances(asgName string) ([]*autoscaling.Instance, error) {
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{&asgName},
+	}
+
+	response, err := client.AutoScaling.DescribeAutoScalingGroups(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "getAutoScalingGroupInstances failed:")
+	}
+	if len(response.AutoScalingGroups) == 0 {
+		return nil, errors.Errorf("autoscaling group not found: %s", asgName)
+	}
+
+	return response.AutoScalingGroups[0].Instances, nil
+}
+
+// pickAutoScalingGroup chooses an autoscaling group in a fuzzy finder.
+func (client *Client) pickAutoScalingGroup() (string, error) {
+	groups := []*autoscaling.Group{}
+	err := client.AutoScaling.DescribeAutoScalingGroupsPagesWithContext(aws.BackgroundContext(), &autoscaling.DescribeAutoScalingGroupsInput{},
+		func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			groups = append(groups, page.AutoScalingGroups...)
+			return true
+		})
+	if err != nil {
+		return "", errors.Wrap(err, "DescribeAutoScalingGroups failed:")
+	}
+
+	fields := []string{"AutoScalingGroupName", "DesiredCapacity", "Instances"}
+	rows := [][]string{}
+	for _, asg := range groups {
+		rows = append(rows, []string{
+			*asg.AutoScalingGroupName,
+			strconv.FormatInt(*asg.DesiredCapacity, 10),
+			formatAutoscalingInstacesLen(asg.Instances),
+		})
+	}
+
+	indexes, err := client.pick(fields, rows, false)
+	if err != nil {
+		return "", err
+	}
+	return *groups[indexes[0]].AutoScalingGroupName, nil
+}
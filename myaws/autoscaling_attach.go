@@ -17,6 +17,14 @@ type AutoscalingAttachOptions struct {
 
 // AutoscalingAttach attaches instances or load balancers from autoscaling group.
 func (client *Client) AutoscalingAttach(options AutoscalingAttachOptions) error {
+	if err := client.guardAutoScalingGroup(options.AsgName); err != nil {
+		return err
+	}
+
+	if client.dryRun {
+		return client.planAutoscalingAttach(options)
+	}
+
 	if len(options.InstanceIds) > 0 {
 		if err := client.autoscalingAttachInstances(options.AsgName, options.InstanceIds); err != nil {
 			return err
@@ -43,7 +51,9 @@ func (client *Client) autoscalingAttachInstances(asgName string, instanceIds []*
 		InstanceIds:          instanceIds,
 	}
 
-	if _, err := client.AutoScaling.AttachInstances(params); err != nil {
+	_, err := client.AutoScaling.AttachInstances(params)
+	client.auditCall("AutoScaling.AttachInstances", params, auditResources(asgName, instanceIds), err)
+	if err != nil {
 		return errors.Wrap(err, "AttachInstances failed:")
 	}
 
@@ -56,9 +66,28 @@ func (client *Client) autoscalingAttachLoadBalancers(asgName string, loadBalance
 		LoadBalancerNames:    loadBalancerNames,
 	}
 
-	if _, err := client.AutoScaling.AttachLoadBalancers(params); err != nil {
+	_, err := client.AutoScaling.AttachLoadBalancers(params)
+	client.auditCall("AutoScaling.AttachLoadBalancers", params, auditResources(asgName, loadBalancerNames), err)
+	if err != nil {
 		return errors.Wrap(err, "AttachLoadBalancers failed:")
 	}
 
 	return nil
 }
+
+func (client *Client) planAutoscalingAttach(options AutoscalingAttachOptions) error {
+	if len(options.InstanceIds) > 0 {
+		desiredCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+		if err != nil {
+			return err
+		}
+		client.printPlan("AutoScaling.AttachInstances", "%s InstanceIds %s (DesiredCapacity %d => %d)",
+			options.AsgName, formatPlanValues(options.InstanceIds), desiredCapacity, desiredCapacity+int64(len(options.InstanceIds)))
+	}
+
+	if len(options.LoadBalancerNames) > 0 {
+		client.printPlan("AutoScaling.AttachLoadBalancers", "%s LoadBalancerNames %s", options.AsgName, formatPlanValues(options.LoadBalancerNames))
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package myaws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestAutoscalingAttach(t *testing.T) {
+	c := newFakeCluster(1)
+	client, _ := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+	err := client.AutoscalingAttach(AutoscalingAttachOptions{
+		AsgName:           c.asgName,
+		InstanceIds:       aws.StringSlice([]string{"i-1000"}),
+		LoadBalancerNames: aws.StringSlice([]string{"clb"}),
+		Wait:              true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := c.group(); *got.DesiredCapacity != 2 || len(got.Instances) != 2 || *got.Instances[1].LifecycleState != "InService" {
+		t.Errorf("unexpected group: %s", got)
+	}
+	if got, want := aws.StringValueSlice(c.loadBalancerNames), []string{"clb"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadBalancerNames = %v, want %v", got, want)
+	}
+}
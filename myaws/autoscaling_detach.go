@@ -17,6 +17,25 @@ type AutoscalingDetachOptions struct {
 
 // AutoscalingDetach detaches instances or load balancers from autoscaling group.
 func (client *Client) AutoscalingDetach(options AutoscalingDetachOptions) error {
+	if err := client.guardAutoScalingGroup(options.AsgName); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Are you sure want to detach %s from autoscaling group %s?",
+		formatPlanValues(append(options.InstanceIds, options.LoadBalancerNames...)), options.AsgName)
+	if ok, err := client.confirm(message); !ok {
+		return err
+	}
+
+	return client.autoscalingDetach(options)
+}
+
+// autoscalingDetach detaches instances or load balancers without
+// confirmation.
+func (client *Client) autoscalingDetach(options AutoscalingDetachOptions) error {
+	if client.dryRun {
+		return client.planAutoscalingDetach(options)
+	}
+
 	if len(options.InstanceIds) > 0 {
 		if err := client.autoscalingDetachInstances(options.AsgName, options.InstanceIds); err != nil {
 			return err
@@ -45,7 +64,9 @@ func (client *Client) autoscalingDetachInstances(asgName string, instanceIds []*
 		ShouldDecrementDesiredCapacity: &decrementCapacity,
 	}
 
-	if _, err := client.AutoScaling.DetachInstances(params); err != nil {
+	_, err := client.AutoScaling.DetachInstances(params)
+	client.auditCall("AutoScaling.DetachInstances", params, auditResources(asgName, instanceIds), err)
+	if err != nil {
 		return errors.Wrap(err, "DetachInstances failed:")
 	}
 
@@ -58,9 +79,28 @@ func (client *Client) autoscalingDetachLoadBalancers(asgName string, loadBalance
 		LoadBalancerNames:    loadBalancerNames,
 	}
 
-	if _, err := client.AutoScaling.DetachLoadBalancers(params); err != nil {
+	_, err := client.AutoScaling.DetachLoadBalancers(params)
+	client.auditCall("AutoScaling.DetachLoadBalancers", params, auditResources(asgName, loadBalancerNames), err)
+	if err != nil {
 		return errors.Wrap(err, "DetachLoadBalancers failed:")
 	}
 
 	return nil
 }
+
+func (client *Client) planAutoscalingDetach(options AutoscalingDetachOptions) error {
+	if len(options.InstanceIds) > 0 {
+		desiredCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+		if err != nil {
+			return err
+		}
+		client.printPlan("AutoScaling.DetachInstances", "%s InstanceIds %s with ShouldDecrementDesiredCapacity (DesiredCapacity %d => %d)",
+			options.AsgName, formatPlanValues(options.InstanceIds), desiredCapacity, desiredCapacity-int64(len(options.InstanceIds)))
+	}
+
+	if len(options.LoadBalancerNames) > 0 {
+		client.printPlan("AutoScaling.DetachLoadBalancers", "%s LoadBalancerNames %s", options.AsgName, formatPlanValues(options.LoadBalancerNames))
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestAutoscalingDetach(t *testing.T) {
+	c := newFakeCluster(2)
+	c.loadBalancerNames = aws.StringSlice([]string{"clb"})
+	client, _ := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+	err := client.AutoscalingDetach(AutoscalingDetachOptions{
+		AsgName:           c.asgName,
+		InstanceIds:       aws.StringSlice([]string{"i-0001"}),
+		LoadBalancerNames: aws.StringSlice([]string{"clb"}),
+		Wait:              true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := c.group(); *got.DesiredCapacity != 1 || len(got.Instances) != 1 || *got.Instances[0].InstanceId != "i-0002" {
+		t.Errorf("unexpected group: %s", got)
+	}
+	if len(c.loadBalancerNames) != 0 {
+		t.Errorf("LoadBalancerNames = %v, want empty", aws.StringValueSlice(c.loadBalancerNames))
+	}
+}
@@ -1,7 +1,6 @@
 package myaws
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -12,36 +11,61 @@ import (
 
 // AutoscalingLsOptions customize the behavior of the Ls command.
 type AutoscalingLsOptions struct {
-	All bool
+	All     bool
+	Targets TargetOptions
 }
 
 // AutoscalingLs describes autoscaling groups.
 func (client *Client) AutoscalingLs(options AutoscalingLsOptions) error {
-	params := &autoscaling.DescribeAutoScalingGroupsInput{}
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		params := &autoscaling.DescribeAutoScalingGroupsInput{}
 
-	response, err := client.AutoScaling.DescribeAutoScalingGroups(params)
-	if err != nil {
-		return errors.Wrap(err, "DescribeAutoScalingGroups failed:")
+		resources := []interface{}{}
+		err := c.AutoScaling.DescribeAutoScalingGroupsPagesWithContext(aws.BackgroundContext(), params,
+			func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+				for _, asg := range page.AutoScalingGroups {
+					resources = append(resources, asg)
+				}
+				return true
+			})
+		if err != nil {
+			return nil, errors.Wrap(err, "DescribeAutoScalingGroups failed:")
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	for _, asg := range response.AutoScalingGroups {
+	fields := options.Targets.outputFields([]string{
+		"Instances",
+		"AutoScalingGroupName",
+		"InstanceIds",
+		"LoadBalancerNames",
+	})
+
+	rows := [][]string{}
+	for _, resource := range resources {
+		r, target := client.unwrapTargetResource(resource)
+		asg := r.(*autoscaling.Group)
 		if options.All || len(asg.Instances) > 0 {
-			fmt.Fprintln(client.stdout, formatAutoscalingGroup(asg))
+			rows = append(rows, options.Targets.formatRow(target, formatAutoscalingGroup(asg)))
 		}
 	}
 
-	return nil
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
+	}
+	return findErr
 }
 
-func formatAutoscalingGroup(asg *autoscaling.Group) string {
-	output := []string{
+func formatAutoscalingGroup(asg *autoscaling.Group) []string {
+	return []string{
 		formatAutoscalingInstacesLen(asg.Instances),
 		*asg.AutoScalingGroupName,
 		formatAutoscalingInstanceIds(asg.Instances),
 		formatAutoscalingLoadBalancerNames(asg.LoadBalancerNames),
 	}
-
-	return strings.Join(output[:], "\t")
 }
 
 func formatAutoscalingInstacesLen(instances []*autoscaling.Instance) string {
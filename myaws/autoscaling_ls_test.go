@@ -0,0 +1,35 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestAutoscalingLs(t *testing.T) {
+	cases := []struct {
+		desc      string
+		instances int
+		all       bool
+		want      string
+	}{
+		{desc: "with instances", instances: 2, want: "2\ttest-asg\ti-0001 i-0002\tclb\n"},
+		{desc: "empty group", instances: 0, want: ""},
+		{desc: "empty group with all", instances: 0, all: true, want: "0\ttest-asg\t\tclb\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newFakeCluster(tc.instances)
+			c.loadBalancerNames = aws.StringSlice([]string{"clb"})
+			client, stdout := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+			if err := client.AutoscalingLs(AutoscalingLsOptions{All: tc.all}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tc.want)
+			}
+		})
+	}
+}
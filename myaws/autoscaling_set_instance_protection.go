@@ -1,6 +1,9 @@
 package myaws
 
 import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/pkg/errors"
 	funk "github.com/thoas/go-funk"
@@ -15,6 +18,10 @@ type AutoScalingSetInstanceProtectionOptions struct {
 
 // AutoScalingSetInstanceProtection protects from termination when scale in your autoscaling group.
 func (client *Client) AutoScalingSetInstanceProtection(options AutoScalingSetInstanceProtectionOptions) error {
+	if client.dryRun {
+		return client.planAutoScalingSetInstanceProtection(options)
+	}
+
 	// the number of maximum InstanceIds is limited to 19.
 	// https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_SetInstanceProtection.html
 	maxInstanceIDCount := 19
@@ -34,9 +41,31 @@ func (client *Client) autoScalingSetInstanceProtectionInstances(asgName string,
 		ProtectedFromScaleIn: &protectedFromScaleIn,
 	}
 
-	if _, err := client.AutoScaling.SetInstanceProtection(params); err != nil {
+	_, err := client.AutoScaling.SetInstanceProtection(params)
+	client.auditCall("AutoScaling.SetInstanceProtection", params, auditResources(asgName, instanceIds), err)
+	if err != nil {
 		return errors.Wrap(err, "SetInstanceProtection failed:")
 	}
 
 	return nil
 }
+
+func (client *Client) planAutoScalingSetInstanceProtection(options AutoScalingSetInstanceProtectionOptions) error {
+	instances, err := client.getAutoScalingGroupInstances(options.AsgName)
+	if err != nil {
+		return err
+	}
+	protected := map[string]string{}
+	for _, instance := range instances {
+		protected[*instance.InstanceId] = fmt.Sprint(aws.BoolValue(instance.ProtectedFromScaleIn))
+	}
+
+	for _, id := range options.InstanceIds {
+		current, ok := protected[*id]
+		if !ok {
+			current = "(not in the group)"
+		}
+		client.printPlan("AutoScaling.SetInstanceProtection", "%s %s ProtectedFromScaleIn %s => %t", options.AsgName, *id, current, options.ProtectedFromScaleIn)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package myaws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestAutoScalingSetInstanceProtection(t *testing.T) {
+	// The number of instance ids in a request is limited to 19.
+	c := newFakeCluster(40)
+	client, _ := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+	ids := []*string{}
+	for i := 1; i <= 40; i++ {
+		ids = append(ids, aws.String(fmt.Sprintf("i-%04d", i)))
+	}
+
+	err := client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
+		AsgName:              c.asgName,
+		InstanceIds:          ids,
+		ProtectedFromScaleIn: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := len(c.protectionCalls); got != 3 {
+		t.Errorf("len(SetInstanceProtection calls) = %d, want 3", got)
+	}
+	for _, instance := range c.instances {
+		if !*instance.ProtectedFromScaleIn {
+			t.Errorf("instance %s is not protected", *instance.InstanceId)
+		}
+	}
+}
@@ -12,17 +12,51 @@ type AutoscalingUpdateOptions struct {
 	AsgName         string
 	DesiredCapacity int64
 	Wait            bool
+	// Select chooses an autoscaling group in a fuzzy finder.
+	Select bool
 }
 
 // AutoscalingUpdate updates autoscaling group setting.
 // Available param is currently desired-capacity only.
 func (client *Client) AutoscalingUpdate(options AutoscalingUpdateOptions) error {
+	if options.Select {
+		asgName, err := client.pickAutoScalingGroup()
+		if err != nil {
+			return err
+		}
+		options.AsgName = asgName
+	}
+
+	if err := client.guardAutoScalingGroup(options.AsgName); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Are you sure want to update the desired capacity of autoscaling group %s to %d?", options.AsgName, options.DesiredCapacity)
+	if ok, err := client.confirm(message); !ok {
+		return err
+	}
+
+	return client.autoscalingUpdate(options)
+}
+
+// autoscalingUpdate updates autoscaling group setting without confirmation.
+func (client *Client) autoscalingUpdate(options AutoscalingUpdateOptions) error {
+	if client.dryRun {
+		desiredCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+		if err != nil {
+			return err
+		}
+		client.printPlan("AutoScaling.SetDesiredCapacity", "%s DesiredCapacity %d => %d", options.AsgName, desiredCapacity, options.DesiredCapacity)
+		return nil
+	}
+
 	params := &autoscaling.SetDesiredCapacityInput{
 		AutoScalingGroupName: &options.AsgName,
 		DesiredCapacity:      &options.DesiredCapacity,
 	}
 
-	if _, err := client.AutoScaling.SetDesiredCapacity(params); err != nil {
+	_, err := client.AutoScaling.SetDesiredCapacity(params)
+	client.auditCall("AutoScaling.SetDesiredCapacity", params, auditResources(options.AsgName, nil), err)
+	if err != nil {
 		return errors.Wrap(err, "SetDesiredCapacity failed:")
 	}
 
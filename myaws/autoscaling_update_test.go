@@ -0,0 +1,45 @@
+package myaws
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAutoscalingUpdate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		desired int64
+		want    []string
+	}{
+		{desc: "scale out", desired: 4, want: []string{"i-0001", "i-0002", "i-0003", "i-0004"}},
+		{desc: "scale in", desired: 1, want: []string{"i-0002"}},
+		{desc: "scale to zero", desired: 0, want: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newFakeCluster(2)
+			client, _ := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+			err := client.AutoscalingUpdate(AutoscalingUpdateOptions{
+				AsgName:         c.asgName,
+				DesiredCapacity: tc.desired,
+				Wait:            true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			got := []string{}
+			for _, instance := range c.instances {
+				if *instance.LifecycleState != "InService" {
+					t.Errorf("instance %s is %s, want InService", *instance.InstanceId, *instance.LifecycleState)
+				}
+				got = append(got, *instance.InstanceId)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("instances = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -83,6 +83,7 @@ func (client *Client) waitUntilAutoScalingGroupNumberOfInstancesEqualsDesiredCap
 			return req, nil
 		},
 	}
+	w.ApplyOptions(client.waiterOptions...)
 	w.ApplyOptions(opts...)
 
 	return w.WaitWithContext(ctx)
@@ -123,6 +124,7 @@ func (client *Client) waitUntilAutoScalingGroupAllInstancesAreInServiceWithConte
 			return req, nil
 		},
 	}
+	w.ApplyOptions(client.waiterOptions...)
 	w.ApplyOptions(opts...)
 
 	return w.WaitWithContext(ctx)
@@ -5,68 +5,163 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
 	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
 	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/pkg/errors"
 )
 
 // Client represents myaws CLI
 type Client struct {
-	config      *aws.Config
-	stdin       io.Reader
-	stdout      io.Writer
-	stderr      io.Writer
-	profile     string
-	region      string
-	timezone    string
-	humanize    bool
-	debug       bool
-	AutoScaling *autoscaling.AutoScaling
-	EC2         *ec2.EC2
-	ECS         *ecs.ECS
-	ECR         *ecr.ECR
-	ELB         *elb.ELB
-	ELBV2       *elbv2.ELBV2
-	IAM         *iam.IAM
-	RDS         *rds.RDS
-	SSM         *ssm.SSM
-	STS         *sts.STS
+	config     *aws.Config
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	profile    string
+	region     string
+	timezone   string
+	humanize   bool
+	debug      bool
+	output     string
+	credential CredentialOptions
+	endpoint   EndpointOptions
+	// dryRun prints a plan of write API calls instead of calling them.
+	dryRun bool
+	// guard requires confirmation of destructive operations and refuses to
+	// change protected resources.
+	guard GuardOptions
+	// audit records mutating API calls to the audit log.
+	audit AuditOptions
+	// auditCaller caches the caller identity for the audit log. It's resolved
+	// once, because API calls can be audited concurrently.
+	auditCaller     *sts.GetCallerIdentityOutput
+	auditCallerOnce sync.Once
+	waiterOptions   []request.WaiterOption
+	AutoScaling     autoscalingiface.AutoScalingAPI
+	EC2             ec2iface.EC2API
+	ECS             ecsiface.ECSAPI
+	ECR             ecriface.ECRAPI
+	ELB             elbiface.ELBAPI
+	ELBV2           elbv2iface.ELBV2API
+	IAM             iamiface.IAMAPI
+	RDS             rdsiface.RDSAPI
+	SSM             ssmiface.SSMAPI
+	STS             stsiface.STSAPI
+}
+
+// Services is a set of AWS service clients used by Client.
+type Services struct {
+	AutoScaling autoscalingiface.AutoScalingAPI
+	EC2         ec2iface.EC2API
+	ECS         ecsiface.ECSAPI
+	ECR         ecriface.ECRAPI
+	ELB         elbiface.ELBAPI
+	ELBV2       elbv2iface.ELBV2API
+	IAM         iamiface.IAMAPI
+	RDS         rdsiface.RDSAPI
+	SSM         ssmiface.SSMAPI
+	STS         stsiface.STSAPI
 }
 
 // NewClient initializes Client instance
-func NewClient(stdin io.Reader, stdout io.Writer, stderr io.Writer, profile string, region string, timezone string, humanize bool, debug bool) (*Client, error) {
+func NewClient(stdin io.Reader, stdout io.Writer, stderr io.Writer, profile string, region string, timezone string, humanize bool, debug bool, output string, credential CredentialOptions, endpoint EndpointOptions, dryRun bool, guard GuardOptions, audit AuditOptions) (*Client, error) {
+	if err := validateOutputFormat(output); err != nil {
+		return nil, err
+	}
+	if err := endpoint.validate(); err != nil {
+		return nil, err
+	}
+	if err := guard.validate(); err != nil {
+		return nil, err
+	}
+
+	auditPath, err := audit.auditPath()
+	if err != nil {
+		return nil, err
+	}
+	audit.Path = auditPath
+
 	session := session.New()
-	config := newConfig(profile, region, debug)
+	config, err := newConfig(profile, region, debug, credential, endpoint, newMFATokenProvider(stdin, stderr))
+	if err != nil {
+		return nil, err
+	}
+
+	services := Services{
+		AutoScaling: autoscaling.New(session, endpoint.serviceConfig(config, "autoscaling")),
+		EC2:         ec2.New(session, endpoint.serviceConfig(config, "ec2")),
+		ECS:         ecs.New(session, endpoint.serviceConfig(config, "ecs")),
+		ECR:         ecr.New(session, endpoint.serviceConfig(config, "ecr")),
+		ELB:         elb.New(session, endpoint.serviceConfig(config, "elb")),
+		ELBV2:       elbv2.New(session, endpoint.serviceConfig(config, "elbv2")),
+		IAM:         iam.New(session, endpoint.serviceConfig(config, "iam")),
+		RDS:         rds.New(session, endpoint.serviceConfig(config, "rds")),
+		SSM:         ssm.New(session, endpoint.serviceConfig(config, "ssm")),
+		STS:         sts.New(session, endpoint.serviceConfig(config, "sts")),
+	}
+
+	client, err := NewClientWithServices(stdin, stdout, stderr, config, timezone, humanize, output, services)
+	if err != nil {
+		return nil, err
+	}
+	client.profile = profile
+	client.debug = debug
+	client.credential = credential
+	client.endpoint = endpoint
+	client.dryRun = dryRun
+	client.guard = guard
+	client.audit = audit
+	return client, nil
+}
+
+// NewClientWithServices initializes Client instance with given AWS service
+// clients. It allows us to inject fakes for testing.
+func NewClientWithServices(stdin io.Reader, stdout io.Writer, stderr io.Writer, config *aws.Config, timezone string, humanize bool, output string, services Services) (*Client, error) {
+	if err := validateOutputFormat(output); err != nil {
+		return nil, err
+	}
+
 	client := &Client{
 		config:      config,
 		stdin:       stdin,
 		stdout:      stdout,
 		stderr:      stderr,
-		profile:     profile,
-		region:      region,
+		region:      aws.StringValue(config.Region),
 		timezone:    timezone,
 		humanize:    humanize,
-		AutoScaling: autoscaling.New(session, config),
-		EC2:         ec2.New(session, config),
-		ECS:         ecs.New(session, config),
-		ECR:         ecr.New(session, config),
-		ELB:         elb.New(session, config),
-		ELBV2:       elbv2.New(session, config),
-		IAM:         iam.New(session, config),
-		RDS:         rds.New(session, config),
-		SSM:         ssm.New(session, config),
-		STS:         sts.New(session, config),
+		output:      output,
+		AutoScaling: services.AutoScaling,
+		EC2:         services.EC2,
+		ECS:         services.ECS,
+		ECR:         services.ECR,
+		ELB:         services.ELB,
+		ELBV2:       services.ELBV2,
+		IAM:         services.IAM,
+		RDS:         services.RDS,
+		SSM:         services.SSM,
+		STS:         services.STS,
 	}
 	return client, nil
 }
@@ -5,17 +5,61 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
 )
 
+// CredentialOptions customize how to get AWS credentials.
+type CredentialOptions struct {
+	// RoleArn is an ARN of an IAM role to assume with the base credentials.
+	RoleArn string
+	// ExternalID is an external ID passed to AssumeRole.
+	ExternalID string
+	// MFASerial is a serial number or an ARN of an MFA device passed to
+	// AssumeRole. The token code is prompted.
+	MFASerial string
+}
+
 // newConfig creates *aws.config from profile and region options.
 // AWS credentials are checked in the order of
 // profile, environment variables, IAM Task Role (ECS), IAM Role.
 // Unlike the aws default, load profile before environment variables
 // because we want to prioritize explicit arguments over the environment.
-func newConfig(profile string, region string, debug bool) *aws.Config {
+// If a role ARN is given, assume it with the above credentials. An MFA device
+// is only used to assume the role, so it's an error without a role ARN.
+// The endpoint options are applied to the STS client to assume the role.
+func newConfig(profile string, region string, debug bool, credential CredentialOptions, endpoint EndpointOptions, tokenProvider func() (string, error)) (*aws.Config, error) {
+	if credential.MFASerial != "" && credential.RoleArn == "" {
+		return nil, errors.New("--mfa-serial requires --role-arn")
+	}
+
 	defaultConfig := defaults.Get().Config
-	cred := newCredentials(getenv(profile, "AWS_DEFAULT_PROFILE"), getenv(region, "AWS_DEFAULT_REGION"))
+	if httpClient := endpoint.httpClient(); httpClient != nil {
+		defaultConfig = defaultConfig.WithHTTPClient(httpClient)
+	}
+	profile = getenv(profile, "AWS_DEFAULT_PROFILE")
+	region = getenv(region, "AWS_DEFAULT_REGION")
+
+	cred, profileRegion, err := newCredentials(profile, region, tokenProvider)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		// fallback to the region in the shared config of the profile.
+		region = profileRegion
+	}
+
+	if credential.RoleArn != "" {
+		stsConfig := endpoint.serviceConfig(defaultConfig.Copy().WithCredentials(cred).WithRegion(region), "sts")
+		cred = newAssumeRoleCredentials(stsConfig, credential, tokenProvider)
+	}
+
+	if profile != "" || credential.RoleArn != "" {
+		cred = newCachedCredentials(cred, credentialCacheKey(profile, credential))
+	}
 
 	logLevel := aws.LogLevel(aws.LogOff)
 	if debug {
@@ -25,13 +69,35 @@ func newConfig(profile string, region string, debug bool) *aws.Config {
 
 	config := defaultConfig.
 		WithCredentials(cred).
-		WithRegion(getenv(region, "AWS_DEFAULT_REGION")).
+		WithRegion(region).
 		WithLogLevel(*logLevel)
 
-	return config
+	return config, nil
+}
+
+// newCredentials returns credentials and a region of the profile.
+// If the profile is given, it is loaded from the shared config and
+// credentials files in the same way as the aws cli, so that role_arn,
+// source_profile, mfa_serial, credential_process and sso_* are supported.
+func newCredentials(profile string, region string, tokenProvider func() (string, error)) (*credentials.Credentials, string, error) {
+	if profile == "" {
+		return newDefaultCredentials(profile, region), "", nil
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:                  aws.Config{Region: aws.String(region)},
+		Profile:                 profile,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: tokenProvider,
+	})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to load profile %s:", profile)
+	}
+
+	return sess.Config.Credentials, aws.StringValue(sess.Config.Region), nil
 }
 
-func newCredentials(profile string, region string) *credentials.Credentials {
+func newDefaultCredentials(profile string, region string) *credentials.Credentials {
 	// temporary config to resolve RemoteCredProvider
 	tmpConfig := defaults.Get().Config.WithRegion(region)
 	tmpHandlers := defaults.Handlers()
@@ -48,6 +114,21 @@ func newCredentials(profile string, region string) *credentials.Credentials {
 		})
 }
 
+// newAssumeRoleCredentials returns credentials of the role assumed with the
+// base credentials in the config.
+func newAssumeRoleCredentials(config *aws.Config, credential CredentialOptions, tokenProvider func() (string, error)) *credentials.Credentials {
+	svc := sts.New(session.New(), config)
+	return stscreds.NewCredentialsWithClient(svc, credential.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+		if credential.ExternalID != "" {
+			p.ExternalID = aws.String(credential.ExternalID)
+		}
+		if credential.MFASerial != "" {
+			p.SerialNumber = aws.String(credential.MFASerial)
+			p.TokenProvider = tokenProvider
+		}
+	})
+}
+
 func getenv(value, key string) string {
 	if len(value) == 0 {
 		return os.Getenv(key)
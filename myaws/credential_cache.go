@@ -0,0 +1,169 @@
+package myaws
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/pkg/errors"
+)
+
+// credentialCacheExpiryWindow is a margin to refresh cached credentials
+// before they actually expire.
+const credentialCacheExpiryWindow = 5 * time.Minute
+
+// credentialCacheMutex serializes retrieving credentials, so that
+// concurrent clients for multiple regions don't prompt for MFA at once and
+// reuse the cached credentials instead.
+var credentialCacheMutex sync.Mutex
+
+// cachedCredential is an on-disk representation of temporary credentials.
+type cachedCredential struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// cachedProvider is a credentials.Provider which caches temporary
+// credentials in a file, so that repeated invocations don't re-prompt for MFA.
+// Credentials without a known expiration such as static keys are never
+// cached.
+type cachedProvider struct {
+	credentials.Expiry
+	creds    *credentials.Credentials
+	path     string
+	uncached bool
+}
+
+func newCachedCredentials(creds *credentials.Credentials, key string) *credentials.Credentials {
+	return credentials.NewCredentials(&cachedProvider{
+		creds: creds,
+		path:  credentialCachePath(key),
+	})
+}
+
+// credentialCacheKey returns a key of the cache which identifies the source
+// of credentials.
+func credentialCacheKey(profile string, credential CredentialOptions) string {
+	return strings.Join([]string{profile, credential.RoleArn, credential.ExternalID, credential.MFASerial}, "\n")
+}
+
+// credentialCachePath returns a path of the cache file.
+// The cache directory is $HOME/.myaws/cache/credentials.
+func credentialCachePath(key string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(home, ".myaws", "cache", "credentials", hex.EncodeToString(sum[:])+".json")
+}
+
+// Retrieve returns the cached credentials if they are still valid.
+// Otherwise, it retrieves new credentials and caches them.
+func (p *cachedProvider) Retrieve() (credentials.Value, error) {
+	credentialCacheMutex.Lock()
+	defer credentialCacheMutex.Unlock()
+
+	p.uncached = false
+	if c, ok := readCredentialCache(p.path); ok {
+		p.SetExpiration(c.Expiration, credentialCacheExpiryWindow)
+		return credentials.Value{
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			SessionToken:    c.SessionToken,
+			ProviderName:    "CachedProvider",
+		}, nil
+	}
+
+	v, err := p.creds.Get()
+	if err != nil {
+		return v, err
+	}
+
+	expiration, err := p.creds.ExpiresAt()
+	if err != nil {
+		// The expiration is unknown, so delegate it to the original credentials.
+		p.uncached = true
+		return v, nil
+	}
+
+	p.SetExpiration(expiration, credentialCacheExpiryWindow)
+	// The cache is best effort, so ignore the error.
+	writeCredentialCache(p.path, cachedCredential{
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
+		SessionToken:    v.SessionToken,
+		Expiration:      expiration,
+	})
+	return v, nil
+}
+
+// IsExpired returns true if the credentials need to be refreshed.
+func (p *cachedProvider) IsExpired() bool {
+	if p.uncached {
+		return p.creds.IsExpired()
+	}
+	return p.Expiry.IsExpired()
+}
+
+func readCredentialCache(path string) (cachedCredential, bool) {
+	var c cachedCredential
+	if path == "" {
+		return c, false
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, false
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, false
+	}
+	if time.Now().Add(credentialCacheExpiryWindow).After(c.Expiration) {
+		return c, false
+	}
+	return c, true
+}
+
+func writeCredentialCache(path string, c cachedCredential) error {
+	if path == "" {
+		return errors.New("cache path is unknown")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create cache directory:")
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal credentials:")
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// newMFATokenProvider returns a function which prompts for an MFA token code
+// on stderr and reads it from stdin.
+func newMFATokenProvider(stdin io.Reader, stderr io.Writer) func() (string, error) {
+	return func() (string, error) {
+		fmt.Fprint(stderr, "Enter MFA code: ")
+
+		reader := bufio.NewReader(stdin)
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return "", errors.Wrap(err, "failed to read MFA code:")
+		}
+		return strings.TrimSpace(input), nil
+	}
+}
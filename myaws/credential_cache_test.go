@@ -0,0 +1,117 @@
+package myaws
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// countingProvider returns temporary credentials and counts calls.
+type countingProvider struct {
+	credentials.Expiry
+	calls      int
+	expiration time.Time
+}
+
+func (p *countingProvider) Retrieve() (credentials.Value, error) {
+	p.calls++
+	p.SetExpiration(p.expiration, 0)
+	return credentials.Value{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}, nil
+}
+
+func setTestHome(t *testing.T) {
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { os.Setenv("HOME", home) })
+}
+
+func TestCachedCredentials(t *testing.T) {
+	setTestHome(t)
+	p := &countingProvider{expiration: time.Now().Add(time.Hour)}
+	key := credentialCacheKey("dev", CredentialOptions{RoleArn: "arn:aws:iam::123456789012:role/admin"})
+
+	for i := 0; i < 2; i++ {
+		// A new process reads the cache file instead of the provider.
+		creds := newCachedCredentials(credentials.NewCredentials(p), key)
+		v, err := creds.Get()
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+		if v.AccessKeyID != "ASIAEXAMPLE" || v.SessionToken != "token" {
+			t.Errorf("unexpected credentials: %+v", v)
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("provider is called %d times, want 1", p.calls)
+	}
+
+	// Another key doesn't share the cache.
+	other := credentialCacheKey("prd", CredentialOptions{RoleArn: "arn:aws:iam::123456789012:role/admin"})
+	if _, err := newCachedCredentials(credentials.NewCredentials(p), other).Get(); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if p.calls != 2 {
+		t.Errorf("provider is called %d times, want 2", p.calls)
+	}
+}
+
+func TestCachedCredentialsExpired(t *testing.T) {
+	setTestHome(t)
+	// It expires within the expiry window.
+	p := &countingProvider{expiration: time.Now().Add(time.Minute)}
+	key := credentialCacheKey("dev", CredentialOptions{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := newCachedCredentials(credentials.NewCredentials(p), key).Get(); err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+	}
+	if p.calls != 2 {
+		t.Errorf("provider is called %d times, want 2", p.calls)
+	}
+}
+
+func TestCachedCredentialsStatic(t *testing.T) {
+	setTestHome(t)
+	key := credentialCacheKey("dev", CredentialOptions{})
+	creds := newCachedCredentials(credentials.NewStaticCredentials("AKIAEXAMPLE", "secret", ""), key)
+
+	if _, err := creds.Get(); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, ok := readCredentialCache(credentialCachePath(key)); ok {
+		t.Error("static credentials should not be cached")
+	}
+}
+
+func TestMFATokenProvider(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	provider := newMFATokenProvider(strings.NewReader(" 123456 \n"), stderr)
+
+	code, err := provider()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if code != "123456" {
+		t.Errorf("code = %q, want 123456", code)
+	}
+	if got := stderr.String(); got != "Enter MFA code: " {
+		t.Errorf("stderr = %q", got)
+	}
+
+	if _, err := newMFATokenProvider(strings.NewReader(""), stderr)(); err == nil {
+		t.Error("expected an error for empty input")
+	}
+}
+
+func TestNewConfigMFASerialWithoutRoleArn(t *testing.T) {
+	credential := CredentialOptions{MFASerial: "arn:aws:iam::123456789012:mfa/alice"}
+	_, err := newConfig("", "", false, credential, EndpointOptions{}, nil)
+	if err == nil || err.Error() != "--mfa-serial requires --role-arn" {
+		t.Errorf("expected an error of --mfa-serial, got: %v", err)
+	}
+}
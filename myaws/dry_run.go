@@ -0,0 +1,70 @@
+package myaws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+)
+
+// printPlan prints an API call which would be made in dry-run mode.
+func (client *Client) printPlan(api string, format string, a ...interface{}) {
+	fmt.Fprintf(client.stdout, "[dry-run] %s: %s\n", api, fmt.Sprintf(format, a...))
+}
+
+// formatPlanValues formats a list of values in a plan.
+func formatPlanValues(values []*string) string {
+	return "[" + strings.Join(aws.StringValueSlice(values), ", ") + "]"
+}
+
+// checkEC2DryRun converts an error of an EC2 API call with the DryRun
+// parameter. The DryRunOperation error means the request would have
+// succeeded, so it returns nil. Other errors such as UnauthorizedOperation
+// are returned as is.
+func checkEC2DryRun(err error) error {
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "DryRunOperation" {
+		return nil
+	}
+	if err == nil {
+		return errors.New("the request with DryRun unexpectedly succeeded")
+	}
+	return err
+}
+
+// describeEC2InstanceStates returns a map of instance IDs to their state
+// names.
+func (client *Client) describeEC2InstanceStates(instanceIds []*string) (map[string]string, error) {
+	states := map[string]string{}
+	err := client.EC2.DescribeInstancesPagesWithContext(aws.BackgroundContext(), &ec2.DescribeInstancesInput{InstanceIds: instanceIds},
+		func(p *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, r := range p.Reservations {
+				for _, instance := range r.Instances {
+					states[*instance.InstanceId] = *instance.State.Name
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "DescribeInstances failed:")
+	}
+	return states, nil
+}
+
+// planEC2InstanceStates prints a plan to change states of EC2 instances.
+func (client *Client) planEC2InstanceStates(api string, instanceIds []*string, state string) error {
+	states, err := client.describeEC2InstanceStates(instanceIds)
+	if err != nil {
+		return err
+	}
+	for _, id := range instanceIds {
+		current, ok := states[*id]
+		if !ok {
+			current = "(not found)"
+		}
+		client.printPlan(api, "%s State %s => %s", *id, current, state)
+	}
+	return nil
+}
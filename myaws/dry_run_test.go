@@ -0,0 +1,205 @@
+package myaws
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func assertPlan(t *testing.T, stdout string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(stdout, "[dry-run] "+w+"\n") {
+			t.Errorf("stdout doesn't contain a plan %q:\n%s", w, stdout)
+		}
+	}
+}
+
+func TestEC2StartStopDryRun(t *testing.T) {
+	f := newFakeEC2WithInstances()
+	client, stdout := newTestClient(t, Services{EC2: f})
+	client.dryRun = true
+
+	if err := client.EC2Start(EC2StartOptions{InstanceIds: aws.StringSlice([]string{"i-0003"}), Wait: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := client.EC2Stop(EC2StopOptions{InstanceIds: aws.StringSlice([]string{"i-0001"}), Wait: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	assertPlan(t, stdout.String(),
+		"EC2.StartInstances: i-0003 State stopped => running",
+		"EC2.StopInstances: i-0001 State running => stopped",
+	)
+	if *f.instances[0].State.Name != "running" || *f.instances[2].State.Name != "stopped" {
+		t.Error("instance states should not change")
+	}
+	if len(f.waitUntilRunning) != 0 || len(f.waitUntilStopped) != 0 {
+		t.Error("should not wait in dry-run mode")
+	}
+}
+
+func TestAutoscalingDryRun(t *testing.T) {
+	c := newFakeCluster(2)
+	client, stdout := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+	client.dryRun = true
+
+	if err := client.AutoscalingUpdate(AutoscalingUpdateOptions{AsgName: c.asgName, DesiredCapacity: 4, Wait: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := client.AutoscalingDetach(AutoscalingDetachOptions{AsgName: c.asgName, InstanceIds: aws.StringSlice([]string{"i-0001"})}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	assertPlan(t, stdout.String(),
+		"AutoScaling.SetDesiredCapacity: test-asg DesiredCapacity 2 => 4",
+		"AutoScaling.DetachInstances: test-asg InstanceIds [i-0001] with ShouldDecrementDesiredCapacity (DesiredCapacity 2 => 1)",
+	)
+	if len(c.setDesiredCapacityCalls) != 0 || c.desiredCapacity != 2 || len(c.instances) != 2 {
+		t.Errorf("the group should not change: %v", c.setDesiredCapacityCalls)
+	}
+}
+
+func TestSSMParameterDryRun(t *testing.T) {
+	f := newFakeSSM("foo", "old")
+	f.put("secret", "p@ss", "SecureString", "")
+	client, stdout := newTestClient(t, Services{SSM: f})
+	client.dryRun = true
+
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "foo", Value: "new"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "bar", Value: "s3cret", KeyID: "alias/myapp"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if err := client.SSMParameterDel(SSMParameterDelOptions{Names: []string{"secret"}}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	got := stdout.String()
+	assertPlan(t, got,
+		`SSM.PutParameter: foo (Version 1) Type String => String, Value "old" => "new"`,
+		"SSM.PutParameter: bar (new) Type SecureString, Value (secure)",
+		"SSM.DeleteParameter: secret (Type SecureString, Version 1)",
+	)
+	if strings.Contains(got, "s3cret") || strings.Contains(got, "p@ss") {
+		t.Errorf("secure values should be masked:\n%s", got)
+	}
+	if *f.find("foo").Value != "old" || f.find("bar") != nil || f.find("secret") == nil {
+		t.Error("parameters should not change")
+	}
+}
+
+func TestIAMUserResetPasswordDryRun(t *testing.T) {
+	f := &fakeIAM{users: []*iam.User{{UserName: aws.String("alice")}}}
+	f.setLoginProfile("alice", "old", false)
+	client, stdout := newTestClient(t, Services{IAM: f})
+	client.dryRun = true
+	// No confirmation is needed in dry-run mode.
+	client.stdin = strings.NewReader("")
+
+	if err := client.IAMUserResetPassword(IAMUserResetPasswordOptions{UserName: "alice"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	assertPlan(t, stdout.String(), "IAM.UpdateLoginProfile: alice Password => (random), PasswordResetRequired => true")
+	if f.loginProfiles["alice"] != "old" || f.passwordResetRequired["alice"] {
+		t.Error("the login profile should not change")
+	}
+}
+
+func TestECSNodeDrainDryRun(t *testing.T) {
+	c := newFakeCluster(2, newFakeECSService("web", 2, ""))
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+	client.dryRun = true
+
+	arn := *c.containerInstances[0].ContainerInstanceArn
+	err := client.ECSNodeDrain(ECSNodeDrainOptions{
+		Cluster:            c.cluster,
+		ContainerInstances: aws.StringSlice([]string{arn}),
+		Wait:               true,
+		Timeout:            time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	assertPlan(t, stdout.String(), "ECS.UpdateContainerInstancesState: "+arn+" (i-0001) Status ACTIVE => DRAINING (RunningTasks: 2)")
+	if len(c.updateStateCalls) != 0 {
+		t.Errorf("UpdateContainerInstancesState should not be called: %v", c.updateStateCalls)
+	}
+}
+
+func TestECSNodeRenewDryRun(t *testing.T) {
+	cases := []struct {
+		desc     string
+		strategy string
+		want     []string
+	}{
+		{
+			desc:     "blue-green",
+			strategy: ECSNodeRenewStrategyBlueGreen,
+			want: []string{
+				"AutoScaling.SetDesiredCapacity: test-asg DesiredCapacity 3 => 6",
+				"AutoScaling.SetInstanceProtection: test-asg 3 new instances ProtectedFromScaleIn false => true",
+				"AutoScaling.SetDesiredCapacity: test-asg DesiredCapacity 6 => 3",
+			},
+		},
+		{
+			desc:     "rolling",
+			strategy: ECSNodeRenewStrategyRolling,
+			want: []string{
+				"AutoScaling.SetDesiredCapacity: test-asg DesiredCapacity 3 => 4",
+				"AutoScaling.DetachInstances: test-asg InstanceIds [i-0001, i-0002] with ShouldDecrementDesiredCapacity (DesiredCapacity 4 => 2)",
+				"EC2.TerminateInstances: i-0001 State running => terminated",
+				"AutoScaling.SetDesiredCapacity: test-asg DesiredCapacity 2 => 3",
+				"AutoScaling.DetachInstances: test-asg InstanceIds [i-0003] with ShouldDecrementDesiredCapacity (DesiredCapacity 4 => 3)",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			setTestHome(t)
+			c := newFakeCluster(3, newFakeECSService("web", 2, ""))
+			ec2 := &fakeEC2{}
+			for _, instance := range c.instances {
+				ec2.instances = append(ec2.instances, newFakeEC2Instance(*instance.InstanceId, "running", "", "", ""))
+			}
+			services := newFakeRenewServices(c, &fakeELBV2{})
+			services.EC2 = ec2
+			client, stdout := newTestClient(t, services)
+			client.dryRun = true
+
+			err := client.ECSNodeRenew(ECSNodeRenewOptions{
+				Cluster:   c.cluster,
+				AsgName:   c.asgName,
+				Timeout:   time.Minute,
+				Strategy:  tc.strategy,
+				BatchSize: 2,
+				MaxSurge:  1,
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+			}
+
+			got := stdout.String()
+			assertPlan(t, got, tc.want...)
+			for _, ci := range c.containerInstances {
+				assertPlan(t, got, "ECS.UpdateContainerInstancesState: "+*ci.ContainerInstanceArn+" ("+*ci.Ec2InstanceId+") Status ACTIVE => DRAINING (RunningTasks: 2)")
+			}
+
+			if len(c.setDesiredCapacityCalls) != 0 || len(c.updateStateCalls) != 0 || len(c.protectionCalls) != 0 || len(ec2.terminateInstancesCalls) != 0 {
+				t.Errorf("no write API should be called: capacity=%v, state=%v, protection=%v, terminate=%v",
+					c.setDesiredCapacityCalls, c.updateStateCalls, c.protectionCalls, ec2.terminateInstancesCalls)
+			}
+			if _, err := os.Stat(ecsNodeRenewStateTestPath(t, c)); !os.IsNotExist(err) {
+				t.Errorf("state file should not be written: %v", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package myaws
 
 import (
+	"regexp"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -10,6 +11,13 @@ import (
 
 // FindEC2Instances returns an array of instances matching the conditions.
 func (client *Client) FindEC2Instances(filterTag string, all bool) ([]*ec2.Instance, error) {
+	return client.FindEC2InstancesWithContext(aws.BackgroundContext(), filterTag, all, 0)
+}
+
+// FindEC2InstancesWithContext returns an array of instances matching the
+// conditions. It fetches all pages until maxItems instances are found.
+// If maxItems is 0, it is unlimited.
+func (client *Client) FindEC2InstancesWithContext(ctx aws.Context, filterTag string, all bool, maxItems int) ([]*ec2.Instance, error) {
 	params := &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
 			buildEC2StateFilter(all),
@@ -17,18 +25,21 @@ func (client *Client) FindEC2Instances(filterTag string, all bool) ([]*ec2.Insta
 		},
 	}
 
-	response, err := client.EC2.DescribeInstances(params)
+	var instances []*ec2.Instance
+	err := client.EC2.DescribeInstancesPagesWithContext(ctx, params,
+		func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				instances = append(instances, reservation.Instances...)
+			}
+			return !reachedMaxItems(len(instances), maxItems)
+		})
 	if err != nil {
 		return nil, errors.Wrap(err, "DescribeInstances failed")
 	}
 
-	var instances []*ec2.Instance
-	for _, reservation := range response.Reservations {
-		for _, instance := range reservation.Instances {
-			instances = append(instances, instance)
-		}
+	if reachedMaxItems(len(instances), maxItems) {
+		instances = instances[:maxItems]
 	}
-
 	return instances, nil
 }
 
@@ -48,7 +59,12 @@ func buildEC2StateFilter(all bool) *ec2.Filter {
 func buildEC2TagFilter(filterTag string) *ec2.Filter {
 	var tagFilter *ec2.Filter
 	if filterTag != "" {
-		tagParts := strings.Split(filterTag, ":")
+		// The value of tag may contain colons, so we split only at the first one.
+		// If the value is omitted, match any resources with the tag key.
+		tagParts := strings.SplitN(filterTag, ":", 2)
+		if len(tagParts) == 1 {
+			tagParts = append(tagParts, "")
+		}
 		tagFilter = &ec2.Filter{
 			Name: aws.String("tag:" + tagParts[0]),
 			Values: []*string{
@@ -61,72 +77,172 @@ func buildEC2TagFilter(filterTag string) *ec2.Filter {
 
 // FindEC2Volumes returns an array of volumes matching the conditions.
 func (client *Client) FindEC2Volumes(filterTag string, all bool) ([]*ec2.Volume, error) {
+	return client.FindEC2VolumesWithContext(aws.BackgroundContext(), filterTag, all, 0)
+}
+
+// FindEC2VolumesWithContext returns an array of volumes matching the
+// conditions. It fetches all pages until maxItems volumes are found.
+// If maxItems is 0, it is unlimited.
+func (client *Client) FindEC2VolumesWithContext(ctx aws.Context, filterTag string, all bool, maxItems int) ([]*ec2.Volume, error) {
 	params := &ec2.DescribeVolumesInput{}
 
-	response, err := client.EC2.DescribeVolumes(params)
+	var volumes []*ec2.Volume
+	err := client.EC2.DescribeVolumesPagesWithContext(ctx, params,
+		func(page *ec2.DescribeVolumesOutput, lastPage bool) bool {
+			volumes = append(volumes, page.Volumes...)
+			return !reachedMaxItems(len(volumes), maxItems)
+		})
 	if err != nil {
 		return nil, errors.Wrap(err, "DescribeVolumes failed")
 	}
 
-	var volumes []*ec2.Volume
-
-	for _, volume := range response.Volumes {
-		volumes = append(volumes, volume)
+	if reachedMaxItems(len(volumes), maxItems) {
+		volumes = volumes[:maxItems]
 	}
-
 	return volumes, nil
 }
 
 // FindEC2Ips returns an array of volumes matching the conditions.
 func (client *Client) FindEC2Ips(filterTag string, all bool) ([]*ec2.Address, error) {
+	return client.FindEC2IpsWithContext(aws.BackgroundContext(), filterTag, all, 0)
+}
+
+// FindEC2IpsWithContext returns an array of addresses matching the conditions.
+// The DescribeAddresses API doesn't support pagination and returns all
+// addresses at once, so maxItems only truncates the results.
+func (client *Client) FindEC2IpsWithContext(ctx aws.Context, filterTag string, all bool, maxItems int) ([]*ec2.Address, error) {
 	params := &ec2.DescribeAddressesInput{}
 
-	response, err := client.EC2.DescribeAddresses(params)
+	response, err := client.EC2.DescribeAddressesWithContext(ctx, params)
 	if err != nil {
 		return nil, errors.Wrap(err, "DescribeIps failed")
 	}
 
-	var Addresses []*ec2.Address
-
-	for _, Address := range response.Addresses {
-		Addresses = append(Addresses, Address)
+	addresses := response.Addresses
+	if reachedMaxItems(len(addresses), maxItems) {
+		addresses = addresses[:maxItems]
 	}
-
-	return Addresses, nil
+	return addresses, nil
 }
 
 // FindEC2Amis returns an array of volumes matching the conditions.
 func (client *Client) FindEC2Amis(filterTag string, all bool) ([]*ec2.Image, error) {
+	return client.FindEC2AmisWithContext(aws.BackgroundContext(), filterTag, all, 0)
+}
+
+// FindEC2AmisWithContext returns an array of images matching the conditions.
+// The DescribeImages API doesn't support pagination and returns all images at
+// once, so maxItems only truncates the results.
+func (client *Client) FindEC2AmisWithContext(ctx aws.Context, filterTag string, all bool, maxItems int) ([]*ec2.Image, error) {
 	params := &ec2.DescribeImagesInput{}
 
-	response, err := client.EC2.DescribeImages(params)
+	response, err := client.EC2.DescribeImagesWithContext(ctx, params)
 	if err != nil {
 		return nil, errors.Wrap(err, "DescribeAmis failed")
 	}
 
-	var Images []*ec2.Image
-
-	for _, Image := range response.Images {
-		Images = append(Images, Image)
+	images := response.Images
+	if reachedMaxItems(len(images), maxItems) {
+		images = images[:maxItems]
 	}
-
-	return Images, nil
+	return images, nil
 }
 
 // FindEC2Snapshots returns an array of volumes matching the conditions.
 func (client *Client) FindEC2Snapshots(filterTag string, all bool) ([]*ec2.Snapshot, error) {
+	return client.FindEC2SnapshotsWithContext(aws.BackgroundContext(), filterTag, all, 0)
+}
+
+// FindEC2SnapshotsWithContext returns an array of snapshots matching the
+// conditions. It fetches all pages until maxItems snapshots are found. If
+// maxItems is 0, it is unlimited.
+func (client *Client) FindEC2SnapshotsWithContext(ctx aws.Context, filterTag string, all bool, maxItems int) ([]*ec2.Snapshot, error) {
 	params := &ec2.DescribeSnapshotsInput{}
 
-	response, err := client.EC2.DescribeSnapshots(params)
+	var snapshots []*ec2.Snapshot
+	err := client.EC2.DescribeSnapshotsPagesWithContext(ctx, params,
+		func(page *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+			snapshots = append(snapshots, page.Snapshots...)
+			return !reachedMaxItems(len(snapshots), maxItems)
+		})
 	if err != nil {
 		return nil, errors.Wrap(err, "DescribeSnapshots failed")
 	}
 
-	var Snapshots []*ec2.Snapshot
+	if reachedMaxItems(len(snapshots), maxItems) {
+		snapshots = snapshots[:maxItems]
+	}
+	return snapshots, nil
+}
+
+// compileEC2Domain compiles the first pattern of the domain given by -D. It
+// returns nil if no domain is given. We should call it before any API call
+// to fail fast.
+func compileEC2Domain(domain []string) (*regexp.Regexp, error) {
+	if len(domain) == 0 {
+		return nil, nil
+	}
+	re, err := regexp.Compile(domain[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse --domain:")
+	}
+	return re, nil
+}
 
-	for _, Snapshot := range response.Snapshots {
-		Snapshots = append(Snapshots, Snapshot)
+// matchEC2Domain returns true if the Name tag of the resource matches the
+// compiled domain. If no domain is given, it always returns true.
+func matchEC2Domain(domain *regexp.Regexp, resource interface{}) bool {
+	if domain == nil {
+		return true
 	}
+	return domain.MatchString(lookupTag(resource, "Name"))
+}
+
+// ec2PickerFields is a list of fields shown in the picker of EC2 instances.
+var ec2PickerFields = []string{"InstanceId", "Tag:Name", "InstanceType", "PrivateIpAddress", "StateName", "LaunchTime"}
 
-	return Snapshots, nil
+// pickEC2Instances chooses instances in a fuzzy finder.
+func (client *Client) pickEC2Instances(instances []*ec2.Instance, multi bool) ([]*ec2.Instance, error) {
+	resources := []interface{}{}
+	for _, instance := range instances {
+		resources = append(resources, instance)
+	}
+
+	chosen, err := client.pickResources(ec2InstanceFields, ec2PickerFields, resources, multi)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := []*ec2.Instance{}
+	for _, resource := range chosen {
+		picked = append(picked, resource.(*ec2.Instance))
+	}
+	return picked, nil
+}
+
+// pickEC2InstanceIds chooses IDs of instances in a given state in a fuzzy
+// finder.
+func (client *Client) pickEC2InstanceIds(state string) ([]*string, error) {
+	instances, err := client.FindEC2Instances("", true)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*ec2.Instance{}
+	for _, instance := range instances {
+		if *instance.State.Name == state {
+			candidates = append(candidates, instance)
+		}
+	}
+
+	picked, err := client.pickEC2Instances(candidates, true)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIds := []*string{}
+	for _, instance := range picked {
+		instanceIds = append(instanceIds, instance.InstanceId)
+	}
+	return instanceIds, nil
 }
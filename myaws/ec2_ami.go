@@ -2,103 +2,92 @@ package myaws
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2ALsOptions customize the behavior of the Ls command.
 type EC2ALsOptions struct {
-	All       bool
-	Quiet     bool
-	FilterTag string
-	Fields    []string
-	Domain    []string
+	All        bool
+	Quiet      bool
+	FilterTag  string
+	Fields     []string
+	Domain     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
 }
 
+// ec2ImageFields is a registry of output fields for EC2 images.
+var ec2ImageFields = newFieldRegistry(ec2.Image{}, "ImageId", []field{
+	{"AmiName", formatEC2AmiName},
+	{"ImageId", formatEC2AmiImageId},
+	{"CreationDate", formatEC2AmiCreationDate},
+})
+
 // EC2ALs describes EC2 images.
 func (client *Client) EC2ALs(options EC2ALsOptions) error {
-	images, err := client.FindEC2Amis(options.FilterTag, options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2ImageFields)
 	}
 
-	for _, image := range images {
-		fmt.Fprintln(client.stdout, formatEC2Ami(client, options, image))
+	fields := ec2ImageFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := ec2ImageFields.validate(fields); err != nil {
+		return err
 	}
-	return nil
-}
 
-func formatEC2Ami(client *Client, options EC2ALsOptions, image *ec2.Image) string {
-	formatFuncs := map[string]func(client *Client, options EC2ALsOptions, image *ec2.Image) string{
-		"AmiName":      formatEC2AmiName,
-		"ImageId":      formatEC2AmiImageId,
-		"CreationDate": formatEC2AmiCreationDate,
+	q, err := ec2ImageFields.compileQuery(options.Query)
+	if err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"AllocationId"}
-	} else {
-		outputFields = options.Fields
+	domain, err := compileEC2Domain(options.Domain)
+	if err != nil {
+		return err
 	}
 
-	output := []string{}
-	address_name := ""
-
-	for _, field := range outputFields {
-		value := ""
-
-		if strings.Index(field, "Tag:") != -1 {
-			key := strings.Split(field, ":")[1]
-			value = formatEC2AMITag(image, key)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		images, err := c.FindEC2AmisWithContext(aws.BackgroundContext(), options.FilterTag, options.All, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
 
-			if field == "Tag:Name" {
-				address_name = value
+		resources := []interface{}{}
+		for _, image := range images {
+			// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
+			if !matchEC2Domain(domain, image) {
+				continue
 			}
-
-		} else {
-			value = formatFuncs[field](client, options, image)
+			resources = append(resources, image)
 		}
-		output = append(output, value)
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
-	if len(options.Domain) == 0 {
-		return strings.Join(output[:], "\t")
-	} else {
-		if regexp.MustCompile(options.Domain[0]).Match([]byte(address_name)) {
-			return strings.Join(output[:], "\t")
-		} else {
-			output = nil
-			return strings.Join(output[:], "")
-		}
+	rows := ec2ImageFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
 }
 
-func formatEC2AmiName(client *Client, options EC2ALsOptions, image *ec2.Image) string {
+func formatEC2AmiName(client *Client, resource interface{}) string {
+	image := resource.(*ec2.Image)
 	if image.Name == nil {
 		return "-"
 	}
 	return fmt.Sprintf("%-11s", *image.Name)
 }
 
-func formatEC2AmiImageId(client *Client, options EC2ALsOptions, image *ec2.Image) string {
+func formatEC2AmiImageId(client *Client, resource interface{}) string {
+	image := resource.(*ec2.Image)
 	return fmt.Sprintf("%-11s", *image.ImageId)
 }
 
-func formatEC2AmiCreationDate(client *Client, options EC2ALsOptions, image *ec2.Image) string {
+func formatEC2AmiCreationDate(client *Client, resource interface{}) string {
+	image := resource.(*ec2.Image)
 	return fmt.Sprintf("%-11s", *image.CreationDate)
 }
-
-func formatEC2AMITag(image *ec2.Image, key string) string {
-	var value string
-	for _, t := range image.Tags {
-		if *t.Key == key {
-			value = *t.Value
-			break
-		}
-	}
-	return value
-}
@@ -0,0 +1,45 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEC2ALs(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options EC2ALsOptions
+		want    string
+	}{
+		{
+			desc:    "fields",
+			options: EC2ALsOptions{Fields: []string{"ImageId", "AmiName"}},
+			want:    "ami-0001   \tbase       \nami-0002   \t-\n",
+		},
+		{
+			desc:    "max items",
+			options: EC2ALsOptions{All: true, Quiet: true, Query: QueryOptions{MaxItems: 1}},
+			want:    "ami-0001   \n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := &fakeEC2{
+				images: []*ec2.Image{
+					{ImageId: aws.String("ami-0001"), Name: aws.String("base"), CreationDate: aws.String("2021-05-01T00:00:00.000Z")},
+					{ImageId: aws.String("ami-0002"), CreationDate: aws.String("2021-05-02T00:00:00.000Z")},
+				},
+			}
+			client, stdout := newTestClient(t, Services{EC2: f})
+			if err := client.EC2ALs(tc.options); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package myaws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EC2CpOptions customize the behavior of the Cp command.
+type EC2CpOptions struct {
+	// Source is a local path or a remote path in the form of
+	// [USER@]NAME:PATH, where NAME is a Name tag.
+	Source string
+	// Destination is a local path or a remote path. Either Source or
+	// Destination must be remote.
+	Destination  string
+	Recursive    bool
+	LoginName    string
+	IdentityFile string
+	Private      bool
+	Port         string
+	AcceptNew    bool
+	Jump         string
+	// Parallel is the number of hosts to copy at once.
+	Parallel int
+	// Quiet disables progress output.
+	Quiet bool
+	// Select chooses instances in a fuzzy finder if multiple instances are
+	// found.
+	Select bool
+}
+
+// ec2CpPath is a local or remote path of the Cp command.
+type ec2CpPath struct {
+	remote    bool
+	loginName string
+	name      string
+	path      string
+}
+
+// parseEC2CpPath parses a path in the form of [USER@]NAME:PATH as remote.
+// As with scp, a path with a slash before the first colon is local.
+func parseEC2CpPath(s string) ec2CpPath {
+	i := strings.Index(s, ":")
+	if i < 0 || strings.Contains(s[:i], "/") {
+		return ec2CpPath{path: s}
+	}
+
+	p := ec2CpPath{remote: true, name: s[:i], path: s[i+1:]}
+	if j := strings.Index(p.name, "@"); j >= 0 {
+		p.loginName, p.name = p.name[:j], p.name[j+1:]
+	}
+	return p
+}
+
+// EC2Cp copies files between local and EC2 instances by the scp protocol.
+// A file is copied to or from all instances matching the Name tag. When
+// copying from multiple instances, files of each instance are copied into a
+// directory named by its instance ID under the local path.
+func (client *Client) EC2Cp(options EC2CpOptions) error {
+	src := parseEC2CpPath(options.Source)
+	dst := parseEC2CpPath(options.Destination)
+	if src.remote == dst.remote {
+		return errors.New("either SOURCE or DESTINATION must be a remote path in the form of [USER@]NAME:PATH")
+	}
+	if options.Parallel < 1 {
+		return errors.Errorf("--parallel must be a positive number: %d", options.Parallel)
+	}
+	remote := src
+	if dst.remote {
+		remote = dst
+	}
+	if remote.name == "" {
+		return errors.New("instance name is required in the remote path")
+	}
+
+	auth, err := client.newSSHAuth(sshAuthOptions{
+		LoginName:    firstNonEmpty(remote.loginName, options.LoginName),
+		IdentityFile: options.IdentityFile,
+		Port:         options.Port,
+		AcceptNew:    options.AcceptNew,
+	})
+	if err != nil {
+		return err
+	}
+	defer auth.close()
+
+	hosts, err := client.findSSHHosts(auth, sshHostsOptions{
+		FilterTag: "Name:" + remote.name,
+		Private:   options.Private,
+		Jump:      options.Jump,
+		Select:    options.Select,
+		Multi:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	transfer := func(ctx context.Context, host sshHost, progress *scpProgress) error {
+		if dst.remote {
+			return client.scpUpload(ctx, host, src.path, dst.path, options.Recursive, progress)
+		}
+		local := dst.path
+		if len(hosts) > 1 {
+			local = filepath.Join(dst.path, host.instanceID)
+			if err := os.MkdirAll(local, 0755); err != nil {
+				return errors.Wrap(err, "failed to create local directory:")
+			}
+		}
+		return client.scpDownload(ctx, host, src.path, local, options.Recursive, progress)
+	}
+
+	return client.copySSHHosts(hosts, options.Parallel, options.Quiet, transfer)
+}
+
+// copySSHHosts copies files to or from hosts with bounded parallelism. If
+// there are multiple hosts, it prints a summary of the results.
+func (client *Client) copySSHHosts(hosts []sshHost, parallel int, quiet bool, transfer func(ctx context.Context, host sshHost, progress *scpProgress) error) error {
+	stderr := &syncWriter{w: client.stderr}
+	newProgress := func(host sshHost) *scpProgress {
+		if quiet {
+			return nil
+		}
+		return newSCPProgress(stderr, host)
+	}
+
+	if len(hosts) == 1 {
+		return transfer(context.Background(), hosts[0], newProgress(hosts[0]))
+	}
+
+	results := make([]sshResult, len(hosts))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, host sshHost) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			err := transfer(context.Background(), host, newProgress(host))
+			results[i] = sshResult{host: host, status: sshStatusOK, exitCode: -1, duration: time.Since(start), err: err}
+			if err != nil {
+				results[i].status = sshStatusError
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	return client.printSSHResults(results, "copy")
+}
@@ -0,0 +1,281 @@
+package myaws
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// requireSCP skips the test if the scp command, which the test SSH server
+// runs, is not installed.
+func requireSCP(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("scp"); err != nil {
+		t.Skip("scp is not installed")
+	}
+}
+
+// writeTestFiles writes files of the contents under the directory.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+}
+
+// readTestFile returns the content of a file, or an empty string if missing.
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	b, _ := ioutil.ReadFile(path)
+	return string(b)
+}
+
+func TestParseEC2CpPath(t *testing.T) {
+	cases := []struct {
+		s    string
+		want ec2CpPath
+	}{
+		{s: "web:/etc/app.conf", want: ec2CpPath{remote: true, name: "web", path: "/etc/app.conf"}},
+		{s: "ec2-user@web:", want: ec2CpPath{remote: true, loginName: "ec2-user", name: "web", path: ""}},
+		{s: "./a:b", want: ec2CpPath{path: "./a:b"}},
+		{s: "app.conf", want: ec2CpPath{path: "app.conf"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.s, func(t *testing.T) {
+			if got := parseEC2CpPath(tc.s); got != tc.want {
+				t.Errorf("got = %+v, want = %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEC2Cp(t *testing.T) {
+	requireSCP(t)
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	identityFile := writeTestIdentityFile(t)
+
+	cases := []struct {
+		desc      string
+		instances []string
+		files     map[string]string
+		source    func(local string, remote string) string
+		dest      func(local string, remote string) string
+		recursive bool
+		// want are files under the local or remote directory.
+		want       map[string]string
+		wantRemote bool
+		wantStdout string
+	}{
+		{
+			desc:       "upload",
+			instances:  []string{"web-1"},
+			files:      map[string]string{"local/app.conf": "foo"},
+			source:     func(local, remote string) string { return local + "/app.conf" },
+			dest:       func(local, remote string) string { return "web-1:" + remote + "/app.conf" },
+			want:       map[string]string{"app.conf": "foo"},
+			wantRemote: true,
+		},
+		{
+			desc:       "upload recursive",
+			instances:  []string{"web-1"},
+			files:      map[string]string{"local/conf/a.conf": "a", "local/conf/sub/b.conf": "b"},
+			source:     func(local, remote string) string { return local + "/conf" },
+			dest:       func(local, remote string) string { return "ec2-user@web-1:" + remote },
+			recursive:  true,
+			want:       map[string]string{"conf/a.conf": "a", "conf/sub/b.conf": "b"},
+			wantRemote: true,
+		},
+		{
+			desc:      "download",
+			instances: []string{"web-1"},
+			files:     map[string]string{"remote/app.log": "log"},
+			source:    func(local, remote string) string { return "web-1:" + remote + "/app.log" },
+			dest:      func(local, remote string) string { return local },
+			want:      map[string]string{"app.log": "log"},
+		},
+		{
+			desc:      "download recursive",
+			instances: []string{"web-1"},
+			files:     map[string]string{"remote/logs/a.log": "a", "remote/logs/sub/b.log": "b"},
+			source:    func(local, remote string) string { return "web-1:" + remote + "/logs" },
+			dest:      func(local, remote string) string { return local + "/copied" },
+			recursive: true,
+			want:      map[string]string{"copied/a.log": "a", "copied/sub/b.log": "b"},
+		},
+		{
+			desc:       "download from multiple hosts",
+			instances:  []string{"web-1", "web-2"},
+			files:      map[string]string{"remote/app.log": "log"},
+			source:     func(local, remote string) string { return "web:" + remote + "/app.log" },
+			dest:       func(local, remote string) string { return local },
+			want:       map[string]string{"i-0001/app.log": "log", "i-0002/app.log": "log"},
+			wantStdout: "i-0002\tweb-2\t127.0.0.1\tok\t\t",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := newTestSSHServer(t)
+			dir := t.TempDir()
+			local := filepath.Join(dir, "local")
+			remote := filepath.Join(dir, "remote")
+			writeTestFiles(t, dir, tc.files)
+			os.MkdirAll(local, 0755)
+			os.MkdirAll(remote, 0755)
+
+			fake := &fakeEC2{}
+			for i, name := range tc.instances {
+				fake.instances = append(fake.instances, newFakeEC2Instance(fmt.Sprintf("i-%04d", i+1), "running", name, "", "127.0.0.1"))
+			}
+			client, stdout := newTestClient(t, Services{EC2: fake})
+
+			err := client.EC2Cp(EC2CpOptions{
+				Source:       tc.source(local, remote),
+				Destination:  tc.dest(local, remote),
+				Recursive:    tc.recursive,
+				IdentityFile: identityFile,
+				Private:      true,
+				Port:         s.port,
+				AcceptNew:    true,
+				Parallel:     2,
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s\n%s", err, stderrOf(client))
+			}
+
+			base := local
+			if tc.wantRemote {
+				base = remote
+			}
+			for name, content := range tc.want {
+				if got := readTestFile(t, filepath.Join(base, name)); got != content {
+					t.Errorf("%s = %q, want = %q", name, got, content)
+				}
+			}
+			if !strings.Contains(stdout.String(), tc.wantStdout) {
+				t.Errorf("stdout doesn't contain %q:\n%s", tc.wantStdout, stdout)
+			}
+			if !strings.Contains(stderrOf(client).String(), "127.0.0.1 | ") {
+				t.Errorf("no progress: %s", stderrOf(client))
+			}
+		})
+	}
+}
+
+func TestEC2CpErrors(t *testing.T) {
+	requireSCP(t)
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	identityFile := writeTestIdentityFile(t)
+	dir := t.TempDir()
+
+	cases := []struct {
+		desc    string
+		source  string
+		dest    string
+		want    string
+		summary bool
+	}{
+		{desc: "both local", source: dir, dest: dir + "/copied", want: "either SOURCE or DESTINATION must be a remote path"},
+		{desc: "both remote", source: "web-1:a", dest: "web-1:b", want: "either SOURCE or DESTINATION must be a remote path"},
+		{desc: "no name", source: ":a", dest: dir, want: "instance name is required"},
+		{desc: "directory without recursive", source: dir, dest: "web-1:" + dir + "/copied", want: "is a directory (use --recursive)"},
+		{desc: "no such remote file", source: "web-1:" + dir + "/missing", dest: dir, want: "No such file or directory"},
+		{desc: "failed on a host", source: "web:" + dir + "/missing", dest: dir, want: "copy failed on 2 of 2 hosts", summary: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := newTestSSHServer(t)
+			client, stdout := newTestClient(t, Services{EC2: &fakeEC2{instances: []*ec2.Instance{
+				newFakeEC2Instance("i-0001", "running", "web-1", "", "127.0.0.1"),
+				newFakeEC2Instance("i-0002", "running", "web-2", "", "127.0.0.1"),
+			}}})
+
+			err := client.EC2Cp(EC2CpOptions{
+				Source:       tc.source,
+				Destination:  tc.dest,
+				IdentityFile: identityFile,
+				Private:      true,
+				Port:         s.port,
+				AcceptNew:    true,
+				Parallel:     1,
+				Quiet:        true,
+			})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+			if tc.summary && !strings.Contains(stdout.String(), "No such file or directory") {
+				t.Errorf("no error in the summary:\n%s", stdout)
+			}
+		})
+	}
+}
+
+func TestSCPReceiverInvalidName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"../evil", "..", "a/b"} {
+		s := &scpReceiver{
+			w:      &bytes.Buffer{},
+			r:      bufio.NewReader(strings.NewReader("C0644 4 " + name + "\nevil\x00")),
+			target: dir,
+		}
+		err := s.receive()
+		if err == nil || !strings.Contains(err.Error(), "invalid file name from scp") {
+			t.Errorf("expected an invalid name error of %q, but got: %v", name, err)
+		}
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files should not be written: %v", entries)
+	}
+}
+
+func TestSCPSenderSymlinkLoop(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "conf")
+	writeTestFiles(t, filepath.Dir(dir), map[string]string{"conf/a.conf": "a", "conf/sub/b.conf": "b"})
+	for link, target := range map[string]string{"self": ".", "sub/up": ".."} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatalf("failed to create a symlink: %s", err)
+		}
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("failed to stat: %s", err)
+	}
+
+	w := &bytes.Buffer{}
+	s := &scpSender{
+		w:        w,
+		r:        bufio.NewReader(strings.NewReader(strings.Repeat("\x00", 100))),
+		progress: newSCPProgress(&bytes.Buffer{}, sshHost{hostname: "127.0.0.1"}),
+	}
+	if err := s.sendDir(dir, fi); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	perm := func(name string) os.FileMode {
+		fi, _ := os.Stat(filepath.Join(dir, name))
+		return fi.Mode().Perm()
+	}
+	want := fmt.Sprintf("D%04o 0 conf\nC%04o 1 a.conf\na\x00D%04o 0 sub\nC%04o 1 b.conf\nb\x00E\nE\n",
+		perm("."), perm("a.conf"), perm("sub"), perm("sub/b.conf"))
+	if got := w.String(); got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+}
@@ -2,113 +2,110 @@ package myaws
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2ILsOptions customize the behavior of the Ls command.
 type EC2ILsOptions struct {
-	All       bool
-	Quiet     bool
-	FilterTag string
-	Fields    []string
-	Domain    []string
+	All        bool
+	Quiet      bool
+	FilterTag  string
+	Fields     []string
+	Domain     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
 }
 
+// ec2AddressFields is a registry of output fields for EC2 addresses.
+var ec2AddressFields = newFieldRegistry(ec2.Address{}, "AllocationId", []field{
+	{"PublicIp", formatEC2PublicIp},
+	{"AllocationId", formatEC2AllocationId},
+	{"InstanceId", formatEC2InstanceId},
+	{"PrivateIpAddress", formatEC2PrivateIpAddress},
+	{"AssociationId", formatEC2AssociationId},
+})
+
 // EC2ILs describes EC2 addresses.
 func (client *Client) EC2ILs(options EC2ILsOptions) error {
-	addresses, err := client.FindEC2Ips(options.FilterTag, options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2AddressFields)
 	}
 
-	for _, address := range addresses {
-		fmt.Fprintln(client.stdout, formatEC2Ip(client, options, address))
+	fields := ec2AddressFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := ec2AddressFields.validate(fields); err != nil {
+		return err
 	}
-	return nil
-}
 
-func formatEC2Ip(client *Client, options EC2ILsOptions, address *ec2.Address) string {
-	formatFuncs := map[string]func(client *Client, options EC2ILsOptions, address *ec2.Address) string{
-		"PublicIp":         formatEC2PublicIp,
-		"AllocationId":     formatEC2AllocationId,
-		"InstanceId":       formatEC2InstanceId,
-		"PrivateIpAddress": formatEC2PrivateIpAddress,
-		"AssociationId":    formatEC2AssociationId,
+	q, err := ec2AddressFields.compileQuery(options.Query)
+	if err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"AllocationId"}
-	} else {
-		outputFields = options.Fields
+	domain, err := compileEC2Domain(options.Domain)
+	if err != nil {
+		return err
 	}
 
-	output := []string{}
-	address_name := ""
-
-	for _, field := range outputFields {
-		value := ""
-
-		if strings.Index(field, "Tag:") != -1 {
-			key := strings.Split(field, ":")[1]
-			value = formatEC2ITag(address, key)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		addresses, err := c.FindEC2IpsWithContext(aws.BackgroundContext(), options.FilterTag, options.All, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
 
-			if field == "Tag:Name" {
-				address_name = value
+		resources := []interface{}{}
+		for _, address := range addresses {
+			// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
+			if !matchEC2Domain(domain, address) {
+				continue
 			}
-
-		} else {
-			value = formatFuncs[field](client, options, address)
+			resources = append(resources, address)
 		}
-		output = append(output, value)
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
-	if len(options.Domain) == 0 {
-		return strings.Join(output[:], "\t")
-	} else {
-		if regexp.MustCompile(options.Domain[0]).Match([]byte(address_name)) {
-			return strings.Join(output[:], "\t")
-		} else {
-			output = nil
-			return strings.Join(output[:], "")
-		}
+	rows := ec2AddressFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
 }
 
-func formatEC2PublicIp(client *Client, options EC2ILsOptions, address *ec2.Address) string {
+func formatEC2PublicIp(client *Client, resource interface{}) string {
+	address := resource.(*ec2.Address)
 	return fmt.Sprintf("%-11s", *address.PublicIp)
 }
 
-func formatEC2AllocationId(client *Client, options EC2ILsOptions, address *ec2.Address) string {
+func formatEC2AllocationId(client *Client, resource interface{}) string {
+	address := resource.(*ec2.Address)
 	return fmt.Sprintf("%-11s", *address.AllocationId)
 }
 
-func formatEC2InstanceId(client *Client, options EC2ILsOptions, address *ec2.Address) string {
+func formatEC2InstanceId(client *Client, resource interface{}) string {
+	address := resource.(*ec2.Address)
 	if address.InstanceId == nil {
 		return "-"
 	}
 	return fmt.Sprintf("%-11s", *address.InstanceId)
 }
 
-func formatEC2PrivateIpAddress(client *Client, options EC2ILsOptions, address *ec2.Address) string {
+func formatEC2PrivateIpAddress(client *Client, resource interface{}) string {
+	address := resource.(*ec2.Address)
+	if address.PrivateIpAddress == nil {
+		return "-"
+	}
 	return fmt.Sprintf("%-11s", *address.PrivateIpAddress)
 }
 
-func formatEC2AssociationId(client *Client, options EC2ILsOptions, address *ec2.Address) string {
-	return fmt.Sprintf("%-11s", *address.AssociationId)
-}
-
-func formatEC2ITag(address *ec2.Address, key string) string {
-	var value string
-	for _, t := range address.Tags {
-		if *t.Key == key {
-			value = *t.Value
-			break
-		}
+func formatEC2AssociationId(client *Client, resource interface{}) string {
+	address := resource.(*ec2.Address)
+	if address.AssociationId == nil {
+		return "-"
 	}
-	return value
+	return fmt.Sprintf("%-11s", *address.AssociationId)
 }
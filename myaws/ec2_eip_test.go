@@ -0,0 +1,38 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEC2ILs(t *testing.T) {
+	f := &fakeEC2{
+		addresses: []*ec2.Address{
+			{
+				PublicIp:         aws.String("203.0.113.1"),
+				AllocationId:     aws.String("eipalloc-0001"),
+				InstanceId:       aws.String("i-0001"),
+				PrivateIpAddress: aws.String("10.0.0.1"),
+				AssociationId:    aws.String("eipassoc-0001"),
+			},
+			{
+				PublicIp:     aws.String("203.0.113.2"),
+				AllocationId: aws.String("eipalloc-0002"),
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{EC2: f})
+	client.output = OutputFormatCSV
+
+	err := client.EC2ILs(EC2ILsOptions{Fields: []string{"PublicIp", "InstanceId", "AssociationId"}})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "PublicIp,InstanceId,AssociationId\n203.0.113.1,i-0001,eipassoc-0001\n203.0.113.2,-,-\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -2,126 +2,120 @@ package myaws
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2LsOptions customize the behavior of the Ls command.
 type EC2LsOptions struct {
-	All       bool
-	Quiet     bool
-	FilterTag string
-	Fields    []string
-	Domain    []string
+	All        bool
+	Quiet      bool
+	FilterTag  string
+	Fields     []string
+	Domain     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
 }
 
+// ec2InstanceFields is a registry of output fields for EC2 instances.
+var ec2InstanceFields = newFieldRegistry(ec2.Instance{}, "InstanceId", []field{
+	{"InstanceId", formatEC2InstanceID},
+	{"InstanceType", formatEC2InstanceType},
+	{"PublicIpAddress", formatEC2PublicIPAddress},
+	{"PrivateIpAddress", formatEC2PrivateIPAddress},
+	{"AvailabilityZone", formatEC2AvailabilityZone},
+	{"StateName", formatEC2StateName},
+	{"State", formatEC2StateName},
+	{"LaunchTime", formatEC2LaunchTime},
+})
+
 // EC2Ls describes EC2 instances.
 func (client *Client) EC2Ls(options EC2LsOptions) error {
-	instances, err := client.FindEC2Instances(options.FilterTag, options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2InstanceFields)
 	}
 
-	for _, instance := range instances {
-		fmt.Fprintln(client.stdout, formatEC2Instance(client, options, instance))
+	fields := ec2InstanceFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := ec2InstanceFields.validate(fields); err != nil {
+		return err
 	}
-	return nil
-}
 
-func formatEC2Instance(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
-	formatFuncs := map[string]func(client *Client, options EC2LsOptions, instance *ec2.Instance) string{
-		"InstanceId":       formatEC2InstanceID,
-		"InstanceType":     formatEC2InstanceType,
-		"PublicIpAddress":  formatEC2PublicIPAddress,
-		"PrivateIpAddress": formatEC2PrivateIPAddress,
-		"AvailabilityZone": formatEC2AvailabilityZone,
-		"StateName":        formatEC2StateName,
-		"LaunchTime":       formatEC2LaunchTime,
+	q, err := ec2InstanceFields.compileQuery(options.Query)
+	if err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"InstanceId"}
-	} else {
-		outputFields = options.Fields
+	domain, err := compileEC2Domain(options.Domain)
+	if err != nil {
+		return err
 	}
 
-	output := []string{}
-	instance_name := ""
-
-	for _, field := range outputFields {
-		value := ""
-
-		if strings.Index(field, "Tag:") != -1 {
-			key := strings.Split(field, ":")[1]
-			value = formatEC2Tag(instance, key)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		instances, err := c.FindEC2InstancesWithContext(aws.BackgroundContext(), options.FilterTag, options.All, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
 
-			if field == "Tag:Name" {
-				instance_name = value
+		resources := []interface{}{}
+		for _, instance := range instances {
+			// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
+			if !matchEC2Domain(domain, instance) {
+				continue
 			}
-
-		} else {
-			value = formatFuncs[field](client, options, instance)
+			resources = append(resources, instance)
 		}
-		output = append(output, value)
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
-	if len(options.Domain) == 0 {
-		return strings.Join(output[:], "\t")
-	} else {
-		if regexp.MustCompile(options.Domain[0]).Match([]byte(instance_name)) {
-			return strings.Join(output[:], "\t")
-		} else {
-			output = nil
-			return strings.Join(output[:], "")
-		}
+	rows := ec2InstanceFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
 }
 
-func formatEC2InstanceID(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2InstanceID(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	return *instance.InstanceId
 }
 
-func formatEC2InstanceType(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2InstanceType(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	return fmt.Sprintf("%-11s", *instance.InstanceType)
 }
 
-func formatEC2PublicIPAddress(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2PublicIPAddress(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	if instance.PublicIpAddress == nil {
 		return "___.___.___.___"
 	}
 	return *instance.PublicIpAddress
 }
 
-func formatEC2PrivateIPAddress(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2PrivateIPAddress(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	if instance.PrivateIpAddress == nil {
 		return "___.___.___.___"
 	}
 	return *instance.PrivateIpAddress
 }
 
-func formatEC2StateName(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2StateName(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	return *instance.State.Name
 }
 
-func formatEC2LaunchTime(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2LaunchTime(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	return client.FormatTime(instance.LaunchTime)
 }
 
-func formatEC2Tag(instance *ec2.Instance, key string) string {
-	var value string
-	for _, t := range instance.Tags {
-		if *t.Key == key {
-			value = *t.Value
-			break
-		}
-	}
-	return value
-}
-
-func formatEC2AvailabilityZone(client *Client, options EC2LsOptions, instance *ec2.Instance) string {
+func formatEC2AvailabilityZone(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.Instance)
 	return *instance.Placement.AvailabilityZone
 }
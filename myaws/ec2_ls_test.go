@@ -0,0 +1,196 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func newFakeEC2WithInstances() *fakeEC2 {
+	return &fakeEC2{
+		instances: []*ec2.Instance{
+			newFakeEC2Instance("i-0001", "running", "web-prod-1", "203.0.113.1", "10.0.0.1"),
+			newFakeEC2Instance("i-0002", "running", "web-stg-1", "", "10.0.0.2"),
+			newFakeEC2Instance("i-0003", "stopped", "db-prod-1", "", "10.0.0.3"),
+			newFakeEC2Instance("i-0004", "running", "db-prod-2", "", "10.0.0.4"),
+		},
+		pageSize: 1,
+	}
+}
+
+func TestEC2Ls(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options EC2LsOptions
+		want    string
+	}{
+		{
+			desc:    "running only",
+			options: EC2LsOptions{Fields: []string{"InstanceId", "PublicIpAddress", "Tag:Name"}},
+			want: "i-0001\t203.0.113.1\tweb-prod-1\n" +
+				"i-0002\t___.___.___.___\tweb-stg-1\n" +
+				"i-0004\t___.___.___.___\tdb-prod-2\n",
+		},
+		{
+			desc:    "all",
+			options: EC2LsOptions{All: true, Fields: []string{"InstanceId", "StateName"}},
+			want:    "i-0001\trunning\ni-0002\trunning\ni-0003\tstopped\ni-0004\trunning\n",
+		},
+		{
+			desc:    "filter tag",
+			options: EC2LsOptions{All: true, FilterTag: "Name:prod", Quiet: true},
+			want:    "i-0001\ni-0003\ni-0004\n",
+		},
+		{
+			desc:    "domain",
+			options: EC2LsOptions{Domain: []string{"^db-"}, Quiet: true},
+			want:    "i-0004\n",
+		},
+		{
+			desc:    "member path",
+			options: EC2LsOptions{Fields: []string{"InstanceId", "Placement.AvailabilityZone", "Tags[].Key"}, Query: QueryOptions{Limit: 1}},
+			want:    "i-0001\tap-northeast-1a\tName\n",
+		},
+		{
+			desc: "where and sort",
+			options: EC2LsOptions{
+				All:    true,
+				Fields: []string{"InstanceId"},
+				Query:  QueryOptions{Where: `Tag:Name =~ "prod" && State != "stopped"`, SortBy: "PrivateIpAddress", Reverse: true},
+			},
+			want: "i-0004\ni-0001\n",
+		},
+		{
+			desc:    "max items",
+			options: EC2LsOptions{All: true, Quiet: true, Query: QueryOptions{MaxItems: 2}},
+			want:    "i-0001\ni-0002\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{EC2: newFakeEC2WithInstances()})
+			if err := client.EC2Ls(tc.options); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEC2LsMaxItemsStopsPaging(t *testing.T) {
+	pages := 0
+	f := newFakeEC2WithInstances()
+	client, _ := newTestClient(t, Services{EC2: &countingEC2{fakeEC2: f, pages: &pages}})
+
+	if err := client.EC2Ls(EC2LsOptions{All: true, Quiet: true, Query: QueryOptions{MaxItems: 2}}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if pages != 2 {
+		t.Errorf("fetched %d pages, want 2", pages)
+	}
+}
+
+// countingEC2 counts pages of DescribeInstances.
+type countingEC2 struct {
+	*fakeEC2
+	pages *int
+}
+
+func (f *countingEC2) DescribeInstancesPagesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
+	return f.fakeEC2.DescribeInstancesPagesWithContext(ctx, input, func(p *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		*f.pages++
+		return fn(p, lastPage)
+	})
+}
+
+func TestEC2LsInvalidOptions(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options EC2LsOptions
+		want    string
+	}{
+		{
+			desc:    "unknown field",
+			options: EC2LsOptions{Fields: []string{"Foo"}},
+			want:    "unknown field: Foo",
+		},
+		{
+			desc:    "unknown where field",
+			options: EC2LsOptions{Fields: []string{"InstanceId"}, Query: QueryOptions{Where: "Foo == 1"}},
+			want:    "unknown field: Foo",
+		},
+		{
+			desc:    "negative limit",
+			options: EC2LsOptions{Fields: []string{"InstanceId"}, Query: QueryOptions{Limit: -1}},
+			want:    "--limit must be a positive number",
+		},
+		{
+			desc:    "invalid domain",
+			options: EC2LsOptions{Fields: []string{"InstanceId"}, Domain: []string{"web-("}},
+			want:    "failed to parse --domain:",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := &fakeEC2{err: awserr.New("Unexpected", "should not be called", nil)}
+			client, _ := newTestClient(t, Services{EC2: f})
+			err := client.EC2Ls(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestEC2LsListFields(t *testing.T) {
+	client, stdout := newTestClient(t, Services{EC2: &fakeEC2{}})
+	if err := client.EC2Ls(EC2LsOptions{ListFields: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	got := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if got[0] != "InstanceId" || got[len(got)-1] != "Tag:KEY" {
+		t.Errorf("unexpected fields: %v", got)
+	}
+}
+
+func TestEC2LsTargets(t *testing.T) {
+	regions := map[string]*fakeEC2{
+		"us-east-1": {instances: []*ec2.Instance{newFakeEC2Instance("i-use1", "running", "a", "", "")}},
+		"us-west-2": {err: awserr.New("UnauthorizedOperation", "denied", nil)},
+		"eu-west-1": {instances: []*ec2.Instance{newFakeEC2Instance("i-euw1", "running", "b", "", "")}},
+	}
+	client, stdout := newTestClient(t, Services{
+		EC2: &fakeEC2{regions: []string{"us-west-2", "eu-west-1", "us-east-1"}},
+	})
+	setTestTargetServices(t, func(target Target) Services {
+		if f, ok := regions[target.Region]; ok {
+			return Services{EC2: f}
+		}
+		return Services{EC2: &fakeEC2{regions: []string{"us-west-2", "eu-west-1", "us-east-1"}}}
+	})
+
+	err := client.EC2Ls(EC2LsOptions{
+		Fields:  []string{"InstanceId"},
+		Targets: TargetOptions{Profiles: []string{"dev"}, Regions: []string{"all"}, Parallel: 2},
+	})
+	if !isTargetsError(err) {
+		t.Fatalf("expected a targetsError, but got: %v", err)
+	}
+
+	// The regions are sorted, and the failed one is reported to stderr.
+	want := "dev\teu-west-1\ti-euw1\ndev\tus-east-1\ti-use1\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := stderrOf(client).String(); !strings.Contains(got, "dev/us-west-2: ") {
+		t.Errorf("stderr doesn't report the failed target: %s", got)
+	}
+}
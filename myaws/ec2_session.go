@@ -0,0 +1,261 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// EC2SessionOptions customize the behavior of the Session command.
+type EC2SessionOptions struct {
+	FilterTag string
+	// Select chooses an instance in a fuzzy finder if multiple instances are
+	// found.
+	Select bool
+}
+
+// EC2Session starts a shell session on an instance by Session Manager. It
+// doesn't require SSH or inbound ports, but the SSM agent on the instance.
+func (client *Client) EC2Session(options EC2SessionOptions) error {
+	instance, err := client.findEC2Instance(options.FilterTag, options.Select)
+	if err != nil {
+		return err
+	}
+
+	if client.dryRun {
+		client.printPlan("SSM.StartSession", "shell session on %s", *instance.InstanceId)
+		return nil
+	}
+
+	return client.startEC2Session(context.Background(), *instance.InstanceId)
+}
+
+// findEC2Instance finds a running instance matching the filter.
+func (client *Client) findEC2Instance(filterTag string, selectInstance bool) (*ec2.Instance, error) {
+	instances, err := client.FindEC2Instances(filterTag, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(instances) == 0 {
+		return nil, errors.Errorf("no such instance: %s", filterTag)
+	}
+
+	if len(instances) >= 2 && selectInstance {
+		instances, err = client.pickEC2Instances(instances, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(instances) >= 2 {
+		return nil, errors.Errorf("multiple instances found (use --select to choose one)")
+	}
+	return instances[0], nil
+}
+
+// startEC2Session streams the shell of the instance with stdin and stdout
+// until the session is closed. If stdin is a terminal, it's put in raw mode
+// and its size is sent to the instance.
+func (client *Client) startEC2Session(ctx context.Context, instanceID string) error {
+	sessionID, channel, err := client.startSSMSession(ctx, instanceID, "", nil, client.stdout)
+	if err != nil {
+		return err
+	}
+	defer client.terminateSSMSession(sessionID)
+	defer channel.close()
+
+	fmt.Fprintf(client.stderr, "Starting session with SessionId: %s\n", sessionID)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- channel.run()
+	}()
+
+	if f, ok := client.stdin.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		oldState, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return errors.Wrap(err, "unable to put terminal in Raw Mode:")
+		}
+		defer terminal.Restore(fd, oldState)
+
+		getSize := func() (int, int, error) { return terminal.GetSize(fd) }
+		resize := func(width int, height int) { channel.resize(width, height) }
+		if width, height, err := getSize(); err == nil {
+			go resize(width, height)
+		}
+		stopWatching := watchTerminalSize(getSize, resize)
+		defer stopWatching()
+	}
+
+	go io.Copy(channel, client.stdin)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+	fmt.Fprintf(client.stderr, "\nExiting session with sessionId: %s.\n", sessionID)
+	return nil
+}
+
+// EC2PortForwardOptions customize the behavior of the PortForward command.
+type EC2PortForwardOptions struct {
+	FilterTag string
+	// Ports is a spec in the form of LOCAL:REMOTE or LOCAL:HOST:REMOTE. If HOST
+	// is given, the instance forwards connections to the remote host.
+	Ports string
+	// Select chooses an instance in a fuzzy finder if multiple instances are
+	// found.
+	Select bool
+}
+
+// ssmPortForward is a parsed spec of port forwarding by Session Manager.
+type ssmPortForward struct {
+	localPort  string
+	host       string
+	remotePort string
+}
+
+// parseSSMPortForward parses a spec in the form of LOCAL:REMOTE or
+// LOCAL:HOST:REMOTE.
+func parseSSMPortForward(spec string) (ssmPortForward, error) {
+	parts := splitSSHForwardSpec(spec)
+	var f ssmPortForward
+	switch len(parts) {
+	case 2:
+		f = ssmPortForward{localPort: parts[0], remotePort: parts[1]}
+	case 3:
+		f = ssmPortForward{localPort: parts[0], host: parts[1], remotePort: parts[2]}
+		if f.host == "" {
+			return f, errors.Errorf("missing host in port forwarding: %s", spec)
+		}
+	default:
+		return f, errors.Errorf("invalid port forwarding: %s (expected LOCAL:REMOTE or LOCAL:HOST:REMOTE)", spec)
+	}
+
+	for _, p := range []string{f.localPort, f.remotePort} {
+		if n, err := strconv.Atoi(p); err != nil || n < 0 || n > 65535 {
+			return f, errors.Errorf("invalid port number in port forwarding: %s", spec)
+		}
+	}
+	return f, nil
+}
+
+// document returns a document name and parameters to start a session of the
+// port forwarding.
+func (f ssmPortForward) document() (string, map[string][]*string) {
+	parameters := map[string][]*string{
+		"portNumber":      {aws.String(f.remotePort)},
+		"localPortNumber": {aws.String(f.localPort)},
+	}
+	if f.host == "" {
+		return "AWS-StartPortForwardingSession", parameters
+	}
+	parameters["host"] = []*string{aws.String(f.host)}
+	return "AWS-StartPortForwardingSessionToRemoteHost", parameters
+}
+
+// EC2PortForward forwards a local port to a port of an instance or a remote
+// host through the instance by Session Manager until interrupted.
+func (client *Client) EC2PortForward(options EC2PortForwardOptions) error {
+	f, err := parseSSMPortForward(options.Ports)
+	if err != nil {
+		return err
+	}
+
+	instance, err := client.findEC2Instance(options.FilterTag, options.Select)
+	if err != nil {
+		return err
+	}
+
+	if client.dryRun {
+		document, _ := f.document()
+		client.printPlan("SSM.StartSession", "%s on %s for each connection to localhost:%s", document, *instance.InstanceId, f.localPort)
+		return nil
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort("localhost", f.localPort))
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for port forwarding:")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return client.forwardSSMPort(ctx, *instance.InstanceId, f, l)
+}
+
+// forwardSSMPort accepts connections on the listener until the context is
+// done, and starts a session for each connection.
+func (client *Client) forwardSSMPort(ctx context.Context, instanceID string, f ssmPortForward, l net.Listener) error {
+	remote := f.remotePort
+	if f.host != "" {
+		remote = net.JoinHostPort(f.host, f.remotePort)
+	}
+	fmt.Fprintf(client.stderr, "Forwarding local %s -> %s %s\n", l.Addr(), instanceID, remote)
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept connection:")
+		}
+		go func() {
+			if err := client.forwardSSMConn(ctx, instanceID, f, conn); err != nil {
+				fmt.Fprintf(client.stderr, "port forwarding failed: %s\n", err)
+			}
+		}()
+	}
+}
+
+// forwardSSMConn starts a session of the port forwarding and copies data
+// between the connection and the session until either side is closed.
+func (client *Client) forwardSSMConn(ctx context.Context, instanceID string, f ssmPortForward, conn net.Conn) error {
+	defer conn.Close()
+
+	document, parameters := f.document()
+	sessionID, channel, err := client.startSSMSession(ctx, instanceID, document, parameters, conn)
+	if err != nil {
+		return err
+	}
+	defer client.terminateSSMSession(sessionID)
+	defer channel.close()
+
+	done := make(chan error, 2)
+	go func() {
+		done <- channel.run()
+	}()
+	go func() {
+		// Tell the agent when the local connection is closed.
+		io.Copy(channel, conn)
+		channel.disconnectPort()
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
@@ -0,0 +1,271 @@
+package myaws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestEC2SessionDryRun(t *testing.T) {
+	cases := []struct {
+		desc string
+		run  func(client *Client) error
+		want string
+	}{
+		{
+			desc: "session",
+			run: func(client *Client) error {
+				return client.EC2Session(EC2SessionOptions{FilterTag: "Name:web-prod"})
+			},
+			want: "[dry-run] SSM.StartSession: shell session on i-0001\n",
+		},
+		{
+			desc: "port forward",
+			run: func(client *Client) error {
+				// The port is in use, but it's not listened in dry-run mode.
+				l, err := net.Listen("tcp", "localhost:0")
+				if err != nil {
+					t.Fatalf("failed to listen: %s", err)
+				}
+				defer l.Close()
+				_, port, _ := net.SplitHostPort(l.Addr().String())
+				return client.EC2PortForward(EC2PortForwardOptions{FilterTag: "Name:web-prod", Ports: port + ":db.example.com:5432"})
+			},
+			want: "[dry-run] SSM.StartSession: AWS-StartPortForwardingSessionToRemoteHost on i-0001 for each connection to localhost:",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ssmClient := newFakeSSM()
+			client, stdout := newTestClient(t, Services{EC2: newFakeEC2WithInstances(), SSM: ssmClient})
+			client.dryRun = true
+
+			if err := tc.run(client); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !strings.HasPrefix(stdout.String(), tc.want) {
+				t.Errorf("stdout = %q, want = %q", stdout.String(), tc.want)
+			}
+			if len(ssmClient.sessionInputs) != 0 {
+				t.Errorf("unexpected StartSession: %v", ssmClient.sessionInputs)
+			}
+		})
+	}
+}
+
+func TestEC2Session(t *testing.T) {
+	cases := []struct {
+		desc    string
+		shuffle bool
+		options EC2SessionOptions
+		want    string
+		wantErr string
+	}{
+		{
+			desc:    "shell",
+			options: EC2SessionOptions{FilterTag: "Name:web-prod"},
+			want:    "hello\nworld\n",
+		},
+		{
+			desc:    "output out of order",
+			shuffle: true,
+			options: EC2SessionOptions{FilterTag: "Name:web-prod"},
+			want:    "hello\nworld\n",
+		},
+		{
+			desc:    "no such instance",
+			options: EC2SessionOptions{FilterTag: "Name:app"},
+			wantErr: "no such instance: Name:app",
+		},
+		{
+			desc:    "multiple instances",
+			options: EC2SessionOptions{FilterTag: "Name:web"},
+			wantErr: "multiple instances found (use --select to choose one)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			agent := newTestSSMAgent(t, ssmSessionTypeShell)
+			agent.shuffle = tc.shuffle
+			ssmClient := newFakeSSM()
+			ssmClient.streamURL = agent.url()
+			client, stdout := newTestClient(t, Services{EC2: newFakeEC2WithInstances(), SSM: ssmClient})
+			client.stdin = strings.NewReader("echo hello\necho world\nexit\n")
+
+			err := client.EC2Session(tc.options)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("stdout = %q, want = %q", got, tc.want)
+			}
+			if len(ssmClient.sessionInputs) != 1 {
+				t.Fatalf("sessions = %d, want = 1", len(ssmClient.sessionInputs))
+			}
+			input := ssmClient.sessionInputs[0]
+			if *input.Target != "i-0001" || input.DocumentName != nil {
+				t.Errorf("StartSession input = %s", input)
+			}
+			if got := strings.Join(ssmClient.terminated, ","); got != "alice-1" {
+				t.Errorf("terminated = %s", got)
+			}
+			stderr := stderrOf(client).String()
+			for _, want := range []string{"Starting session with SessionId: alice-1", "Session is encrypted", "Exiting session with sessionId: alice-1."} {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("stderr doesn't contain %q: %s", want, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSSMPortForward(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    ssmPortForward
+		wantErr bool
+	}{
+		{spec: "8080:80", want: ssmPortForward{localPort: "8080", remotePort: "80"}},
+		{spec: "5432:db.example.com:5432", want: ssmPortForward{localPort: "5432", host: "db.example.com", remotePort: "5432"}},
+		{spec: "8080:[::1]:80", want: ssmPortForward{localPort: "8080", host: "::1", remotePort: "80"}},
+		{spec: "8080", wantErr: true},
+		{spec: "8080::80", wantErr: true},
+		{spec: "http:80", wantErr: true},
+		{spec: "1:2:3:4", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := parseSSMPortForward(tc.spec)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("got = %+v, want = %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEC2PortForward(t *testing.T) {
+	cases := []struct {
+		desc         string
+		spec         string
+		wantDocument string
+		wantParams   string
+	}{
+		{
+			desc:         "instance",
+			spec:         "8080:80",
+			wantDocument: "AWS-StartPortForwardingSession",
+			wantParams:   "localPortNumber=8080 portNumber=80",
+		},
+		{
+			desc:         "remote host",
+			spec:         "5432:db.example.com:5432",
+			wantDocument: "AWS-StartPortForwardingSessionToRemoteHost",
+			wantParams:   "host=db.example.com localPortNumber=5432 portNumber=5432",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			agent := newTestSSMAgent(t, ssmSessionTypePort)
+			ssmClient := newFakeSSM()
+			ssmClient.streamURL = agent.url()
+			client, _ := newTestClient(t, Services{SSM: ssmClient})
+			// Sessions of connections write to stderr concurrently.
+			client.stderr = &syncWriter{w: &bytes.Buffer{}}
+
+			f, err := parseSSMPortForward(tc.spec)
+			if err != nil {
+				t.Fatalf("parseSSMPortForward failed: %s", err)
+			}
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %s", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- client.forwardSSMPort(ctx, "i-0001", f, l)
+			}()
+
+			// Each connection has its own session.
+			for _, msg := range []string{"ping", "pong"} {
+				conn, err := net.Dial("tcp", l.Addr().String())
+				if err != nil {
+					t.Fatalf("failed to connect: %s", err)
+				}
+				fmt.Fprintln(conn, msg)
+				got, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || got != msg+"\n" {
+					t.Errorf("got = %q, %v, want = %q", got, err, msg)
+				}
+				conn.Close()
+			}
+
+			waitTestSSMSessions(t, ssmClient, 2)
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			_, _, _, flags, _ := agent.stats()
+			if fmt.Sprint(flags) != "[1 1]" {
+				t.Errorf("flags = %v, want = [1 1]", flags)
+			}
+			for _, input := range ssmClient.sessionInputs {
+				if *input.Target != "i-0001" || aws.StringValue(input.DocumentName) != tc.wantDocument {
+					t.Errorf("StartSession input = %s", input)
+				}
+				params := []string{}
+				for _, k := range []string{"host", "localPortNumber", "portNumber"} {
+					if v, ok := input.Parameters[k]; ok {
+						params = append(params, k+"="+aws.StringValue(v[0]))
+					}
+				}
+				if got := strings.Join(params, " "); got != tc.wantParams {
+					t.Errorf("parameters = %s, want = %s", got, tc.wantParams)
+				}
+			}
+		})
+	}
+}
+
+// waitTestSSMSessions waits until the sessions are terminated.
+func waitTestSSMSessions(t *testing.T, f *fakeSSM, n int) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		f.mu.Lock()
+		terminated := len(f.terminated)
+		f.mu.Unlock()
+		if terminated >= n {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("sessions are not terminated")
+}
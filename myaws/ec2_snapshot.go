@@ -2,103 +2,92 @@ package myaws
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2SLsOptions customize the behavior of the Ls command.
 type EC2SLsOptions struct {
-	All       bool
-	Quiet     bool
-	FilterTag string
-	Fields    []string
-	Domain    []string
+	All        bool
+	Quiet      bool
+	FilterTag  string
+	Fields     []string
+	Domain     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
 }
 
+// ec2SnapshotFields is a registry of output fields for EC2 snapshots.
+var ec2SnapshotFields = newFieldRegistry(ec2.Snapshot{}, "SnapshotId", []field{
+	{"SnapshotId", formatEC2SnapshotId},
+	{"Description", formatEC2SnapshotDescription},
+	{"SnapshotStartTime", formatEC2SnapshotStartTime},
+})
+
 // EC2SLs describes EC2 snapshots.
 func (client *Client) EC2SLs(options EC2SLsOptions) error {
-	snapshots, err := client.FindEC2Snapshots(options.FilterTag, options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2SnapshotFields)
 	}
 
-	for _, snapshot := range snapshots {
-		fmt.Fprintln(client.stdout, formatEC2Snapshot(client, options, snapshot))
+	fields := ec2SnapshotFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := ec2SnapshotFields.validate(fields); err != nil {
+		return err
 	}
-	return nil
-}
 
-func formatEC2Snapshot(client *Client, options EC2SLsOptions, snapshot *ec2.Snapshot) string {
-	formatFuncs := map[string]func(client *Client, options EC2SLsOptions, snapshot *ec2.Snapshot) string{
-		"SnapshotId":        formatEC2SnapshotId,
-		"Description":       formatEC2SnapshotDescription,
-		"SnapshotStartTime": formatEC2SnapshotStartTime,
+	q, err := ec2SnapshotFields.compileQuery(options.Query)
+	if err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"AllocationId"}
-	} else {
-		outputFields = options.Fields
+	domain, err := compileEC2Domain(options.Domain)
+	if err != nil {
+		return err
 	}
 
-	output := []string{}
-	address_name := ""
-
-	for _, field := range outputFields {
-		value := ""
-
-		if strings.Index(field, "Tag:") != -1 {
-			key := strings.Split(field, ":")[1]
-			value = formatEC2SnapshotTag(snapshot, key)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		snapshots, err := c.FindEC2SnapshotsWithContext(aws.BackgroundContext(), options.FilterTag, options.All, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
 
-			if field == "Tag:Name" {
-				address_name = value
+		resources := []interface{}{}
+		for _, snapshot := range snapshots {
+			// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
+			if !matchEC2Domain(domain, snapshot) {
+				continue
 			}
-
-		} else {
-			value = formatFuncs[field](client, options, snapshot)
+			resources = append(resources, snapshot)
 		}
-		output = append(output, value)
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
-	if len(options.Domain) == 0 {
-		return strings.Join(output[:], "\t")
-	} else {
-		if regexp.MustCompile(options.Domain[0]).Match([]byte(address_name)) {
-			return strings.Join(output[:], "\t")
-		} else {
-			output = nil
-			return strings.Join(output[:], "")
-		}
+	rows := ec2SnapshotFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
 }
 
-func formatEC2SnapshotStartTime(client *Client, options EC2SLsOptions, snapshot *ec2.Snapshot) string {
+func formatEC2SnapshotStartTime(client *Client, resource interface{}) string {
+	snapshot := resource.(*ec2.Snapshot)
 	if snapshot.StartTime == nil {
 		return "-"
 	}
 	return fmt.Sprintf("%-11s", *snapshot.StartTime)
 }
 
-func formatEC2SnapshotId(client *Client, options EC2SLsOptions, snapshot *ec2.Snapshot) string {
+func formatEC2SnapshotId(client *Client, resource interface{}) string {
+	snapshot := resource.(*ec2.Snapshot)
 	return fmt.Sprintf("%-11s", *snapshot.SnapshotId)
 }
 
-func formatEC2SnapshotDescription(client *Client, options EC2SLsOptions, snapshot *ec2.Snapshot) string {
+func formatEC2SnapshotDescription(client *Client, resource interface{}) string {
+	snapshot := resource.(*ec2.Snapshot)
 	return fmt.Sprintf("%-11s", *snapshot.Description)
 }
-
-func formatEC2SnapshotTag(snapshot *ec2.Snapshot, key string) string {
-	var value string
-	for _, t := range snapshot.Tags {
-		if *t.Key == key {
-			value = *t.Value
-			break
-		}
-	}
-	return value
-}
@@ -0,0 +1,29 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEC2SLs(t *testing.T) {
+	f := &fakeEC2{
+		snapshots: []*ec2.Snapshot{
+			{SnapshotId: aws.String("snap-0001"), Description: aws.String("daily"), StartTime: aws.Time(testTime)},
+			{SnapshotId: aws.String("snap-0002"), Description: aws.String("weekly"), StartTime: aws.Time(testTime.AddDate(0, 0, -7))},
+			{SnapshotId: aws.String("snap-0003"), Description: aws.String("monthly"), StartTime: aws.Time(testTime.AddDate(0, -1, 0))},
+		},
+		pageSize: 2,
+	}
+	client, stdout := newTestClient(t, Services{EC2: f})
+
+	err := client.EC2SLs(EC2SLsOptions{Quiet: true, Query: QueryOptions{SortBy: "StartTime", Limit: 2}})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got, want := stdout.String(), "snap-0003  \nsnap-0002  \n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -1,15 +1,20 @@
 package myaws
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"net"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -20,50 +25,192 @@ type EC2SSHOptions struct {
 	IdentityFile string
 	Private      bool
 	Command      string
+	// Port is a port of SSH. Defaults to the ssh config or 22.
+	Port string
+	// AcceptNew adds host keys of unknown hosts to known_hosts.
+	AcceptNew bool
+	// ForwardAgent forwards the connection to ssh-agent.
+	ForwardAgent bool
+	// Parallel is the number of hosts to execute the command at once.
+	Parallel int
+	// OutputMode is how to print output of the command on multiple hosts.
+	OutputMode string
+	// FailFast stops executing the command after the first failure.
+	FailFast bool
+	// NoPty disables requesting a pty to execute the command.
+	NoPty bool
+	// Timeout is a timeout of the command per host. 0 means no timeout.
+	Timeout time.Duration
+	// Select chooses instances in a fuzzy finder if multiple instances are
+	// found.
+	Select bool
+	// Jump is a Name tag or a TAG:VALUE filter of a jump host in the form of
+	// [USER@]NAME. Instances are connected through it by their private IP.
+	Jump string
+	// LocalForwards are specs of local port forwarding like ssh -L.
+	LocalForwards []string
+	// RemoteForwards are specs of remote port forwarding like ssh -R.
+	RemoteForwards []string
+	// NoCommand only forwards ports without starting a shell like ssh -N.
+	NoCommand bool
+	// KeepAlive is an interval of keepalive messages. 0 means
+	// ServerAliveInterval in the ssh config or 30s.
+	KeepAlive time.Duration
 }
 
 // EC2SSH resolves IP address of EC2 instance and connects to it by SSH.
+// Defaults of the user, port and identity files are read from ~/.ssh/config,
+// where a Host pattern matches a Name tag, an instance ID or an IP address.
+// Host keys are verified with ~/.ssh/known_hosts.
 func (client *Client) EC2SSH(options EC2SSHOptions) error {
-	config, err := buildSSHConfig(options.LoginName, options.IdentityFile)
+	forwards, err := parseSSHForwards(options.LocalForwards, options.RemoteForwards)
 	if err != nil {
 		return err
 	}
+	if options.Command != "" && (len(forwards) > 0 || options.NoCommand) {
+		return errors.New("port forwarding and --no-command can't be used with a command")
+	}
+	if options.NoCommand && len(forwards) == 0 {
+		return errors.New("--no-command requires port forwarding")
+	}
 
-	instances, err := client.FindEC2Instances(options.FilterTag, false)
+	auth, err := client.newSSHAuth(sshAuthOptions{
+		LoginName:    options.LoginName,
+		IdentityFile: options.IdentityFile,
+		Port:         options.Port,
+		AcceptNew:    options.AcceptNew,
+		ForwardAgent: options.ForwardAgent,
+		KeepAlive:    options.KeepAlive,
+	})
+	if err != nil {
+		return err
+	}
+	defer auth.close()
+
+	// Choose one to start a session, or any to execute a command.
+	hosts, err := client.findSSHHosts(auth, sshHostsOptions{
+		FilterTag: options.FilterTag,
+		Private:   options.Private,
+		Jump:      options.Jump,
+		Select:    options.Select,
+		Multi:     options.Command != "",
+	})
 	if err != nil {
 		return err
 	}
 
+	if options.NoCommand {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Fprintln(client.stderr, "Press Ctrl-C to stop port forwarding")
+		return client.forwardSSHPorts(ctx, hosts[0], forwards)
+	}
+
+	// Start single ssh session with terminal
+	if options.Command == "" {
+		return client.startSSHSessionWithTerminal(hosts[0], forwards)
+	}
+
+	return client.executeSSHCommands(hosts, sshExecOptions{
+		Command:    options.Command,
+		Parallel:   options.Parallel,
+		OutputMode: options.OutputMode,
+		FailFast:   options.FailFast,
+		NoPty:      options.NoPty,
+		Timeout:    options.Timeout,
+	})
+}
+
+// sshHostsOptions customize how to find hosts to connect by SSH.
+type sshHostsOptions struct {
+	FilterTag string
+	Private   bool
+	// Jump is a jump host in the form of [USER@]NAME.
+	Jump   string
+	Select bool
+	// Multi allows multiple hosts.
+	Multi bool
+}
+
+// findSSHHosts finds running instances matching the filter and resolves them
+// to hosts. Instances behind a jump host are connected by their private IP.
+func (client *Client) findSSHHosts(auth *sshAuth, options sshHostsOptions) ([]sshHost, error) {
+	var jump *sshHost
+	if options.Jump != "" {
+		var err error
+		jump, err = client.resolveSSHJumpHost(auth, options.Jump)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	instances, err := client.FindEC2Instances(options.FilterTag, false)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(instances) == 0 {
-		return errors.Errorf("no such instance: %s", options.FilterTag)
+		return nil, errors.Errorf("no such instance: %s", options.FilterTag)
 	}
 
-	if len(instances) >= 2 && options.Command == "" {
-		return errors.Errorf("multiple instances found")
+	if len(instances) >= 2 && options.Select {
+		instances, err = client.pickEC2Instances(instances, options.Multi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(instances) >= 2 && !options.Multi {
+		return nil, errors.Errorf("multiple instances found (use --select to choose one)")
 	}
 
-	hostnames := []string{}
+	hosts := []sshHost{}
 	for _, instance := range instances {
-		hostname, err := client.resolveEC2IPAddress(instance, options.Private)
+		hostname, err := client.resolveEC2IPAddress(instance, options.Private || jump != nil)
+		if err != nil {
+			return nil, err
+		}
+		host, err := auth.host(*instance.InstanceId, lookupTag(instance, "Name"), hostname)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		hostnames = append(hostnames, hostname)
+		host.jump = jump
+		hosts = append(hosts, host)
 	}
+	return hosts, nil
+}
 
-	// Start single ssh session with terminal
-	if options.Command == "" {
-		return client.startSSHSessionWithTerminal(hostnames[0], "22", config)
+// resolveSSHJumpHost finds a running jump host in the form of [USER@]NAME,
+// where NAME is a Name tag or a TAG:VALUE filter. It's connected by its public
+// IP.
+func (client *Client) resolveSSHJumpHost(auth *sshAuth, jump string) (*sshHost, error) {
+	var loginName string
+	if i := strings.Index(jump, "@"); i >= 0 {
+		loginName, jump = jump[:i], jump[i+1:]
+	}
+	filterTag := jump
+	if !strings.Contains(jump, ":") {
+		filterTag = "Name:" + jump
 	}
 
-	// Execute ssh command to multiple hosts in series
-	for _, hostname := range hostnames {
-		if err := client.executeSSHCommand(hostname, "22", config, options.Command); err != nil {
-			return err
-		}
+	instances, err := client.FindEC2Instances(filterTag, false)
+	if err != nil {
+		return nil, err
+	}
+	switch len(instances) {
+	case 0:
+		return nil, errors.Errorf("no such jump host: %s", filterTag)
+	case 1:
+	default:
+		return nil, errors.Errorf("multiple jump hosts found: %s", filterTag)
 	}
 
-	return nil
+	instance := instances[0]
+	hostname, err := client.resolveEC2PublicIPAddress(instance)
+	if err != nil {
+		return nil, err
+	}
+	return auth.jumpHost(loginName, *instance.InstanceId, lookupTag(instance, "Name"), hostname)
 }
 
 func (client *Client) resolveEC2IPAddress(instance *ec2.Instance, private bool) (string, error) {
@@ -87,27 +234,126 @@ func (client *Client) resolveEC2PublicIPAddress(instance *ec2.Instance) (string,
 	return *instance.PublicIpAddress, nil
 }
 
-func buildSSHConfig(loginName string, identityFile string) (*ssh.ClientConfig, error) {
-	normalizedIdentityFile := strings.Replace(identityFile, "~", os.Getenv("HOME"), 1)
-	key, err := ioutil.ReadFile(normalizedIdentityFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to read private key:")
+// dialSSH connects to the host, through the jump host if any. The connection
+// is closed when the context is done. If agent forwarding is enabled, requests
+// from the host are forwarded to the agent.
+func dialSSH(ctx context.Context, host sshHost) (*ssh.Client, error) {
+	var jump *ssh.Client
+	var conn net.Conn
+	var err error
+	if host.jump != nil {
+		jump, err = dialSSH(ctx, *host.jump)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to jump host %s:", host.jump.hostname)
+		}
+		conn, err = jump.Dial("tcp", host.addr())
+		if err != nil {
+			jump.Close()
+			return nil, errors.Wrapf(err, "unable to connect via jump host %s:", host.jump.hostname)
+		}
+	} else {
+		dialer := &net.Dialer{Timeout: host.config.Timeout}
+		conn, err = dialer.DialContext(ctx, "tcp", host.addr())
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to connect:")
+		}
+	}
+	closeAll := func() {
+		conn.Close()
+		if jump != nil {
+			jump.Close()
+		}
 	}
 
-	signer, err := ssh.ParsePrivateKey(key)
+	// Close the connection to abort the handshake or sessions when the context
+	// is done.
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeAll()
+		case <-stop:
+		}
+	}()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, host.addr(), host.config)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to parse private key:")
+		close(stop)
+		closeAll()
+		return nil, errors.Wrap(err, "unable to connect:")
+	}
+	connection := ssh.NewClient(c, chans, reqs)
+	go func() {
+		connection.Wait()
+		close(stop)
+		closeAll()
+	}()
+	if host.keepAlive > 0 {
+		go keepAliveSSH(connection, host.keepAlive, stop)
+	}
+
+	if host.forwardAgent != nil {
+		if err := agent.ForwardToAgent(connection, host.forwardAgent); err != nil {
+			connection.Close()
+			return nil, errors.Wrap(err, "unable to forward agent:")
+		}
+	}
+	return connection, nil
+}
+
+// sshKeepAliveCountMax is the number of intervals to wait for a response of a
+// keepalive message before disconnecting, like ServerAliveCountMax of ssh.
+const sshKeepAliveCountMax = 3
+
+// keepAliveSSH sends keepalive messages periodically until done is closed. If
+// the host doesn't respond, it closes the connection to avoid hanging on a
+// dead connection.
+func keepAliveSSH(connection *ssh.Client, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		reply := make(chan error, 1)
+		go func() {
+			// The host replies a failure to the unknown request, which is fine.
+			_, _, err := connection.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+
+		select {
+		case <-done:
+			return
+		case err := <-reply:
+			if err != nil {
+				connection.Close()
+				return
+			}
+		case <-time.After(interval * sshKeepAliveCountMax):
+			connection.Close()
+			return
+		}
 	}
+}
 
-	config := &ssh.ClientConfig{
-		User: loginName,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+// newSSHSession opens a new session and requests agent forwarding if enabled.
+func newSSHSession(connection *ssh.Client, host sshHost) (*ssh.Session, error) {
+	session, err := connection.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to new session failed:")
 	}
 
-	return config, nil
+	if host.forwardAgent != nil {
+		if err := agent.RequestAgentForwarding(session); err != nil {
+			session.Close()
+			return nil, errors.Wrap(err, "request for agent forwarding failed:")
+		}
+	}
+	return session, nil
 }
 
 func buildSSHSessionPipe(session *ssh.Session) error {
@@ -132,17 +378,22 @@ func buildSSHSessionPipe(session *ssh.Session) error {
 	return nil
 }
 
-func (client *Client) startSSHSessionWithTerminal(hostname string, port string, config *ssh.ClientConfig) error {
-	addr := fmt.Sprintf("%s:%s", hostname, port)
-	connection, err := ssh.Dial("tcp", addr, config)
+func (client *Client) startSSHSessionWithTerminal(host sshHost, forwards []sshForward) error {
+	connection, err := dialSSH(context.Background(), host)
 	if err != nil {
-		return errors.Wrap(err, "unable to connect:")
+		return err
 	}
 	defer connection.Close()
 
-	session, err := connection.NewSession()
+	stop, err := client.startSSHForwards(connection, forwards)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	session, err := newSSHSession(connection, host)
 	if err != nil {
-		return errors.Wrap(err, "unable to new session failed:")
+		return err
 	}
 	defer session.Close()
 
@@ -159,54 +410,88 @@ func (client *Client) startSSHSessionWithTerminal(hostname string, port string,
 	}
 	defer terminal.Restore(fd, oldState)
 
-	width, height, _ := terminal.GetSize(fd)
+	getSize := func() (int, int, error) { return terminal.GetSize(fd) }
+	width, height, _ := getSize()
 
 	if err := session.RequestPty("xterm", height, width, modes); err != nil {
 		return errors.Wrap(err, "request for pseudo terminal failed:")
 	}
 
+	stopWatching := watchTerminalSize(getSize, func(width int, height int) {
+		session.WindowChange(height, width)
+	})
+	defer stopWatching()
+
 	if err := buildSSHSessionPipe(session); err != nil {
 		return err
 	}
 
+	// In raw mode, Ctrl-C is sent to the host. Other signals close the
+	// connection so that the terminal state is restored before exiting.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-sigs:
+			received <- sig
+			connection.Close()
+		case <-done:
+		}
+	}()
+
 	if err := session.Shell(); err != nil {
 		return errors.Wrap(err, "failed to start shell:")
 	}
-	session.Wait()
-
-	return nil
-}
-
-func (client *Client) executeSSHCommand(hostname string, port string, config *ssh.ClientConfig, command string) error {
-	addr := fmt.Sprintf("%s:%s", hostname, port)
-	connection, err := ssh.Dial("tcp", addr, config)
-	if err != nil {
-		return errors.Wrap(err, "unable to connect:")
-	}
-	defer connection.Close()
+	err = session.Wait()
 
-	session, err := connection.NewSession()
-	if err != nil {
-		return errors.Wrap(err, "unable to new session failed:")
+	select {
+	case sig := <-received:
+		return &ExitError{Code: 128 + int(sig.(syscall.Signal)), Err: errors.Errorf("terminated by signal: %s", sig)}
+	default:
 	}
-	defer session.Close()
+	return sshExitError(err)
+}
 
-	// Request pty for sudo
-	fd := int(os.Stdin.Fd())
-	width, height, _ := terminal.GetSize(fd)
-	if err := session.RequestPty("xterm", height, width, ssh.TerminalModes{}); err != nil {
-		return errors.Wrap(err, "request for pseudo terminal failed:")
+// sshExitError converts an error of a remote shell or command to an
+// ExitError with its exit status. As with ssh, it exits with 255 if the exit
+// status is unknown, such as when the connection is lost.
+func sshExitError(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *ssh.ExitError:
+		// The shell has already printed the reason if any.
+		return &ExitError{Code: e.ExitStatus()}
+	default:
+		return &ExitError{Code: 255, Err: errors.Wrap(err, "session failed:")}
 	}
+}
 
-	out, err := session.CombinedOutput(command)
-
-	fmt.Fprintf(client.stdout, "========== Start output on host: %s ==========\n", hostname)
-	fmt.Fprintln(client.stdout, string(out))
-	fmt.Fprintf(client.stdout, "========== End   output on host: %s ==========\n", hostname)
-
-	if err != nil {
-		return errors.Wrapf(err, "failed to execute command: %s", command)
+// watchTerminalSize calls resize when the size of the local terminal changes,
+// such as to send a window-change request. It returns a function to stop
+// watching.
+func watchTerminalSize(getSize func() (width int, height int, err error), resize func(width int, height int)) func() {
+	sigwinch := make(chan os.Signal, 1)
+	signal.Notify(sigwinch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigwinch:
+				if width, height, err := getSize(); err == nil {
+					resize(width, height)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigwinch)
+		close(done)
 	}
-
-	return nil
 }
@@ -0,0 +1,361 @@
+package myaws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Output modes of a command executed on multiple hosts.
+const (
+	// SSHOutputGroup prints output of each host at once when it finishes.
+	SSHOutputGroup = "group"
+	// SSHOutputPrefix streams output of all hosts with a prefix of the host.
+	SSHOutputPrefix = "prefix"
+)
+
+// SSHOutputs is a list of valid output modes.
+var SSHOutputs = []string{SSHOutputGroup, SSHOutputPrefix}
+
+// Statuses of a command in the summary.
+const (
+	sshStatusOK        = "ok"
+	sshStatusFailed    = "failed"
+	sshStatusError     = "error"
+	sshStatusTimeout   = "timeout"
+	sshStatusCancelled = "cancelled"
+	sshStatusSkipped   = "skipped"
+)
+
+// sshHost is a host to connect by SSH.
+type sshHost struct {
+	instanceID string
+	name       string
+	hostname   string
+	port       string
+	config     *ssh.ClientConfig
+	// forwardAgent is an agent forwarded to the host, or nil if disabled.
+	forwardAgent agent.Agent
+	// jump is a host to connect through, or nil to connect directly.
+	jump *sshHost
+	// keepAlive is an interval of keepalive messages. 0 disables them.
+	keepAlive time.Duration
+}
+
+// addr returns an address to dial.
+func (h sshHost) addr() string {
+	return net.JoinHostPort(h.hostname, h.port)
+}
+
+// sshExecOptions customize the behavior of executing a command on hosts.
+type sshExecOptions struct {
+	Command    string
+	Parallel   int
+	OutputMode string
+	FailFast   bool
+	NoPty      bool
+	Timeout    time.Duration
+}
+
+// validate returns an error if the options are invalid.
+func (options sshExecOptions) validate() error {
+	if options.Parallel < 1 {
+		return errors.Errorf("--parallel must be a positive number: %d", options.Parallel)
+	}
+	for _, m := range SSHOutputs {
+		if options.OutputMode == m {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown output mode: %s (must be one of %s)", options.OutputMode, strings.Join(SSHOutputs, ", "))
+}
+
+// sshResult is a result of a command on a host.
+type sshResult struct {
+	host     sshHost
+	status   string
+	exitCode int
+	duration time.Duration
+	err      error
+}
+
+// executeSSHCommands executes a command on hosts with bounded parallelism and
+// prints a summary of the results. It returns an error if the command failed
+// on any host. With FailFast, it stops starting new hosts and cancels running
+// ones after the first failure.
+func (client *Client) executeSSHCommands(hosts []sshHost, options sshExecOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := &sshOutput{stdout: client.stdout, stderr: client.stderr}
+	results := make([]sshResult, len(hosts))
+	sem := make(chan struct{}, options.Parallel)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			results[i] = sshResult{host: host, status: sshStatusSkipped, exitCode: -1}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, host sshHost) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = client.executeSSHCommand(ctx, host, options, out)
+			if results[i].status != sshStatusOK && options.FailFast {
+				cancel()
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	if err := client.printSSHResults(results, "command"); err != nil {
+		return &ExitError{Code: sshResultsExitCode(results), Err: err}
+	}
+	return nil
+}
+
+// sshResultsExitCode returns an exit code for results of a command. It's the
+// largest exit status of the hosts, or 255 if the command didn't exit on any
+// host as ssh does.
+func sshResultsExitCode(results []sshResult) int {
+	code := 0
+	for _, r := range results {
+		c := r.exitCode
+		switch r.status {
+		case sshStatusOK:
+			continue
+		case sshStatusFailed:
+		default:
+			c = 255
+		}
+		if c > code {
+			code = c
+		}
+	}
+	return code
+}
+
+// executeSSHCommand executes a command on a host and prints its output.
+func (client *Client) executeSSHCommand(ctx context.Context, host sshHost, options sshExecOptions, out *sshOutput) sshResult {
+	start := time.Now()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr io.Writer
+	var group *bytes.Buffer
+	switch options.OutputMode {
+	case SSHOutputPrefix:
+		o := out.prefixed(host.hostname + " | ")
+		defer o.Flush()
+		stdout, stderr = o, o.Stderr()
+	default:
+		// stdout and stderr are copied concurrently.
+		group = &bytes.Buffer{}
+		w := &syncWriter{w: group}
+		stdout, stderr = w, w
+	}
+
+	exitCode, err := client.runSSHCommand(ctx, host, options, stdout, stderr)
+	result := sshResult{host: host, status: sshStatusOK, exitCode: exitCode, duration: time.Since(start), err: err}
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		result.status = sshStatusTimeout
+		result.err = errors.Errorf("timed out after %s", options.Timeout)
+	case ctx.Err() == context.Canceled:
+		result.status = sshStatusCancelled
+		result.err = errors.New("cancelled by --fail-fast")
+	case exitCode > 0:
+		result.status = sshStatusFailed
+	default:
+		result.status = sshStatusError
+	}
+
+	if group != nil {
+		out.group(host.hostname, group.Bytes())
+	}
+	return result
+}
+
+// runSSHCommand runs a command on a host until it exits or the context is
+// done. It returns an exit code of the command, or -1 if unknown.
+func (client *Client) runSSHCommand(ctx context.Context, host sshHost, options sshExecOptions, stdout io.Writer, stderr io.Writer) (int, error) {
+	connection, err := dialSSH(ctx, host)
+	if err != nil {
+		return -1, err
+	}
+	defer connection.Close()
+
+	session, err := newSSHSession(connection, host)
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+
+	if !options.NoPty {
+		// Request pty for sudo
+		width, height, _ := terminal.GetSize(int(os.Stdin.Fd()))
+		if err := session.RequestPty("xterm", height, width, ssh.TerminalModes{}); err != nil {
+			return -1, errors.Wrap(err, "request for pseudo terminal failed:")
+		}
+	}
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	err = session.Run(options.Command)
+	switch e := err.(type) {
+	case nil:
+		return 0, nil
+	case *ssh.ExitError:
+		return e.ExitStatus(), errors.Wrapf(err, "failed to execute command: %s", options.Command)
+	default:
+		return -1, errors.Wrapf(err, "failed to execute command: %s", options.Command)
+	}
+}
+
+// printSSHResults prints a summary of results, and returns an error if the
+// action such as a command didn't succeed on all hosts.
+func (client *Client) printSSHResults(results []sshResult, action string) error {
+	fields := []string{"InstanceId", "Name", "Host", "Status", "ExitCode", "Duration", "Error"}
+	rows := [][]string{}
+	failed := 0
+	for _, r := range results {
+		exitCode := ""
+		if r.exitCode >= 0 {
+			exitCode = strconv.Itoa(r.exitCode)
+		}
+		duration := ""
+		if r.status != sshStatusSkipped {
+			duration = r.duration.Round(time.Millisecond).String()
+		}
+		errMsg := ""
+		if r.err != nil && r.status != sshStatusFailed {
+			errMsg = r.err.Error()
+		}
+		rows = append(rows, []string{r.host.instanceID, r.host.name, r.host.hostname, r.status, exitCode, duration, errMsg})
+		if r.status != sshStatusOK {
+			failed++
+		}
+	}
+
+	if err := client.printRows(fields, rows, true); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%s failed on %d of %d hosts", action, failed, len(results))
+	}
+	return nil
+}
+
+// syncWriter is a writer which can be written concurrently.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// sshOutput serializes output of commands running on multiple hosts.
+type sshOutput struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// group prints the whole output of a host at once.
+func (o *sshOutput) group(hostname string, b []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	fmt.Fprintf(o.stdout, "========== Start output on host: %s ==========\n", hostname)
+	fmt.Fprintln(o.stdout, string(b))
+	fmt.Fprintf(o.stdout, "========== End   output on host: %s ==========\n", hostname)
+}
+
+// prefixed returns a writer which prints each line with a prefix.
+func (o *sshOutput) prefixed(prefix string) *sshPrefixWriter {
+	return &sshPrefixWriter{o: o, w: o.stdout, prefix: prefix}
+}
+
+// sshPrefixWriter writes complete lines with a prefix to the output.
+// An incomplete last line is buffered until Flush.
+type sshPrefixWriter struct {
+	o      *sshOutput
+	w      io.Writer
+	prefix string
+	buf    []byte
+	stderr *sshPrefixWriter
+}
+
+// Stderr returns a writer for stderr with the same prefix.
+func (w *sshPrefixWriter) Stderr() *sshPrefixWriter {
+	if w.stderr == nil {
+		w.stderr = &sshPrefixWriter{o: w.o, w: w.o.stderr, prefix: w.prefix}
+	}
+	return w.stderr
+}
+
+func (w *sshPrefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+
+	w.writeLines(w.buf[:i+1])
+	w.buf = append([]byte{}, w.buf[i+1:]...)
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line.
+func (w *sshPrefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLines(append(w.buf, '\n'))
+		w.buf = nil
+	}
+	if w.stderr != nil {
+		w.stderr.Flush()
+	}
+}
+
+func (w *sshPrefixWriter) writeLines(b []byte) {
+	w.o.mu.Lock()
+	defer w.o.mu.Unlock()
+
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if line == "" {
+			continue
+		}
+		// A pty translates a newline to CRLF.
+		line = strings.TrimRight(line, "\r\n")
+		fmt.Fprintf(w.w, "%s%s\n", w.prefix, line)
+	}
+}
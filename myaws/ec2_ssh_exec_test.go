@@ -0,0 +1,246 @@
+package myaws
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closedSSHHost returns a host which refuses connections.
+func closedSSHHost(t *testing.T, instanceID string) sshHost {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+	return sshHost{instanceID: instanceID, name: "test-" + instanceID, hostname: "127.0.0.1", port: port, config: testSSHClientConfig(t)}
+}
+
+func TestExecuteSSHCommandsGroup(t *testing.T) {
+	s := newTestSSHServer(t)
+	client, stdout := newTestClient(t, Services{})
+	hosts := []sshHost{s.host("i-0001"), s.host("i-0002"), s.host("i-0003")}
+
+	err := client.executeSSHCommands(hosts, sshExecOptions{
+		Command:    "echo hello",
+		Parallel:   2,
+		OutputMode: SSHOutputGroup,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	got := stdout.String()
+	if n := strings.Count(got, "========== Start output on host: 127.0.0.1 ==========\nhello\n"); n != 3 {
+		t.Errorf("got %d groups of output, want 3:\n%s", n, got)
+	}
+	if !strings.Contains(got, "InstanceId\tName\tHost\tStatus\tExitCode\tDuration\tError\n") {
+		t.Errorf("no summary header:\n%s", got)
+	}
+	for _, id := range []string{"i-0001", "i-0002", "i-0003"} {
+		if !strings.Contains(got, id+"\ttest-"+id+"\t127.0.0.1\tok\t0\t") {
+			t.Errorf("no summary of %s:\n%s", id, got)
+		}
+	}
+	if n := s.ptys(); n != 3 {
+		t.Errorf("ptyRequests = %d, want 3", n)
+	}
+}
+
+func TestExecuteSSHCommandsPrefix(t *testing.T) {
+	s := newTestSSHServer(t)
+	client, stdout := newTestClient(t, Services{})
+	hosts := []sshHost{s.host("i-0001"), s.host("i-0002")}
+
+	for _, command := range []string{"echo hello", "warn oops"} {
+		err := client.executeSSHCommands(hosts, sshExecOptions{
+			Command:    command,
+			Parallel:   2,
+			OutputMode: SSHOutputPrefix,
+			NoPty:      true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+	}
+
+	if n := strings.Count(stdout.String(), "127.0.0.1 | hello\n"); n != 2 {
+		t.Errorf("got %d prefixed lines, want 2:\n%s", n, stdout)
+	}
+	if n := strings.Count(stderrOf(client).String(), "127.0.0.1 | oops\n"); n != 2 {
+		t.Errorf("got %d prefixed lines in stderr, want 2:\n%s", n, stderrOf(client))
+	}
+	if n := s.ptys(); n != 0 {
+		t.Errorf("pty should not be requested: %d", n)
+	}
+}
+
+func TestExecuteSSHCommandsFailures(t *testing.T) {
+	cases := []struct {
+		desc     string
+		hosts    func(t *testing.T, s *testSSHServer) []sshHost
+		options  sshExecOptions
+		want     []string
+		wantErr  string
+		wantCode int
+		executed int
+	}{
+		{
+			desc: "exit code",
+			hosts: func(t *testing.T, s *testSSHServer) []sshHost {
+				return []sshHost{s.host("i-0001"), s.host("i-0002")}
+			},
+			options:  sshExecOptions{Command: "exit 3", Parallel: 2},
+			want:     []string{"i-0001\ttest-i-0001\t127.0.0.1\tfailed\t3\t", "i-0002\ttest-i-0002\t127.0.0.1\tfailed\t3\t"},
+			wantErr:  "command failed on 2 of 2 hosts",
+			wantCode: 3,
+			executed: 2,
+		},
+		{
+			desc: "connection error",
+			hosts: func(t *testing.T, s *testSSHServer) []sshHost {
+				return []sshHost{closedSSHHost(t, "i-0001"), s.host("i-0002")}
+			},
+			options:  sshExecOptions{Command: "echo hello", Parallel: 1},
+			want:     []string{"i-0001\ttest-i-0001\t127.0.0.1\terror\t\t", "unable to connect", "i-0002\ttest-i-0002\t127.0.0.1\tok\t0\t"},
+			wantErr:  "command failed on 1 of 2 hosts",
+			wantCode: 255,
+			executed: 1,
+		},
+		{
+			desc: "timeout",
+			hosts: func(t *testing.T, s *testSSHServer) []sshHost {
+				return []sshHost{s.host("i-0001")}
+			},
+			options:  sshExecOptions{Command: "sleep 10s", Parallel: 1, Timeout: 100 * time.Millisecond},
+			want:     []string{"i-0001\ttest-i-0001\t127.0.0.1\ttimeout\t\t", "timed out after 100ms"},
+			wantErr:  "command failed on 1 of 1 hosts",
+			wantCode: 255,
+			executed: 1,
+		},
+		{
+			desc: "fail fast",
+			hosts: func(t *testing.T, s *testSSHServer) []sshHost {
+				return []sshHost{closedSSHHost(t, "i-0001"), s.host("i-0002"), s.host("i-0003")}
+			},
+			options:  sshExecOptions{Command: "sleep 10s", Parallel: 2, FailFast: true},
+			want:     []string{"i-0001\ttest-i-0001\t127.0.0.1\terror\t", "i-0002\ttest-i-0002\t127.0.0.1\tcancelled\t", "i-0003\ttest-i-0003\t127.0.0.1\tskipped\t\t\t\n"},
+			wantErr:  "command failed on 3 of 3 hosts",
+			wantCode: 255,
+			executed: -1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := newTestSSHServer(t)
+			client, stdout := newTestClient(t, Services{})
+			options := tc.options
+			options.OutputMode = SSHOutputGroup
+
+			start := time.Now()
+			err := client.executeSSHCommands(tc.hosts(t, s), options)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected an error %q, but got: %v", tc.wantErr, err)
+			}
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != tc.wantCode {
+				t.Errorf("expected an exit code %d, but got: %#v", tc.wantCode, err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("should not wait for a sleeping command: %s", elapsed)
+			}
+
+			got := stdout.String()
+			for _, w := range tc.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("stdout doesn't contain %q:\n%s", w, got)
+				}
+			}
+			if tc.executed >= 0 && len(s.executed()) != tc.executed {
+				t.Errorf("executed %v, want %d commands", s.executed(), tc.executed)
+			}
+		})
+	}
+}
+
+func TestSSHExecOptionsValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options sshExecOptions
+		want    string
+	}{
+		{desc: "parallel", options: sshExecOptions{Parallel: 0, OutputMode: SSHOutputGroup}, want: "--parallel must be a positive number: 0"},
+		{desc: "output mode", options: sshExecOptions{Parallel: 1, OutputMode: "foo"}, want: "unknown output mode: foo"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.options.validate()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error %q, but got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestSSHResultsExitCode(t *testing.T) {
+	cases := []struct {
+		desc    string
+		results []sshResult
+		want    int
+	}{
+		{desc: "ok", results: []sshResult{{status: sshStatusOK}}, want: 0},
+		{desc: "largest", results: []sshResult{{status: sshStatusFailed, exitCode: 1}, {status: sshStatusOK}, {status: sshStatusFailed, exitCode: 2}}, want: 2},
+		{desc: "error", results: []sshResult{{status: sshStatusFailed, exitCode: 1}, {status: sshStatusError, exitCode: -1}}, want: 255},
+		{desc: "skipped", results: []sshResult{{status: sshStatusSkipped, exitCode: -1}}, want: 255},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := sshResultsExitCode(tc.results); got != tc.want {
+				t.Errorf("got = %d, want = %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKeepAliveSSH(t *testing.T) {
+	cases := []struct {
+		desc    string
+		ignore  bool
+		command string
+		wantErr bool
+		// min is the minimum number of keepalive messages.
+		min int
+	}{
+		{desc: "alive", command: "sleep 300ms", min: 2},
+		{desc: "dead", ignore: true, command: "sleep 10s", wantErr: true, min: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := newTestSSHServer(t)
+			s.ignoreKeepAlive = tc.ignore
+			client, _ := newTestClient(t, Services{})
+			host := s.host("i-0001")
+			host.keepAlive = 50 * time.Millisecond
+
+			start := time.Now()
+			_, err := runTestSSHCommand(client, host, tc.command)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("should disconnect a dead host: %s", elapsed)
+			}
+			if n, _ := s.stats(); n < tc.min {
+				t.Errorf("keepalives = %d, want >= %d", n, tc.min)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package myaws
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestIdentityFile writes a new private key and returns its path.
+func writeTestIdentityFile(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "myaws")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "id_rsa")
+	b := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatalf("failed to write key: %s", err)
+	}
+	return path
+}
+
+func TestEC2SSHErrors(t *testing.T) {
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	identityFile := writeTestIdentityFile(t)
+
+	cases := []struct {
+		desc    string
+		options EC2SSHOptions
+		want    string
+	}{
+		{
+			desc:    "no such instance",
+			options: EC2SSHOptions{FilterTag: "Name:foo", IdentityFile: identityFile},
+			want:    "no such instance: Name:foo",
+		},
+		{
+			desc:    "multiple instances without command",
+			options: EC2SSHOptions{FilterTag: "Name:prod", IdentityFile: identityFile},
+			want:    "multiple instances found",
+		},
+		{
+			desc:    "no public ip",
+			options: EC2SSHOptions{FilterTag: "Name:stg", IdentityFile: identityFile},
+			want:    "no public ip address: i-0002",
+		},
+		{
+			desc:    "invalid identity file",
+			options: EC2SSHOptions{FilterTag: "Name:web-prod", IdentityFile: identityFile + ".missing"},
+			want:    "unable to read private key",
+		},
+		{
+			desc:    "no keys",
+			options: EC2SSHOptions{FilterTag: "Name:web-prod"},
+			want:    "no SSH keys found",
+		},
+		{
+			desc:    "forward agent without agent",
+			options: EC2SSHOptions{FilterTag: "Name:web-prod", IdentityFile: identityFile, ForwardAgent: true},
+			want:    "agent forwarding requires ssh-agent",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, _ := newTestClient(t, Services{EC2: newFakeEC2WithInstances()})
+			err := client.EC2SSH(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestResolveEC2IPAddress(t *testing.T) {
+	client, _ := newTestClient(t, Services{})
+	instance := newFakeEC2Instance("i-0001", "running", "web", "203.0.113.1", "10.0.0.1")
+
+	if got, _ := client.resolveEC2IPAddress(instance, false); got != "203.0.113.1" {
+		t.Errorf("public ip = %s", got)
+	}
+	if got, _ := client.resolveEC2IPAddress(instance, true); got != "10.0.0.1" {
+		t.Errorf("private ip = %s", got)
+	}
+}
+
+func TestEC2SSHJump(t *testing.T) {
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	bastion := newTestSSHServer(t)
+	target := newTestSSHServer(t)
+	// Both servers listen on 127.0.0.1, so their ports are in the ssh config.
+	config := fmt.Sprintf("Host bastion\n  Port %s\nHost web-*\n  Port %s\n", bastion.port, target.port)
+	if err := os.MkdirAll(expandSSHPath("~/.ssh"), 0700); err != nil {
+		t.Fatalf("failed to create dir: %s", err)
+	}
+	if err := ioutil.WriteFile(expandSSHPath("~/.ssh/config"), []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+
+	client, stdout := newTestClient(t, Services{EC2: &fakeEC2{
+		instances: []*ec2.Instance{
+			newFakeEC2Instance("i-0001", "running", "bastion", "127.0.0.1", "10.0.0.1"),
+			newFakeEC2Instance("i-0002", "running", "web-1", "", "127.0.0.1"),
+		},
+	}})
+
+	err := client.EC2SSH(EC2SSHOptions{
+		FilterTag:    "Name:web-1",
+		IdentityFile: writeTestIdentityFile(t),
+		Command:      "echo hello",
+		AcceptNew:    true,
+		Jump:         "admin@bastion",
+		Parallel:     1,
+		OutputMode:   SSHOutputGroup,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if !strings.Contains(stdout.String(), "hello\n") {
+		t.Errorf("unexpected stdout: %s", stdout)
+	}
+	if got := bastion.dialed(); len(got) != 1 || got[0] != "127.0.0.1:"+target.port {
+		t.Errorf("bastion dialed %v, want the target", got)
+	}
+	if got := bastion.loginUsers(); len(got) != 1 || got[0] != "admin" {
+		t.Errorf("bastion login users = %v, want = [admin]", got)
+	}
+	if got := target.executed(); len(got) != 1 || got[0] != "echo hello" {
+		t.Errorf("target executed %v", got)
+	}
+}
+
+func TestEC2SSHJumpErrors(t *testing.T) {
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	identityFile := writeTestIdentityFile(t)
+
+	cases := []struct {
+		desc    string
+		options EC2SSHOptions
+		want    string
+	}{
+		{
+			desc:    "no such jump host",
+			options: EC2SSHOptions{FilterTag: "Name:web-stg", IdentityFile: identityFile, Jump: "bastion"},
+			want:    "no such jump host: Name:bastion",
+		},
+		{
+			desc:    "multiple jump hosts",
+			options: EC2SSHOptions{FilterTag: "Name:web-stg", IdentityFile: identityFile, Jump: "Name:web"},
+			want:    "multiple jump hosts found: Name:web",
+		},
+		{
+			desc:    "forwarding with command",
+			options: EC2SSHOptions{FilterTag: "Name:web-stg", IdentityFile: identityFile, Command: "uptime", LocalForwards: []string{"5432:db:5432"}},
+			want:    "port forwarding and --no-command can't be used with a command",
+		},
+		{
+			desc:    "no command without forwarding",
+			options: EC2SSHOptions{FilterTag: "Name:web-stg", IdentityFile: identityFile, NoCommand: true},
+			want:    "--no-command requires port forwarding",
+		},
+		{
+			desc:    "invalid forwarding",
+			options: EC2SSHOptions{FilterTag: "Name:web-stg", IdentityFile: identityFile, RemoteForwards: []string{"8080"}},
+			want:    "invalid port forwarding: 8080",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, _ := newTestClient(t, Services{EC2: newFakeEC2WithInstances()})
+			err := client.EC2SSH(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestSSHExitError(t *testing.T) {
+	cases := []struct {
+		desc     string
+		err      error
+		wantCode int
+		wantMsg  string
+	}{
+		{desc: "lost", err: io.EOF, wantCode: 255, wantMsg: "session failed:: EOF"},
+		{desc: "missing", err: &ssh.ExitMissingError{}, wantCode: 255, wantMsg: "session failed:"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := sshExitError(tc.err)
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != tc.wantCode || !strings.Contains(err.Error(), tc.wantMsg) {
+				t.Errorf("got = %#v, want code %d and %q", err, tc.wantCode, tc.wantMsg)
+			}
+		})
+	}
+
+	if err := sshExitError(nil); err != nil {
+		t.Errorf("unexpected err: %s", err)
+	}
+}
+
+// startTestSSHSession connects to the server and returns a new session.
+func startTestSSHSession(t *testing.T, s *testSSHServer) *ssh.Session {
+	t.Helper()
+	connection, err := dialSSH(context.Background(), s.host("i-0001"))
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	session, err := connection.NewSession()
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	return session
+}
+
+func TestSSHSessionExitStatus(t *testing.T) {
+	s := newTestSSHServer(t)
+	session := startTestSSHSession(t, s)
+
+	err := sshExitError(session.Run("exit 3"))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 || exitErr.Err != nil {
+		t.Errorf("expected a silent exit code 3, but got: %#v", err)
+	}
+}
+
+func TestWatchTerminalSize(t *testing.T) {
+	s := newTestSSHServer(t)
+	session := startTestSSHSession(t, s)
+	getSize := func() (int, int, error) { return 120, 40, nil }
+
+	stop := watchTerminalSize(getSize, func(width int, height int) {
+		session.WindowChange(height, width)
+	})
+	defer stop()
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatalf("failed to send SIGWINCH: %s", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		if _, sizes := s.stats(); len(sizes) > 0 {
+			if sizes[0] != "120x40" {
+				t.Errorf("window size = %s, want = 120x40", sizes[0])
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("no window-change request")
+}
@@ -3,6 +3,7 @@ package myaws
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
 )
@@ -11,16 +12,41 @@ import (
 type EC2StartOptions struct {
 	InstanceIds []*string
 	Wait        bool
+	// Select chooses stopped instances in a fuzzy finder.
+	Select bool
 }
 
 // EC2Start starts EC2 instances.
 // If wait flag is true, wait until instance is in running state.
 func (client *Client) EC2Start(options EC2StartOptions) error {
+	if options.Select {
+		instanceIds, err := client.pickEC2InstanceIds("stopped")
+		if err != nil {
+			return err
+		}
+		options.InstanceIds = instanceIds
+	}
+
+	if err := client.guardEC2Instances(options.InstanceIds); err != nil {
+		return err
+	}
+
 	params := &ec2.StartInstancesInput{
 		InstanceIds: options.InstanceIds,
 	}
 
+	if client.dryRun {
+		// Check permissions with the native DryRun parameter.
+		params.DryRun = aws.Bool(true)
+		_, err := client.EC2.StartInstances(params)
+		if err = checkEC2DryRun(err); err != nil {
+			return errors.Wrap(err, "StartInstances failed:")
+		}
+		return client.planEC2InstanceStates("EC2.StartInstances", options.InstanceIds, "running")
+	}
+
 	response, err := client.EC2.StartInstances(params)
+	client.auditCall("EC2.StartInstances", params, aws.StringValueSlice(options.InstanceIds), err)
 	if err != nil {
 		return errors.Wrap(err, "StartInstances failed:")
 	}
@@ -0,0 +1,38 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestEC2Start(t *testing.T) {
+	f := newFakeEC2WithInstances()
+	client, stdout := newTestClient(t, Services{EC2: f})
+
+	err := client.EC2Start(EC2StartOptions{InstanceIds: aws.StringSlice([]string{"i-0003"}), Wait: true})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := *f.instances[2].State.Name; got != "running" {
+		t.Errorf("state = %s, want running", got)
+	}
+	if got, want := f.waitUntilRunning, []string{"i-0003"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WaitUntilInstanceRunning = %v, want %v", got, want)
+	}
+	if got := stdout.String(); !strings.Contains(got, "Wait until instance running...") {
+		t.Errorf("unexpected stdout: %s", got)
+	}
+}
+
+func TestEC2StartNotFound(t *testing.T) {
+	client, _ := newTestClient(t, Services{EC2: newFakeEC2WithInstances()})
+
+	err := client.EC2Start(EC2StartOptions{InstanceIds: aws.StringSlice([]string{"i-9999"})})
+	if err == nil || !strings.Contains(err.Error(), "StartInstances failed") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}
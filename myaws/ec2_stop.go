@@ -3,6 +3,7 @@ package myaws
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
 )
@@ -11,16 +12,44 @@ import (
 type EC2StopOptions struct {
 	InstanceIds []*string
 	Wait        bool
+	// Select chooses running instances in a fuzzy finder.
+	Select bool
 }
 
 // EC2Stop stops EC2 instances.
 // If wait flag is true, wait until instance is in stopped state.
 func (client *Client) EC2Stop(options EC2StopOptions) error {
+	if options.Select {
+		instanceIds, err := client.pickEC2InstanceIds("running")
+		if err != nil {
+			return err
+		}
+		options.InstanceIds = instanceIds
+	}
+
+	if err := client.guardEC2Instances(options.InstanceIds); err != nil {
+		return err
+	}
+	if ok, err := client.confirm(fmt.Sprintf("Are you sure want to stop instances %s?", formatPlanValues(options.InstanceIds))); !ok {
+		return err
+	}
+
 	params := &ec2.StopInstancesInput{
 		InstanceIds: options.InstanceIds,
 	}
 
+	if client.dryRun {
+		// Check permissions with the native DryRun parameter.
+		params.DryRun = aws.Bool(true)
+		_, err := client.EC2.StopInstances(params)
+		if err = checkEC2DryRun(err); err != nil {
+			return errors.Wrap(err, "StopInstances failed:")
+		}
+		return client.planEC2InstanceStates("EC2.StopInstances", options.InstanceIds, "stopped")
+	}
+
 	response, err := client.EC2.StopInstances(params)
+	client.auditCall("EC2.StopInstances", params, aws.StringValueSlice(options.InstanceIds), err)
 	if err != nil {
 		return errors.Wrap(err, "StopInstances failed:")
 	}
@@ -0,0 +1,38 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestEC2Stop(t *testing.T) {
+	cases := []struct {
+		desc string
+		wait bool
+	}{
+		{desc: "no wait", wait: false},
+		{desc: "wait", wait: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeEC2WithInstances()
+			client, _ := newTestClient(t, Services{EC2: f})
+
+			err := client.EC2Stop(EC2StopOptions{InstanceIds: aws.StringSlice([]string{"i-0001", "i-0002"}), Wait: tc.wait})
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			for _, instance := range f.instances[:2] {
+				if got := *instance.State.Name; got != "stopped" {
+					t.Errorf("state of %s = %s, want stopped", *instance.InstanceId, got)
+				}
+			}
+			if got := len(f.waitUntilStopped) > 0; got != tc.wait {
+				t.Errorf("waited = %t, want %t", got, tc.wait)
+			}
+		})
+	}
+}
@@ -2,106 +2,122 @@ package myaws
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2VLsOptions customize the behavior of the Ls command.
 type EC2VLsOptions struct {
-	All       bool
-	Quiet     bool
-	FilterTag string
-	Fields    []string
-	Domain    []string
+	All        bool
+	Quiet      bool
+	FilterTag  string
+	Fields     []string
+	Domain     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
+}
+
+// ec2VolumeFields is a registry of output fields for EC2 volumes.
+var ec2VolumeFields = newFieldRegistry(ec2.Volume{}, "VolumeId", []field{
+	{"VolumeId", formatEC2VolumeID},
+	{"VolumeType", formatEC2VolumeType},
+	{"Size", formatEC2VolumeSize},
+	{"InstanceId", formatEC2VolumeInstanceID},
+	{"Device", formatEC2VolumeDevice},
+})
+
+// expandEC2VolumeFields replaces the Attachments field, which was printed as
+// two columns of InstanceId and Device, with the two fields.
+func expandEC2VolumeFields(fields []string) []string {
+	expanded := []string{}
+	for _, f := range fields {
+		if f == "Attachments" {
+			expanded = append(expanded, "InstanceId", "Device")
+			continue
+		}
+		expanded = append(expanded, f)
+	}
+	return expanded
 }
 
 // EC2VLs describes EC2 volumes.
 func (client *Client) EC2VLs(options EC2VLsOptions) error {
-	volumes, err := client.FindEC2Volumes(options.FilterTag, options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2VolumeFields)
 	}
 
-	for _, volume := range volumes {
-		fmt.Fprintln(client.stdout, formatEC2Volume(client, options, volume))
+	fields := ec2VolumeFields.outputFields(options.Quiet, options.Targets.outputFields(expandEC2VolumeFields(options.Fields)))
+	if err := ec2VolumeFields.validate(fields); err != nil {
+		return err
 	}
-	return nil
-}
 
-func formatEC2Volume(client *Client, options EC2VLsOptions, volume *ec2.Volume) string {
-	formatFuncs := map[string]func(client *Client, options EC2VLsOptions, volume *ec2.Volume) string{
-		"VolumeId":    formatEC2VolumeID,
-		"VolumeType":  formatEC2VolumeType,
-		"Size":        formatEC2VolumeSize,
-		"Attachments": formatEC2VolumeAttachments,
+	q, err := ec2VolumeFields.compileQuery(options.Query)
+	if err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"VolumeId"}
-	} else {
-		outputFields = options.Fields
+	domain, err := compileEC2Domain(options.Domain)
+	if err != nil {
+		return err
 	}
 
-	output := []string{}
-	volume_name := ""
-
-	for _, field := range outputFields {
-		value := ""
-
-		if strings.Index(field, "Tag:") != -1 {
-			key := strings.Split(field, ":")[1]
-			value = formatEC2VTag(volume, key)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		volumes, err := c.FindEC2VolumesWithContext(aws.BackgroundContext(), options.FilterTag, options.All, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
 
-			if field == "Tag:Name" {
-				volume_name = value
+		resources := []interface{}{}
+		for _, volume := range volumes {
+			// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
+			if !matchEC2Domain(domain, volume) {
+				continue
 			}
-
-		} else {
-			value = formatFuncs[field](client, options, volume)
+			resources = append(resources, volume)
 		}
-		output = append(output, value)
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	// -Dの指定時に、引数で指定された文字列とインスタンスのtag:nameに合致する対象だけoutputに追記する。
-	if len(options.Domain) == 0 {
-		return strings.Join(output[:], "\t")
-	} else {
-		if regexp.MustCompile(options.Domain[0]).Match([]byte(volume_name)) {
-			return strings.Join(output[:], "\t")
-		} else {
-			output = nil
-			return strings.Join(output[:], "")
-		}
+	rows := ec2VolumeFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
 }
 
-func formatEC2VolumeID(client *Client, options EC2VLsOptions, volume *ec2.Volume) string {
+func formatEC2VolumeID(client *Client, resource interface{}) string {
+	volume := resource.(*ec2.Volume)
 	return *volume.VolumeId
 }
 
-func formatEC2VolumeType(client *Client, options EC2VLsOptions, volume *ec2.Volume) string {
+func formatEC2VolumeType(client *Client, resource interface{}) string {
+	volume := resource.(*ec2.Volume)
 	return fmt.Sprintf("%-11s", *volume.VolumeType)
 }
 
-func formatEC2VolumeSize(client *Client, options EC2VLsOptions, volume *ec2.Volume) string {
+func formatEC2VolumeSize(client *Client, resource interface{}) string {
+	volume := resource.(*ec2.Volume)
 	return fmt.Sprintf("%dGib", *volume.Size)
 }
 
-func formatEC2VolumeAttachments(client *Client, options EC2VLsOptions, volume *ec2.Volume) string {
-
-	return fmt.Sprintf("%-11s\t%-11s", *volume.Attachments[0].InstanceId, *volume.Attachments[0].Device)
+func formatEC2VolumeInstanceID(client *Client, resource interface{}) string {
+	volume := resource.(*ec2.Volume)
+	// An available volume has no attachments.
+	if len(volume.Attachments) == 0 {
+		return fmt.Sprintf("%-11s", "-")
+	}
+	return fmt.Sprintf("%-11s", *volume.Attachments[0].InstanceId)
 }
 
-func formatEC2VTag(volume *ec2.Volume, key string) string {
-	var value string
-	for _, t := range volume.Tags {
-		if *t.Key == key {
-			value = *t.Value
-			break
-		}
+func formatEC2VolumeDevice(client *Client, resource interface{}) string {
+	volume := resource.(*ec2.Volume)
+	if len(volume.Attachments) == 0 {
+		return fmt.Sprintf("%-11s", "-")
 	}
-	return value
+	return fmt.Sprintf("%-11s", *volume.Attachments[0].Device)
 }
@@ -0,0 +1,76 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEC2VLs(t *testing.T) {
+	f := &fakeEC2{
+		volumes: []*ec2.Volume{
+			{
+				VolumeId:   aws.String("vol-0001"),
+				VolumeType: aws.String("gp3"),
+				Size:       aws.Int64(8),
+				Attachments: []*ec2.VolumeAttachment{
+					{InstanceId: aws.String("i-0001"), Device: aws.String("/dev/xvda")},
+				},
+				Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+			},
+			{
+				VolumeId:   aws.String("vol-0002"),
+				VolumeType: aws.String("io1"),
+				Size:       aws.Int64(100),
+				Tags:       []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("db")}},
+			},
+		},
+		pageSize: 1,
+	}
+
+	cases := []struct {
+		desc    string
+		options EC2VLsOptions
+		output  string
+		want    string
+	}{
+		{
+			desc:    "fields",
+			options: EC2VLsOptions{Fields: []string{"VolumeId", "Size", "Attachments"}},
+			want: "vol-0001\t8Gib\ti-0001     \t/dev/xvda  \n" +
+				"vol-0002\t100Gib\t-          \t-          \n",
+		},
+		{
+			desc:    "csv",
+			options: EC2VLsOptions{Fields: []string{"VolumeId", "InstanceId", "Device"}},
+			output:  OutputFormatCSV,
+			want:    "VolumeId,InstanceId,Device\nvol-0001,i-0001,/dev/xvda\nvol-0002,-,-\n",
+		},
+		{
+			desc:    "where",
+			options: EC2VLsOptions{Quiet: true, Query: QueryOptions{Where: "Size > 10"}},
+			want:    "vol-0002\n",
+		},
+		{
+			desc:    "domain",
+			options: EC2VLsOptions{Quiet: true, Domain: []string{"^web$"}},
+			want:    "vol-0001\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{EC2: f})
+			if tc.output != "" {
+				client.output = tc.output
+			}
+			if err := client.EC2VLs(tc.options); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,97 +2,111 @@ package myaws
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // EC2RILsOptions customize the behavior of the Ls command.
 type EC2RILsOptions struct {
-	All    bool
-	Quiet  bool
-	Fields []string
+	All        bool
+	Quiet      bool
+	Fields     []string
+	ListFields bool
+	Targets    TargetOptions
 }
 
+// ec2ReservedInstanceFields is a registry of output fields for EC2 Reserved Instances.
+var ec2ReservedInstanceFields = newFieldRegistry(ec2.ReservedInstances{}, "ReservedInstancesId", []field{
+	{"ReservedInstancesId", formatEC2ReservedInstanceID},
+	{"AvailabilityZone", formatEC2RIAvailabilityZone},
+	{"InstanceType", formatEC2RIInstanceType},
+	{"InstanceCount", formatEC2RIInstanceCount},
+	{"State", formatEC2RIState},
+	{"Scope", formatEC2RIScope},
+	{"Start", formatEC2RIStart},
+	{"End", formatEC2RIEnd},
+	{"Duration", formatEC2RIDuration},
+})
+
 // EC2RILs describes EC2 Reserved Instances.
 func (client *Client) EC2RILs(options EC2RILsOptions) error {
-	instances, err := client.FindEC2ReservedInstances(options.All)
-	if err != nil {
-		return err
+	if options.ListFields {
+		return client.printFields(ec2ReservedInstanceFields)
 	}
 
-	for _, instance := range instances {
-		fmt.Fprintln(client.stdout, formatEC2RIInstance(client, options, instance))
-	}
-
-	return nil
-}
-
-func formatEC2RIInstance(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
-	formatFuncs := map[string]func(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string{
-		"ReservedInstancesId": formatEC2ReservedInstanceID,
-		"AvailabilityZone":    formatEC2RIAvailabilityZone,
-		"InstanceType":        formatEC2RIInstanceType,
-		"InstanceCount":       formatEC2RIInstanceCount,
-		"State":               formatEC2RIState,
-		"Scope":               formatEC2RIScope,
-		"Start":               formatEC2RIStart,
-		"End":                 formatEC2RIEnd,
-		"Duration":            formatEC2RIDuration,
+	fields := ec2ReservedInstanceFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := ec2ReservedInstanceFields.validate(fields); err != nil {
+		return err
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"InstanceId"}
-	} else {
-		outputFields = options.Fields
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		instances, err := c.FindEC2ReservedInstances(options.All)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		for _, instance := range instances {
+			resources = append(resources, instance)
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	output := []string{}
+	rows := ec2ReservedInstanceFields.formatRows(client, fields, resources)
 
-	for _, field := range outputFields {
-		value := formatFuncs[field](client, options, instance)
-		output = append(output, value)
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
-
-	return strings.Join(output[:], "\t")
+	return findErr
 }
 
-func formatEC2ReservedInstanceID(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2ReservedInstanceID(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return *instance.ReservedInstancesId
 }
 
-func formatEC2RIAvailabilityZone(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIAvailabilityZone(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	if instance.AvailabilityZone != nil {
 		return *instance.AvailabilityZone
 	}
 	return "N/A"
 }
 
-func formatEC2RIInstanceType(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIInstanceType(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return *instance.InstanceType
 }
 
-func formatEC2RIInstanceCount(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIInstanceCount(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return fmt.Sprintf("%3d", *instance.InstanceCount)
 }
 
-func formatEC2RIState(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIState(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return *instance.State
 }
 
-func formatEC2RIScope(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIScope(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return *instance.Scope
 }
 
-func formatEC2RIStart(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIStart(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return instance.Start.Format("2006-01-02")
 }
 
-func formatEC2RIEnd(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIEnd(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return instance.End.Format("2006-01-02")
 }
 
-func formatEC2RIDuration(client *Client, options EC2RILsOptions, instance *ec2.ReservedInstances) string {
+func formatEC2RIDuration(client *Client, resource interface{}) string {
+	instance := resource.(*ec2.ReservedInstances)
 	return fmt.Sprintf("%2dyear", *instance.Duration/(3600*24*365))
 }
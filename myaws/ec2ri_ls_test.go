@@ -0,0 +1,56 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEC2RILs(t *testing.T) {
+	cases := []struct {
+		desc       string
+		all        bool
+		wantFilter bool
+	}{
+		{desc: "active only", all: false, wantFilter: true},
+		{desc: "all", all: true, wantFilter: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := &fakeEC2{
+				reservedInstances: []*ec2.ReservedInstances{
+					{
+						ReservedInstancesId: aws.String("ri-0001"),
+						InstanceType:        aws.String("m5.large"),
+						InstanceCount:       aws.Int64(2),
+						State:               aws.String("active"),
+						Scope:               aws.String("Region"),
+						Start:               aws.Time(testTime),
+						End:                 aws.Time(testTime.AddDate(1, 0, 0)),
+						Duration:            aws.Int64(31536000),
+					},
+				},
+			}
+			client, stdout := newTestClient(t, Services{EC2: f})
+
+			err := client.EC2RILs(EC2RILsOptions{
+				All:    tc.all,
+				Fields: []string{"ReservedInstancesId", "AvailabilityZone", "InstanceCount", "Start", "End", "Duration"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			filter := f.describeReservedInstancesInput.Filters[0]
+			if (filter != nil) != tc.wantFilter {
+				t.Errorf("state filter = %v, want filter: %t", filter, tc.wantFilter)
+			}
+			want := "ri-0001\tN/A\t  2\t2021-05-01\t2022-05-01\t 1year\n"
+			if got := stdout.String(); got != want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, want)
+			}
+		})
+	}
+}
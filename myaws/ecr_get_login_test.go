@@ -0,0 +1,39 @@
+package myaws
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+func TestECRGetLogin(t *testing.T) {
+	f := &fakeECR{
+		authorizationData: []*ecr.AuthorizationData{
+			{
+				AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("AWS:secret"))),
+				ProxyEndpoint:      aws.String("https://123456789012.dkr.ecr.ap-northeast-1.amazonaws.com"),
+			},
+			{
+				AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("AWS:another"))),
+				ProxyEndpoint:      aws.String("https://210987654321.dkr.ecr.ap-northeast-1.amazonaws.com"),
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ECR: f})
+
+	err := client.ECRGetLogin(ECRGetLoginOptions{RegistryIds: aws.StringSlice([]string{"123456789012", "210987654321"})})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := len(f.input.RegistryIds); got != 2 {
+		t.Errorf("len(RegistryIds) = %d, want 2", got)
+	}
+	want := "docker login -u AWS -p secret https://123456789012.dkr.ecr.ap-northeast-1.amazonaws.com\n" +
+		"docker login -u AWS -p another https://210987654321.dkr.ecr.ap-northeast-1.amazonaws.com\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
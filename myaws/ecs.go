@@ -3,6 +3,7 @@ package myaws
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/pkg/errors"
 	funk "github.com/thoas/go-funk"
@@ -10,53 +11,108 @@ import (
 
 // findECSNodes finds ECS container instances
 func (client *Client) findECSNodes(cluster string) ([]*ecs.ContainerInstance, error) {
-	arns, err := client.ECS.ListContainerInstances(
-		&ecs.ListContainerInstancesInput{
-			Cluster: &cluster,
-		},
-	)
+	return client.findECSNodesWithContext(aws.BackgroundContext(), cluster, 0)
+}
+
+// findECSNodesWithContext finds ECS container instances.
+// It fetches all pages until maxItems instances are found.
+// If maxItems is 0, it is unlimited.
+func (client *Client) findECSNodesWithContext(ctx aws.Context, cluster string, maxItems int) ([]*ecs.ContainerInstance, error) {
+	arns, err := client.listECSContainerInstanceArns(ctx, cluster, maxItems)
 	if err != nil {
-		return nil, errors.Wrapf(err, "ListContainerInstances failed")
+		return nil, err
 	}
 
-	if len(arns.ContainerInstanceArns) == 0 {
+	if len(arns) == 0 {
 		return nil, errors.New("container instances not found")
 	}
 
-	instances, err := client.ECS.DescribeContainerInstances(
-		&ecs.DescribeContainerInstancesInput{
-			Cluster:            &cluster,
-			ContainerInstances: arns.ContainerInstanceArns,
+	instances, err := client.describeECSContainerInstances(ctx, cluster, arns)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(instances) == 0 {
+		return nil, errors.New("ListContainerInstances succeed, but DescribeContainerInstances returns no instances")
+	}
+
+	return instances, nil
+}
+
+// listECSContainerInstanceArns returns ARNs of container instances in the
+// cluster. If maxItems is 0, it is unlimited.
+func (client *Client) listECSContainerInstanceArns(ctx aws.Context, cluster string, maxItems int) ([]*string, error) {
+	arns := []*string{}
+
+	err := client.ECS.ListContainerInstancesPagesWithContext(ctx,
+		&ecs.ListContainerInstancesInput{
+			Cluster: &cluster,
+		},
+		func(p *ecs.ListContainerInstancesOutput, lastPage bool) bool {
+			arns = append(arns, p.ContainerInstanceArns...)
+			return !reachedMaxItems(len(arns), maxItems)
 		},
 	)
 	if err != nil {
-		return nil, errors.Wrapf(err, "DescribeContainerInstances failed")
+		return nil, errors.Wrapf(err, "ListContainerInstances failed")
 	}
 
-	if len(instances.ContainerInstances) == 0 {
-		return nil, errors.New("ListContainerInstances succeed, but DescribeContainerInstances returns no instances")
+	if reachedMaxItems(len(arns), maxItems) {
+		arns = arns[:maxItems]
 	}
+	return arns, nil
+}
 
-	return instances.ContainerInstances, nil
+// describeECSContainerInstances describes container instances.
+func (client *Client) describeECSContainerInstances(ctx aws.Context, cluster string, arns []*string) ([]*ecs.ContainerInstance, error) {
+	// We can specify up to 100 container instances to describe in a single
+	// operation. So we need to divide the list by 100.
+	chunks := (funk.Chunk(arns, 100)).([][]*string)
+	instances := []*ecs.ContainerInstance{}
+	for _, c := range chunks {
+		output, err := client.ECS.DescribeContainerInstancesWithContext(ctx,
+			&ecs.DescribeContainerInstancesInput{
+				Cluster:            &cluster,
+				ContainerInstances: c,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "DescribeContainerInstances failed")
+		}
+		instances = append(instances, output.ContainerInstances...)
+	}
+
+	return instances, nil
 }
 
 // findECSService find ECS services.
 func (client *Client) findECSServices(cluster string) ([]*ecs.Service, error) {
+	return client.findECSServicesWithContext(aws.BackgroundContext(), cluster, 0)
+}
+
+// findECSServicesWithContext find ECS services.
+// It fetches all pages until maxItems services are found.
+// If maxItems is 0, it is unlimited.
+func (client *Client) findECSServicesWithContext(ctx aws.Context, cluster string, maxItems int) ([]*ecs.Service, error) {
 	serviceArns := []*string{}
 
-	err := client.ECS.ListServicesPages(
+	err := client.ECS.ListServicesPagesWithContext(ctx,
 		&ecs.ListServicesInput{
 			Cluster: &cluster,
 		},
 		func(p *ecs.ListServicesOutput, lastPage bool) bool {
 			serviceArns = append(serviceArns, p.ServiceArns...)
-			return true
+			return !reachedMaxItems(len(serviceArns), maxItems)
 		},
 	)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ListServices failed")
 	}
 
+	if reachedMaxItems(len(serviceArns), maxItems) {
+		serviceArns = serviceArns[:maxItems]
+	}
+
 	if len(serviceArns) == 0 {
 		return nil, errors.New("services not found")
 	}
@@ -66,7 +122,7 @@ func (client *Client) findECSServices(cluster string) ([]*ecs.Service, error) {
 	chunks := (funk.Chunk(serviceArns, 10)).([][]*string)
 	services := []*ecs.Service{}
 	for _, c := range chunks {
-		ss, err := client.ECS.DescribeServices(
+		ss, err := client.ECS.DescribeServicesWithContext(ctx,
 			&ecs.DescribeServicesInput{
 				Cluster:  &cluster,
 				Services: c,
@@ -106,3 +162,22 @@ func (client *Client) printECSStatus(cluster string) error {
 
 	return nil
 }
+
+// pickECSService chooses a service of the cluster in a fuzzy finder.
+func (client *Client) pickECSService(cluster string) (string, error) {
+	services, err := client.findECSServicesWithContext(aws.BackgroundContext(), cluster, 0)
+	if err != nil {
+		return "", err
+	}
+
+	resources := []interface{}{}
+	for _, service := range services {
+		resources = append(resources, service)
+	}
+
+	chosen, err := client.pickResources(ecsServiceFields, ecsServiceLsFields, resources, false)
+	if err != nil {
+		return "", err
+	}
+	return *chosen[0].(*ecs.Service).ServiceName, nil
+}
@@ -24,23 +24,45 @@ type ECSNodeDrainOptions struct {
 // method is general purpose, so we implement a wait option to specialized
 // method for draining.
 func (client *Client) ECSNodeDrain(options ECSNodeDrainOptions) error {
+	if err := client.guardECSCluster(options.Cluster); err != nil {
+		return err
+	}
+
+	// Draining many container instances at once may stop a service, so we ask
+	// the user to type the cluster name.
+	message := fmt.Sprintf("Are you sure want to drain container instances %s of cluster %s?", formatPlanValues(options.ContainerInstances), options.Cluster)
+	var ok bool
+	var err error
+	if len(options.ContainerInstances) >= highImpactThreshold {
+		ok, err = client.confirmName(message, options.Cluster)
+	} else {
+		ok, err = client.confirm(message)
+	}
+	if !ok {
+		return err
+	}
+
 	return client.ecsNodeDrainWithContext(context.Background(), options)
 }
 
 func (client *Client) ecsNodeDrainWithContext(ctx context.Context, options ECSNodeDrainOptions) error {
+	if client.dryRun {
+		return client.planECSNodeStatus(options.Cluster, options.ContainerInstances, "DRAINING")
+	}
+
 	// We can specify up to 10 container instances to update state in a single operation.
 	// This constraint is not specified in the API reference, but it returns the following error:
 	//   InvalidParameterException: instanceIds can have at most 10 items.
 	// So we need to divide the list by 10.
 	chunks := (funk.Chunk(options.ContainerInstances, 10)).([][]*string)
 	for _, c := range chunks {
-		_, err := client.ECS.UpdateContainerInstancesState(
-			&ecs.UpdateContainerInstancesStateInput{
-				Cluster:            &options.Cluster,
-				ContainerInstances: c,
-				Status:             aws.String("DRAINING"),
-			},
-		)
+		params := &ecs.UpdateContainerInstancesStateInput{
+			Cluster:            &options.Cluster,
+			ContainerInstances: c,
+			Status:             aws.String("DRAINING"),
+		}
+		_, err := client.ECS.UpdateContainerInstancesState(params)
+		client.auditCall("ECS.UpdateContainerInstancesState", params, auditResources(options.Cluster, c), err)
 		if err != nil {
 			return errors.Wrapf(err, "UpdateContainerInstancesState failed")
 		}
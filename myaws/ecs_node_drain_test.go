@@ -0,0 +1,84 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestECSNodeDrain(t *testing.T) {
+	// 12 nodes need 2 requests for UpdateContainerInstancesState.
+	c := newFakeCluster(12)
+	c.tasksPerNode = 3
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	arns := []*string{}
+	for _, ci := range c.containerInstances {
+		arns = append(arns, ci.ContainerInstanceArn)
+	}
+
+	err := client.ECSNodeDrain(ECSNodeDrainOptions{
+		Cluster:            c.cluster,
+		ContainerInstances: arns,
+		Wait:               true,
+		Timeout:            time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := len(c.updateStateCalls); got != 2 {
+		t.Errorf("len(UpdateContainerInstancesState calls) = %d, want 2", got)
+	}
+	for _, ci := range c.containerInstances {
+		if *ci.Status != "DRAINING" || *ci.RunningTasksCount != 0 {
+			t.Errorf("container instance %s is %s with %d tasks", *ci.ContainerInstanceArn, *ci.Status, *ci.RunningTasksCount)
+		}
+	}
+	if got := stdout.String(); !strings.Contains(got, "Wait until container instances are drained...") {
+		t.Errorf("unexpected stdout: %s", got)
+	}
+}
+
+func TestECSNodeDrainNoWait(t *testing.T) {
+	c := newFakeCluster(2)
+	client, _ := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	err := client.ECSNodeDrain(ECSNodeDrainOptions{
+		Cluster:            c.cluster,
+		ContainerInstances: []*string{c.containerInstances[0].ContainerInstanceArn},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := *c.containerInstances[0].Status; got != "DRAINING" {
+		t.Errorf("status = %s, want DRAINING", got)
+	}
+	if got := *c.containerInstances[0].RunningTasksCount; got != c.tasksPerNode {
+		t.Errorf("running tasks = %d, want %d", got, c.tasksPerNode)
+	}
+	if got := *c.containerInstances[1].Status; got != "ACTIVE" {
+		t.Errorf("status = %s, want ACTIVE", got)
+	}
+}
+
+func TestECSNodeDrainTimeout(t *testing.T) {
+	c := newFakeCluster(1)
+	client, _ := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+	// A task never stops.
+	c.tasksPerNode = 1 << 30
+	c.containerInstances[0].RunningTasksCount = aws.Int64(c.tasksPerNode)
+
+	err := client.ECSNodeDrain(ECSNodeDrainOptions{
+		Cluster:            c.cluster,
+		ContainerInstances: []*string{c.containerInstances[0].ContainerInstanceArn},
+		Wait:               true,
+		Timeout:            10 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected a timeout error, but got nil")
+	}
+}
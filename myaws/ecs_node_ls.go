@@ -4,52 +4,73 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/pkg/errors"
 )
 
 // ECSNodeLsOptions customize the behavior of the Ls command.
 type ECSNodeLsOptions struct {
 	Cluster     string
 	PrintHeader bool
+	MaxItems    int
+	Targets     TargetOptions
 }
 
 // ECSNodeLs describes ECS container instances.
 func (client *Client) ECSNodeLs(options ECSNodeLsOptions) error {
-	instances, err := client.findECSNodes(options.Cluster)
-	if err != nil {
-		return err
+	if options.MaxItems < 0 {
+		return errors.Errorf("--max-items must be a positive number: %d", options.MaxItems)
 	}
 
-	if options.PrintHeader {
-		header := fmt.Sprintf("%-32s\t%-19s\t%-10s\t%s\t%s\t%s",
-			"ContainerInstanceId",
-			"Ec2InstanceId",
-			"Status",
-			"Running",
-			"Pending",
-			"RegisteredAt",
-		)
-		fmt.Fprintln(client.stdout, header)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		instances, err := c.findECSNodesWithContext(aws.BackgroundContext(), options.Cluster, options.MaxItems)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		for _, instance := range instances {
+			resources = append(resources, instance)
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	for _, instance := range instances {
-		fmt.Fprintln(client.stdout, formatECSNode(client, instance))
+	fields := options.Targets.outputFields([]string{
+		"ContainerInstanceId",
+		"Ec2InstanceId",
+		"Status",
+		"Running",
+		"Pending",
+		"RegisteredAt",
+	})
+
+	rows := [][]string{}
+	for _, resource := range resources {
+		instance, target := client.unwrapTargetResource(resource)
+		rows = append(rows, options.Targets.formatRow(target, formatECSNode(client, instance.(*ecs.ContainerInstance))))
 	}
 
-	return nil
+	if err := client.printRows(fields, rows, options.PrintHeader); err != nil {
+		return err
+	}
+	return findErr
 }
 
-func formatECSNode(client *Client, instance *ecs.ContainerInstance) string {
+func formatECSNode(client *Client, instance *ecs.ContainerInstance) []string {
 	arn := strings.Split(*instance.ContainerInstanceArn, "/")
 	// To fix misalignment, we use the width of state is 10 characters here,
 	// because 8 characters + 2 characters as future margin of change.
 	// The valid values of status are ACTIVE, INACTIVE, or DRAINING.
-	return fmt.Sprintf("%s\t%s\t%-10s\t%d\t%d\t%s",
+	return []string{
 		arn[2],
 		*instance.Ec2InstanceId,
-		*instance.Status,
-		*instance.RunningTasksCount,
-		*instance.PendingTasksCount,
+		fmt.Sprintf("%-10s", *instance.Status),
+		fmt.Sprintf("%d", *instance.RunningTasksCount),
+		fmt.Sprintf("%d", *instance.PendingTasksCount),
 		client.FormatTime(instance.RegisteredAt),
-	)
+	}
 }
@@ -0,0 +1,56 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestECSNodeLs(t *testing.T) {
+	c := newFakeCluster(3)
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	if err := client.ECSNodeLs(ECSNodeLsOptions{Cluster: c.cluster, PrintHeader: true, MaxItems: 2}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "ContainerInstanceId\tEc2InstanceId\tStatus\tRunning\tPending\tRegisteredAt\n" +
+		"ci-0001\ti-0001\tACTIVE    \t2\t0\t2021-05-01 12:00:00\n" +
+		"ci-0002\ti-0002\tACTIVE    \t2\t0\t2021-05-01 12:00:00\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestECSNodeLsErrors(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options ECSNodeLsOptions
+		want    string
+	}{
+		{
+			desc:    "no container instances",
+			options: ECSNodeLsOptions{Cluster: "test-cluster"},
+			want:    "container instances not found",
+		},
+		{
+			desc:    "cluster not found",
+			options: ECSNodeLsOptions{Cluster: "foo"},
+			want:    "ClusterNotFoundException",
+		},
+		{
+			desc:    "negative max items",
+			options: ECSNodeLsOptions{Cluster: "test-cluster", MaxItems: -1},
+			want:    "--max-items must be a positive number",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, _ := newTestClient(t, Services{ECS: &fakeECS{c: newFakeCluster(0)}})
+			err := client.ECSNodeLs(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -3,27 +3,81 @@ package myaws
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/ecs"
-
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/pkg/errors"
 )
 
+const (
+	// ECSNodeRenewStrategyBlueGreen doubles the desired capacity, drains all
+	// the old instances at once and discards them.
+	ECSNodeRenewStrategyBlueGreen = "blue-green"
+	// ECSNodeRenewStrategyRolling replaces the old instances in batches.
+	ECSNodeRenewStrategyRolling = "rolling"
+)
+
+// ECSNodeRenewStrategies is a list of valid strategies.
+var ECSNodeRenewStrategies = []string{
+	ECSNodeRenewStrategyBlueGreen,
+	ECSNodeRenewStrategyRolling,
+}
+
 // ECSNodeRenewOptions customize the behavior of the Renew command.
 type ECSNodeRenewOptions struct {
 	Cluster string
 	AsgName string
 	Timeout time.Duration
+	// Strategy is blue-green or rolling. Defaults to blue-green.
+	Strategy string
+	// BatchSize is the number of old instances replaced at a time in the
+	// rolling strategy.
+	BatchSize int64
+	// MaxSurge is the maximum number of instances launched above the desired
+	// capacity in the rolling strategy. If it's less than BatchSize, the
+	// capacity temporarily decreases in each batch.
+	MaxSurge int64
+	// Resume continues an interrupted renew from the phase recorded in the
+	// state file.
+	Resume bool
+	// Rollback restores the original capacity of an interrupted renew and
+	// terminates the new instances.
+	Rollback bool
 }
 
-// ECSNodeRenew renew ECS container instances with blue-green deployment.
+// ECSNodeRenew renew ECS container instances with blue-green deployment or
+// rolling update.
 // This method is an automation process to renew your ECS container instances
 // if you update the AMI. creates new instances, drains the old instances,
 // and discards the old instances.
 func (client *Client) ECSNodeRenew(options ECSNodeRenewOptions) error {
+	if err := validateECSNodeRenewOptions(options); err != nil {
+		return err
+	}
+	if err := client.guardECSCluster(options.Cluster); err != nil {
+		return err
+	}
+	if err := client.guardECSClusterServices(options.Cluster); err != nil {
+		return err
+	}
+	if err := client.guardAutoScalingGroup(options.AsgName); err != nil {
+		return err
+	}
+
+	// Renew replaces all the container instances of the cluster, so we ask the
+	// user to type the cluster name.
+	action := "renew all container instances"
+	if options.Rollback {
+		action = "roll back the renew of container instances"
+	}
+	message := fmt.Sprintf("Are you sure want to %s of cluster %s in autoscaling group %s?", action, options.Cluster, options.AsgName)
+	if ok, err := client.confirmName(message, options.Cluster); !ok {
+		return err
+	}
+
 	// We should use a context everywhere in all AWS API calls,
 	// but for the moment only some are supported.
 	// So this is a total timeout, and indivisual wait operations can timeout in
@@ -49,74 +103,56 @@ func (client *Client) ECSNodeRenew(options ECSNodeRenewOptions) error {
 }
 
 func (client *Client) ecsNodeRenewWithContext(ctx context.Context, options ECSNodeRenewOptions) error {
-	fmt.Fprintf(client.stdout, "start: ecs node renew\noptions: %s\n", awsutil.Prettify(options))
-
-	if err := client.printECSStatus(options.Cluster); err != nil {
-		return err
-	}
-
-	// get the current desired capacity
-	desiredCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+	path, err := ecsNodeRenewStatePath(aws.StringValue(client.config.Region), options.Cluster, options.AsgName)
 	if err != nil {
 		return err
 	}
-
-	// list the current container instances
-	oldNodes, err := client.findECSNodes(options.Cluster)
+	state, err := readECSNodeRenewState(path)
 	if err != nil {
 		return err
 	}
 
-	if len(oldNodes) != int(desiredCapacity) {
-		return errors.Errorf("assertion failed: currentCapacity(%d) != desiredCapacity(%d)", len(oldNodes), desiredCapacity)
+	if state != nil {
+		state.dryRun = client.dryRun
 	}
 
-	// Update the desired capacity and wait until new instances are InService
-	// We simply double the number of instances here.
-	// If you need more flexible control, please implement a strategy such as
-	// rolling update.
-	targetCapacity := desiredCapacity * 2
-
-	fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, desiredCapacity, targetCapacity)
-
-	err = client.AutoscalingUpdate(AutoscalingUpdateOptions{
-		AsgName:         options.AsgName,
-		DesiredCapacity: targetCapacity,
-		Wait:            true,
-	})
-	if err != nil {
-		return err
+	if options.Rollback {
+		if state == nil {
+			return errors.Errorf("no renew to roll back for %s/%s: %s", options.Cluster, options.AsgName, path)
+		}
+		return client.ecsNodeRenewRollbackWithContext(ctx, options, state)
 	}
 
-	if err = client.printECSStatus(options.Cluster); err != nil {
-		return err
+	if options.Resume && state == nil {
+		return errors.Errorf("no renew to resume for %s/%s: %s", options.Cluster, options.AsgName, path)
+	}
+	if !options.Resume && state != nil {
+		return errors.Errorf("a renew for %s/%s was interrupted at phase %s. Use --resume or --rollback: %s", options.Cluster, options.AsgName, state.Phase, path)
 	}
 
-	// A status of instance in autoscaling group is InService doesn't mean the
-	// container instance is registered. We should make sure container instances
-	// are registered
-	fmt.Fprintln(client.stdout, "Wait until ECS container instances are registered...")
-	err = client.WaitUntilECSContainerInstancesAreRegistered(options.Cluster, targetCapacity)
-	if err != nil {
+	fmt.Fprintf(client.stdout, "start: ecs node renew\noptions: %s\n", awsutil.Prettify(options))
+
+	if err := client.printECSStatus(options.Cluster); err != nil {
 		return err
 	}
 
-	if err = client.printECSStatus(options.Cluster); err != nil {
-		return err
+	if options.Resume {
+		fmt.Fprintf(client.stdout, "Resume from phase %s (batch: %d) recorded in %s\n", state.Phase, state.Batch, path)
+	} else {
+		state, err = client.newECSNodeRenewState(options, path)
+		if err != nil {
+			return err
+		}
+		if !client.dryRun {
+			fmt.Fprintf(client.stdout, "Record state to %s\n", path)
+		}
 	}
 
-	// drain old container instances and wait until no task running
-	oldNodeArns := []*string{}
-	for _, oldNode := range oldNodes {
-		oldNodeArns = append(oldNodeArns, oldNode.ContainerInstanceArn)
+	if state.Strategy == ECSNodeRenewStrategyRolling {
+		err = client.ecsNodeRenewRollingWithContext(ctx, options, state)
+	} else {
+		err = client.ecsNodeRenewBlueGreenWithContext(ctx, options, state)
 	}
-	fmt.Fprintf(client.stdout, "Drain old container instances and wait until no task running...\n%v\n", awsutil.Prettify(oldNodeArns))
-	err = client.ecsNodeDrainWithContext(ctx, ECSNodeDrainOptions{
-		Cluster:            options.Cluster,
-		ContainerInstances: oldNodeArns,
-		Wait:               true,
-		Timeout:            options.Timeout,
-	})
 	if err != nil {
 		return err
 	}
@@ -125,95 +161,244 @@ func (client *Client) ecsNodeRenewWithContext(ctx context.Context, options ECSNo
 		return err
 	}
 
-	// All old container instances are drained doesn't mean all services are stable.
-	// It depends on the deployment strategy of each service.
-	// We should make sure all services are stable
-	fmt.Fprintln(client.stdout, "Wait until all ECS services stable...")
-	err = client.WaitUntilECSAllServicesStableWithContext(ctx, options.Cluster)
-	if err != nil {
+	if err = state.remove(); err != nil {
 		return err
 	}
 
-	if err = client.printECSStatus(options.Cluster); err != nil {
-		return err
-	}
+	fmt.Fprintln(client.stdout, "end: ecs node renew")
+	return nil
+}
 
-	// A stable state for all services does not mean that all targets are healthy.
-	// We need to explicitly confirm it.
-	fmt.Fprintln(client.stdout, "Wait until all targets healthy...")
-	err = client.WaitUntilECSAllTargetsInService(options.Cluster)
+// newECSNodeRenewState records the current capacity and instances, and
+// saves them to the state file.
+func (client *Client) newECSNodeRenewState(options ECSNodeRenewOptions, path string) (*ecsNodeRenewState, error) {
+	// get the current desired capacity
+	desiredCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err = client.printECSStatus(options.Cluster); err != nil {
-		return err
+	// list the current container instances
+	oldNodes, err := client.findECSNodes(options.Cluster)
+	if err != nil {
+		return nil, err
 	}
 
-	// Select instances to protect from scale in.
-	// By setting "scale-in protection" to instances created at scale-out,
-	// the intended instances (instances created before scale-in) are only terminated at scale-in process.
-	protectInstanceIds, err := client.selectInstanceToProtectFromScaleIn(oldNodes, options.Cluster)
-	if err != nil {
-		return err
+	if len(oldNodes) != int(desiredCapacity) {
+		return nil, errors.Errorf("assertion failed: currentCapacity(%d) != desiredCapacity(%d)", len(oldNodes), desiredCapacity)
 	}
 
-	// Enable scale in protection for specific instances before scaling in.
-	// In the default termination policy of auto scaling, instances close to the next billing time will terminate when the launch configuration is the same.
-	// By running this function, your auto scaling group scales out, then scales in.
-	// During scale in, instances created during scale out may be subject to termination.
-	// To prevent this, set scale in protection for instances created at scale out.
-	// https://docs.aws.amazon.com/autoscaling/ec2/userguide/as-instance-termination.html
-	fmt.Fprintln(client.stdout, "Setting scale in protection: ", awsutil.Prettify(protectInstanceIds))
-	// set "scale in protection" to instances created at scale-out.
-	err = client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
-		options.AsgName,
-		protectInstanceIds,
-		true})
-	if err != nil {
-		return err
+	strategy := options.Strategy
+	if strategy == "" {
+		strategy = ECSNodeRenewStrategyBlueGreen
 	}
 
-	// restore the desired capacity and wait until old instances are discarded
-	fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, targetCapacity, desiredCapacity)
+	now := time.Now().UTC()
+	state := &ecsNodeRenewState{
+		Cluster:                  options.Cluster,
+		AsgName:                  options.AsgName,
+		Strategy:                 strategy,
+		DesiredCapacity:          desiredCapacity,
+		OldInstanceIds:           []string{},
+		OldContainerInstanceArns: []string{},
+		NewInstanceIds:           []string{},
+		ProtectedInstanceIds:     []string{},
+		Phase:                    ecsNodeRenewPhaseStarted,
+		StartedAt:                now,
+		path:                     path,
+		dryRun:                   client.dryRun,
+	}
+	if strategy == ECSNodeRenewStrategyRolling {
+		state.BatchSize = options.BatchSize
+		state.MaxSurge = options.MaxSurge
+		state.Batch = 1
+	}
+	for _, node := range oldNodes {
+		state.OldInstanceIds = append(state.OldInstanceIds, *node.Ec2InstanceId)
+		state.OldContainerInstanceArns = append(state.OldContainerInstanceArns, *node.ContainerInstanceArn)
+	}
 
-	err = client.AutoscalingUpdate(AutoscalingUpdateOptions{
-		AsgName:         options.AsgName,
-		DesiredCapacity: desiredCapacity,
-		Wait:            true,
-	})
-	if err != nil {
-		return err
+	if err := state.save(); err != nil {
+		return nil, err
 	}
+	return state, nil
+}
 
-	// remove "scale in protection" to instances created at scale-out.
-	fmt.Fprintln(client.stdout, "Removing scale in protection: ", awsutil.Prettify(protectInstanceIds))
-	err = client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
-		options.AsgName,
-		protectInstanceIds,
-		false})
-	if err != nil {
-		return err
+// validateECSNodeRenewOptions returns an error if the options are invalid.
+func validateECSNodeRenewOptions(options ECSNodeRenewOptions) error {
+	if options.Resume && options.Rollback {
+		return errors.New("--resume and --rollback are mutually exclusive")
 	}
 
-	if err = client.printECSStatus(options.Cluster); err != nil {
-		return err
+	switch options.Strategy {
+	case "", ECSNodeRenewStrategyBlueGreen:
+		return nil
+	case ECSNodeRenewStrategyRolling:
+		if options.BatchSize < 1 {
+			return errors.Errorf("--batch-size must be a positive number: %d", options.BatchSize)
+		}
+		if options.MaxSurge < 0 {
+			return errors.Errorf("--max-surge must not be negative: %d", options.MaxSurge)
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown strategy: %s (valid: %s)", options.Strategy, strings.Join(ECSNodeRenewStrategies, ", "))
 	}
+}
 
-	fmt.Fprintln(client.stdout, "end: ecs node renew")
-	return nil
+// ecsNodeRenewBlueGreenWithContext doubles the desired capacity, drains all
+// the old instances and restores the desired capacity.
+func (client *Client) ecsNodeRenewBlueGreenWithContext(ctx context.Context, options ECSNodeRenewOptions, state *ecsNodeRenewState) error {
+	desiredCapacity := state.DesiredCapacity
+	// Update the desired capacity and wait until new instances are InService
+	// We simply double the number of instances here.
+	targetCapacity := desiredCapacity * 2
+
+	return state.runSteps(client, []ecsNodeRenewStep{
+		{phase: ecsNodeRenewPhaseScaledOut, run: func() error {
+			fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, desiredCapacity, targetCapacity)
+
+			err := client.autoscalingUpdate(AutoscalingUpdateOptions{
+				AsgName:         options.AsgName,
+				DesiredCapacity: targetCapacity,
+				Wait:            true,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err = client.printECSStatus(options.Cluster); err != nil {
+				return err
+			}
+
+			// A status of instance in autoscaling group is InService doesn't mean the
+			// container instance is registered. We should make sure container instances
+			// are registered
+			fmt.Fprintln(client.stdout, "Wait until ECS container instances are registered...")
+			err = client.WaitUntilECSContainerInstancesAreRegistered(options.Cluster, targetCapacity)
+			if err != nil {
+				return err
+			}
+
+			instances, err := client.getAutoScalingGroupInstances(options.AsgName)
+			if err != nil {
+				return err
+			}
+			instanceIds := []*string{}
+			for _, instance := range instances {
+				instanceIds = append(instanceIds, instance.InstanceId)
+			}
+			state.addNewInstanceIds(instanceIds)
+
+			return client.printECSStatus(options.Cluster)
+		}, plan: func() error {
+			client.printPlan("AutoScaling.SetDesiredCapacity", "%s DesiredCapacity %d => %d", options.AsgName, desiredCapacity, targetCapacity)
+			return nil
+		}},
+		{phase: ecsNodeRenewPhaseDrained, run: func() error {
+			// drain old container instances and wait until no task running
+			oldNodeArns := state.oldContainerInstanceArns()
+			fmt.Fprintf(client.stdout, "Drain old container instances and wait until no task running...\n%v\n", awsutil.Prettify(oldNodeArns))
+			err := client.ecsNodeDrainWithContext(ctx, ECSNodeDrainOptions{
+				Cluster:            options.Cluster,
+				ContainerInstances: oldNodeArns,
+				Wait:               true,
+				Timeout:            options.Timeout,
+			})
+			if err != nil {
+				return err
+			}
+
+			return client.printECSStatus(options.Cluster)
+		}, plan: func() error {
+			return client.planECSNodeStatus(options.Cluster, state.oldContainerInstanceArns(), "DRAINING")
+		}},
+		{phase: ecsNodeRenewPhaseStable, run: func() error {
+			// All old container instances are drained doesn't mean all services are stable.
+			// It depends on the deployment strategy of each service.
+			// We should make sure all services are stable
+			fmt.Fprintln(client.stdout, "Wait until all ECS services stable...")
+			err := client.WaitUntilECSAllServicesStableWithContext(ctx, options.Cluster)
+			if err != nil {
+				return err
+			}
+
+			if err = client.printECSStatus(options.Cluster); err != nil {
+				return err
+			}
+
+			// A stable state for all services does not mean that all targets are healthy.
+			// We need to explicitly confirm it.
+			fmt.Fprintln(client.stdout, "Wait until all targets healthy...")
+			err = client.WaitUntilECSAllTargetsInService(options.Cluster)
+			if err != nil {
+				return err
+			}
+
+			return client.printECSStatus(options.Cluster)
+		}},
+		{phase: ecsNodeRenewPhaseProtected, run: func() error {
+			// Select instances to protect from scale in.
+			// By setting "scale-in protection" to instances created at scale-out,
+			// the intended instances (instances created before scale-in) are only terminated at scale-in process.
+			protectInstanceIds, err := client.selectInstanceToProtectFromScaleIn(state.oldInstanceIds(), options.Cluster)
+			if err != nil {
+				return err
+			}
+
+			// Enable scale in protection for specific instances before scaling in.
+			// In the default termination policy of auto scaling, instances close to the next billing time will terminate when the launch configuration is the same.
+			// By running this function, your auto scaling group scales out, then scales in.
+			// During scale in, instances created during scale out may be subject to termination.
+			// To prevent this, set scale in protection for instances created at scale out.
+			// https://docs.aws.amazon.com/autoscaling/ec2/userguide/as-instance-termination.html
+			fmt.Fprintln(client.stdout, "Setting scale in protection: ", awsutil.Prettify(protectInstanceIds))
+			// Record them before setting, so that we can clear them on rollback.
+			state.ProtectedInstanceIds = aws.StringValueSlice(protectInstanceIds)
+			if err = state.save(); err != nil {
+				return err
+			}
+			// set "scale in protection" to instances created at scale-out.
+			return client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
+				options.AsgName,
+				protectInstanceIds,
+				true})
+		}, plan: func() error {
+			client.printPlan("AutoScaling.SetInstanceProtection", "%s %d new instances ProtectedFromScaleIn false => true", options.AsgName, desiredCapacity)
+			return nil
+		}},
+		{phase: ecsNodeRenewPhaseScaledIn, run: func() error {
+			// restore the desired capacity and wait until old instances are discarded
+			fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, targetCapacity, desiredCapacity)
+
+			return client.autoscalingUpdate(AutoscalingUpdateOptions{
+				AsgName:         options.AsgName,
+				DesiredCapacity: desiredCapacity,
+				Wait:            true,
+			})
+		}, plan: func() error {
+			client.printPlan("AutoScaling.SetDesiredCapacity", "%s DesiredCapacity %d => %d", options.AsgName, targetCapacity, desiredCapacity)
+			return nil
+		}},
+		{phase: ecsNodeRenewPhaseUnprotected, run: func() error {
+			// remove "scale in protection" to instances created at scale-out.
+			protectInstanceIds := aws.StringSlice(state.ProtectedInstanceIds)
+			fmt.Fprintln(client.stdout, "Removing scale in protection: ", awsutil.Prettify(protectInstanceIds))
+			return client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
+				options.AsgName,
+				protectInstanceIds,
+				false})
+		}, plan: func() error {
+			client.printPlan("AutoScaling.SetInstanceProtection", "%s %d new instances ProtectedFromScaleIn true => false", options.AsgName, desiredCapacity)
+			return nil
+		}},
+	})
 }
 
 // selectInstanceToProtectFromScaleIn selects instance to protect from Scale in.
 // instance select rule:
 //   instances after scale out - instances before scale out - instances which already set `InstanceProtection==true`
-func (client *Client) selectInstanceToProtectFromScaleIn(oldNodes []*ecs.ContainerInstance, cluster string) ([]*string, error) {
-	// Get a list of instance IDs before auto scaling
-	var oldInstanceIds []*string
-	for _, oldNode := range oldNodes {
-		oldInstanceIds = append(oldInstanceIds, oldNode.Ec2InstanceId)
-	}
-
+func (client *Client) selectInstanceToProtectFromScaleIn(oldInstanceIds []*string, cluster string) ([]*string, error) {
 	// Get a list of instances after auto scaling
 	allNodes, err := client.findECSNodes(cluster)
 	if err != nil {
@@ -0,0 +1,192 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/pkg/errors"
+	funk "github.com/thoas/go-funk"
+)
+
+// ecsNodeRenewRollbackWithContext rolls back an interrupted renew recorded in
+// the state. It clears scale in protection, reactivates the old container
+// instances, drains and terminates the new instances, and restores the
+// original desired capacity.
+// Old instances already terminated by the rolling strategy can't be
+// restored. In that case, it asks for confirmation and keeps as many new
+// instances as the terminated old ones to replace them, so that the capacity
+// is not cut in the middle of the rollback.
+func (client *Client) ecsNodeRenewRollbackWithContext(ctx context.Context, options ECSNodeRenewOptions, state *ecsNodeRenewState) error {
+	fmt.Fprintf(client.stdout, "start: ecs node renew rollback\nstate: %s\n", awsutil.Prettify(state))
+
+	if err := client.printECSStatus(options.Cluster); err != nil {
+		return err
+	}
+
+	instances, err := client.getAutoScalingGroupInstances(options.AsgName)
+	if err != nil {
+		return err
+	}
+
+	instanceIds := []*string{}
+	protectedInstanceIds := []*string{}
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, instance.InstanceId)
+		if aws.BoolValue(instance.ProtectedFromScaleIn) && containsStringValue(state.ProtectedInstanceIds, *instance.InstanceId) {
+			protectedInstanceIds = append(protectedInstanceIds, instance.InstanceId)
+		}
+	}
+
+	// Instances in the group which are not old are new ones, even if they are
+	// launched but not recorded before the interruption.
+	newInstanceIds := difference(instanceIds, state.oldInstanceIds())
+	if len(newInstanceIds) == len(instanceIds) && len(instanceIds) > 0 {
+		return errors.Errorf("all the old instances have already been discarded at phase %s. Use --resume instead", state.Phase)
+	}
+
+	discardedInstanceIds := difference(state.oldInstanceIds(), instanceIds)
+	if len(discardedInstanceIds) > 0 {
+		var keptInstanceIds []*string
+		newInstanceIds, keptInstanceIds = selectECSNodeRenewRollbackInstances(state, newInstanceIds, len(discardedInstanceIds))
+		fmt.Fprintf(client.stdout, "WARNING: %d old instances have already been terminated and can't be restored: %v\n"+
+			"%d new instances are kept to replace them: %v\n",
+			len(discardedInstanceIds), awsutil.Prettify(discardedInstanceIds), len(keptInstanceIds), awsutil.Prettify(keptInstanceIds))
+		if ok, err := client.confirm("Are you sure want to roll back with them?"); !ok {
+			return err
+		}
+	}
+
+	if len(protectedInstanceIds) > 0 {
+		fmt.Fprintln(client.stdout, "Removing scale in protection: ", awsutil.Prettify(protectedInstanceIds))
+		err = client.AutoScalingSetInstanceProtection(AutoScalingSetInstanceProtectionOptions{
+			options.AsgName,
+			protectedInstanceIds,
+			false})
+		if err != nil {
+			return err
+		}
+	}
+
+	nodes, err := client.findECSNodes(options.Cluster)
+	if err != nil {
+		return err
+	}
+
+	drainingOldNodeArns := []*string{}
+	newNodeArns := []*string{}
+	for _, node := range nodes {
+		switch {
+		case containsStringValue(state.OldContainerInstanceArns, *node.ContainerInstanceArn):
+			if *node.Status == "DRAINING" {
+				drainingOldNodeArns = append(drainingOldNodeArns, node.ContainerInstanceArn)
+			}
+		case containsStringValue(aws.StringValueSlice(newInstanceIds), *node.Ec2InstanceId):
+			newNodeArns = append(newNodeArns, node.ContainerInstanceArn)
+		}
+	}
+
+	// Reactivate the old container instances before draining the new ones, so
+	// that tasks can be placed on them.
+	if len(drainingOldNodeArns) > 0 {
+		fmt.Fprintf(client.stdout, "Reactivate old container instances...\n%v\n", awsutil.Prettify(drainingOldNodeArns))
+		// We can specify up to 10 container instances to update state in a single operation.
+		chunks := (funk.Chunk(drainingOldNodeArns, 10)).([][]*string)
+		for _, c := range chunks {
+			err = client.ecsNodeUpdate(ECSNodeUpdateOptions{
+				Cluster:            options.Cluster,
+				ContainerInstances: c,
+				Status:             "ACTIVE",
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(newNodeArns) > 0 {
+		fmt.Fprintf(client.stdout, "Drain new container instances and wait until no task running...\n%v\n", awsutil.Prettify(newNodeArns))
+		err = client.ecsNodeDrainWithContext(ctx, ECSNodeDrainOptions{
+			Cluster:            options.Cluster,
+			ContainerInstances: newNodeArns,
+			Wait:               true,
+			Timeout:            options.Timeout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(newInstanceIds) > 0 {
+		fmt.Fprintf(client.stdout, "Detach new instances from autoscaling group %s\n%v\n", options.AsgName, awsutil.Prettify(newInstanceIds))
+		if err = client.detachAutoScalingGroupInstances(options.AsgName, newInstanceIds); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(client.stdout, "Terminate new instances...\n%v\n", awsutil.Prettify(newInstanceIds))
+		if err = client.terminateEC2Instances(newInstanceIds); err != nil {
+			return err
+		}
+	}
+
+	currentCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, currentCapacity, state.DesiredCapacity)
+	err = client.autoscalingUpdate(AutoscalingUpdateOptions{
+		AsgName:         options.AsgName,
+		DesiredCapacity: state.DesiredCapacity,
+		Wait:            true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if client.dryRun {
+		fmt.Fprintln(client.stdout, "end: ecs node renew rollback")
+		return nil
+	}
+
+	fmt.Fprintln(client.stdout, "Wait until ECS container instances are registered...")
+	if err = client.WaitUntilECSContainerInstancesAreRegistered(options.Cluster, state.DesiredCapacity); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(client.stdout, "Wait until all ECS services stable...")
+	if err = client.WaitUntilECSAllServicesStableWithContext(ctx, options.Cluster); err != nil {
+		return err
+	}
+
+	if err = client.printECSStatus(options.Cluster); err != nil {
+		return err
+	}
+
+	if err = state.remove(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(client.stdout, "end: ecs node renew rollback")
+	return nil
+}
+
+// selectECSNodeRenewRollbackInstances splits new instances into ones to
+// terminate and ones to keep in place of discarded old instances. Instances
+// recorded earlier in the state are kept first, because they have been
+// running tasks longer than ones launched just before the interruption.
+func selectECSNodeRenewRollbackInstances(state *ecsNodeRenewState, newInstanceIds []*string, discarded int) ([]*string, []*string) {
+	ordered := []*string{}
+	for _, id := range state.NewInstanceIds {
+		if containsStringValue(aws.StringValueSlice(newInstanceIds), id) {
+			ordered = append(ordered, aws.String(id))
+		}
+	}
+	ordered = append(ordered, difference(newInstanceIds, ordered)...)
+
+	if discarded > len(ordered) {
+		discarded = len(ordered)
+	}
+	return ordered[discarded:], ordered[:discarded]
+}
@@ -0,0 +1,160 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestECSNodeRenewRollback(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(3, newFakeECSService("web", 2, ""))
+	options := ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute}
+
+	// The drain fails after the old container instances become DRAINING, and
+	// a new instance is protected from scale in by hand.
+	newInterruptedRenew(t, c, options, 1)
+	for _, ci := range c.containerInstances[:3] {
+		ci.Status = aws.String("DRAINING")
+	}
+	c.instances[3].ProtectedFromScaleIn = aws.Bool(true)
+	state, err := readECSNodeRenewState(ecsNodeRenewStateTestPath(t, c))
+	if err != nil {
+		t.Fatalf("failed to read state: %s", err)
+	}
+	state.ProtectedInstanceIds = []string{"i-0004"}
+	if err := state.save(); err != nil {
+		t.Fatalf("failed to save state: %s", err)
+	}
+
+	ec2 := &fakeEC2{}
+	services := newFakeRenewServices(c, &fakeELBV2{})
+	services.EC2 = ec2
+	client, stdout := newTestClient(t, services)
+
+	options.Rollback = true
+	if err := client.ECSNodeRenew(options); err != nil {
+		t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+	}
+
+	if got, want := ec2.terminateInstancesCalls, [][]string{{"i-0004", "i-0005", "i-0006"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TerminateInstances calls = %v, want %v", got, want)
+	}
+	if got, want := c.protectionCalls, [][]string{{"i-0004"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetInstanceProtection calls = %v, want %v", got, want)
+	}
+
+	current := []string{}
+	for _, instance := range c.instances {
+		current = append(current, *instance.InstanceId)
+	}
+	if want := []string{"i-0001", "i-0002", "i-0003"}; !reflect.DeepEqual(current, want) {
+		t.Errorf("instances = %v, want %v", current, want)
+	}
+	if c.desiredCapacity != 3 {
+		t.Errorf("desiredCapacity = %d, want 3", c.desiredCapacity)
+	}
+	for _, ci := range c.containerInstances {
+		if *ci.Status != "ACTIVE" {
+			t.Errorf("container instance %s is %s, want ACTIVE", *ci.ContainerInstanceArn, *ci.Status)
+		}
+	}
+
+	if state, err := readECSNodeRenewState(ecsNodeRenewStateTestPath(t, c)); err != nil || state != nil {
+		t.Errorf("state file is not removed: %v, %v", state, err)
+	}
+}
+
+func TestECSNodeRenewRollbackOldInstancesDiscarded(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(2, newFakeECSService("web", 1, ""))
+	options := ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute}
+
+	newInterruptedRenew(t, c, options, 1)
+	// The old instances have gone.
+	c.instances = c.instances[2:]
+	c.containerInstances = c.containerInstances[2:]
+
+	client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+	options.Rollback = true
+	err := client.ECSNodeRenew(options)
+	if err == nil || !strings.Contains(err.Error(), "Use --resume instead") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}
+
+func TestECSNodeRenewRollbackRolling(t *testing.T) {
+	cases := []struct {
+		desc string
+		yes  bool
+		// want are instances after the rollback.
+		want          []string
+		wantTerminate [][]string
+	}{
+		{
+			desc:          "confirmed",
+			yes:           true,
+			want:          []string{"i-0003", "i-0004", "i-0005", "i-0006"},
+			wantTerminate: [][]string{{"i-0007", "i-0008"}},
+		},
+		{
+			desc: "cancelled",
+			want: []string{"i-0003", "i-0004", "i-0005", "i-0006", "i-0007", "i-0008"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			setTestHome(t)
+			c := newFakeCluster(4, newFakeECSService("web", 2, ""))
+			options := ECSNodeRenewOptions{
+				Cluster:   c.cluster,
+				AsgName:   c.asgName,
+				Timeout:   time.Minute,
+				Strategy:  ECSNodeRenewStrategyRolling,
+				BatchSize: 2,
+				MaxSurge:  2,
+			}
+
+			// The first batch replaced i-0001 and i-0002 with i-0005 and i-0006,
+			// and the drain of the second batch fails after launching i-0007
+			// and i-0008.
+			newInterruptedRenew(t, c, options, 2)
+
+			ec2 := &fakeEC2{}
+			services := newFakeRenewServices(c, &fakeELBV2{})
+			services.EC2 = ec2
+			client, stdout := newTestClient(t, services)
+			client.guard.Yes = tc.yes
+			// Type the cluster name and then cancel on the warning. Each prompt
+			// reads a line with its own buffer, so feed input byte by byte.
+			client.stdin = iotest.OneByteReader(strings.NewReader(c.cluster + "\nn\n"))
+
+			options.Rollback = true
+			if err := client.ECSNodeRenew(options); err != nil {
+				t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+			}
+
+			if !strings.Contains(stdout.String(), "2 old instances have already been terminated and can't be restored") {
+				t.Errorf("no warning of discarded instances:\n%s", stdout)
+			}
+			if got := ec2.terminateInstancesCalls; !reflect.DeepEqual(got, tc.wantTerminate) {
+				t.Errorf("TerminateInstances calls = %v, want %v", got, tc.wantTerminate)
+			}
+			current := []string{}
+			for _, instance := range c.instances {
+				current = append(current, *instance.InstanceId)
+			}
+			if !reflect.DeepEqual(current, tc.want) {
+				t.Errorf("instances = %v, want %v", current, tc.want)
+			}
+			if tc.yes && c.desiredCapacity != 4 {
+				t.Errorf("desiredCapacity = %d, want 4", c.desiredCapacity)
+			}
+		})
+	}
+}
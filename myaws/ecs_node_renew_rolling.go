@@ -0,0 +1,231 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	funk "github.com/thoas/go-funk"
+)
+
+// ecsNodeRenewRollingWithContext replaces the old instances in batches.
+// For each batch, it launches up to MaxSurge new instances, drains BatchSize
+// old instances, detaches them from the autoscaling group and terminates
+// them. Unlike the blue-green strategy, it never scales in, so we don't need
+// scale in protection to choose instances to be terminated.
+// The batch size and the max surge recorded in the state are used, so that
+// resumed batches are the same as the interrupted ones.
+func (client *Client) ecsNodeRenewRollingWithContext(ctx context.Context, options ECSNodeRenewOptions, state *ecsNodeRenewState) error {
+	total := int64(len(state.OldInstanceIds))
+	for {
+		start := int64(state.Batch-1) * state.BatchSize
+		if start >= total {
+			break
+		}
+		end := start + state.BatchSize
+		if end > total {
+			end = total
+		}
+		n := end - start
+
+		surge := state.MaxSurge
+		if surge > n {
+			surge = n
+		}
+
+		fmt.Fprintf(client.stdout, "Batch %d: renew %d old container instances (%d remains)\n", state.Batch, n, total-end)
+
+		err := client.ecsNodeRenewRollingBatchWithContext(ctx, options, state, surge,
+			aws.StringSlice(state.OldInstanceIds[start:end]),
+			aws.StringSlice(state.OldContainerInstanceArns[start:end]),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "batch %d failed:", state.Batch)
+		}
+
+		// The next batch starts from the beginning.
+		state.Batch++
+		state.Phase = ecsNodeRenewPhaseStarted
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (client *Client) ecsNodeRenewRollingBatchWithContext(ctx context.Context, options ECSNodeRenewOptions, state *ecsNodeRenewState, surge int64, instanceIds []*string, nodeArns []*string) error {
+	desiredCapacity := state.DesiredCapacity
+	targetCapacity := desiredCapacity + surge
+	n := int64(len(instanceIds))
+
+	return state.runSteps(client, []ecsNodeRenewStep{
+		{phase: ecsNodeRenewPhaseScaledOut, run: func() error {
+			// Launch new instances before draining, so that the tasks on the old
+			// instances can be placed on them.
+			if surge == 0 {
+				return nil
+			}
+			return client.ecsNodeRenewScaleOut(options, state, desiredCapacity, targetCapacity)
+		}, plan: func() error {
+			if surge > 0 {
+				client.printPlan("AutoScaling.SetDesiredCapacity", "%s DesiredCapacity %d => %d", options.AsgName, desiredCapacity, targetCapacity)
+			}
+			return nil
+		}},
+		{phase: ecsNodeRenewPhaseDrained, run: func() error {
+			fmt.Fprintf(client.stdout, "Drain old container instances and wait until no task running...\n%v\n", awsutil.Prettify(nodeArns))
+			return client.ecsNodeDrainWithContext(ctx, ECSNodeDrainOptions{
+				Cluster:            options.Cluster,
+				ContainerInstances: nodeArns,
+				Wait:               true,
+				Timeout:            options.Timeout,
+			})
+		}, plan: func() error {
+			return client.planECSNodeStatus(options.Cluster, nodeArns, "DRAINING")
+		}},
+		{phase: ecsNodeRenewPhaseStable, run: func() error {
+			fmt.Fprintln(client.stdout, "Wait until all ECS services stable...")
+			if err := client.WaitUntilECSAllServicesStableWithContext(ctx, options.Cluster); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(client.stdout, "Wait until all targets healthy...")
+			return client.WaitUntilECSAllTargetsInService(options.Cluster)
+		}},
+		{phase: ecsNodeRenewPhaseDetached, run: func() error {
+			// Detach the drained instances with decrementing the desired capacity,
+			// and then terminate them. We don't scale in here because the autoscaling
+			// group may terminate the new instances instead.
+			fmt.Fprintf(client.stdout, "Detach old instances from autoscaling group %s\n%v\n", options.AsgName, awsutil.Prettify(instanceIds))
+			return client.detachAutoScalingGroupInstances(options.AsgName, instanceIds)
+		}, plan: func() error {
+			client.printPlan("AutoScaling.DetachInstances", "%s InstanceIds %s with ShouldDecrementDesiredCapacity (DesiredCapacity %d => %d)",
+				options.AsgName, formatPlanValues(instanceIds), targetCapacity, targetCapacity-n)
+			return nil
+		}},
+		{phase: ecsNodeRenewPhaseTerminated, run: func() error {
+			fmt.Fprintf(client.stdout, "Terminate old instances...\n%v\n", awsutil.Prettify(instanceIds))
+			return client.terminateEC2Instances(instanceIds)
+		}, plan: func() error {
+			return client.terminateEC2Instances(instanceIds)
+		}},
+		{phase: ecsNodeRenewPhaseRestored, run: func() error {
+			fmt.Fprintln(client.stdout, "Wait until desired capacity instances are InService...")
+			if err := client.WaitUntilAutoScalingGroupStable(options.AsgName); err != nil {
+				return err
+			}
+
+			// If the surge is less than the batch size, restore the desired capacity.
+			currentCapacity, err := client.getAutoScalingGroupDesiredCapacity(options.AsgName)
+			if err != nil {
+				return err
+			}
+			if currentCapacity < desiredCapacity {
+				if err := client.ecsNodeRenewScaleOut(options, state, currentCapacity, desiredCapacity); err != nil {
+					return err
+				}
+			}
+
+			return client.printECSStatus(options.Cluster)
+		}, plan: func() error {
+			if targetCapacity-n < desiredCapacity {
+				client.printPlan("AutoScaling.SetDesiredCapacity", "%s DesiredCapacity %d => %d", options.AsgName, targetCapacity-n, desiredCapacity)
+			}
+			return nil
+		}},
+	})
+}
+
+// ecsNodeRenewScaleOut updates the desired capacity, waits until new
+// container instances are registered and records them to the state.
+func (client *Client) ecsNodeRenewScaleOut(options ECSNodeRenewOptions, state *ecsNodeRenewState, currentCapacity int64, targetCapacity int64) error {
+	fmt.Fprintf(client.stdout, "Update autoscaling group %s (DesiredCapacity: %d => %d)\n", options.AsgName, currentCapacity, targetCapacity)
+	err := client.autoscalingUpdate(AutoscalingUpdateOptions{
+		AsgName:         options.AsgName,
+		DesiredCapacity: targetCapacity,
+		Wait:            true,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(client.stdout, "Wait until ECS container instances are registered...")
+	if err := client.WaitUntilECSContainerInstancesAreRegistered(options.Cluster, targetCapacity); err != nil {
+		return err
+	}
+
+	instances, err := client.getAutoScalingGroupInstances(options.AsgName)
+	if err != nil {
+		return err
+	}
+	instanceIds := []*string{}
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, instance.InstanceId)
+	}
+	state.addNewInstanceIds(instanceIds)
+	return nil
+}
+
+// detachAutoScalingGroupInstances detaches instances which still belong to
+// the autoscaling group with decrementing the desired capacity, so that it
+// can be retried.
+func (client *Client) detachAutoScalingGroupInstances(asgName string, instanceIds []*string) error {
+	instances, err := client.getAutoScalingGroupInstances(asgName)
+	if err != nil {
+		return err
+	}
+	attached := map[string]bool{}
+	for _, instance := range instances {
+		attached[*instance.InstanceId] = true
+	}
+	targets := []*string{}
+	for _, id := range instanceIds {
+		if attached[*id] {
+			targets = append(targets, id)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// We can specify up to 20 instances to detach in a single operation.
+	chunks := (funk.Chunk(targets, 20)).([][]*string)
+	for _, c := range chunks {
+		err := client.autoscalingDetach(AutoscalingDetachOptions{
+			AsgName:     asgName,
+			InstanceIds: c,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// terminateEC2Instances terminates EC2 instances.
+func (client *Client) terminateEC2Instances(instanceIds []*string) error {
+	params := &ec2.TerminateInstancesInput{
+		InstanceIds: instanceIds,
+	}
+
+	if client.dryRun {
+		// Check permissions with the native DryRun parameter.
+		params.DryRun = aws.Bool(true)
+		_, err := client.EC2.TerminateInstances(params)
+		if err = checkEC2DryRun(err); err != nil {
+			return errors.Wrap(err, "TerminateInstances failed:")
+		}
+		return client.planEC2InstanceStates("EC2.TerminateInstances", instanceIds, "terminated")
+	}
+
+	_, err := client.EC2.TerminateInstances(params)
+	client.auditCall("EC2.TerminateInstances", params, aws.StringValueSlice(instanceIds), err)
+	if err != nil {
+		return errors.Wrap(err, "TerminateInstances failed:")
+	}
+	return nil
+}
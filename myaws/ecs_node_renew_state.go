@@ -0,0 +1,185 @@
+package myaws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+// Phases of ecs node renew recorded in the state file.
+// A phase is the last step completed.
+const (
+	ecsNodeRenewPhaseStarted     = "started"
+	ecsNodeRenewPhaseScaledOut   = "scaled-out"
+	ecsNodeRenewPhaseDrained     = "drained"
+	ecsNodeRenewPhaseStable      = "stable"
+	ecsNodeRenewPhaseProtected   = "protected"
+	ecsNodeRenewPhaseScaledIn    = "scaled-in"
+	ecsNodeRenewPhaseUnprotected = "unprotected"
+	ecsNodeRenewPhaseDetached    = "detached"
+	ecsNodeRenewPhaseTerminated  = "terminated"
+	ecsNodeRenewPhaseRestored    = "restored"
+)
+
+// ecsNodeRenewState is a checkpoint of ecs node renew, which allows us to
+// resume or roll back an interrupted operation.
+type ecsNodeRenewState struct {
+	Cluster   string `json:"cluster"`
+	AsgName   string `json:"asg_name"`
+	Strategy  string `json:"strategy"`
+	BatchSize int64  `json:"batch_size,omitempty"`
+	MaxSurge  int64  `json:"max_surge,omitempty"`
+	// DesiredCapacity is the original desired capacity.
+	DesiredCapacity          int64    `json:"desired_capacity"`
+	OldInstanceIds           []string `json:"old_instance_ids"`
+	OldContainerInstanceArns []string `json:"old_container_instance_arns"`
+	NewInstanceIds           []string `json:"new_instance_ids"`
+	ProtectedInstanceIds     []string `json:"protected_instance_ids"`
+	// Batch is the current batch number of the rolling strategy.
+	Batch     int       `json:"batch,omitempty"`
+	Phase     string    `json:"phase"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	path string
+	// dryRun disables writing the state file.
+	dryRun bool
+}
+
+// ecsNodeRenewStep is a step of ecs node renew, which is skipped on resume if
+// its phase has already been reached.
+// In dry-run mode, plan is called instead of run. A step without plan makes
+// no write API calls.
+type ecsNodeRenewStep struct {
+	phase string
+	run   func() error
+	plan  func() error
+}
+
+// ecsNodeRenewStatePath returns a path of the state file.
+// The state directory is $HOME/.myaws/state/ecs-node-renew.
+func ecsNodeRenewStatePath(region string, cluster string, asgName string) (string, error) {
+	// The same cluster and ASG names can exist in other regions.
+	if region == "" {
+		return "", errors.New("region is required to store the state of renew (use --region or AWS_DEFAULT_REGION)")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory:")
+	}
+	name := fmt.Sprintf("%s_%s_%s.json", url.PathEscape(region), url.PathEscape(cluster), url.PathEscape(asgName))
+	return filepath.Join(home, ".myaws", "state", "ecs-node-renew", name), nil
+}
+
+// readECSNodeRenewState reads the state file. It returns nil if not exist.
+func readECSNodeRenewState(path string) (*ecsNodeRenewState, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read state file:")
+	}
+
+	state := &ecsNodeRenewState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state file %s:", path)
+	}
+	state.path = path
+	return state, nil
+}
+
+// save writes the state to the file.
+func (state *ecsNodeRenewState) save() error {
+	if state.dryRun {
+		return nil
+	}
+	state.UpdatedAt = time.Now().UTC()
+	if err := os.MkdirAll(filepath.Dir(state.path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create state directory:")
+	}
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state:")
+	}
+	if err := ioutil.WriteFile(state.path, b, 0600); err != nil {
+		return errors.Wrap(err, "failed to write state file:")
+	}
+	return nil
+}
+
+// remove deletes the state file.
+func (state *ecsNodeRenewState) remove() error {
+	if state.dryRun {
+		return nil
+	}
+	if err := os.Remove(state.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove state file:")
+	}
+	return nil
+}
+
+// runSteps runs steps after the phase reached and saves the state after each
+// step.
+func (state *ecsNodeRenewState) runSteps(client *Client, steps []ecsNodeRenewStep) error {
+	reached := -1
+	for i, step := range steps {
+		if step.phase == state.Phase {
+			reached = i
+		}
+	}
+
+	for i, step := range steps {
+		if i <= reached {
+			fmt.Fprintf(client.stdout, "Skip phase %s (already done)\n", step.phase)
+			continue
+		}
+		if client.dryRun {
+			if step.plan != nil {
+				if err := step.plan(); err != nil {
+					return err
+				}
+			}
+		} else if err := step.run(); err != nil {
+			return err
+		}
+		state.Phase = step.phase
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (state *ecsNodeRenewState) oldInstanceIds() []*string {
+	return aws.StringSlice(state.OldInstanceIds)
+}
+
+func (state *ecsNodeRenewState) oldContainerInstanceArns() []*string {
+	return aws.StringSlice(state.OldContainerInstanceArns)
+}
+
+// addNewInstanceIds records instance IDs of the group which are not old.
+func (state *ecsNodeRenewState) addNewInstanceIds(instanceIds []*string) {
+	for _, id := range difference(instanceIds, state.oldInstanceIds()) {
+		if !containsStringValue(state.NewInstanceIds, *id) {
+			state.NewInstanceIds = append(state.NewInstanceIds, *id)
+		}
+	}
+}
+
+func containsStringValue(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// failingDrainECS fails the n-th request to drain container instances.
+type failingDrainECS struct {
+	*fakeECS
+	failAt     int
+	drainCalls int
+}
+
+func (f *failingDrainECS) UpdateContainerInstancesState(input *ecs.UpdateContainerInstancesStateInput) (*ecs.UpdateContainerInstancesStateOutput, error) {
+	if aws.StringValue(input.Status) == "DRAINING" {
+		f.drainCalls++
+		if f.drainCalls == f.failAt {
+			return nil, awserr.New("ServerException", "injected failure", nil)
+		}
+	}
+	return f.fakeECS.UpdateContainerInstancesState(input)
+}
+
+func newInterruptedRenew(t *testing.T, c *fakeCluster, options ECSNodeRenewOptions, failAt int) *ecsNodeRenewState {
+	t.Helper()
+
+	services := newFakeRenewServices(c, &fakeELBV2{})
+	services.EC2 = &fakeEC2{}
+	services.ECS = &failingDrainECS{fakeECS: &fakeECS{c: c}, failAt: failAt}
+	client, _ := newTestClient(t, services)
+
+	err := client.ECSNodeRenew(options)
+	if err == nil || !strings.Contains(err.Error(), "injected failure") {
+		t.Fatalf("expected an injected failure, but got: %v", err)
+	}
+
+	state, err := readECSNodeRenewState(ecsNodeRenewStateTestPath(t, c))
+	if err != nil || state == nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	return state
+}
+
+func ecsNodeRenewStateTestPath(t *testing.T, c *fakeCluster) string {
+	t.Helper()
+	path, err := ecsNodeRenewStatePath(testRegion, c.cluster, c.asgName)
+	if err != nil {
+		t.Fatalf("failed to get state path: %s", err)
+	}
+	return path
+}
+
+func TestECSNodeRenewResumeBlueGreen(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(3, newFakeECSService("web", 2, ""))
+	options := ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute}
+
+	state := newInterruptedRenew(t, c, options, 1)
+	if state.Phase != ecsNodeRenewPhaseScaledOut {
+		t.Errorf("phase = %s, want %s", state.Phase, ecsNodeRenewPhaseScaledOut)
+	}
+	if got, want := state.OldInstanceIds, []string{"i-0001", "i-0002", "i-0003"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OldInstanceIds = %v, want %v", got, want)
+	}
+	if got, want := state.NewInstanceIds, []string{"i-0004", "i-0005", "i-0006"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewInstanceIds = %v, want %v", got, want)
+	}
+	if state.DesiredCapacity != 3 {
+		t.Errorf("DesiredCapacity = %d, want 3", state.DesiredCapacity)
+	}
+
+	client, stdout := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+
+	// A new renew is refused while the previous one is interrupted.
+	err := client.ECSNodeRenew(options)
+	if err == nil || !strings.Contains(err.Error(), "was interrupted at phase scaled-out") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+
+	options.Resume = true
+	if err := client.ECSNodeRenew(options); err != nil {
+		t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+	}
+
+	// The desired capacity is doubled only once.
+	if got, want := c.setDesiredCapacityCalls, []int64{6, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetDesiredCapacity calls = %v, want %v", got, want)
+	}
+	current := []string{}
+	for _, instance := range c.instances {
+		current = append(current, *instance.InstanceId)
+	}
+	if want := []string{"i-0004", "i-0005", "i-0006"}; !reflect.DeepEqual(current, want) {
+		t.Errorf("instances = %v, want %v", current, want)
+	}
+	if !strings.Contains(stdout.String(), "Skip phase scaled-out (already done)") {
+		t.Errorf("the completed phase is not skipped:\n%s", stdout)
+	}
+
+	// The state file is removed after completion.
+	if state, err := readECSNodeRenewState(ecsNodeRenewStateTestPath(t, c)); err != nil || state != nil {
+		t.Errorf("state file is not removed: %v, %v", state, err)
+	}
+}
+
+func TestECSNodeRenewResumeRolling(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(4, newFakeECSService("web", 2, ""))
+	options := ECSNodeRenewOptions{
+		Cluster:   c.cluster,
+		AsgName:   c.asgName,
+		Timeout:   time.Minute,
+		Strategy:  ECSNodeRenewStrategyRolling,
+		BatchSize: 2,
+		MaxSurge:  2,
+	}
+
+	// The drain of the second batch fails.
+	state := newInterruptedRenew(t, c, options, 2)
+	if state.Batch != 2 || state.Phase != ecsNodeRenewPhaseScaledOut {
+		t.Errorf("batch = %d, phase = %s, want 2, %s", state.Batch, state.Phase, ecsNodeRenewPhaseScaledOut)
+	}
+
+	ec2 := &fakeEC2{}
+	services := newFakeRenewServices(c, &fakeELBV2{})
+	services.EC2 = ec2
+	client, stdout := newTestClient(t, services)
+
+	// The batch size of the state is used.
+	options.Resume = true
+	options.BatchSize = 1
+	if err := client.ECSNodeRenew(options); err != nil {
+		t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+	}
+
+	if got, want := ec2.terminateInstancesCalls, [][]string{{"i-0003", "i-0004"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TerminateInstances calls = %v, want %v", got, want)
+	}
+	if got, want := c.setDesiredCapacityCalls, []int64{6, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetDesiredCapacity calls = %v, want %v", got, want)
+	}
+	if got := len(c.instances); got != 4 {
+		t.Errorf("len(instances) = %d, want 4", got)
+	}
+	for _, instance := range c.instances {
+		if *instance.InstanceId <= "i-0004" {
+			t.Errorf("old instance %s is not replaced", *instance.InstanceId)
+		}
+	}
+}
+
+func TestECSNodeRenewResumeWithoutState(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(1)
+	client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+
+	cases := []struct {
+		options ECSNodeRenewOptions
+		want    string
+	}{
+		{options: ECSNodeRenewOptions{Resume: true}, want: "no renew to resume"},
+		{options: ECSNodeRenewOptions{Rollback: true}, want: "no renew to roll back"},
+		{options: ECSNodeRenewOptions{Resume: true, Rollback: true}, want: "mutually exclusive"},
+	}
+
+	for _, tc := range cases {
+		tc.options.Cluster = c.cluster
+		tc.options.AsgName = c.asgName
+		tc.options.Timeout = time.Minute
+		err := client.ECSNodeRenew(tc.options)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected an error containing %q, but got: %v", tc.want, err)
+		}
+	}
+}
+
+func TestECSNodeRenewWithoutRegion(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(1)
+	client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+	client.config = client.config.Copy().WithRegion("")
+
+	err := client.ECSNodeRenew(ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute})
+	if err == nil || !strings.Contains(err.Error(), "region is required") {
+		t.Errorf("expected an error of the region, but got: %v", err)
+	}
+}
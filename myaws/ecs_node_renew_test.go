@@ -0,0 +1,300 @@
+package myaws
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func newFakeRenewServices(c *fakeCluster, elbv2 *fakeELBV2) Services {
+	return Services{
+		AutoScaling: &fakeAutoScaling{c: c},
+		ECS:         &fakeECS{c: c},
+		ELBV2:       elbv2,
+	}
+}
+
+func TestECSNodeRenew(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(3,
+		newFakeECSService("web", 2, "arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1"),
+		newFakeECSService("worker", 1, ""),
+		newFakeECSService("batch", 0, "arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/batch/1"),
+	)
+	elbv2 := &fakeELBV2{}
+	client, stdout := newTestClient(t, newFakeRenewServices(c, elbv2))
+
+	oldInstanceIds := []string{}
+	oldContainerInstanceArns := []string{}
+	for _, ci := range c.containerInstances {
+		oldInstanceIds = append(oldInstanceIds, *ci.Ec2InstanceId)
+		oldContainerInstanceArns = append(oldContainerInstanceArns, *ci.ContainerInstanceArn)
+	}
+
+	err := client.ECSNodeRenew(ECSNodeRenewOptions{
+		Cluster: c.cluster,
+		AsgName: c.asgName,
+		Timeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+	}
+
+	if got, want := c.setDesiredCapacityCalls, []int64{6, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetDesiredCapacity calls = %v, want %v", got, want)
+	}
+
+	// Only the old container instances are drained.
+	drained := []string{}
+	for _, call := range c.updateStateCalls {
+		drained = append(drained, call...)
+	}
+	if !reflect.DeepEqual(drained, oldContainerInstanceArns) {
+		t.Errorf("drained = %v, want %v", drained, oldContainerInstanceArns)
+	}
+
+	// The old instances are terminated and the new ones remain unprotected.
+	if got := len(c.instances); got != 3 {
+		t.Fatalf("len(instances) = %d, want 3", got)
+	}
+	for _, instance := range c.instances {
+		for _, old := range oldInstanceIds {
+			if *instance.InstanceId == old {
+				t.Errorf("old instance %s is not terminated", old)
+			}
+		}
+		if *instance.LifecycleState != "InService" {
+			t.Errorf("instance %s is %s, want InService", *instance.InstanceId, *instance.LifecycleState)
+		}
+		if *instance.ProtectedFromScaleIn {
+			t.Errorf("instance %s is still protected from scale in", *instance.InstanceId)
+		}
+	}
+	if got := len(c.containerInstances); got != 3 {
+		t.Errorf("len(containerInstances) = %d, want 3", got)
+	}
+	for _, ci := range c.containerInstances {
+		if *ci.Status != "ACTIVE" {
+			t.Errorf("container instance %s is %s, want ACTIVE", *ci.ContainerInstanceArn, *ci.Status)
+		}
+	}
+
+	// The new instances are protected while scaling in, then unprotected.
+	if got := len(c.protectionCalls); got != 2 {
+		t.Fatalf("len(SetInstanceProtection calls) = %d, want 2", got)
+	}
+	protected := append([]string{}, c.protectionCalls[0]...)
+	sort.Strings(protected)
+	current := []string{}
+	for _, instance := range c.instances {
+		current = append(current, *instance.InstanceId)
+	}
+	sort.Strings(current)
+	if !reflect.DeepEqual(protected, current) {
+		t.Errorf("protected = %v, want %v", protected, current)
+	}
+
+	if got := len(c.servicesStableCalls); got != 1 {
+		t.Errorf("len(WaitUntilServicesStable calls) = %d, want 1", got)
+	}
+
+	// A service without desired tasks is skipped.
+	if got, want := elbv2.waitUntilTargetInServiceCalls, []string{"arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WaitUntilTargetInService calls = %v, want %v", got, want)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"start: ecs node renew",
+		"Update autoscaling group test-asg (DesiredCapacity: 3 => 6)",
+		"Update autoscaling group test-asg (DesiredCapacity: 6 => 3)",
+		"end: ecs node renew",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout doesn't contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestECSNodeRenewCapacityMismatch(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(2, newFakeECSService("web", 1, ""))
+	// The desired capacity is not converged.
+	c.desiredCapacity = 3
+	client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+
+	err := client.ECSNodeRenew(ECSNodeRenewOptions{
+		Cluster: c.cluster,
+		AsgName: c.asgName,
+		Timeout: time.Minute,
+	})
+	if err == nil || !strings.Contains(err.Error(), "currentCapacity(2) != desiredCapacity(3)") {
+		t.Fatalf("expected an assertion error, but got: %v", err)
+	}
+	if len(c.setDesiredCapacityCalls) != 0 {
+		t.Errorf("SetDesiredCapacity should not be called: %v", c.setDesiredCapacityCalls)
+	}
+}
+
+func TestSelectInstanceToProtectFromScaleIn(t *testing.T) {
+	c := newFakeCluster(4, newFakeECSService("web", 1, ""))
+	client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+
+	oldNodes, err := client.findECSNodes(c.cluster)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	// i-0001 and i-0002 are old, i-0003 and i-0004 are new,
+	// and i-0004 is already protected.
+	oldInstanceIds := []*string{oldNodes[0].Ec2InstanceId, oldNodes[1].Ec2InstanceId}
+	c.instances[3].ProtectedFromScaleIn = aws.Bool(true)
+
+	got, err := client.selectInstanceToProtectFromScaleIn(oldInstanceIds, c.cluster)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if want := []string{"i-0003"}; !reflect.DeepEqual(aws.StringValueSlice(got), want) {
+		t.Errorf("got = %v, want %v", aws.StringValueSlice(got), want)
+	}
+}
+
+func TestDifference(t *testing.T) {
+	a := aws.StringSlice([]string{"a", "b", "c", "d"})
+	b := aws.StringSlice([]string{"b", "d", "e"})
+	if got, want := aws.StringValueSlice(difference(a, b)), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func TestECSNodeRenewRolling(t *testing.T) {
+	cases := []struct {
+		desc             string
+		batchSize        int64
+		maxSurge         int64
+		wantCapacities   []int64
+		wantTerminations [][]string
+	}{
+		{
+			desc:           "surge less than batch size",
+			batchSize:      2,
+			maxSurge:       1,
+			wantCapacities: []int64{6, 5, 6, 5, 6},
+			wantTerminations: [][]string{
+				{"i-0001", "i-0002"},
+				{"i-0003", "i-0004"},
+				{"i-0005"},
+			},
+		},
+		{
+			desc:           "no surge",
+			batchSize:      3,
+			maxSurge:       0,
+			wantCapacities: []int64{5, 5},
+			wantTerminations: [][]string{
+				{"i-0001", "i-0002", "i-0003"},
+				{"i-0004", "i-0005"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			setTestHome(t)
+			c := newFakeCluster(5,
+				newFakeECSService("web", 2, "arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1"),
+			)
+			ec2 := &fakeEC2{}
+			services := newFakeRenewServices(c, &fakeELBV2{})
+			services.EC2 = ec2
+			client, stdout := newTestClient(t, services)
+
+			err := client.ECSNodeRenew(ECSNodeRenewOptions{
+				Cluster:   c.cluster,
+				AsgName:   c.asgName,
+				Timeout:   time.Minute,
+				Strategy:  ECSNodeRenewStrategyRolling,
+				BatchSize: tc.batchSize,
+				MaxSurge:  tc.maxSurge,
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s\nstdout:\n%s", err, stdout)
+			}
+
+			if !reflect.DeepEqual(c.setDesiredCapacityCalls, tc.wantCapacities) {
+				t.Errorf("SetDesiredCapacity calls = %v, want %v", c.setDesiredCapacityCalls, tc.wantCapacities)
+			}
+			if !reflect.DeepEqual(ec2.terminateInstancesCalls, tc.wantTerminations) {
+				t.Errorf("TerminateInstances calls = %v, want %v", ec2.terminateInstancesCalls, tc.wantTerminations)
+			}
+
+			// Every old instance is replaced with a new one.
+			if got := len(c.instances); got != 5 {
+				t.Fatalf("len(instances) = %d, want 5", got)
+			}
+			for _, instance := range c.instances {
+				if *instance.InstanceId <= "i-0005" {
+					t.Errorf("old instance %s is not replaced", *instance.InstanceId)
+				}
+				if *instance.LifecycleState != "InService" {
+					t.Errorf("instance %s is %s, want InService", *instance.InstanceId, *instance.LifecycleState)
+				}
+			}
+			if got := c.desiredCapacity; got != 5 {
+				t.Errorf("desiredCapacity = %d, want 5", got)
+			}
+			if len(c.protectionCalls) != 0 {
+				t.Errorf("SetInstanceProtection should not be called: %v", c.protectionCalls)
+			}
+			if !strings.Contains(stdout.String(), "end: ecs node renew") {
+				t.Errorf("renew is not completed:\n%s", stdout)
+			}
+		})
+	}
+}
+
+func TestECSNodeRenewInvalidOptions(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options ECSNodeRenewOptions
+		want    string
+	}{
+		{
+			desc:    "unknown strategy",
+			options: ECSNodeRenewOptions{Strategy: "canary"},
+			want:    "unknown strategy: canary",
+		},
+		{
+			desc:    "zero batch size",
+			options: ECSNodeRenewOptions{Strategy: ECSNodeRenewStrategyRolling, BatchSize: 0, MaxSurge: 1},
+			want:    "--batch-size must be a positive number",
+		},
+		{
+			desc:    "negative max surge",
+			options: ECSNodeRenewOptions{Strategy: ECSNodeRenewStrategyRolling, BatchSize: 1, MaxSurge: -1},
+			want:    "--max-surge must not be negative",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			setTestHome(t)
+			c := newFakeCluster(1)
+			client, _ := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+			tc.options.Cluster = c.cluster
+			tc.options.AsgName = c.asgName
+			tc.options.Timeout = time.Minute
+
+			err := client.ECSNodeRenew(tc.options)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, but got: %v", tc.want, err)
+			}
+			if len(c.setDesiredCapacityCalls) != 0 {
+				t.Errorf("SetDesiredCapacity should not be called: %v", c.setDesiredCapacityCalls)
+			}
+		})
+	}
+}
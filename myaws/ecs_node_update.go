@@ -1,6 +1,10 @@
 package myaws
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/pkg/errors"
 )
@@ -14,17 +18,61 @@ type ECSNodeUpdateOptions struct {
 
 // ECSNodeUpdate Update ECS container instances.
 func (client *Client) ECSNodeUpdate(options ECSNodeUpdateOptions) error {
-	_, err := client.ECS.UpdateContainerInstancesState(
-		&ecs.UpdateContainerInstancesStateInput{
-			Cluster:            &options.Cluster,
-			ContainerInstances: options.ContainerInstances,
-			Status:             &options.Status,
-		},
-	)
+	if err := client.guardECSCluster(options.Cluster); err != nil {
+		return err
+	}
+	if options.Status == "DRAINING" {
+		message := fmt.Sprintf("Are you sure want to drain container instances %s of cluster %s?", formatPlanValues(options.ContainerInstances), options.Cluster)
+		if ok, err := client.confirm(message); !ok {
+			return err
+		}
+	}
+
+	return client.ecsNodeUpdate(options)
+}
+
+// ecsNodeUpdate updates ECS container instances without confirmation.
+func (client *Client) ecsNodeUpdate(options ECSNodeUpdateOptions) error {
+	if client.dryRun {
+		return client.planECSNodeStatus(options.Cluster, options.ContainerInstances, options.Status)
+	}
 
+	params := &ecs.UpdateContainerInstancesStateInput{
+		Cluster:            &options.Cluster,
+		ContainerInstances: options.ContainerInstances,
+		Status:             &options.Status,
+	}
+	_, err := client.ECS.UpdateContainerInstancesState(params)
+	client.auditCall("ECS.UpdateContainerInstancesState", params, auditResources(options.Cluster, options.ContainerInstances), err)
 	if err != nil {
 		return errors.Wrapf(err, "UpdateContainerInstancesState failed")
 	}
 
 	return nil
 }
+
+// planECSNodeStatus prints a plan to update the status of container
+// instances. The container instances can be given as either ARNs or IDs.
+func (client *Client) planECSNodeStatus(cluster string, containerInstances []*string, status string) error {
+	instances, err := client.describeECSContainerInstances(aws.BackgroundContext(), cluster, containerInstances)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range containerInstances {
+		var found *ecs.ContainerInstance
+		for _, instance := range instances {
+			if *instance.ContainerInstanceArn == *c || strings.HasSuffix(*instance.ContainerInstanceArn, "/"+*c) {
+				found = instance
+				break
+			}
+		}
+		if found == nil {
+			client.printPlan("ECS.UpdateContainerInstancesState", "%s (not found) Status => %s", *c, status)
+			continue
+		}
+		client.printPlan("ECS.UpdateContainerInstancesState", "%s (%s) Status %s => %s (RunningTasks: %d)",
+			*c, aws.StringValue(found.Ec2InstanceId), *found.Status, status, aws.Int64Value(found.RunningTasksCount))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestECSNodeUpdate(t *testing.T) {
+	c := newFakeCluster(2)
+	c.containerInstances[0].Status = aws.String("DRAINING")
+	client, _ := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	err := client.ECSNodeUpdate(ECSNodeUpdateOptions{
+		Cluster:            c.cluster,
+		ContainerInstances: []*string{c.containerInstances[0].ContainerInstanceArn},
+		Status:             "ACTIVE",
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := *c.containerInstances[0].Status; got != "ACTIVE" {
+		t.Errorf("status = %s, want ACTIVE", got)
+	}
+}
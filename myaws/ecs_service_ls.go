@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 )
 
@@ -11,43 +12,88 @@ import (
 type ECSServiceLsOptions struct {
 	Cluster     string
 	PrintHeader bool
+	Query       QueryOptions
+	Targets     TargetOptions
+}
+
+// ecsServiceFields is a registry of output fields for ECS services.
+var ecsServiceFields = newFieldRegistry(ecs.Service{}, "ServiceName", []field{
+	{"Desired", formatECSServiceDesired},
+	{"Running", formatECSServiceRunning},
+	{"Pending", formatECSServicePending},
+	{"Deploy", formatECSServiceDeploy},
+	{"Service", formatECSServiceName},
+	{"TaskDefinition", formatECSServiceTaskDefinition},
+})
+
+// ecsServiceLsFields is a list of fields printed by the ECSServiceLs.
+var ecsServiceLsFields = []string{
+	"Desired",
+	"Running",
+	"Pending",
+	"Deploy",
+	"Service",
+	"TaskDefinition",
 }
 
 // ECSServiceLs describes ECS services.
 func (client *Client) ECSServiceLs(options ECSServiceLsOptions) error {
-	services, err := client.findECSServices(options.Cluster)
+	q, err := ecsServiceFields.compileQuery(options.Query)
 	if err != nil {
 		return err
 	}
 
-	if options.PrintHeader {
-		header := fmt.Sprintf("%s\t%s\t%s\t%s\t%-32s\t%s",
-			"Desired",
-			"Running",
-			"Pending",
-			"Deploy",
-			"Service",
-			"TaskDefinition",
-		)
-		fmt.Fprintln(client.stdout, header)
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		services, err := c.findECSServicesWithContext(aws.BackgroundContext(), options.Cluster, options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		for _, service := range services {
+			resources = append(resources, service)
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	for _, service := range services {
-		fmt.Fprintln(client.stdout, formatECSService(client, options, service))
+	fields := options.Targets.outputFields(ecsServiceLsFields)
+	rows := ecsServiceFields.formatRows(client, fields, q.apply(client, resources))
+	if err := client.printRows(fields, rows, options.PrintHeader); err != nil {
+		return err
 	}
+	return findErr
+}
 
-	return nil
+func formatECSServiceDesired(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	return fmt.Sprintf("%d", *service.DesiredCount)
 }
 
-func formatECSService(client *Client, options ECSServiceLsOptions, service *ecs.Service) string {
-	taskDefinitions := strings.Split(*service.TaskDefinition, "/")
+func formatECSServiceRunning(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	return fmt.Sprintf("%d", *service.RunningCount)
+}
 
-	return fmt.Sprintf("%d\t%d\t%d\t%d\t%-32s\t%s",
-		*service.DesiredCount,
-		*service.RunningCount,
-		*service.PendingCount,
-		len(service.Deployments),
-		*service.ServiceName,
-		taskDefinitions[1],
-	)
+func formatECSServicePending(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	return fmt.Sprintf("%d", *service.PendingCount)
+}
+
+func formatECSServiceDeploy(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	return fmt.Sprintf("%d", len(service.Deployments))
+}
+
+func formatECSServiceName(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	return fmt.Sprintf("%-32s", *service.ServiceName)
+}
+
+func formatECSServiceTaskDefinition(client *Client, resource interface{}) string {
+	service := resource.(*ecs.Service)
+	taskDefinitions := strings.Split(*service.TaskDefinition, "/")
+	return taskDefinitions[1]
 }
@@ -0,0 +1,51 @@
+package myaws
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestECSServiceLs(t *testing.T) {
+	c := newFakeCluster(1,
+		newFakeECSService("web", 2, ""),
+		newFakeECSService("worker", 1, ""),
+		newFakeECSService("batch", 0, ""),
+	)
+	c.services[1].RunningCount = c.services[1].PendingCount
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	err := client.ECSServiceLs(ECSServiceLsOptions{
+		Cluster:     c.cluster,
+		PrintHeader: true,
+		Query:       QueryOptions{Where: "Desired > 0", SortBy: "ServiceName"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "Desired\tRunning\tPending\tDeploy\tService\tTaskDefinition\n" +
+		fmt.Sprintf("2\t2\t0\t1\t%-32s\tweb:3\n", "web") +
+		fmt.Sprintf("1\t0\t0\t1\t%-32s\tworker:3\n", "worker")
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestECSServiceLsManyServices(t *testing.T) {
+	// More than 10 services need multiple DescribeServices requests.
+	c := newFakeCluster(1)
+	for i := 0; i < 25; i++ {
+		c.services = append(c.services, newFakeECSService(fmt.Sprintf("svc%02d", i), 1, ""))
+	}
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+	client.output = OutputFormatCSV
+
+	if err := client.ECSServiceLs(ECSServiceLsOptions{Cluster: c.cluster}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	// 25 services and a header
+	if got := len(splitLines(stdout.String())); got != 26 {
+		t.Errorf("got %d lines, want 26", got)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/pkg/errors"
 )
@@ -18,10 +20,30 @@ type ECSServiceUpdateOptions struct {
 	Wait         bool
 	Timeout      time.Duration
 	Force        bool
+	// Select chooses a service in a fuzzy finder.
+	Select bool
 }
 
 // ECSServiceUpdate update ECS services.
 func (client *Client) ECSServiceUpdate(options ECSServiceUpdateOptions) error {
+	if options.Select {
+		service, err := client.pickECSService(options.Cluster)
+		if err != nil {
+			return err
+		}
+		options.Service = service
+	}
+
+	if err := client.guardECSCluster(options.Cluster); err != nil {
+		return err
+	}
+	if err := client.guardECSService(options.Cluster, options.Service); err != nil {
+		return err
+	}
+	if ok, err := client.confirm(fmt.Sprintf("Are you sure want to update service %s of cluster %s?", options.Service, options.Cluster)); !ok {
+		return err
+	}
+
 	input := &ecs.UpdateServiceInput{
 		Cluster:            &options.Cluster,
 		Service:            &options.Service,
@@ -47,7 +69,12 @@ func (client *Client) ECSServiceUpdate(options ECSServiceUpdateOptions) error {
 		input.TaskDefinition = &family
 	}
 
+	if client.dryRun {
+		return client.planECSServiceUpdate(input)
+	}
+
 	_, err := client.ECS.UpdateService(input)
+	client.auditCall("ECS.UpdateService", input, []string{options.Cluster, options.Service}, err)
 	if err != nil {
 		return errors.Wrapf(err, "UpdateService failed")
 	}
@@ -93,3 +120,35 @@ func (client *Client) getECSTaskDefinitionFamily(cluster string, service string)
 
 	return family, nil
 }
+
+// planECSServiceUpdate prints a plan to update the ECS service.
+func (client *Client) planECSServiceUpdate(input *ecs.UpdateServiceInput) error {
+	resp, err := client.ECS.DescribeServices(&ecs.DescribeServicesInput{
+		Cluster:  input.Cluster,
+		Services: []*string{input.Service},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "DescribeServices failed")
+	}
+	if len(resp.Services) == 0 {
+		return fmt.Errorf("service not fould: cluster = %s, service = %s", *input.Cluster, *input.Service)
+	}
+	service := resp.Services[0]
+
+	changes := []string{}
+	if input.DesiredCount != nil {
+		changes = append(changes, fmt.Sprintf("DesiredCount %d => %d", aws.Int64Value(service.DesiredCount), *input.DesiredCount))
+	}
+	if input.TaskDefinition != nil {
+		changes = append(changes, fmt.Sprintf("TaskDefinition %s => %s (latest ACTIVE revision)", aws.StringValue(service.TaskDefinition), *input.TaskDefinition))
+	}
+	if aws.BoolValue(input.ForceNewDeployment) {
+		changes = append(changes, "ForceNewDeployment")
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "no changes")
+	}
+
+	client.printPlan("ECS.UpdateService", "%s/%s %s", *input.Cluster, *input.Service, strings.Join(changes, ", "))
+	return nil
+}
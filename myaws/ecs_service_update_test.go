@@ -0,0 +1,90 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestECSServiceUpdate(t *testing.T) {
+	cases := []struct {
+		desc               string
+		options            ECSServiceUpdateOptions
+		wantDesiredCount   int64
+		wantTaskDefinition string
+		wantStableCalls    int
+	}{
+		{
+			desc:             "desired count",
+			options:          ECSServiceUpdateOptions{Service: "web", DesiredCount: aws.Int64(4)},
+			wantDesiredCount: 4,
+		},
+		{
+			desc:               "force with wait",
+			options:            ECSServiceUpdateOptions{Service: "web", Force: true, Wait: true, Timeout: time.Minute},
+			wantDesiredCount:   2,
+			wantTaskDefinition: "web",
+			wantStableCalls:    1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newFakeCluster(1, newFakeECSService("web", 2, ""))
+			client, _ := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+			tc.options.Cluster = c.cluster
+			if err := client.ECSServiceUpdate(tc.options); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			input := c.updateServiceInputs[0]
+			if got := aws.StringValue(input.TaskDefinition); got != tc.wantTaskDefinition {
+				t.Errorf("TaskDefinition = %q, want %q", got, tc.wantTaskDefinition)
+			}
+			if got := *c.services[0].DesiredCount; got != tc.wantDesiredCount {
+				t.Errorf("DesiredCount = %d, want %d", got, tc.wantDesiredCount)
+			}
+			if got := len(c.servicesStableCalls); got != tc.wantStableCalls {
+				t.Errorf("len(WaitUntilServicesStable calls) = %d, want %d", got, tc.wantStableCalls)
+			}
+		})
+	}
+}
+
+func TestGetECSTaskDefinitionFamily(t *testing.T) {
+	cases := []struct {
+		arn     string
+		want    string
+		wantErr bool
+	}{
+		{arn: "arn:aws:ecs:ap-northeast-1:123456789012:task-definition/web:12", want: "web"},
+		{arn: "arn:aws:ecs:ap-northeast-1:123456789012:task-definition/web-app_v2:1", want: "web-app_v2"},
+		{arn: "web:12", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.arn, func(t *testing.T) {
+			service := newFakeECSService("web", 1, "")
+			service.TaskDefinition = aws.String(tc.arn)
+			c := newFakeCluster(1, service)
+			client, _ := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+			got, err := client.getECSTaskDefinitionFamily(c.cluster, "web")
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "failed to parse task definition arn") {
+					t.Fatalf("expected a parse error, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("got = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestECSStatus(t *testing.T) {
+	c := newFakeCluster(2, newFakeECSService("web", 2, ""))
+	client, stdout := newTestClient(t, Services{ECS: &fakeECS{c: c}})
+
+	if err := client.ECSStatus(ECSStatusOptions{Cluster: c.cluster}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	lines := splitLines(stdout.String())
+	want := []string{"[Service]", "Desired\tRunning\tPending\tDeploy\tService\tTaskDefinition", "", "[Node]", "ContainerInstanceId\tEc2InstanceId\tStatus\tRunning\tPending\tRegisteredAt", "", ""}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), stdout)
+	}
+	for i, w := range want {
+		if w != "" && lines[i] != w {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+}
@@ -85,6 +85,7 @@ func (client *Client) waitUntilECSContainerInstancesStatusWithContext(ctx aws.Co
 			return req, nil
 		},
 	}
+	w.ApplyOptions(client.waiterOptions...)
 	w.ApplyOptions(opts...)
 
 	return w.WaitWithContext(ctx)
@@ -137,6 +138,7 @@ func (client *Client) waitUntilECSContainerInstancesNoRunningTaskWithContext(ctx
 			return req, nil
 		},
 	}
+	w.ApplyOptions(client.waiterOptions...)
 	w.ApplyOptions(opts...)
 
 	return w.WaitWithContext(ctx)
@@ -161,15 +163,14 @@ func (client *Client) WaitUntilECSContainerInstancesAreRegistered(cluster string
 	}
 
 	// build descirbe input
-	arns, err := client.ECS.ListContainerInstancesWithContext(ctx, listInput)
-
+	arns, err := client.listECSContainerInstanceArns(ctx, cluster, 0)
 	if err != nil {
-		return errors.Wrapf(err, "ListContainerInstances failed")
+		return err
 	}
 
 	describeInput := &ecs.DescribeContainerInstancesInput{
 		Cluster:            &cluster,
-		ContainerInstances: arns.ContainerInstanceArns,
+		ContainerInstances: arns,
 	}
 
 	// make sure container instances are ACTIVE state
@@ -205,6 +206,7 @@ func (client *Client) waitUntilECSContainerInstancesCountWithContext(ctx aws.Con
 			return req, nil
 		},
 	}
+	w.ApplyOptions(client.waiterOptions...)
 	w.ApplyOptions(opts...)
 
 	return w.WaitWithContext(ctx)
@@ -0,0 +1,25 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+)
+
+func TestELBLs(t *testing.T) {
+	f := &fakeELB{
+		loadBalancers: []*elb.LoadBalancerDescription{
+			{LoadBalancerName: aws.String("web")},
+			{LoadBalancerName: aws.String("api")},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ELB: f})
+
+	if err := client.ELBLs(); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got, want := stdout.String(), "web\napi\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
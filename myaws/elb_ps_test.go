@@ -0,0 +1,33 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+)
+
+func TestELBPs(t *testing.T) {
+	f := &fakeELB{
+		instanceStates: map[string][]*elb.InstanceState{
+			"web": {
+				{InstanceId: aws.String("i-0001"), State: aws.String("InService")},
+				{InstanceId: aws.String("i-0002"), State: aws.String("OutOfService")},
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ELB: f})
+
+	if err := client.ELBPs(ELBPsOptions{LoadBalancerName: "web"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got, want := stdout.String(), "i-0001\tInService\ni-0002\tOutOfService\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	err := client.ELBPs(ELBPsOptions{LoadBalancerName: "foo"})
+	if err == nil || !strings.Contains(err.Error(), "LoadBalancerNotFound") {
+		t.Errorf("expected an error, but got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/pkg/errors"
 )
@@ -16,38 +17,63 @@ type ELBv2Options struct {
 	FilterTag string
 	Fields    []string
 	Domain    []string
+	Targets   TargetOptions
 }
 
 // ELBV2Ls describes ELBV2s.
 func (client *Client) ELBV2Ls(options ELBv2Options) error {
-	params := &elbv2.DescribeLoadBalancersInput{}
-
-	response, err := client.ELBV2.DescribeLoadBalancers(params)
-	if err != nil {
-		return errors.Wrap(err, "DescribeLoadBalancers failed:")
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		params := &elbv2.DescribeLoadBalancersInput{}
+
+		resources := []interface{}{}
+		err := c.ELBV2.DescribeLoadBalancersPagesWithContext(aws.BackgroundContext(), params,
+			func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+				for _, lb := range page.LoadBalancers {
+					resources = append(resources, lb)
+				}
+				return true
+			})
+		if err != nil {
+			return nil, errors.Wrap(err, "DescribeLoadBalancers failed:")
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	for _, lb := range response.LoadBalancers {
-		fmt.Fprintln(client.stdout, formatLoadBalancerV2(lb))
+	fields := options.Targets.outputFields([]string{
+		"LoadBalancerName",
+		"DNSName",
+		"VpcId",
+		"Type",
+		"AvailabilityZones",
+	})
+
+	rows := [][]string{}
+	for _, resource := range resources {
+		lb, target := client.unwrapTargetResource(resource)
+		rows = append(rows, options.Targets.formatRow(target, formatLoadBalancerV2(lb.(*elbv2.LoadBalancer))))
 	}
 
-	return nil
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
+	}
+	return findErr
 }
 
-func formatLoadBalancerV2(lb *elbv2.LoadBalancer) string {
-	var a string
-	n := len(lb.AvailabilityZones)
+func formatLoadBalancerV2(lb *elbv2.LoadBalancer) []string {
+	zones := []string{}
+	for _, az := range lb.AvailabilityZones {
+		zones = append(zones, *az.ZoneName)
+	}
 
 	output := []string{
 		*lb.LoadBalancerName,
 		*lb.DNSName,
 		*lb.VpcId,
 		*lb.Type,
-	}
-	// LBの振り分け先をoutputに格納、振り分け先のリージョンはVPCの右列に出力させたいがやり方がわからない
-	for i := 0; i < n; i++ {
-		a = *lb.AvailabilityZones[i].ZoneName
-		output = append(output, a)
+		strings.Join(zones, " "),
 	}
 	// TODO: -Dで指定されたドメイン名でoutputの中身をフィルターする機能を持たせたい。
 	// a = len(options.Domain)
@@ -58,7 +84,7 @@ func formatLoadBalancerV2(lb *elbv2.LoadBalancer) string {
 	// 	output = nil
 	// 	return strings.Join(output[:], "")
 	// }
-	return strings.Join(output[:], "\t")
+	return output
 }
 
 // EC2LsOptions customize the behavior of the Ls command.
@@ -0,0 +1,52 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestELBV2Ls(t *testing.T) {
+	f := &fakeELBV2{
+		loadBalancers: []*elbv2.LoadBalancer{
+			{
+				LoadBalancerName:  aws.String("web"),
+				DNSName:           aws.String("web-123.ap-northeast-1.elb.amazonaws.com"),
+				VpcId:             aws.String("vpc-0001"),
+				Type:              aws.String("application"),
+				AvailabilityZones: []*elbv2.AvailabilityZone{{ZoneName: aws.String("ap-northeast-1a")}, {ZoneName: aws.String("ap-northeast-1c")}},
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ELBV2: f})
+
+	if err := client.ELBV2Ls(ELBv2Options{}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got := splitLines(stdout.String()); len(got) != 1 || got[0][:4] != "web\t" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestELBV2TLs(t *testing.T) {
+	f := &fakeELBV2{
+		targetGroups: []*elbv2.TargetGroup{
+			{
+				TargetGroupName: aws.String("web"),
+				Port:            aws.Int64(80),
+				Protocol:        aws.String("HTTP"),
+				TargetGroupArn:  aws.String("arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1"),
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ELBV2: f})
+
+	if err := client.ELBV2TLs(ELBv2TLsOptions{}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	want := "web\t80\tHTTP\tarn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestELBV2Ps(t *testing.T) {
+	arn := "arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/web/1"
+	f := &fakeELBV2{
+		targetGroups: []*elbv2.TargetGroup{
+			{TargetGroupName: aws.String("web"), TargetGroupArn: aws.String(arn)},
+		},
+		targetHealth: map[string][]*elbv2.TargetHealthDescription{
+			arn: {
+				{
+					Target:       &elbv2.TargetDescription{Id: aws.String("i-0001"), Port: aws.Int64(32768)},
+					TargetHealth: &elbv2.TargetHealth{State: aws.String("healthy")},
+				},
+				{
+					Target: &elbv2.TargetDescription{Id: aws.String("i-0002"), Port: aws.Int64(32769)},
+					TargetHealth: &elbv2.TargetHealth{
+						State:       aws.String("draining"),
+						Reason:      aws.String("Target.DeregistrationInProgress"),
+						Description: aws.String("Target deregistration is in progress"),
+					},
+				},
+			},
+		},
+	}
+	client, stdout := newTestClient(t, Services{ELBV2: f})
+
+	if err := client.ELBV2Ps(ELBV2PsOptions{TargetGroupName: "web"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	want := "i-0001\t32768\t     healthy\t\t\n" +
+		"i-0002\t32769\t    draining\tTarget.DeregistrationInProgress\tTarget deregistration is in progress\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	err := client.ELBV2Ps(ELBV2PsOptions{TargetGroupName: "foo"})
+	if err == nil || !strings.Contains(err.Error(), "expects to return 1 group, but found 0 groups") {
+		t.Errorf("expected an error, but got: %v", err)
+	}
+}
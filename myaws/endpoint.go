@@ -0,0 +1,90 @@
+package myaws
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+// EndpointServices is a list of service names whose endpoint can be
+// overridden.
+var EndpointServices = []string{
+	"autoscaling",
+	"ec2",
+	"ecr",
+	"ecs",
+	"elb",
+	"elbv2",
+	"iam",
+	"rds",
+	"ssm",
+	"sts",
+}
+
+// EndpointOptions customize endpoints of AWS services, so that we can run
+// myaws against a local stand-in such as LocalStack or moto.
+type EndpointOptions struct {
+	// URL is an endpoint URL for all services.
+	URL string
+	// Services is a map of a service name to an endpoint URL, which takes
+	// precedence over URL.
+	Services map[string]string
+	// NoVerifySSL disables SSL certificate verification.
+	NoVerifySSL bool
+}
+
+// validate returns an error if an unknown service is given.
+func (options EndpointOptions) validate() error {
+	valid := map[string]bool{}
+	for _, name := range EndpointServices {
+		valid[name] = true
+	}
+
+	unknown := []string{}
+	for name := range options.Services {
+		if !valid[strings.ToLower(name)] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("unknown service in endpoints: %s (valid: %s)", strings.Join(unknown, ", "), strings.Join(EndpointServices, ", "))
+	}
+	return nil
+}
+
+// endpoint returns an endpoint URL of the service or an empty string if it
+// is not overridden.
+func (options EndpointOptions) endpoint(service string) string {
+	for name, url := range options.Services {
+		if strings.ToLower(name) == service && url != "" {
+			return url
+		}
+	}
+	return options.URL
+}
+
+// serviceConfig returns a config for the service with the endpoint
+// overridden.
+func (options EndpointOptions) serviceConfig(config *aws.Config, service string) *aws.Config {
+	url := options.endpoint(service)
+	if url == "" {
+		return config
+	}
+	return config.Copy().WithEndpoint(url)
+}
+
+// httpClient returns an HTTP client which skips SSL certificate verification
+// if NoVerifySSL is set, or nil to use the default client.
+func (options EndpointOptions) httpClient() *http.Client {
+	if !options.NoVerifySSL {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,26 @@
+package myaws
+
+import (
+	"fmt"
+)
+
+// ExitError is an error with an exit code of the process, such as an exit
+// status of a remote command. If Err is nil, the error has already been
+// reported, and only the exit code matters.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error returns a message of the error.
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
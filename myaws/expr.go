@@ -0,0 +1,546 @@
+package myaws
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// expr is a parsed expression of the --where option.
+//
+// The syntax is as follows:
+//
+//	expr       := or
+//	or         := and ( "||" and )*
+//	and        := unary ( "&&" unary )*
+//	unary      := "!" unary | "(" expr ")" | comparison
+//	comparison := operand [ op operand ]
+//	op         := "==" | "!=" | "=~" | "!~" | "<" | "<=" | ">" | ">="
+//	operand    := field | string | number | duration | true | false
+//
+// A field is a name of the field registry, such as StateName, Tag:Env,
+// Tag:"In Charge" or Placement.Tenancy. A duration is a number with a unit
+// s, m, h, d or w. When a time field is compared with a duration, the age of
+// the time is compared. For example, `LaunchTime < 30d` matches instances
+// launched within 30 days.
+type expr interface {
+	eval(ctx *exprContext) bool
+}
+
+// exprContext is a context to evaluate an expression for a resource.
+type exprContext struct {
+	client   *Client
+	registry *fieldRegistry
+	resource interface{}
+	now      time.Time
+}
+
+type orExpr struct{ left, right expr }
+
+func (e *orExpr) eval(ctx *exprContext) bool { return e.left.eval(ctx) || e.right.eval(ctx) }
+
+type andExpr struct{ left, right expr }
+
+func (e *andExpr) eval(ctx *exprContext) bool { return e.left.eval(ctx) && e.right.eval(ctx) }
+
+type notExpr struct{ expr expr }
+
+func (e *notExpr) eval(ctx *exprContext) bool { return !e.expr.eval(ctx) }
+
+// operand is a field reference or a literal value.
+type operand struct {
+	field string
+	value interface{}
+}
+
+func (o *operand) values(ctx *exprContext) []interface{} {
+	if o.field == "" {
+		return []interface{}{o.value}
+	}
+	return ctx.registry.values(ctx.client, o.field, ctx.resource)
+}
+
+// truthyExpr is an operand without an operator.
+// It is true if the value is not empty and not false.
+type truthyExpr struct{ operand *operand }
+
+func (e *truthyExpr) eval(ctx *exprContext) bool {
+	for _, v := range e.operand.values(ctx) {
+		switch value := v.(type) {
+		case bool:
+			if value {
+				return true
+			}
+		case string:
+			if value != "" && value != "false" {
+				return true
+			}
+		case nil:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+type compareExpr struct {
+	op          string
+	left, right *operand
+	re          *regexp.Regexp
+}
+
+func (e *compareExpr) eval(ctx *exprContext) bool {
+	lvs := e.left.values(ctx)
+	rvs := e.right.values(ctx)
+
+	// Negative operators match if no value matches the positive one.
+	switch e.op {
+	case "!=":
+		return !anyMatch(lvs, rvs, func(l, r interface{}) bool { return e.compare(ctx, "==", l, r) })
+	case "!~":
+		return !anyMatch(lvs, rvs, func(l, r interface{}) bool { return e.compare(ctx, "=~", l, r) })
+	}
+	return anyMatch(lvs, rvs, func(l, r interface{}) bool { return e.compare(ctx, e.op, l, r) })
+}
+
+func anyMatch(lvs []interface{}, rvs []interface{}, f func(l, r interface{}) bool) bool {
+	// A field without any value is treated as an empty string.
+	if len(lvs) == 0 {
+		lvs = []interface{}{""}
+	}
+	if len(rvs) == 0 {
+		rvs = []interface{}{""}
+	}
+	for _, l := range lvs {
+		for _, r := range rvs {
+			if f(l, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *compareExpr) compare(ctx *exprContext, op string, l, r interface{}) bool {
+	if op == "=~" {
+		re := e.re
+		if re == nil {
+			var err error
+			re, err = regexp.Compile(stringValue(r))
+			if err != nil {
+				return false
+			}
+		}
+		return re.MatchString(stringValue(l))
+	}
+
+	c, ok := compareValues(ctx.now, l, r)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "==":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// compareValues compares two values and returns -1, 0 or 1.
+// It returns false if the values are not comparable.
+func compareValues(now time.Time, l, r interface{}) (int, bool) {
+	// Compare the age of time with a duration.
+	if rd, ok := r.(time.Duration); ok {
+		if lt, ok := timeValue(l); ok {
+			return compareFloat(float64(now.Sub(lt)), float64(rd)), true
+		}
+	}
+	if ld, ok := l.(time.Duration); ok {
+		if rt, ok := timeValue(r); ok {
+			return compareFloat(float64(ld), float64(now.Sub(rt))), true
+		}
+	}
+
+	// Compare times. A string is parsed as a date.
+	lt, lok := timeValue(l)
+	rt, rok := timeValue(r)
+	if lok && rok {
+		return compareFloat(float64(lt.UnixNano()), float64(rt.UnixNano())), true
+	}
+
+	// Compare numbers. A string is parsed as a number.
+	lf, lok := floatValue(l)
+	rf, rok := floatValue(r)
+	if lok && rok {
+		return compareFloat(lf, rf), true
+	}
+
+	// Otherwise, compare as strings.
+	return strings.Compare(stringValue(l), stringValue(r)), true
+}
+
+func compareFloat(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func timeValue(v interface{}) (time.Time, bool) {
+	switch value := v.(type) {
+	case time.Time:
+		return value, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05.000Z", "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case time.Duration:
+		return float64(value), true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case time.Time:
+		return value.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseExpr parses a string as an expression.
+func parseExpr(s string) (expr, error) {
+	tokens, err := tokenizeExpr(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, errors.Errorf("unexpected token %q in expression: %s", p.peek().text, s)
+	}
+	return e, nil
+}
+
+// exprFields returns a list of field names referred in the expression.
+func exprFields(e expr) []string {
+	switch v := e.(type) {
+	case *orExpr:
+		return append(exprFields(v.left), exprFields(v.right)...)
+	case *andExpr:
+		return append(exprFields(v.left), exprFields(v.right)...)
+	case *notExpr:
+		return exprFields(v.expr)
+	case *truthyExpr:
+		return operandFields(v.operand)
+	case *compareExpr:
+		return append(operandFields(v.left), operandFields(v.right)...)
+	}
+	return nil
+}
+
+func operandFields(o *operand) []string {
+	if o.field == "" {
+		return nil
+	}
+	return []string{o.field}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenOp
+	tokenField
+	tokenString
+	tokenNumber
+	tokenDuration
+	tokenBool
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"}
+
+var durationUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+func tokenizeExpr(s string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\n' {
+			i++
+			continue
+		}
+
+		if op := matchOperator(s[i:]); op != "" {
+			tokens = append(tokens, token{kind: tokenOp, text: op})
+			i += len(op)
+			continue
+		}
+
+		switch {
+		case c == '"' || c == '\'':
+			str, n, err := readQuoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: str, value: str})
+			i += n
+
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			f, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid number %q in expression: %s", s[i:j], s)
+			}
+			if j < len(s) {
+				if unit, ok := durationUnits[s[j]]; ok && (j+1 == len(s) || !isFieldChar(rune(s[j+1]))) {
+					tokens = append(tokens, token{kind: tokenDuration, text: s[i : j+1], value: time.Duration(f * float64(unit))})
+					i = j + 1
+					continue
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[i:j], value: f})
+			i = j
+
+		case unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(s) && isFieldChar(rune(s[j])) {
+				j++
+			}
+			name := s[i:j]
+			// Allow a quoted tag key such as Tag:"In Charge".
+			if name == "Tag:" && j < len(s) && (s[j] == '"' || s[j] == '\'') {
+				key, n, err := readQuoted(s[j:])
+				if err != nil {
+					return nil, err
+				}
+				name += key
+				j += n
+			}
+			switch name {
+			case "true", "false":
+				tokens = append(tokens, token{kind: tokenBool, text: name, value: name == "true"})
+			default:
+				tokens = append(tokens, token{kind: tokenField, text: name})
+			}
+			i = j
+
+		default:
+			return nil, errors.Errorf("unexpected character %q in expression: %s", c, s)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func matchOperator(s string) string {
+	for _, op := range exprOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isFieldChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.:[]-/", r)
+}
+
+// readQuoted reads a quoted string and returns the unquoted value and the
+// number of bytes consumed. A quote can be escaped by a backslash.
+func readQuoted(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.Errorf("unterminated string in expression: %s", s)
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) acceptOp(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.acceptOp("!") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: e}, nil
+	}
+
+	if p.acceptOp("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptOp(")") {
+			return nil, errors.New("missing ) in expression")
+		}
+		return e, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOp {
+		return &truthyExpr{operand: left}, nil
+	}
+	switch t.text {
+	case "==", "!=", "=~", "!~", "<", "<=", ">", ">=":
+	default:
+		return &truthyExpr{operand: left}, nil
+	}
+	p.next()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &compareExpr{op: t.text, left: left, right: right}
+	// Compile a regular expression in advance if possible.
+	if (t.text == "=~" || t.text == "!~") && right.field == "" {
+		re, err := regexp.Compile(stringValue(right.value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression in expression")
+		}
+		e.re = re
+	}
+	return e, nil
+}
+
+func (p *exprParser) parseOperand() (*operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenField:
+		return &operand{field: t.text}, nil
+	case tokenString, tokenNumber, tokenDuration, tokenBool:
+		return &operand{value: t.value}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, errors.Errorf("unexpected token %q in expression", t.text)
+}
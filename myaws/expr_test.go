@@ -0,0 +1,72 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestExprEval(t *testing.T) {
+	instance := newFakeEC2Instance("i-0001", "running", "web-prod-1", "203.0.113.1", "10.0.0.1")
+	instance.LaunchTime = aws.Time(time.Now().Add(-48 * time.Hour))
+	client, _ := newTestClient(t, Services{})
+
+	cases := []struct {
+		where string
+		want  bool
+	}{
+		{where: `StateName == "running"`, want: true},
+		{where: `StateName != "running"`, want: false},
+		{where: `Tag:Name =~ "^web-"`, want: true},
+		{where: `Tag:Name !~ "prod"`, want: false},
+		{where: `Tag:Owner == ""`, want: true},
+		{where: `Tag:Owner`, want: false},
+		{where: `PublicIpAddress`, want: true},
+		{where: `LaunchTime < 3d`, want: true},
+		{where: `LaunchTime < 1d`, want: false},
+		{where: `LaunchTime >= 1d && LaunchTime < 1w`, want: true},
+		{where: `!(StateName == "running") || Tag:Name == "web-prod-1"`, want: true},
+		{where: `StateName == "stopped" || (Tag:Name =~ "stg" && StateName == "running")`, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.where, func(t *testing.T) {
+			e, err := parseExpr(tc.where)
+			if err != nil {
+				t.Fatalf("failed to parse: %s", err)
+			}
+			ctx := &exprContext{client: client, registry: ec2InstanceFields, resource: instance, now: time.Now()}
+			if got := e.eval(ctx); got != tc.want {
+				t.Errorf("got = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseExprError(t *testing.T) {
+	cases := []string{
+		`StateName ==`,
+		`(StateName == "running"`,
+		`StateName == "running`,
+		`StateName === "running"`,
+		`StateName == "running" &&`,
+	}
+
+	for _, where := range cases {
+		if _, err := parseExpr(where); err == nil {
+			t.Errorf("parseExpr(%q) expects an error", where)
+		}
+	}
+}
+
+func TestExprFields(t *testing.T) {
+	e, err := parseExpr(`Tag:"In Charge" == "alice" && (Placement.Tenancy != "dedicated" || StateName)`)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if got, want := strings.Join(exprFields(e), ","), "Tag:In Charge,Placement.Tenancy,StateName"; got != want {
+		t.Errorf("got = %s, want %s", got, want)
+	}
+}
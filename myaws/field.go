@@ -0,0 +1,344 @@
+package myaws
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/pkg/errors"
+)
+
+// fieldFunc formats a value of the field for a given resource.
+type fieldFunc func(client *Client, resource interface{}) string
+
+// field is a named output field with a custom format.
+type field struct {
+	name   string
+	format fieldFunc
+}
+
+// fieldRegistry is a set of available output fields for a resource type.
+// In addition to the registered fields, the following fields are available
+// for all resource types:
+//   - Tag:KEY returns a value of the tag.
+//   - A path to a member of the resource such as Placement.Tenancy or
+//     SecurityGroups[].GroupName. Multiple values are joined with commas.
+type fieldRegistry struct {
+	resourceType reflect.Type
+	idField      string
+	fields       []field
+	funcs        map[string]fieldFunc
+}
+
+// newFieldRegistry returns a new registry for the type of a given resource.
+// The idField is the field printed in quiet mode.
+func newFieldRegistry(resource interface{}, idField string, fields []field) *fieldRegistry {
+	funcs := make(map[string]fieldFunc, len(fields))
+	for _, f := range fields {
+		funcs[f.name] = f.format
+	}
+
+	return &fieldRegistry{
+		resourceType: reflect.Indirect(reflect.ValueOf(resource)).Type(),
+		idField:      idField,
+		fields:       fields,
+		funcs:        funcs,
+	}
+}
+
+// outputFields returns fields to output. If quiet is true, only the id field
+// is returned.
+func (r *fieldRegistry) outputFields(quiet bool, fields []string) []string {
+	if quiet {
+		return []string{r.idField}
+	}
+	return fields
+}
+
+// validate checks all fields are available. We should call it before any
+// API call to fail fast.
+func (r *fieldRegistry) validate(fields []string) error {
+	for _, f := range fields {
+		if _, ok := r.funcs[f]; ok {
+			continue
+		}
+		if isTagField(f) || isTargetField(f) {
+			continue
+		}
+		if !hasFieldPath(r.resourceType, f) {
+			return errors.Errorf("unknown field: %s (use --list-fields to show available fields)", f)
+		}
+	}
+	return nil
+}
+
+// names returns a list of available field names. The registered fields come
+// first, followed by other members of the resource, fields of the target
+// where the resource was found and a tag field.
+func (r *fieldRegistry) names() []string {
+	names := []string{}
+	for _, f := range r.fields {
+		names = append(names, f.name)
+	}
+
+	for i := 0; i < r.resourceType.NumField(); i++ {
+		sf := r.resourceType.Field(i)
+		if sf.PkgPath != "" || sf.Anonymous {
+			// skip unexported fields and embedded _ struct{}
+			continue
+		}
+		if _, ok := r.funcs[sf.Name]; ok {
+			continue
+		}
+		names = append(names, sf.Name)
+	}
+
+	return append(names, targetFieldProfile, targetFieldRegion, "Tag:KEY")
+}
+
+// format returns a row of formatted values of the resource.
+func (r *fieldRegistry) format(client *Client, fields []string, resource interface{}) []string {
+	row := make([]string, 0, len(fields))
+	for _, f := range fields {
+		row = append(row, r.formatField(client, f, resource))
+	}
+	return row
+}
+
+func (r *fieldRegistry) formatField(client *Client, name string, resource interface{}) string {
+	resource, target := client.unwrapTargetResource(resource)
+	if f, ok := r.funcs[name]; ok {
+		return f(client, resource)
+	}
+
+	switch name {
+	case targetFieldProfile:
+		return target.profileName()
+	case targetFieldRegion:
+		return target.Region
+	}
+
+	if isTagField(name) {
+		return lookupTag(resource, strings.TrimPrefix(name, "Tag:"))
+	}
+
+	return formatFieldPath(client, resource, name)
+}
+
+// printFields prints available fields of the registry.
+func (client *Client) printFields(r *fieldRegistry) error {
+	for _, name := range r.names() {
+		fmt.Fprintln(client.stdout, name)
+	}
+	return nil
+}
+
+// isTargetField returns true if the field is a profile or region of the
+// target where the resource was found.
+func isTargetField(name string) bool {
+	return name == targetFieldProfile || name == targetFieldRegion
+}
+
+func isTagField(name string) bool {
+	return strings.HasPrefix(name, "Tag:") && len(name) > len("Tag:")
+}
+
+// lookupTag returns a value of the tag. All AWS resources which have tags
+// have a list of structs with Key and Value members, but the member name is
+// Tags or TagList depending on the resource type.
+func lookupTag(resource interface{}, key string) string {
+	v := reflect.Indirect(reflect.ValueOf(resource))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	tags := v.FieldByName("Tags")
+	if !tags.IsValid() {
+		tags = v.FieldByName("TagList")
+	}
+	if !tags.IsValid() || tags.Kind() != reflect.Slice {
+		return ""
+	}
+
+	for i := 0; i < tags.Len(); i++ {
+		tag := reflect.Indirect(tags.Index(i))
+		if tag.Kind() != reflect.Struct {
+			continue
+		}
+		k, ok := tag.FieldByName("Key").Interface().(*string)
+		if !ok || k == nil || *k != key {
+			continue
+		}
+		if value, ok := tag.FieldByName("Value").Interface().(*string); ok && value != nil {
+			return *value
+		}
+		return ""
+	}
+	return ""
+}
+
+// fieldPathComponentRe matches a component of a field path such as
+// SecurityGroups[] or SecurityGroups[0].
+var fieldPathComponentRe = regexp.MustCompile(`^([A-Z][A-Za-z0-9]*)(\[\d*\])?$`)
+
+// hasFieldPath checks a given path is reachable in the type.
+func hasFieldPath(t reflect.Type, path string) bool {
+	for _, c := range strings.Split(path, ".") {
+		m := fieldPathComponentRe.FindStringSubmatch(c)
+		if m == nil {
+			return false
+		}
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+
+		sf, ok := t.FieldByName(m[1])
+		if !ok || sf.PkgPath != "" {
+			return false
+		}
+		t = sf.Type
+
+		if m[2] != "" {
+			if t.Kind() != reflect.Slice {
+				return false
+			}
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+// formatFieldPath returns values at the path of the resource.
+// The path is evaluated as a JMESPath expression in the same way as waiters.
+func formatFieldPath(client *Client, resource interface{}, path string) string {
+	values, err := awsutil.ValuesAtPath(resource, path)
+	if err != nil {
+		return ""
+	}
+
+	output := []string{}
+	for _, v := range values {
+		if s := formatFieldValue(client, v); s != "" {
+			output = append(output, s)
+		}
+	}
+	return strings.Join(output, ",")
+}
+
+func formatFieldValue(client *Client, v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case *time.Time:
+		return client.FormatTime(value)
+	case time.Time:
+		return client.FormatTime(&value)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map:
+		// compact the pretty printed representation into a single line.
+		return strings.Join(strings.Fields(awsutil.Prettify(rv.Interface())), " ")
+	default:
+		return fmt.Sprint(rv.Interface())
+	}
+}
+
+// values returns raw values of the field to evaluate expressions and sort.
+// A path to a member of the resource returns raw values such as time.Time
+// and int64 instead of formatted strings, so that they can be compared.
+// If a registered field is not a path or points at a struct, the formatted
+// value is used.
+func (r *fieldRegistry) values(client *Client, name string, resource interface{}) []interface{} {
+	if _, registered := r.funcs[name]; !registered && isTargetField(name) {
+		return []interface{}{r.formatField(client, name, resource)}
+	}
+
+	resource, _ = client.unwrapTargetResource(resource)
+	if isTagField(name) {
+		return []interface{}{lookupTag(resource, strings.TrimPrefix(name, "Tag:"))}
+	}
+
+	f, registered := r.funcs[name]
+	if hasFieldPath(r.resourceType, name) {
+		raw, err := awsutil.ValuesAtPath(resource, name)
+		if err == nil {
+			values := []interface{}{}
+			hasStruct := false
+			for _, v := range raw {
+				value, isStruct := normalizeFieldValue(client, v)
+				if value == nil {
+					continue
+				}
+				hasStruct = hasStruct || isStruct
+				values = append(values, value)
+			}
+			if !registered || !hasStruct {
+				return values
+			}
+		}
+	}
+
+	if registered {
+		return []interface{}{strings.TrimSpace(f(client, resource))}
+	}
+	return nil
+}
+
+// normalizeFieldValue converts a value returned by aws-sdk-go into one of
+// string, bool, int64, float64 or time.Time. A struct or a collection is
+// converted into a formatted string and reported by the second return value.
+func normalizeFieldValue(client *Client, v interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, false
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), false
+	case reflect.Bool:
+		return rv.Bool(), false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), false
+	}
+
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t, false
+	}
+	return formatFieldValue(client, v), true
+}
+
+// formatRows returns rows of formatted values of resources.
+func (r *fieldRegistry) formatRows(client *Client, fields []string, resources []interface{}) [][]string {
+	rows := [][]string{}
+	for _, resource := range resources {
+		rows = append(rows, r.format(client, fields, resource))
+	}
+	return rows
+}
@@ -0,0 +1,301 @@
+package myaws
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+)
+
+// highImpactThreshold is the number of resources changed at once from which
+// the user has to type a confirmation instead of y/n.
+const highImpactThreshold = 5
+
+// GuardOptions customize the safety guard of destructive operations.
+type GuardOptions struct {
+	// Yes skips confirmation prompts for automation.
+	Yes bool
+	// ProtectedTags are tags of resources which must not be changed, such as
+	// Env=production. A value can be a glob pattern.
+	ProtectedTags []string
+	// ProtectedNames are glob patterns of resource names which must not be
+	// changed, such as names of autoscaling groups, ECS clusters and services,
+	// IAM users, and EC2 instance IDs.
+	ProtectedNames []string
+	// ProtectedSSMPaths are path prefixes of SSM parameters which must not be
+	// changed, such as /prod/.
+	ProtectedSSMPaths []string
+}
+
+// validate returns an error if the options are invalid.
+func (guard GuardOptions) validate() error {
+	for _, tag := range guard.ProtectedTags {
+		if !strings.Contains(tag, "=") {
+			return errors.Errorf("invalid protected tag: %s (must be KEY=VALUE)", tag)
+		}
+		if _, err := path.Match(strings.SplitN(tag, "=", 2)[1], ""); err != nil {
+			return errors.Wrapf(err, "invalid protected tag: %s:", tag)
+		}
+	}
+	for _, name := range guard.ProtectedNames {
+		if _, err := path.Match(name, ""); err != nil {
+			return errors.Wrapf(err, "invalid protected name: %s:", name)
+		}
+	}
+	return nil
+}
+
+// checkNames returns an error if any of the names is protected.
+func (guard GuardOptions) checkNames(kind string, names ...string) error {
+	for _, name := range names {
+		for _, pattern := range guard.ProtectedNames {
+			if ok, _ := path.Match(pattern, name); ok {
+				return errors.Errorf("refused to change the protected %s %s (matches name %s)", kind, name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// checkTags returns an error if the resource has a protected tag.
+func (guard GuardOptions) checkTags(kind string, name string, tags map[string]string) error {
+	for _, tag := range guard.ProtectedTags {
+		kv := strings.SplitN(tag, "=", 2)
+		value, ok := tags[kv[0]]
+		if !ok {
+			continue
+		}
+		if matched, _ := path.Match(kv[1], value); matched {
+			return errors.Errorf("refused to change the protected %s %s (matches tag %s)", kind, name, tag)
+		}
+	}
+	return nil
+}
+
+// checkSSMPaths returns an error if any of the parameters is protected.
+func (guard GuardOptions) checkSSMPaths(names ...string) error {
+	for _, name := range names {
+		for _, prefix := range guard.ProtectedSSMPaths {
+			if strings.HasPrefix(name, prefix) {
+				return errors.Errorf("refused to change the protected SSM parameter %s (matches path %s)", name, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// confirm asks user for confirmation of a destructive operation.
+// It returns true without asking if --yes is given or in dry-run mode.
+func (client *Client) confirm(message string) (bool, error) {
+	if client.guard.Yes || client.dryRun {
+		return true, nil
+	}
+
+	ok, err := client.Confirmation(message)
+	if err == nil && !ok {
+		fmt.Fprintln(client.stdout, "Cancelled.")
+	}
+	return ok, err
+}
+
+// confirmName asks user to type the name of a resource for confirmation of
+// a high impact operation.
+// It returns true without asking if --yes is given or in dry-run mode.
+func (client *Client) confirmName(message string, name string) (bool, error) {
+	if client.guard.Yes || client.dryRun {
+		return true, nil
+	}
+
+	fmt.Fprintf(client.stdout, "%s\nType %s to confirm: ", message, name)
+
+	reader := bufio.NewReader(client.stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false, errors.Wrap(err, "ReadString failed:")
+	}
+
+	if strings.TrimSpace(input) != name {
+		fmt.Fprintln(client.stdout, "Cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// guardEC2Instances returns an error if any of the instances is protected.
+func (client *Client) guardEC2Instances(instanceIds []*string) error {
+	if err := client.guard.checkNames("EC2 instance", aws.StringValueSlice(instanceIds)...); err != nil {
+		return err
+	}
+	if len(client.guard.ProtectedTags) == 0 {
+		return nil
+	}
+
+	var guardErr error
+	err := client.EC2.DescribeInstancesPagesWithContext(aws.BackgroundContext(), &ec2.DescribeInstancesInput{InstanceIds: instanceIds},
+		func(p *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, r := range p.Reservations {
+				for _, instance := range r.Instances {
+					tags := map[string]string{}
+					for _, t := range instance.Tags {
+						tags[*t.Key] = *t.Value
+					}
+					if guardErr = client.guard.checkTags("EC2 instance", *instance.InstanceId, tags); guardErr != nil {
+						return false
+					}
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return errors.Wrap(err, "DescribeInstances failed:")
+	}
+	return guardErr
+}
+
+// guardAutoScalingGroup returns an error if the autoscaling group is
+// protected.
+func (client *Client) guardAutoScalingGroup(asgName string) error {
+	if err := client.guard.checkNames("autoscaling group", asgName); err != nil {
+		return err
+	}
+	if len(client.guard.ProtectedTags) == 0 {
+		return nil
+	}
+
+	response, err := client.AutoScaling.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{&asgName},
+	})
+	if err != nil {
+		return errors.Wrap(err, "DescribeAutoScalingGroups failed:")
+	}
+	for _, group := range response.AutoScalingGroups {
+		tags := map[string]string{}
+		for _, t := range group.Tags {
+			tags[*t.Key] = *t.Value
+		}
+		if err := client.guard.checkTags("autoscaling group", asgName, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardECSCluster returns an error if the ECS cluster is protected.
+func (client *Client) guardECSCluster(cluster string) error {
+	if err := client.guard.checkNames("ECS cluster", cluster); err != nil {
+		return err
+	}
+	if len(client.guard.ProtectedTags) == 0 {
+		return nil
+	}
+
+	response, err := client.ECS.DescribeClusters(&ecs.DescribeClustersInput{
+		Clusters: []*string{&cluster},
+		Include:  aws.StringSlice([]string{"TAGS"}),
+	})
+	if err != nil {
+		return errors.Wrap(err, "DescribeClusters failed:")
+	}
+	for _, c := range response.Clusters {
+		tags := map[string]string{}
+		for _, t := range c.Tags {
+			tags[*t.Key] = *t.Value
+		}
+		if err := client.guard.checkTags("ECS cluster", cluster, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardECSService returns an error if the ECS service is protected.
+func (client *Client) guardECSService(cluster string, service string) error {
+	if err := client.guard.checkNames("ECS service", service); err != nil {
+		return err
+	}
+	if len(client.guard.ProtectedTags) == 0 {
+		return nil
+	}
+	return client.guardECSServicesByDescribe(cluster, []*string{&service})
+}
+
+// guardECSClusterServices returns an error if any of the services of the ECS
+// cluster is protected. Operations on container instances, such as renew,
+// move tasks of all the services.
+func (client *Client) guardECSClusterServices(cluster string) error {
+	if len(client.guard.ProtectedNames) == 0 && len(client.guard.ProtectedTags) == 0 {
+		return nil
+	}
+
+	serviceArns := []*string{}
+	err := client.ECS.ListServicesPages(&ecs.ListServicesInput{Cluster: &cluster},
+		func(p *ecs.ListServicesOutput, lastPage bool) bool {
+			serviceArns = append(serviceArns, p.ServiceArns...)
+			return true
+		})
+	if err != nil {
+		return errors.Wrap(err, "ListServices failed:")
+	}
+	return client.guardECSServicesByDescribe(cluster, serviceArns)
+}
+
+// guardECSServicesByDescribe describes the services with tags and returns an
+// error if any of them is protected.
+func (client *Client) guardECSServicesByDescribe(cluster string, services []*string) error {
+	// We can specify up to 10 services to describe in a single operation.
+	chunkSize := 10
+	for i := 0; i < len(services); i += chunkSize {
+		end := i + chunkSize
+		if end > len(services) {
+			end = len(services)
+		}
+		response, err := client.ECS.DescribeServices(&ecs.DescribeServicesInput{
+			Cluster:  &cluster,
+			Services: services[i:end],
+			Include:  aws.StringSlice([]string{"TAGS"}),
+		})
+		if err != nil {
+			return errors.Wrap(err, "DescribeServices failed:")
+		}
+		for _, s := range response.Services {
+			if err := client.guard.checkNames("ECS service", *s.ServiceName); err != nil {
+				return err
+			}
+			tags := map[string]string{}
+			for _, t := range s.Tags {
+				tags[*t.Key] = *t.Value
+			}
+			if err := client.guard.checkTags("ECS service", *s.ServiceName, tags); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// guardIAMUser returns an error if the IAM user is protected.
+func (client *Client) guardIAMUser(user *iam.User) error {
+	if err := client.guard.checkNames("IAM user", *user.UserName); err != nil {
+		return err
+	}
+	tags := map[string]string{}
+	for _, t := range user.Tags {
+		tags[*t.Key] = *t.Value
+	}
+	return client.guard.checkTags("IAM user", *user.UserName, tags)
+}
+
+// guardSSMParameters returns an error if any of the parameters is protected.
+func (client *Client) guardSSMParameters(names ...string) error {
+	if err := client.guard.checkNames("SSM parameter", names...); err != nil {
+		return err
+	}
+	return client.guard.checkSSMPaths(names...)
+}
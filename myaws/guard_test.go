@@ -0,0 +1,249 @@
+package myaws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestGuardOptionsValidate(t *testing.T) {
+	cases := []struct {
+		desc  string
+		guard GuardOptions
+		ok    bool
+	}{
+		{desc: "valid", guard: GuardOptions{ProtectedTags: []string{"Env=prod*"}, ProtectedNames: []string{"prod-*"}}, ok: true},
+		{desc: "tag without value", guard: GuardOptions{ProtectedTags: []string{"Env"}}, ok: false},
+		{desc: "bad pattern", guard: GuardOptions{ProtectedNames: []string{"prod-["}}, ok: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.guard.validate()
+			if tc.ok && err != nil {
+				t.Errorf("unexpected err: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Error("expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	cases := []struct {
+		desc      string
+		input     string
+		wantState string
+		wantErr   bool
+	}{
+		{desc: "yes", input: "y\n", wantState: "stopped"},
+		{desc: "no", input: "n\n", wantState: "running"},
+		{desc: "no input", input: "", wantState: "running", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeEC2WithInstances()
+			client, stdout := newTestClient(t, Services{EC2: f})
+			client.guard.Yes = false
+			client.stdin = strings.NewReader(tc.input)
+
+			err := client.EC2Stop(EC2StopOptions{InstanceIds: aws.StringSlice([]string{"i-0001"})})
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if got := *f.instances[0].State.Name; got != tc.wantState {
+				t.Errorf("state = %s, want %s", got, tc.wantState)
+			}
+			if !strings.Contains(stdout.String(), "Are you sure want to stop instances [i-0001]? [y/n]: ") {
+				t.Errorf("unexpected stdout: %s", stdout)
+			}
+		})
+	}
+}
+
+func TestConfirmName(t *testing.T) {
+	names := []string{"/app/a", "/app/b", "/app/c", "/app/d", "/app/e"}
+
+	cases := []struct {
+		desc        string
+		input       string
+		wantDeleted bool
+	}{
+		{desc: "match", input: "5\n", wantDeleted: true},
+		{desc: "mismatch", input: "y\n", wantDeleted: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("/app/a", "1", "/app/b", "2", "/app/c", "3", "/app/d", "4", "/app/e", "5")
+			client, stdout := newTestClient(t, Services{SSM: f})
+			client.guard.Yes = false
+			client.stdin = strings.NewReader(tc.input)
+
+			if err := client.SSMParameterDel(SSMParameterDelOptions{Names: names}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			if deleted := len(f.parameters) == 0; deleted != tc.wantDeleted {
+				t.Errorf("deleted = %t, want %t: %v", deleted, tc.wantDeleted, f.parameters)
+			}
+			if !strings.Contains(stdout.String(), "Type 5 to confirm: ") {
+				t.Errorf("unexpected stdout: %s", stdout)
+			}
+		})
+	}
+}
+
+func TestECSNodeRenewConfirmName(t *testing.T) {
+	setTestHome(t)
+	c := newFakeCluster(2, newFakeECSService("web", 1, ""))
+	client, stdout := newTestClient(t, newFakeRenewServices(c, &fakeELBV2{}))
+	client.guard.Yes = false
+	client.stdin = strings.NewReader("y\n")
+
+	err := client.ECSNodeRenew(ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if !strings.Contains(stdout.String(), "Type test-cluster to confirm: ") || !strings.Contains(stdout.String(), "Cancelled.") {
+		t.Errorf("unexpected stdout: %s", stdout)
+	}
+	if len(c.setDesiredCapacityCalls) != 0 {
+		t.Errorf("SetDesiredCapacity should not be called: %v", c.setDesiredCapacityCalls)
+	}
+}
+
+func TestProtectedResources(t *testing.T) {
+	cases := []struct {
+		desc  string
+		guard GuardOptions
+		run   func(client *Client, c *fakeCluster) error
+		want  string
+	}{
+		{
+			desc:  "instance tag",
+			guard: GuardOptions{ProtectedTags: []string{"Env=prod*"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.EC2Stop(EC2StopOptions{InstanceIds: aws.StringSlice([]string{"i-0001", "i-0002"})})
+			},
+			want: "refused to change the protected EC2 instance i-0002 (matches tag Env=prod*)",
+		},
+		{
+			desc:  "ssm run on instance tag",
+			guard: GuardOptions{ProtectedTags: []string{"Env=prod*"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.SSMRun(SSMRunOptions{FilterTag: "Name:web", Command: "uptime"})
+			},
+			want: "refused to change the protected EC2 instance i-0002 (matches tag Env=prod*)",
+		},
+		{
+			desc:  "autoscaling group tag",
+			guard: GuardOptions{ProtectedTags: []string{"Env=production"}},
+			run: func(client *Client, c *fakeCluster) error {
+				c.asgTags = map[string]string{"Env": "production"}
+				return client.AutoscalingUpdate(AutoscalingUpdateOptions{AsgName: c.asgName, DesiredCapacity: 0})
+			},
+			want: "refused to change the protected autoscaling group test-asg (matches tag Env=production)",
+		},
+		{
+			desc:  "cluster tag",
+			guard: GuardOptions{ProtectedTags: []string{"Env=production"}},
+			run: func(client *Client, c *fakeCluster) error {
+				c.clusterTags = map[string]string{"Env": "production"}
+				return client.ECSNodeRenew(ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute})
+			},
+			want: "refused to change the protected ECS cluster test-cluster (matches tag Env=production)",
+		},
+		{
+			desc:  "service tag",
+			guard: GuardOptions{ProtectedTags: []string{"Env=production"}},
+			run: func(client *Client, c *fakeCluster) error {
+				c.services[0].Tags = []*ecs.Tag{{Key: aws.String("Env"), Value: aws.String("production")}}
+				return client.ECSServiceUpdate(ECSServiceUpdateOptions{Cluster: c.cluster, Service: "web", DesiredCount: aws.Int64(0)})
+			},
+			want: "refused to change the protected ECS service web (matches tag Env=production)",
+		},
+		{
+			desc:  "service tag on renew",
+			guard: GuardOptions{ProtectedTags: []string{"Env=production"}},
+			run: func(client *Client, c *fakeCluster) error {
+				c.services[0].Tags = []*ecs.Tag{{Key: aws.String("Env"), Value: aws.String("production")}}
+				return client.ECSNodeRenew(ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute})
+			},
+			want: "refused to change the protected ECS service web (matches tag Env=production)",
+		},
+		{
+			desc:  "service name on renew",
+			guard: GuardOptions{ProtectedNames: []string{"web"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.ECSNodeRenew(ECSNodeRenewOptions{Cluster: c.cluster, AsgName: c.asgName, Timeout: time.Minute})
+			},
+			want: "refused to change the protected ECS service web (matches name web)",
+		},
+		{
+			desc:  "cluster name",
+			guard: GuardOptions{ProtectedNames: []string{"test-*"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.ECSNodeDrain(ECSNodeDrainOptions{Cluster: c.cluster, ContainerInstances: aws.StringSlice([]string{"i-0001"})})
+			},
+			want: "refused to change the protected ECS cluster test-cluster (matches name test-*)",
+		},
+		{
+			desc:  "ssm path",
+			guard: GuardOptions{ProtectedSSMPaths: []string{"/prod/"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.SSMParameterPut(SSMParameterPutOptions{Name: "/prod/db/password", Value: "new"})
+			},
+			want: "refused to change the protected SSM parameter /prod/db/password (matches path /prod/)",
+		},
+		{
+			desc:  "iam user tag",
+			guard: GuardOptions{ProtectedTags: []string{"Role=admin"}},
+			run: func(client *Client, c *fakeCluster) error {
+				return client.IAMUserResetPassword(IAMUserResetPasswordOptions{UserName: "root-admin"})
+			},
+			want: "refused to change the protected IAM user root-admin (matches tag Role=admin)",
+		},
+	}
+
+	for _, tc := range cases {
+		for _, dryRun := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (dry-run: %t)", tc.desc, dryRun), func(t *testing.T) {
+				setTestHome(t)
+				c := newFakeCluster(2, newFakeECSService("web", 1, ""))
+				ec2Fake := newFakeEC2WithInstances()
+				ec2Fake.instances[1].Tags = append(ec2Fake.instances[1].Tags, &ec2.Tag{Key: aws.String("Env"), Value: aws.String("production")})
+				iamFake := &fakeIAM{users: []*iam.User{{UserName: aws.String("root-admin"), Tags: []*iam.Tag{{Key: aws.String("Role"), Value: aws.String("admin")}}}}}
+				ssmFake := newFakeSSM("/prod/db/password", "old")
+				services := newFakeRenewServices(c, &fakeELBV2{})
+				services.EC2 = ec2Fake
+				services.IAM = iamFake
+				services.SSM = ssmFake
+				client, _ := newTestClient(t, services)
+				client.guard = tc.guard
+				client.dryRun = dryRun
+				// Protection can't be skipped by --yes.
+				client.guard.Yes = true
+
+				err := tc.run(client, c)
+				if err == nil || !strings.Contains(err.Error(), tc.want) {
+					t.Fatalf("expected an error %q, but got: %v", tc.want, err)
+				}
+
+				if *ec2Fake.instances[0].State.Name != "running" || len(c.setDesiredCapacityCalls) != 0 || len(c.updateStateCalls) != 0 || len(c.updateServiceInputs) != 0 || *ssmFake.find("/prod/db/password").Value != "old" || len(ssmFake.commandInputs) != 0 {
+					t.Error("protected resources should not change")
+				}
+			})
+		}
+	}
+}
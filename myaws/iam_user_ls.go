@@ -1,26 +1,44 @@
 package myaws
 
 import (
-	"fmt"
-
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/pkg/errors"
 )
 
+// IAMUserLsOptions customize the behavior of the Ls command.
+type IAMUserLsOptions struct {
+	MaxItems int
+}
+
 // IAMUserLs describes IAM users
-func (client *Client) IAMUserLs() error {
-	response, err := client.IAM.ListUsers(&iam.ListUsersInput{})
+func (client *Client) IAMUserLs(options IAMUserLsOptions) error {
+	if options.MaxItems < 0 {
+		return errors.Errorf("--max-items must be a positive number: %d", options.MaxItems)
+	}
+
+	var users []*iam.User
+	err := client.IAM.ListUsersPagesWithContext(aws.BackgroundContext(), &iam.ListUsersInput{},
+		func(page *iam.ListUsersOutput, lastPage bool) bool {
+			users = append(users, page.Users...)
+			return !reachedMaxItems(len(users), options.MaxItems)
+		})
 	if err != nil {
 		return errors.Wrap(err, "ListUsers failed:")
 	}
 
-	// TODO: support pagenation
-	for _, user := range response.Users {
-		fmt.Fprintln(client.stdout, formatIAMUser(client, user))
+	if reachedMaxItems(len(users), options.MaxItems) {
+		users = users[:options.MaxItems]
+	}
+
+	fields := []string{"UserName", "CreateDate", "PasswordLastUsed"}
+	rows := [][]string{}
+	for _, user := range users {
+		rows = append(rows, formatIAMUser(client, user))
 	}
-	return nil
+	return client.printRows(fields, rows, false)
 }
 
-func formatIAMUser(client *Client, user *iam.User) string {
-	return fmt.Sprintf("%s\t%s\t%s", *user.UserName, client.FormatTime(user.CreateDate), client.FormatTime(user.PasswordLastUsed))
+func formatIAMUser(client *Client, user *iam.User) []string {
+	return []string{*user.UserName, client.FormatTime(user.CreateDate), client.FormatTime(user.PasswordLastUsed)}
 }
@@ -0,0 +1,49 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestIAMUserLs(t *testing.T) {
+	f := &fakeIAM{
+		users: []*iam.User{
+			{UserName: aws.String("alice"), CreateDate: aws.Time(testTime), PasswordLastUsed: aws.Time(testTime.AddDate(0, 1, 0))},
+			{UserName: aws.String("bob"), CreateDate: aws.Time(testTime)},
+			{UserName: aws.String("carol"), CreateDate: aws.Time(testTime)},
+		},
+		pageSize: 2,
+	}
+
+	cases := []struct {
+		desc     string
+		maxItems int
+		want     string
+	}{
+		{
+			desc: "all",
+			want: "alice\t2021-05-01 12:00:00\t2021-06-01 12:00:00\n" +
+				"bob\t2021-05-01 12:00:00\t\n" +
+				"carol\t2021-05-01 12:00:00\t\n",
+		},
+		{
+			desc:     "max items",
+			maxItems: 1,
+			want:     "alice\t2021-05-01 12:00:00\t2021-06-01 12:00:00\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{IAM: f})
+			if err := client.IAMUserLs(IAMUserLsOptions{MaxItems: tc.maxItems}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -39,15 +39,16 @@ func (client *Client) IAMUserResetPassword(options IAMUserResetPasswordOptions)
 
 	fmt.Fprintf(client.stdout, "%v\n", user)
 
-	confirm, err := client.Confirmation("Are you sure want to reset password?")
-	if err != nil {
+	if err := client.guardIAMUser(user); err != nil {
 		return err
 	}
 
-	if !confirm {
-		// cancel reset password.
-		fmt.Fprintln(client.stdout, "Cancelled.")
-		return nil
+	if client.dryRun {
+		return client.planIAMUserResetPassword(options.UserName)
+	}
+
+	if ok, err := client.confirm("Are you sure want to reset password?"); !ok {
+		return err
 	}
 
 	password := generateRandomPassword(16)
@@ -108,6 +109,7 @@ func (client *Client) IAMUserCreateLoginProfile(username string, password string
 	}
 
 	_, err := client.IAM.CreateLoginProfile(params)
+	client.auditCall("IAM.CreateLoginProfile", params, []string{username}, err)
 	if err != nil {
 		return errors.Wrap(err, "CreateLoginProfile failed:")
 	}
@@ -124,9 +126,28 @@ func (client *Client) IAMUserUpdatePassword(username string, password string, ch
 	}
 
 	_, err := client.IAM.UpdateLoginProfile(params)
+	client.auditCall("IAM.UpdateLoginProfile", params, []string{username}, err)
 	if err != nil {
 		return errors.Wrap(err, "UpdateLoginProfile failed:")
 	}
 
 	return nil
 }
+
+// planIAMUserResetPassword prints a plan to reset the password.
+func (client *Client) planIAMUserResetPassword(username string) error {
+	_, err := client.IAM.GetLoginProfile(&iam.GetLoginProfileInput{
+		UserName: aws.String(username),
+	})
+
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "NoSuchEntity" {
+			client.printPlan("IAM.CreateLoginProfile", "%s Password => (random), PasswordResetRequired => true", username)
+			return nil
+		}
+		return err
+	}
+
+	client.printPlan("IAM.UpdateLoginProfile", "%s Password => (random), PasswordResetRequired => true", username)
+	return nil
+}
@@ -0,0 +1,65 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestIAMUserResetPassword(t *testing.T) {
+	cases := []struct {
+		desc            string
+		hasLoginProfile bool
+		input           string
+		want            string
+		wantChanged     bool
+	}{
+		{desc: "create login profile", hasLoginProfile: false, input: "y\n", want: "InitialPassword: ", wantChanged: true},
+		{desc: "update password", hasLoginProfile: true, input: "Y\n", want: "NewPassword: ", wantChanged: true},
+		{desc: "cancel", hasLoginProfile: true, input: "n\n", want: "Cancelled.", wantChanged: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := &fakeIAM{users: []*iam.User{{UserName: aws.String("alice")}}}
+			if tc.hasLoginProfile {
+				f.setLoginProfile("alice", "old", false)
+			}
+			client, stdout := newTestClient(t, Services{IAM: f})
+			client.guard.Yes = false
+			client.stdin = strings.NewReader(tc.input)
+
+			if err := client.IAMUserResetPassword(IAMUserResetPasswordOptions{UserName: "alice"}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			if got := stdout.String(); !strings.Contains(got, tc.want) {
+				t.Errorf("stdout doesn't contain %q:\n%s", tc.want, got)
+			}
+			password, ok := f.loginProfiles["alice"]
+			changed := ok && password != "old"
+			if changed != tc.wantChanged {
+				t.Fatalf("password changed = %t, want %t", changed, tc.wantChanged)
+			}
+			if changed {
+				if len(password) != 16 || !strings.Contains(stdout.String(), password) {
+					t.Errorf("unexpected password: %q", password)
+				}
+				if !f.passwordResetRequired["alice"] {
+					t.Error("password reset should be required")
+				}
+			}
+		})
+	}
+}
+
+func TestIAMUserResetPasswordNoSuchUser(t *testing.T) {
+	client, _ := newTestClient(t, Services{IAM: &fakeIAM{}})
+
+	err := client.IAMUserResetPassword(IAMUserResetPasswordOptions{UserName: "alice"})
+	if err == nil || !strings.Contains(err.Error(), "GetUser failed") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package myaws
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Supported output formats.
+const (
+	OutputFormatTSV   = "tsv"
+	OutputFormatCSV   = "csv"
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
+)
+
+// OutputFormats is a list of supported output formats.
+var OutputFormats = []string{
+	OutputFormatTSV,
+	OutputFormatCSV,
+	OutputFormatTable,
+	OutputFormatJSON,
+	OutputFormatYAML,
+}
+
+func validateOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown output format: %s (valid values are %s)", format, strings.Join(OutputFormats, "|"))
+}
+
+// printRows renders rows with named fields to stdout in the output format of
+// the client. Each row must have the same length as fields.
+// The tsv format is the traditional output of myaws, so the header is printed
+// only if printHeader is true. Other formats always have field names.
+func (client *Client) printRows(fields []string, rows [][]string, printHeader bool) error {
+	switch client.output {
+	case OutputFormatCSV:
+		return printRowsAsCSV(client, fields, rows)
+	case OutputFormatTable:
+		return printRowsAsTable(client, fields, rows)
+	case OutputFormatJSON:
+		return printRowsAsJSON(client, fields, rows)
+	case OutputFormatYAML:
+		return printRowsAsYAML(client, fields, rows)
+	default:
+		return printRowsAsTSV(client, fields, rows, printHeader)
+	}
+}
+
+func printRowsAsTSV(client *Client, fields []string, rows [][]string, printHeader bool) error {
+	if printHeader {
+		fmt.Fprintln(client.stdout, strings.Join(fields, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(client.stdout, strings.Join(row, "\t"))
+	}
+	return nil
+}
+
+func printRowsAsCSV(client *Client, fields []string, rows [][]string) error {
+	w := csv.NewWriter(client.stdout)
+	if err := w.Write(fields); err != nil {
+		return errors.Wrap(err, "failed to write csv:")
+	}
+	for _, row := range rows {
+		if err := w.Write(trimRow(row)); err != nil {
+			return errors.Wrap(err, "failed to write csv:")
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printRowsAsTable(client *Client, fields []string, rows [][]string) error {
+	w := tabwriter.NewWriter(client.stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(trimRow(row), "\t"))
+	}
+	return w.Flush()
+}
+
+func printRowsAsJSON(client *Client, fields []string, rows [][]string) error {
+	// Use jsonRecord instead of map to keep the order of fields.
+	records := []jsonRecord{}
+	for _, row := range rows {
+		records = append(records, jsonRecord{fields: fields, values: trimRow(row)})
+	}
+
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json:")
+	}
+	fmt.Fprintln(client.stdout, string(b))
+	return nil
+}
+
+func printRowsAsYAML(client *Client, fields []string, rows [][]string) error {
+	// Use yaml.MapSlice instead of map to keep the order of fields.
+	records := []yaml.MapSlice{}
+	for _, row := range rows {
+		record := yaml.MapSlice{}
+		for i, v := range trimRow(row) {
+			record = append(record, yaml.MapItem{Key: fields[i], Value: v})
+		}
+		records = append(records, record)
+	}
+
+	b, err := yaml.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal yaml:")
+	}
+	fmt.Fprint(client.stdout, string(b))
+	return nil
+}
+
+// jsonRecord is a row which is marshaled to a JSON object with keys in order
+// of the fields.
+type jsonRecord struct {
+	fields []string
+	values []string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r jsonRecord) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, v := range r.values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(r.fields[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// trimRow removes paddings for the tsv format from values.
+func trimRow(row []string) []string {
+	trimmed := make([]string, len(row))
+	for i, v := range row {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return trimmed
+}
@@ -0,0 +1,135 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestPrintRows(t *testing.T) {
+	fields := []string{"InstanceId", "InstanceType"}
+	rows := [][]string{
+		{"i-0001", "t3.micro   "},
+		{"i-0002", "m5.large   "},
+	}
+
+	cases := []struct {
+		output      string
+		printHeader bool
+		want        string
+	}{
+		{
+			output: OutputFormatTSV,
+			want:   "i-0001\tt3.micro   \ni-0002\tm5.large   \n",
+		},
+		{
+			output:      OutputFormatTSV,
+			printHeader: true,
+			want:        "InstanceId\tInstanceType\ni-0001\tt3.micro   \ni-0002\tm5.large   \n",
+		},
+		{
+			output: OutputFormatCSV,
+			want:   "InstanceId,InstanceType\ni-0001,t3.micro\ni-0002,m5.large\n",
+		},
+		{
+			output: OutputFormatTable,
+			want:   "InstanceId  InstanceType\ni-0001      t3.micro\ni-0002      m5.large\n",
+		},
+		{
+			output: OutputFormatJSON,
+			want: `[
+  {
+    "InstanceId": "i-0001",
+    "InstanceType": "t3.micro"
+  },
+  {
+    "InstanceId": "i-0002",
+    "InstanceType": "m5.large"
+  }
+]
+`,
+		},
+		{
+			output: OutputFormatYAML,
+			want: `- InstanceId: i-0001
+  InstanceType: t3.micro
+- InstanceId: i-0002
+  InstanceType: m5.large
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.output, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{})
+			client.output = tc.output
+
+			if err := client.printRows(fields, rows, tc.printHeader); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrintRowsFieldOrder(t *testing.T) {
+	fields := []string{"Name", "InstanceId"}
+	rows := [][]string{{"web", "i-0001"}}
+
+	cases := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: OutputFormatJSON,
+			want: `[
+  {
+    "Name": "web",
+    "InstanceId": "i-0001"
+  }
+]
+`,
+		},
+		{
+			output: OutputFormatYAML,
+			want:   "- Name: web\n  InstanceId: i-0001\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.output, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{})
+			client.output = tc.output
+
+			if err := client.printRows(fields, rows, false); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrintRowsEmpty(t *testing.T) {
+	client, stdout := newTestClient(t, Services{})
+	client.output = OutputFormatJSON
+
+	if err := client.printRows([]string{"InstanceId"}, [][]string{}, false); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got, want := stdout.String(), "[]\n"; got != want {
+		t.Errorf("got = %q, want %q", got, want)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, f := range OutputFormats {
+		if err := validateOutputFormat(f); err != nil {
+			t.Errorf("validateOutputFormat(%q) returns an error: %s", f, err)
+		}
+	}
+	if err := validateOutputFormat("xml"); err == nil {
+		t.Error("validateOutputFormat(\"xml\") expects an error")
+	}
+}
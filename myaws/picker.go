@@ -0,0 +1,341 @@
+package myaws
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// pickerHeight is the maximum number of candidates shown at once.
+const pickerHeight = 15
+
+// Keys of the picker.
+const (
+	keyCtrlC     = 0x03
+	keyCtrlG     = 0x07
+	keyBackspace = 0x08
+	keyTab       = 0x09
+	keyLF        = 0x0a
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyCR        = 0x0d
+	keyCtrlU     = 0x15
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// errPickerCancelled is returned when the selection is cancelled.
+var errPickerCancelled = errors.New("selection cancelled")
+
+// picker is a state of the interactive fuzzy finder.
+type picker struct {
+	header string
+	lines  []string
+	multi  bool
+
+	query   []rune
+	matches []int
+	cursor  int
+	offset  int
+	marked  map[int]bool
+	drawn   int
+	width   int
+}
+
+// pick shows rows in a fuzzy finder on the terminal and returns indexes of
+// the chosen rows. If multi is true, rows can be marked with Tab to choose
+// more than one. If there is only one row, it is returned without asking.
+// The finder is drawn on stderr, so that stdout can be piped.
+func (client *Client) pick(fields []string, rows [][]string, multi bool) ([]int, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("no candidates found")
+	}
+	if len(rows) == 1 {
+		return []int{0}, nil
+	}
+
+	header, lines := formatPickerLines(fields, rows)
+	p := &picker{
+		header: header,
+		lines:  lines,
+		multi:  multi,
+		marked: map[int]bool{},
+	}
+
+	if f, ok := client.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set terminal to raw mode:")
+		}
+		defer term.Restore(int(f.Fd()), state)
+
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+			p.width = width
+		}
+	}
+
+	return p.run(client.stdin, client.stderr)
+}
+
+// formatPickerLines aligns the header and rows in columns.
+func formatPickerLines(fields []string, rows [][]string) (string, []string) {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return lines[0], lines[1:]
+}
+
+// run reads keys until the selection is accepted or cancelled.
+// A read from a terminal in raw mode returns a chunk of bytes for each key,
+// so that a lone escape can be distinguished from an escape sequence.
+func (p *picker) run(r io.Reader, w io.Writer) ([]int, error) {
+	p.filter()
+	p.draw(w)
+	defer p.clear(w)
+
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if n == 0 && err != nil {
+			if err == io.EOF {
+				return nil, errPickerCancelled
+			}
+			return nil, errors.Wrap(err, "failed to read input:")
+		}
+
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			var done bool
+			var result []int
+			chunk, done, result, err = p.handle(chunk)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return result, nil
+			}
+		}
+		p.draw(w)
+	}
+}
+
+// handle processes a key at the head of the chunk and returns the rest.
+// It returns done and the result when the selection is accepted.
+func (p *picker) handle(chunk []byte) ([]byte, bool, []int, error) {
+	switch c := chunk[0]; c {
+	case keyCR, keyLF:
+		if result := p.result(); len(result) > 0 {
+			return nil, true, result, nil
+		}
+		return chunk[1:], false, nil, nil
+	case keyCtrlC, keyCtrlG:
+		return nil, false, nil, errPickerCancelled
+	case keyEscape:
+		if len(chunk) >= 3 && (chunk[1] == '[' || chunk[1] == 'O') {
+			switch chunk[2] {
+			case 'A':
+				p.move(-1)
+			case 'B':
+				p.move(1)
+			}
+			return chunk[3:], false, nil, nil
+		}
+		return nil, false, nil, errPickerCancelled
+	case keyBackspace, keyDelete:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+		return chunk[1:], false, nil, nil
+	case keyCtrlU:
+		p.query = nil
+		p.filter()
+		return chunk[1:], false, nil, nil
+	case keyCtrlN:
+		p.move(1)
+		return chunk[1:], false, nil, nil
+	case keyCtrlP:
+		p.move(-1)
+		return chunk[1:], false, nil, nil
+	case keyTab:
+		if p.multi && len(p.matches) > 0 {
+			i := p.matches[p.cursor]
+			p.marked[i] = !p.marked[i]
+			p.move(1)
+		}
+		return chunk[1:], false, nil, nil
+	}
+
+	r, size := utf8.DecodeRune(chunk)
+	if unicode.IsPrint(r) {
+		p.query = append(p.query, r)
+		p.filter()
+	}
+	return chunk[size:], false, nil, nil
+}
+
+// result returns indexes of marked rows in the original order, or the row
+// under the cursor if nothing is marked.
+func (p *picker) result() []int {
+	result := []int{}
+	for i := range p.lines {
+		if p.marked[i] {
+			result = append(result, i)
+		}
+	}
+	if len(result) == 0 && len(p.matches) > 0 {
+		result = append(result, p.matches[p.cursor])
+	}
+	return result
+}
+
+// filter updates matches for the query. Each word of the query must appear
+// in a row in order, but not necessarily contiguously. Rows containing all
+// words as substrings come first.
+func (p *picker) filter() {
+	terms := strings.Fields(strings.ToLower(string(p.query)))
+	exact := []int{}
+	fuzzy := []int{}
+	for i, line := range p.lines {
+		l := strings.ToLower(line)
+		matched, contiguous := true, true
+		for _, t := range terms {
+			if !fuzzyMatch(l, t) {
+				matched = false
+				break
+			}
+			if !strings.Contains(l, t) {
+				contiguous = false
+			}
+		}
+		if !matched {
+			continue
+		}
+		if contiguous {
+			exact = append(exact, i)
+		} else {
+			fuzzy = append(fuzzy, i)
+		}
+	}
+	p.matches = append(exact, fuzzy...)
+	p.cursor = 0
+	p.offset = 0
+}
+
+// fuzzyMatch returns true if all runes of the pattern appear in s in order.
+func fuzzyMatch(s string, pattern string) bool {
+	for _, r := range pattern {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// move moves the cursor and scrolls the list to keep it visible.
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor += delta
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+pickerHeight {
+		p.offset = p.cursor - pickerHeight + 1
+	}
+}
+
+// draw redraws the finder in place.
+func (p *picker) draw(w io.Writer) {
+	lines := []string{
+		"> " + string(p.query),
+		fmt.Sprintf("  %d/%d", len(p.matches), len(p.lines)),
+		"  " + p.header,
+	}
+
+	end := p.offset + pickerHeight
+	if end > len(p.matches) {
+		end = len(p.matches)
+	}
+	for n := p.offset; n < end; n++ {
+		i := p.matches[n]
+		prefix := "  "
+		if p.marked[i] {
+			prefix = " *"
+		}
+		if n == p.cursor {
+			prefix = ">" + prefix[1:]
+		}
+		lines = append(lines, prefix+p.lines[i])
+	}
+
+	p.clear(w)
+	for i, line := range lines {
+		if p.width > 0 && utf8.RuneCountInString(line) > p.width {
+			line = string([]rune(line)[:p.width])
+		}
+		if i > 0 {
+			fmt.Fprint(w, "\r\n")
+		}
+		fmt.Fprint(w, line)
+	}
+	p.drawn = len(lines)
+
+	// Move the cursor back to the end of the query.
+	if p.drawn > 1 {
+		fmt.Fprintf(w, "\x1b[%dA", p.drawn-1)
+	}
+	fmt.Fprintf(w, "\r\x1b[%dC", utf8.RuneCountInString(lines[0]))
+}
+
+// clear erases the finder drawn last time.
+func (p *picker) clear(w io.Writer) {
+	if p.drawn == 0 {
+		return
+	}
+	fmt.Fprint(w, "\r\x1b[J")
+	p.drawn = 0
+}
+
+// pickResources shows resources formatted with fields of the registry in a
+// fuzzy finder and returns the chosen ones.
+func (client *Client) pickResources(registry *fieldRegistry, fields []string, resources []interface{}, multi bool) ([]interface{}, error) {
+	indexes, err := client.pick(fields, registry.formatRows(client, fields, resources), multi)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Ints(indexes)
+	chosen := []interface{}{}
+	for _, i := range indexes {
+		chosen = append(chosen, resources[i])
+	}
+	return chosen, nil
+}
@@ -0,0 +1,127 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPick(t *testing.T) {
+	fields := []string{"Name", "Env"}
+	rows := [][]string{
+		{"web-1", "prod"},
+		{"web-2", "stg"},
+		{"db-1", "prod"},
+		{"batch", "dev"},
+	}
+
+	cases := []struct {
+		desc    string
+		rows    [][]string
+		multi   bool
+		input   string
+		want    []int
+		wantErr string
+	}{
+		{desc: "enter", rows: rows, input: "\r", want: []int{0}},
+		{desc: "query", rows: rows, input: "db\r", want: []int{2}},
+		{desc: "multiple words", rows: rows, input: "web stg\r", want: []int{1}},
+		{desc: "substring first", rows: rows, input: "b-\r", want: []int{0}},
+		{desc: "fuzzy", rows: rows, input: "bth\r", want: []int{3}},
+		{desc: "arrow keys", rows: rows, input: "\x1b[B\x1b[B\x1b[A\r", want: []int{1}},
+		{desc: "ctrl-n and ctrl-p", rows: rows, input: "\x0e\x0e\x0e\x10\r", want: []int{2}},
+		{desc: "backspace", rows: rows, input: "dbx\x7f\r", want: []int{2}},
+		{desc: "ctrl-u", rows: rows, input: "db\x15\r", want: []int{0}},
+		{desc: "no match", rows: rows, input: "zzz\r", wantErr: "selection cancelled"},
+		{desc: "mark multiple", rows: rows, multi: true, input: "prod\t\t\r", want: []int{0, 2}},
+		{desc: "tab without multi", rows: rows, input: "\t\r", want: []int{0}},
+		{desc: "ctrl-c", rows: rows, input: "\x03", wantErr: "selection cancelled"},
+		{desc: "escape", rows: rows, input: "\x1b", wantErr: "selection cancelled"},
+		{desc: "single row", rows: rows[:1], want: []int{0}},
+		{desc: "no rows", rows: [][]string{}, wantErr: "no candidates found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, _ := newTestClient(t, Services{})
+			client.stdin = strings.NewReader(tc.input)
+
+			got, err := client.pick(fields, tc.rows, tc.multi)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error %q, but got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPickDraw(t *testing.T) {
+	client, _ := newTestClient(t, Services{})
+	// Read a key at a time as a terminal does.
+	client.stdin = iotest.OneByteReader(strings.NewReader("stg\r"))
+
+	if _, err := client.pick([]string{"Name", "Env"}, [][]string{{"web-1", "prod"}, {"web-2", "stg"}}, false); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	got := stderrOf(client).String()
+	for _, want := range []string{"> stg", "  1/2", "  Name   Env", "> web-2  stg"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stderr doesn't contain %q:\n%q", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "\r\x1b[J") {
+		t.Errorf("the finder should be cleared: %q", got)
+	}
+}
+
+func TestEC2StopSelect(t *testing.T) {
+	f := newFakeEC2WithInstances()
+	client, _ := newTestClient(t, Services{EC2: f})
+	// Only running instances are candidates.
+	client.stdin = strings.NewReader("db\r")
+
+	if err := client.EC2Stop(EC2StopOptions{Select: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if *f.instances[3].State.Name != "stopped" || *f.instances[0].State.Name != "running" {
+		t.Errorf("only i-0004 should be stopped: %v", f.instances)
+	}
+}
+
+func TestSSMParameterGetSelect(t *testing.T) {
+	f := newFakeSSM("/app/foo", "1", "/app/bar", "2", "/other/baz", "3")
+	client, stdout := newTestClient(t, Services{SSM: f})
+	client.stdin = strings.NewReader("\t\t\r")
+
+	if err := client.SSMParameterGet(SSMParameterGetOptions{Select: true, Path: "/app/"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := stdout.String(); got != "1\n2\n" {
+		t.Errorf("got %q, want %q", got, "1\n2\n")
+	}
+}
+
+func TestAutoscalingUpdateSelect(t *testing.T) {
+	c := newFakeCluster(2)
+	client, _ := newTestClient(t, Services{AutoScaling: &fakeAutoScaling{c: c}})
+
+	if err := client.AutoscalingUpdate(AutoscalingUpdateOptions{DesiredCapacity: 3, Select: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := c.setDesiredCapacityCalls; len(got) != 1 || got[0] != 3 {
+		t.Errorf("unexpected SetDesiredCapacity calls: %v", got)
+	}
+}
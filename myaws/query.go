@@ -0,0 +1,128 @@
+package myaws
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// QueryOptions customize client-side filtering and sorting of ls commands.
+type QueryOptions struct {
+	// Where is an expression to filter resources, such as
+	// `State == "running" && Tag:Env =~ "prod" && LaunchTime < 30d`.
+	Where string
+	// SortBy is a field name to sort resources.
+	SortBy string
+	// Reverse reverses the sort order.
+	Reverse bool
+	// Limit is the maximum number of resources. 0 means unlimited.
+	Limit int
+	// MaxItems is the maximum number of resources fetched from the API.
+	// Unlike Limit, it is applied before filtering and sorting, and stops
+	// fetching further pages. 0 means unlimited.
+	MaxItems int
+}
+
+// query is a compiled QueryOptions for a resource type.
+type query struct {
+	options  QueryOptions
+	registry *fieldRegistry
+	where    expr
+}
+
+// compileQuery parses and validates QueryOptions. We should call it before
+// any API call to fail fast.
+func (r *fieldRegistry) compileQuery(options QueryOptions) (*query, error) {
+	q := &query{options: options, registry: r}
+
+	if options.Where != "" {
+		e, err := parseExpr(options.Where)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse --where:")
+		}
+		if err := r.validate(exprFields(e)); err != nil {
+			return nil, err
+		}
+		q.where = e
+	}
+
+	if options.SortBy != "" {
+		if err := r.validate([]string{options.SortBy}); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Limit < 0 {
+		return nil, errors.Errorf("--limit must be a positive number: %d", options.Limit)
+	}
+
+	if options.MaxItems < 0 {
+		return nil, errors.Errorf("--max-items must be a positive number: %d", options.MaxItems)
+	}
+
+	return q, nil
+}
+
+// apply filters, sorts and limits resources.
+func (q *query) apply(client *Client, resources []interface{}) []interface{} {
+	now := time.Now()
+
+	filtered := []interface{}{}
+	for _, resource := range resources {
+		if q.where != nil {
+			ctx := &exprContext{client: client, registry: q.registry, resource: resource, now: now}
+			if !q.where.eval(ctx) {
+				continue
+			}
+		}
+		filtered = append(filtered, resource)
+	}
+
+	if q.options.SortBy != "" {
+		keys := make(map[interface{}]interface{}, len(filtered))
+		for _, resource := range filtered {
+			keys[resource] = sortKey(q.registry.values(client, q.options.SortBy, resource))
+		}
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return lessSortKey(now, keys[filtered[i]], keys[filtered[j]])
+		})
+	}
+
+	if q.options.Reverse {
+		for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+			filtered[i], filtered[j] = filtered[j], filtered[i]
+		}
+	}
+
+	if q.options.Limit > 0 && len(filtered) > q.options.Limit {
+		filtered = filtered[:q.options.Limit]
+	}
+
+	return filtered
+}
+
+// reachedMaxItems returns true if n items reach maxItems. If maxItems is 0,
+// it is unlimited.
+func reachedMaxItems(n int, maxItems int) bool {
+	return maxItems > 0 && n >= maxItems
+}
+
+func sortKey(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// lessSortKey compares sort keys. A resource without a value comes last.
+func lessSortKey(now time.Time, a, b interface{}) bool {
+	if a == nil || a == "" {
+		return false
+	}
+	if b == nil || b == "" {
+		return true
+	}
+	c, _ := compareValues(now, a, b)
+	return c < 0
+}
@@ -2,87 +2,125 @@ package myaws
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/rds"
 	"github.com/pkg/errors"
 )
 
 // RDSLsOptions customize the behavior of the Ls command.
 type RDSLsOptions struct {
-	Quiet  bool
-	Fields []string
+	Quiet      bool
+	Fields     []string
+	ListFields bool
+	Query      QueryOptions
+	Targets    TargetOptions
 }
 
+// rdsDBInstanceFields is a registry of output fields for RDS instances.
+var rdsDBInstanceFields = newFieldRegistry(rds.DBInstance{}, "DBInstanceIdentifier", []field{
+	{"DBInstanceClass", formatRDSDBInstanceClass},
+	{"Engine", formatRDSEngine},
+	{"AllocatedStorage", formatRDSAllocatedStorage},
+	{"StorageType", formatRDSStorageType},
+	{"StorageTypeIops", formatRDSStorageTypeIops},
+	{"DBInstanceIdentifier", formatRDSDBInstanceIdentifier},
+	{"ReadReplicaSource", formatRDSReadReplicaSource},
+	{"InstanceCreateTime", formatRDSInstanceCreateTime},
+})
+
 // RDSLs describes RDSs.
 func (client *Client) RDSLs(options RDSLsOptions) error {
-	params := &rds.DescribeDBInstancesInput{}
-
-	response, err := client.RDS.DescribeDBInstances(params)
-	if err != nil {
-		return errors.Wrap(err, "DescribeDBInstances failed:")
+	if options.ListFields {
+		return client.printFields(rdsDBInstanceFields)
 	}
 
-	for _, db := range response.DBInstances {
-		fmt.Fprintln(client.stdout, formatDBInstance(client, options, db))
+	fields := rdsDBInstanceFields.outputFields(options.Quiet, options.Targets.outputFields(options.Fields))
+	if err := rdsDBInstanceFields.validate(fields); err != nil {
+		return err
 	}
 
-	return nil
-}
+	q, err := rdsDBInstanceFields.compileQuery(options.Query)
+	if err != nil {
+		return err
+	}
 
-func formatDBInstance(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
-	formatFuncs := map[string]func(client *Client, options RDSLsOptions, db *rds.DBInstance) string{
-		"DBInstanceClass":      formatRDSDBInstanceClass,
-		"Engine":               formatRDSEngine,
-		"AllocatedStorage":     formatRDSAllocatedStorage,
-		"StorageType":          formatRDSStorageType,
-		"StorageTypeIops":      formatRDSStorageTypeIops,
-		"DBInstanceIdentifier": formatRDSDBInstanceIdentifier,
-		"ReadReplicaSource":    formatRDSReadReplicaSource,
-		"InstanceCreateTime":   formatRDSInstanceCreateTime,
+	resources, findErr := client.fanOut(options.Targets, func(c *Client) ([]interface{}, error) {
+		dbs, err := c.findRDSDBInstances(aws.BackgroundContext(), options.Query.MaxItems)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		for _, db := range dbs {
+			resources = append(resources, db)
+		}
+		return resources, nil
+	})
+	if findErr != nil && !isTargetsError(findErr) {
+		return findErr
 	}
 
-	var outputFields []string
-	if options.Quiet {
-		outputFields = []string{"DBInstanceIdentifier"}
-	} else {
-		outputFields = options.Fields
+	rows := rdsDBInstanceFields.formatRows(client, fields, q.apply(client, resources))
+
+	if err := client.printRows(fields, rows, false); err != nil {
+		return err
 	}
+	return findErr
+}
 
-	output := []string{}
+// findRDSDBInstances returns an array of RDS instances.
+// It fetches all pages until maxItems instances are found.
+// If maxItems is 0, it is unlimited.
+func (client *Client) findRDSDBInstances(ctx aws.Context, maxItems int) ([]*rds.DBInstance, error) {
+	params := &rds.DescribeDBInstancesInput{}
 
-	for _, field := range outputFields {
-		value := formatFuncs[field](client, options, db)
-		output = append(output, value)
+	var dbs []*rds.DBInstance
+	err := client.RDS.DescribeDBInstancesPagesWithContext(ctx, params,
+		func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+			dbs = append(dbs, page.DBInstances...)
+			return !reachedMaxItems(len(dbs), maxItems)
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "DescribeDBInstances failed:")
 	}
 
-	return strings.Join(output[:], "\t")
+	if reachedMaxItems(len(dbs), maxItems) {
+		dbs = dbs[:maxItems]
+	}
+	return dbs, nil
 }
 
-func formatRDSDBInstanceIdentifier(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSDBInstanceIdentifier(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	return *db.DBInstanceIdentifier
 }
 
-func formatRDSDBInstanceClass(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSDBInstanceClass(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	if *db.MultiAZ {
 		return fmt.Sprintf("%s:multi", *db.DBInstanceClass)
 	}
 	return fmt.Sprintf("%s:single", *db.DBInstanceClass)
 }
 
-func formatRDSEngine(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSEngine(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	return fmt.Sprintf("%-15s", fmt.Sprintf("%s:%s", *db.Engine, *db.EngineVersion))
 }
 
-func formatRDSAllocatedStorage(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSAllocatedStorage(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	return fmt.Sprintf("%4dGB", *db.AllocatedStorage)
 }
 
-func formatRDSStorageType(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSStorageType(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	return *db.StorageType
 }
 
-func formatRDSStorageTypeIops(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSStorageTypeIops(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	iops := "-"
 	if db.Iops != nil {
 		iops = fmt.Sprint(*db.Iops)
@@ -91,13 +129,15 @@ func formatRDSStorageTypeIops(client *Client, options RDSLsOptions, db *rds.DBIn
 	return fmt.Sprintf("%-8s", fmt.Sprintf("%s:%s", *db.StorageType, iops))
 }
 
-func formatRDSReadReplicaSource(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSReadReplicaSource(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	if db.ReadReplicaSourceDBInstanceIdentifier == nil {
 		return "source:---"
 	}
 	return fmt.Sprintf("source:%s", *db.ReadReplicaSourceDBInstanceIdentifier)
 }
 
-func formatRDSInstanceCreateTime(client *Client, options RDSLsOptions, db *rds.DBInstance) string {
+func formatRDSInstanceCreateTime(client *Client, resource interface{}) string {
+	db := resource.(*rds.DBInstance)
 	return client.FormatTime(db.InstanceCreateTime)
 }
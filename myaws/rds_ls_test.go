@@ -0,0 +1,52 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func TestRDSLs(t *testing.T) {
+	f := &fakeRDS{
+		dbInstances: []*rds.DBInstance{
+			{
+				DBInstanceIdentifier: aws.String("db1"),
+				DBInstanceClass:      aws.String("db.r5.large"),
+				MultiAZ:              aws.Bool(true),
+				Engine:               aws.String("mysql"),
+				EngineVersion:        aws.String("8.0.23"),
+				AllocatedStorage:     aws.Int64(100),
+				StorageType:          aws.String("io1"),
+				Iops:                 aws.Int64(1000),
+				InstanceCreateTime:   aws.Time(testTime),
+			},
+			{
+				DBInstanceIdentifier:                  aws.String("db1-replica"),
+				DBInstanceClass:                       aws.String("db.r5.large"),
+				MultiAZ:                               aws.Bool(false),
+				Engine:                                aws.String("mysql"),
+				EngineVersion:                         aws.String("8.0.23"),
+				AllocatedStorage:                      aws.Int64(100),
+				StorageType:                           aws.String("gp2"),
+				ReadReplicaSourceDBInstanceIdentifier: aws.String("db1"),
+				InstanceCreateTime:                    aws.Time(testTime),
+			},
+		},
+		pageSize: 1,
+	}
+	client, stdout := newTestClient(t, Services{RDS: f})
+
+	err := client.RDSLs(RDSLsOptions{
+		Fields: []string{"DBInstanceIdentifier", "DBInstanceClass", "StorageTypeIops", "ReadReplicaSource"},
+		Query:  QueryOptions{Where: "MultiAZ == false"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "db1-replica\tdb.r5.large:single\tgp2:-   \tsource:db1\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
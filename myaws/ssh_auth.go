@@ -0,0 +1,291 @@
+package myaws
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHKeepAlive is an interval of keepalive messages if neither the
+// option nor ServerAliveInterval in the ssh config is given.
+const defaultSSHKeepAlive = 30 * time.Second
+
+// defaultSSHIdentityFiles are identity files used if neither --identity-file
+// nor IdentityFile in the ssh config is given. Missing files are ignored.
+var defaultSSHIdentityFiles = []string{"~/.ssh/id_rsa", "~/.ssh/id_ecdsa", "~/.ssh/id_ed25519"}
+
+// sshAuthOptions customize authentication and host key verification.
+// Empty values default to the ssh config.
+type sshAuthOptions struct {
+	LoginName    string
+	IdentityFile string
+	Port         string
+	AcceptNew    bool
+	ForwardAgent bool
+	// KeepAlive is an interval of keepalive messages. 0 means the default.
+	KeepAlive time.Duration
+}
+
+// sshAuth builds a client config for each host from the options, the ssh
+// config, ssh-agent and known_hosts.
+type sshAuth struct {
+	options    sshAuthOptions
+	config     *sshConfigFile
+	agent      agent.ExtendedAgent
+	agentConn  net.Conn
+	signers    map[string]ssh.Signer
+	knownHosts map[string]*sshKnownHosts
+	stderr     io.Writer
+}
+
+// newSSHAuth reads ~/.ssh/config and connects to ssh-agent if SSH_AUTH_SOCK
+// is set. The caller should close it.
+func (client *Client) newSSHAuth(options sshAuthOptions) (*sshAuth, error) {
+	config, err := readSSHConfigFile(expandSSHPath("~/.ssh/config"))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &sshAuth{
+		options:    options,
+		config:     config,
+		signers:    map[string]ssh.Signer{},
+		knownHosts: map[string]*sshKnownHosts{},
+		stderr:     client.stderr,
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to connect to ssh-agent:")
+		}
+		a.agentConn = conn
+		a.agent = agent.NewClient(conn)
+	}
+	return a, nil
+}
+
+// close closes the connection to ssh-agent.
+func (a *sshAuth) close() {
+	if a.agentConn != nil {
+		a.agentConn.Close()
+	}
+}
+
+// host returns a host with a client config. The ssh config is looked up by
+// the Name tag, the instance ID and the hostname.
+func (a *sshAuth) host(instanceID string, name string, hostname string) (sshHost, error) {
+	return a.newHost(a.options.LoginName, a.options.Port, instanceID, name, hostname, false)
+}
+
+// jumpHost returns a jump host to connect through. The login name and port of
+// the options are for target hosts, so they don't apply, and the agent isn't
+// forwarded to the jump host.
+func (a *sshAuth) jumpHost(loginName string, instanceID string, name string, hostname string) (*sshHost, error) {
+	host, err := a.newHost(loginName, "", instanceID, name, hostname, true)
+	if err != nil {
+		return nil, err
+	}
+	return &host, nil
+}
+
+func (a *sshAuth) newHost(loginName string, port string, instanceID string, name string, hostname string, jump bool) (sshHost, error) {
+	hc := a.config.lookup(name, instanceID, hostname)
+
+	loginName = firstNonEmpty(loginName, hc.User)
+	if loginName == "" {
+		if u, err := user.Current(); err == nil {
+			loginName = u.Username
+		}
+	}
+
+	auth, err := a.authMethods(hc)
+	if err != nil {
+		return sshHost{}, err
+	}
+
+	keepAlive := a.options.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = defaultSSHKeepAlive
+		if hc.ServerAliveInterval != "" {
+			// ServerAliveInterval 0 disables keepalive.
+			n, err := strconv.Atoi(hc.ServerAliveInterval)
+			if err != nil || n < 0 {
+				return sshHost{}, errors.Errorf("invalid ServerAliveInterval in ssh config: %s", hc.ServerAliveInterval)
+			}
+			keepAlive = time.Duration(n) * time.Second
+		}
+	}
+
+	knownHostsPath := expandSSHPath(firstNonEmpty(hc.UserKnownHostsFile, "~/.ssh/known_hosts"))
+	acceptNew := a.options.AcceptNew || hc.StrictHostKeyChecking == "accept-new"
+	knownHosts, ok := a.knownHosts[knownHostsPath]
+	if !ok {
+		knownHosts = &sshKnownHosts{path: knownHostsPath, stderr: a.stderr}
+		a.knownHosts[knownHostsPath] = knownHosts
+	}
+
+	host := sshHost{
+		instanceID: instanceID,
+		name:       name,
+		hostname:   hostname,
+		port:       firstNonEmpty(port, hc.Port, "22"),
+		keepAlive:  keepAlive,
+		config: &ssh.ClientConfig{
+			User:            loginName,
+			Auth:            auth,
+			HostKeyCallback: knownHosts.callback(acceptNew),
+		},
+	}
+
+	if !jump && (a.options.ForwardAgent || hc.ForwardAgent) {
+		if a.agent == nil {
+			return sshHost{}, errors.New("agent forwarding requires ssh-agent, but SSH_AUTH_SOCK is not set")
+		}
+		host.forwardAgent = a.agent
+	}
+	return host, nil
+}
+
+// authMethods returns keys of ssh-agent and identity files.
+func (a *sshAuth) authMethods(hc sshHostConfig) ([]ssh.AuthMethod, error) {
+	signers := []ssh.Signer{}
+	switch {
+	case a.options.IdentityFile != "":
+		signer, err := a.loadSigner(a.options.IdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	default:
+		files := hc.IdentityFiles
+		if len(files) == 0 {
+			files = defaultSSHIdentityFiles
+		}
+		for _, f := range files {
+			// Skip missing or encrypted keys as ssh does. They may be in ssh-agent.
+			if signer, err := a.loadSigner(f); err == nil {
+				signers = append(signers, signer)
+			}
+		}
+	}
+
+	auth := []ssh.AuthMethod{}
+	if a.agent != nil {
+		auth = append(auth, ssh.PublicKeysCallback(a.agent.Signers))
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("no SSH keys found: use --identity-file or ssh-agent")
+	}
+	return auth, nil
+}
+
+// loadSigner reads a private key. Keys are cached by path.
+func (a *sshAuth) loadSigner(identityFile string) (ssh.Signer, error) {
+	path := expandSSHPath(identityFile)
+	if signer, ok := a.signers[path]; ok {
+		return signer, nil
+	}
+
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read private key:")
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return nil, errors.Errorf("private key %s is protected by a passphrase: add it to ssh-agent", identityFile)
+		}
+		return nil, errors.Wrap(err, "unable to parse private key:")
+	}
+
+	a.signers[path] = signer
+	return signer, nil
+}
+
+// sshKnownHosts verifies host keys with a known_hosts file.
+type sshKnownHosts struct {
+	path   string
+	stderr io.Writer
+	mu     sync.Mutex
+}
+
+// callback returns a callback to verify a host key. If acceptNew is true, a
+// key of an unknown host is added to the file, but a changed key is always
+// refused.
+func (k *sshKnownHosts) callback(acceptNew bool) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		address := knownhosts.Normalize(hostname)
+		// Hosts may be verified concurrently and the file may be updated.
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		if _, err := os.Stat(k.path); err == nil {
+			cb, err := knownhosts.New(k.path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read known hosts %s:", k.path)
+			}
+			err = cb(hostname, remote, key)
+			if err == nil {
+				return nil
+			}
+			keyErr, ok := err.(*knownhosts.KeyError)
+			if !ok {
+				return err
+			}
+			if len(keyErr.Want) > 0 {
+				return errors.Errorf("host key of %s has changed: if it's expected, remove the old key from %s by ssh-keygen -R '%s'", address, k.path, address)
+			}
+		}
+
+		if !acceptNew {
+			return errors.Errorf("host key of %s is unknown: use --accept-new to add it to %s", address, k.path)
+		}
+		return k.add(address, key)
+	}
+}
+
+// add appends a host key of the normalized address to the file.
+func (k *sshKnownHosts) add(address string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create known hosts directory:")
+	}
+
+	f, err := os.OpenFile(k.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open known hosts:")
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{address}, key)); err != nil {
+		return errors.Wrap(err, "failed to write known hosts:")
+	}
+	fmt.Fprintf(k.stderr, "Warning: Permanently added '%s' (%s) to the list of known hosts.\n", address, key.Type())
+	return nil
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+package myaws
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// setTestSSHAuthSock sets SSH_AUTH_SOCK during the test. An empty sock unsets
+// it.
+func setTestSSHAuthSock(t *testing.T, sock string) {
+	old, ok := os.LookupEnv("SSH_AUTH_SOCK")
+	if sock == "" {
+		os.Unsetenv("SSH_AUTH_SOCK")
+	} else {
+		os.Setenv("SSH_AUTH_SOCK", sock)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("SSH_AUTH_SOCK", old)
+		} else {
+			os.Unsetenv("SSH_AUTH_SOCK")
+		}
+	})
+}
+
+// newTestSSHAgent starts ssh-agent with a new key and sets SSH_AUTH_SOCK. It
+// returns the public key in the agent.
+func newTestSSHAgent(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatalf("failed to add key: %s", err)
+	}
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	setTestSSHAuthSock(t, sock)
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %s", err)
+	}
+	return signer.PublicKey()
+}
+
+// runTestSSHCommand runs a command on the host and returns its stdout.
+func runTestSSHCommand(client *Client, host sshHost, command string) (string, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	code, err := client.runSSHCommand(context.Background(), host, sshExecOptions{Command: command, NoPty: true}, stdout, stderr)
+	if err == nil && code != 0 {
+		err = fmt.Errorf("exit code %d: %s", code, stderr)
+	}
+	return stdout.String(), err
+}
+
+func TestSSHAuthKnownHosts(t *testing.T) {
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	s := newTestSSHServer(t)
+	client, _ := newTestClient(t, Services{})
+	identityFile := writeTestIdentityFile(t)
+	knownHosts := expandSSHPath("~/.ssh/known_hosts")
+
+	newHost := func(s *testSSHServer, acceptNew bool) sshHost {
+		t.Helper()
+		auth, err := client.newSSHAuth(sshAuthOptions{IdentityFile: identityFile, Port: s.port, AcceptNew: acceptNew})
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+		t.Cleanup(auth.close)
+		host, err := auth.host("i-0001", "web-prod-1", "127.0.0.1")
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+		return host
+	}
+
+	// unknown
+	_, err := runTestSSHCommand(client, newHost(s, false), "echo hello")
+	if err == nil || !strings.Contains(err.Error(), "host key of [127.0.0.1]:"+s.port+" is unknown: use --accept-new") {
+		t.Fatalf("expected an unknown host error, but got: %v", err)
+	}
+
+	// accept new
+	if _, err := runTestSSHCommand(client, newHost(s, true), "echo hello"); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	b, err := ioutil.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatalf("failed to read known hosts: %s", err)
+	}
+	want := knownhostsLine(s)
+	if string(b) != want+"\n" {
+		t.Errorf("known hosts = %q, want = %q", b, want)
+	}
+	if !strings.Contains(stderrOf(client).String(), "Warning: Permanently added '[127.0.0.1]:"+s.port+"'") {
+		t.Errorf("no warning: %s", stderrOf(client))
+	}
+
+	// known
+	if got, err := runTestSSHCommand(client, newHost(s, false), "echo hello"); err != nil || got != "hello\n" {
+		t.Fatalf("got = %q, err = %v", got, err)
+	}
+
+	// changed: register the key of s as the key of another server.
+	other := newTestSSHServer(t)
+	changed := strings.Replace(want, "]:"+s.port, "]:"+other.port, 1)
+	if err := ioutil.WriteFile(knownHosts, []byte(changed+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known hosts: %s", err)
+	}
+	_, err = runTestSSHCommand(client, newHost(other, true), "echo hello")
+	if err == nil || !strings.Contains(err.Error(), "host key of [127.0.0.1]:"+other.port+" has changed") {
+		t.Fatalf("expected a changed host key error, but got: %v", err)
+	}
+	if len(other.executed()) != 0 {
+		t.Errorf("should not execute commands: %v", other.executed())
+	}
+}
+
+// knownhostsLine returns a line of known_hosts for the server.
+func knownhostsLine(s *testSSHServer) string {
+	return fmt.Sprintf("[127.0.0.1]:%s %s", s.port, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.hostKey))))
+}
+
+func TestSSHAuthAgent(t *testing.T) {
+	setTestHome(t)
+	key := newTestSSHAgent(t)
+	s := newTestSSHServer(t)
+	s.authorize(key)
+	client, _ := newTestClient(t, Services{})
+
+	auth, err := client.newSSHAuth(sshAuthOptions{Port: s.port, AcceptNew: true, ForwardAgent: true})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	defer auth.close()
+	host, err := auth.host("i-0001", "web-prod-1", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	got, err := runTestSSHCommand(client, host, "agent")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got != "1\n" {
+		t.Errorf("got %q keys in the forwarded agent, want 1", got)
+	}
+}
+
+func TestSSHAuthConfig(t *testing.T) {
+	setTestHome(t)
+	setTestSSHAuthSock(t, "")
+	s := newTestSSHServer(t)
+	client, _ := newTestClient(t, Services{})
+
+	// The key in ~/.ssh/config is authorized, but the default key is not.
+	writeTestSSHKey := func(name string) ssh.PublicKey {
+		t.Helper()
+		path := writeTestIdentityFile(t)
+		b, _ := ioutil.ReadFile(path)
+		if err := os.MkdirAll(expandSSHPath("~/.ssh"), 0700); err != nil {
+			t.Fatalf("failed to create dir: %s", err)
+		}
+		if err := ioutil.WriteFile(expandSSHPath("~/.ssh/"+name), b, 0600); err != nil {
+			t.Fatalf("failed to write key: %s", err)
+		}
+		signer, _ := ssh.ParsePrivateKey(b)
+		return signer.PublicKey()
+	}
+	writeTestSSHKey("id_rsa")
+	s.authorize(writeTestSSHKey("web"))
+
+	config := fmt.Sprintf("Host web-*\n  User admin\n  Port %s\n  IdentityFile ~/.ssh/web\n  StrictHostKeyChecking accept-new\n", s.port)
+	if err := ioutil.WriteFile(expandSSHPath("~/.ssh/config"), []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+
+	auth, err := client.newSSHAuth(sshAuthOptions{})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	defer auth.close()
+	host, err := auth.host("i-0001", "web-prod-1", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if host.port != s.port {
+		t.Errorf("port = %s, want = %s", host.port, s.port)
+	}
+
+	if _, err := runTestSSHCommand(client, host, "echo hello"); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got := s.loginUsers(); len(got) != 1 || got[0] != "admin" {
+		t.Errorf("login users = %v, want = [admin]", got)
+	}
+
+	// Options take precedence over the ssh config.
+	auth, err = client.newSSHAuth(sshAuthOptions{LoginName: "ec2-user", Port: "2222"})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	defer auth.close()
+	host, err = auth.host("i-0001", "web-prod-1", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if host.port != "2222" || host.config.User != "ec2-user" {
+		t.Errorf("port = %s, user = %s", host.port, host.config.User)
+	}
+}
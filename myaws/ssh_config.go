@@ -0,0 +1,192 @@
+package myaws
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sshConfigFile is a parsed ssh_config(5) file such as ~/.ssh/config.
+// Only Host blocks and the options used by the ssh command are supported.
+// Match blocks and Include are ignored.
+type sshConfigFile struct {
+	blocks []sshConfigBlock
+}
+
+// sshConfigBlock is a Host block of the ssh config.
+type sshConfigBlock struct {
+	patterns []string
+	options  [][2]string
+}
+
+// sshHostConfig is a set of options for a host.
+type sshHostConfig struct {
+	User                  string
+	Port                  string
+	IdentityFiles         []string
+	UserKnownHostsFile    string
+	ForwardAgent          bool
+	StrictHostKeyChecking string
+	// ServerAliveInterval is an interval of keepalive messages in seconds.
+	ServerAliveInterval string
+}
+
+// readSSHConfigFile reads an ssh config file. It returns an empty config if
+// the file doesn't exist.
+func readSSHConfigFile(path string) (*sshConfigFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &sshConfigFile{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to open ssh config:")
+	}
+	defer f.Close()
+
+	config, err := parseSSHConfig(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse ssh config %s:", path)
+	}
+	return config, nil
+}
+
+// parseSSHConfig parses an ssh config. Options before the first Host block
+// apply to all hosts.
+func parseSSHConfig(r io.Reader) (*sshConfigFile, error) {
+	config := &sshConfigFile{blocks: []sshConfigBlock{{patterns: []string{"*"}}}}
+	current := &config.blocks[0]
+	// skip is true in a Match block.
+	skip := false
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value := splitSSHConfigLine(text)
+		if value == "" {
+			return nil, errors.Errorf("missing value of %s at line %d", key, line)
+		}
+
+		switch strings.ToLower(key) {
+		case "host":
+			config.blocks = append(config.blocks, sshConfigBlock{patterns: strings.Fields(value)})
+			current = &config.blocks[len(config.blocks)-1]
+			skip = false
+		case "match":
+			skip = true
+		default:
+			if !skip {
+				current.options = append(current.options, [2]string{strings.ToLower(key), value})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read ssh config:")
+	}
+	return config, nil
+}
+
+// splitSSHConfigLine splits a line into a key and a value, which are
+// separated by spaces or an equal sign. A quoted value is unquoted.
+func splitSSHConfigLine(text string) (string, string) {
+	i := strings.IndexAny(text, " \t=")
+	if i < 0 {
+		return text, ""
+	}
+	key := text[:i]
+	value := strings.TrimSpace(text[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	return key, value
+}
+
+// lookup returns options for a host. A Host block applies if any of the
+// aliases matches its patterns, such as a Name tag, an instance ID or an IP
+// address. As with ssh, the first obtained value of each option is used,
+// except IdentityFile which can be given multiple times.
+func (c *sshConfigFile) lookup(aliases ...string) sshHostConfig {
+	hc := sshHostConfig{}
+	seen := map[string]bool{}
+	for _, b := range c.blocks {
+		if !b.match(aliases) {
+			continue
+		}
+		for _, o := range b.options {
+			key, value := o[0], o[1]
+			if key == "identityfile" {
+				hc.IdentityFiles = append(hc.IdentityFiles, value)
+				continue
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			switch key {
+			case "user":
+				hc.User = value
+			case "port":
+				hc.Port = value
+			case "userknownhostsfile":
+				// Only the first file is used.
+				hc.UserKnownHostsFile = strings.Fields(value)[0]
+			case "forwardagent":
+				hc.ForwardAgent = strings.ToLower(value) == "yes"
+			case "stricthostkeychecking":
+				hc.StrictHostKeyChecking = strings.ToLower(value)
+			case "serveraliveinterval":
+				hc.ServerAliveInterval = value
+			}
+		}
+	}
+	return hc
+}
+
+// match returns true if any of the aliases matches the patterns of the block.
+// A negated pattern such as !bastion excludes the alias.
+func (b sshConfigBlock) match(aliases []string) bool {
+	for _, alias := range aliases {
+		if alias == "" {
+			continue
+		}
+		matched := false
+		negated := false
+		for _, p := range b.patterns {
+			if strings.HasPrefix(p, "!") {
+				if ok, _ := path.Match(p[1:], alias); ok {
+					negated = true
+				}
+				continue
+			}
+			if ok, _ := path.Match(p, alias); ok {
+				matched = true
+			}
+		}
+		if matched && !negated {
+			return true
+		}
+	}
+	return false
+}
+
+// expandSSHPath expands a leading ~ in a path to the home directory.
+func expandSSHPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
@@ -0,0 +1,95 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSSHConfig = `
+# global
+User default
+
+Host web-* !web-stg-*
+  User admin
+  Port 2222
+  IdentityFile ~/.ssh/web
+
+Host i-0001 10.0.0.*
+  Port=2200
+  IdentityFile "~/.ssh/id 1"
+  ForwardAgent yes
+  ServerAliveInterval 10
+
+Match user foo
+  User ignored
+
+Host *
+  IdentityFile ~/.ssh/default
+  StrictHostKeyChecking accept-new
+  UserKnownHostsFile ~/.ssh/known_hosts_myaws ~/.ssh/known_hosts2
+`
+
+func TestSSHConfigLookup(t *testing.T) {
+	config, err := parseSSHConfig(strings.NewReader(testSSHConfig))
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	cases := []struct {
+		desc    string
+		aliases []string
+		want    sshHostConfig
+	}{
+		{
+			desc:    "name",
+			aliases: []string{"web-prod-1", "i-0009", "203.0.113.1"},
+			want: sshHostConfig{
+				User:                  "default",
+				Port:                  "2222",
+				IdentityFiles:         []string{"~/.ssh/web", "~/.ssh/default"},
+				UserKnownHostsFile:    "~/.ssh/known_hosts_myaws",
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+		{
+			desc:    "negated name and instance id",
+			aliases: []string{"web-stg-1", "i-0001", "10.0.0.1"},
+			want: sshHostConfig{
+				User:                  "default",
+				Port:                  "2200",
+				IdentityFiles:         []string{"~/.ssh/id 1", "~/.ssh/default"},
+				UserKnownHostsFile:    "~/.ssh/known_hosts_myaws",
+				ForwardAgent:          true,
+				StrictHostKeyChecking: "accept-new",
+				ServerAliveInterval:   "10",
+			},
+		},
+		{
+			desc:    "no name",
+			aliases: []string{"", "i-0009", "198.51.100.1"},
+			want: sshHostConfig{
+				User:                  "default",
+				IdentityFiles:         []string{"~/.ssh/default"},
+				UserKnownHostsFile:    "~/.ssh/known_hosts_myaws",
+				StrictHostKeyChecking: "accept-new",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := config.lookup(tc.aliases...)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got = %+v, want = %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSSHConfigError(t *testing.T) {
+	_, err := parseSSHConfig(strings.NewReader("Host foo\n  User\n"))
+	if err == nil || err.Error() != "missing value of User at line 2" {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
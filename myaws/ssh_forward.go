@@ -0,0 +1,216 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshForward is a port forwarding like -L and -R of ssh.
+type sshForward struct {
+	// remote is true if the port is listened on the remote host (-R), or false
+	// if on the local host (-L).
+	remote bool
+	// listenAddr is an address to listen.
+	listenAddr string
+	// connectAddr is an address to connect from the other side.
+	connectAddr string
+}
+
+// String returns a description of the forwarding.
+func (f sshForward) String() string {
+	if f.remote {
+		return fmt.Sprintf("remote %s -> local %s", f.listenAddr, f.connectAddr)
+	}
+	return fmt.Sprintf("local %s -> remote %s", f.listenAddr, f.connectAddr)
+}
+
+// parseSSHForwards parses specs of local and remote port forwarding.
+func parseSSHForwards(locals []string, remotes []string) ([]sshForward, error) {
+	forwards := []sshForward{}
+	for _, spec := range locals {
+		f, err := parseSSHForward(spec, false)
+		if err != nil {
+			return nil, err
+		}
+		forwards = append(forwards, f)
+	}
+	for _, spec := range remotes {
+		f, err := parseSSHForward(spec, true)
+		if err != nil {
+			return nil, err
+		}
+		forwards = append(forwards, f)
+	}
+	return forwards, nil
+}
+
+// parseSSHForward parses a spec of port forwarding in the form of
+// [bind_address:]port:host:hostport as ssh does. An IPv6 address is enclosed
+// in square brackets. The bind address defaults to localhost, and an empty
+// address or * means all interfaces.
+func parseSSHForward(spec string, remote bool) (sshForward, error) {
+	parts := splitSSHForwardSpec(spec)
+	var bind string
+	switch len(parts) {
+	case 3:
+		bind = "localhost"
+	case 4:
+		bind = parts[0]
+		if bind == "*" {
+			bind = ""
+		}
+		parts = parts[1:]
+	default:
+		return sshForward{}, errors.Errorf("invalid port forwarding: %s (expected [bind_address:]port:host:hostport)", spec)
+	}
+
+	port, host, hostport := parts[0], parts[1], parts[2]
+	for _, p := range []string{port, hostport} {
+		if n, err := strconv.Atoi(p); err != nil || n < 0 || n > 65535 {
+			return sshForward{}, errors.Errorf("invalid port number in port forwarding: %s", spec)
+		}
+	}
+	if host == "" {
+		return sshForward{}, errors.Errorf("missing host in port forwarding: %s", spec)
+	}
+
+	return sshForward{
+		remote:      remote,
+		listenAddr:  net.JoinHostPort(bind, port),
+		connectAddr: net.JoinHostPort(host, hostport),
+	}, nil
+}
+
+// splitSSHForwardSpec splits a spec by colons outside of square brackets and
+// removes the brackets.
+func splitSSHForwardSpec(spec string) []string {
+	parts := []string{}
+	bracket := false
+	var b strings.Builder
+	for _, c := range spec {
+		switch {
+		case c == '[':
+			bracket = true
+		case c == ']':
+			bracket = false
+		case c == ':' && !bracket:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return append(parts, b.String())
+}
+
+// startSSHForwards starts listening ports of the forwarding, and returns a
+// function to stop them.
+func (client *Client) startSSHForwards(connection *ssh.Client, forwards []sshForward) (func(), error) {
+	listeners := []net.Listener{}
+	stop := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+
+	for _, f := range forwards {
+		var l net.Listener
+		var err error
+		if f.remote {
+			l, err = connection.Listen("tcp", f.listenAddr)
+		} else {
+			l, err = net.Listen("tcp", f.listenAddr)
+		}
+		if err != nil {
+			stop()
+			return nil, errors.Wrapf(err, "failed to listen for port forwarding %s:", f)
+		}
+		listeners = append(listeners, l)
+		fmt.Fprintf(client.stderr, "Forwarding %s\n", f)
+		go client.serveSSHForward(connection, f, l)
+	}
+	return stop, nil
+}
+
+// serveSSHForward accepts connections on the listener and connects them to
+// the other side until the listener is closed.
+func (client *Client) serveSSHForward(connection *ssh.Client, f sshForward, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			var dst net.Conn
+			var err error
+			if f.remote {
+				dst, err = net.Dial("tcp", f.connectAddr)
+			} else {
+				dst, err = connection.Dial("tcp", f.connectAddr)
+			}
+			if err != nil {
+				fmt.Fprintf(client.stderr, "port forwarding %s failed: %s\n", f, err)
+				conn.Close()
+				return
+			}
+			pipeConns(conn, dst)
+		}()
+	}
+}
+
+// pipeConns copies data between two connections in both directions, and
+// closes them when either side is closed.
+func pipeConns(a net.Conn, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
+
+// forwardSSHPorts connects to the host and forwards ports without executing
+// a command until the context is done or the connection is closed.
+func (client *Client) forwardSSHPorts(ctx context.Context, host sshHost, forwards []sshForward) error {
+	connection, err := dialSSH(ctx, host)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	stop, err := client.startSSHForwards(connection, forwards)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	closed := make(chan struct{})
+	go func() {
+		connection.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-closed:
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errors.Errorf("connection to %s closed", host.hostname)
+	}
+}
@@ -0,0 +1,159 @@
+package myaws
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSHForward(t *testing.T) {
+	cases := []struct {
+		spec    string
+		remote  bool
+		want    sshForward
+		wantErr string
+	}{
+		{
+			spec: "5432:db.example.com:5432",
+			want: sshForward{listenAddr: "localhost:5432", connectAddr: "db.example.com:5432"},
+		},
+		{
+			spec:   "*:8080:localhost:80",
+			remote: true,
+			want:   sshForward{remote: true, listenAddr: ":8080", connectAddr: "localhost:80"},
+		},
+		{
+			spec: "[::1]:8080:[2001:db8::1]:80",
+			want: sshForward{listenAddr: "[::1]:8080", connectAddr: "[2001:db8::1]:80"},
+		},
+		{spec: "5432:db", wantErr: "invalid port forwarding: 5432:db"},
+		{spec: "foo:db:5432", wantErr: "invalid port number"},
+		{spec: "5432::5432", wantErr: "missing host"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := parseSSHForward(tc.spec, tc.remote)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error %q, but got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("got = %+v, want = %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// newTestEchoServer starts a TCP server which sends back lines it receives,
+// and returns its address.
+func newTestEchoServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// freeTestPort returns a port which is likely to be free.
+func freeTestPort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return port
+}
+
+// echoTest sends a line to the address and checks it's sent back.
+func echoTest(t *testing.T, addr string) {
+	t.Helper()
+	var conn net.Conn
+	var err error
+	// Wait for the forwarding to start listening.
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %s", addr, err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, "hello")
+	got, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || got != "hello\n" {
+		t.Errorf("got = %q, err = %v", got, err)
+	}
+}
+
+func TestForwardSSHPorts(t *testing.T) {
+	s := newTestSSHServer(t)
+	client, _ := newTestClient(t, Services{})
+	echo := newTestEchoServer(t)
+	localPort := freeTestPort(t)
+	remotePort := freeTestPort(t)
+
+	forwards, err := parseSSHForwards(
+		[]string{"127.0.0.1:" + localPort + ":" + echo},
+		[]string{"127.0.0.1:" + remotePort + ":" + echo},
+	)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.forwardSSHPorts(ctx, s.host("i-0001"), forwards)
+	}()
+
+	// local: 127.0.0.1:localPort -> ssh -> echo
+	echoTest(t, "127.0.0.1:"+localPort)
+	if got := s.dialed(); len(got) != 1 || got[0] != echo {
+		t.Errorf("dialed = %v, want = [%s]", got, echo)
+	}
+	// remote: the server listens 127.0.0.1:remotePort -> ssh -> echo
+	echoTest(t, "127.0.0.1:"+remotePort)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected err: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarding doesn't stop")
+	}
+	if got := stderrOf(client).String(); !strings.Contains(got, "Forwarding local 127.0.0.1:"+localPort+" -> remote "+echo+"\n") {
+		t.Errorf("unexpected stderr: %s", got)
+	}
+}
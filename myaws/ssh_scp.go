@@ -0,0 +1,419 @@
+package myaws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// scpUpload copies a local file or directory to the host by the scp protocol.
+// The remote path is interpreted by scp on the host as with the scp command.
+func (client *Client) scpUpload(ctx context.Context, host sshHost, localPath string, remotePath string, recursive bool, progress *scpProgress) error {
+	fi, err := os.Stat(localPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read local file:")
+	}
+	if fi.IsDir() && !recursive {
+		return errors.Errorf("%s is a directory (use --recursive)", localPath)
+	}
+
+	return client.runSCP(ctx, host, scpCommand("-t", remotePath, recursive), func(w io.Writer, r *bufio.Reader) error {
+		s := &scpSender{w: w, r: r, progress: progress}
+		if err := s.ack(); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return s.sendDir(localPath, fi)
+		}
+		return s.sendFile(localPath, fi)
+	})
+}
+
+// scpDownload copies a remote file or directory from the host by the scp
+// protocol. As with the scp command, if the local path is an existing
+// directory, it's copied into the directory.
+func (client *Client) scpDownload(ctx context.Context, host sshHost, remotePath string, localPath string, recursive bool, progress *scpProgress) error {
+	return client.runSCP(ctx, host, scpCommand("-f", remotePath, recursive), func(w io.Writer, r *bufio.Reader) error {
+		s := &scpReceiver{w: w, r: r, progress: progress, target: localPath}
+		return s.receive()
+	})
+}
+
+// scpCommand returns a command line of scp on the host in the mode of -t
+// (sink) or -f (source).
+func scpCommand(mode string, remotePath string, recursive bool) string {
+	args := []string{"scp", mode}
+	if recursive {
+		args = append(args, "-r")
+	}
+	return strings.Join(append(args, "--", quoteSCPPath(remotePath)), " ")
+}
+
+// quoteSCPPath quotes a remote path for the shell of the host. A leading ~/
+// is left unquoted to be expanded to the home directory, and an empty path
+// means the home directory.
+func quoteSCPPath(p string) string {
+	if p == "" {
+		return "."
+	}
+	prefix := ""
+	if strings.HasPrefix(p, "~/") {
+		prefix, p = "~/", p[2:]
+		if p == "" {
+			return prefix
+		}
+	}
+	return prefix + "'" + strings.ReplaceAll(p, "'", `'\''`) + "'"
+}
+
+// runSCP starts scp on the host and runs the protocol with its stdin and
+// stdout.
+func (client *Client) runSCP(ctx context.Context, host sshHost, command string, protocol func(w io.Writer, r *bufio.Reader) error) error {
+	connection, err := dialSSH(ctx, host)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	session, err := newSSHSession(connection, host)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "unable to setup stdin for session:")
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "unable to setup stdout for session:")
+	}
+	stderr := &bytes.Buffer{}
+	session.Stderr = stderr
+
+	if err := session.Start(command); err != nil {
+		return errors.Wrapf(err, "failed to start scp: %s", command)
+	}
+
+	perr := protocol(stdin, bufio.NewReader(stdout))
+	stdin.Close()
+	werr := session.Wait()
+	switch {
+	case perr != nil:
+		return perr
+	case werr != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.Errorf("scp failed: %s", msg)
+		}
+		return errors.Wrap(werr, "scp failed:")
+	}
+	return nil
+}
+
+// scpSender sends files to scp -t.
+type scpSender struct {
+	w        io.Writer
+	r        *bufio.Reader
+	progress *scpProgress
+	// dirs is a stack of local directories being sent.
+	dirs []os.FileInfo
+}
+
+func (s *scpSender) ack() error {
+	return readSCPAck(s.r)
+}
+
+// sending returns true if the directory is being sent. A symlink to it makes
+// a loop, such as dir/self -> .
+func (s *scpSender) sending(fi os.FileInfo) bool {
+	for _, d := range s.dirs {
+		if os.SameFile(d, fi) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scpSender) sendFile(path string, fi os.FileInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open local file:")
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(s.w, "C%04o %d %s\n", fi.Mode().Perm(), fi.Size(), fi.Name()); err != nil {
+		return errors.Wrap(err, "failed to send file header:")
+	}
+	if err := s.ack(); err != nil {
+		return err
+	}
+
+	p := s.progress.file(fi.Name(), fi.Size())
+	if _, err := io.CopyN(io.MultiWriter(s.w, p), f, fi.Size()); err != nil {
+		return errors.Wrapf(err, "failed to send %s:", path)
+	}
+	p.done()
+	if _, err := s.w.Write([]byte{0}); err != nil {
+		return errors.Wrap(err, "failed to send file:")
+	}
+	return s.ack()
+}
+
+func (s *scpSender) sendDir(path string, fi os.FileInfo) error {
+	if _, err := fmt.Fprintf(s.w, "D%04o 0 %s\n", fi.Mode().Perm(), fi.Name()); err != nil {
+		return errors.Wrap(err, "failed to send directory header:")
+	}
+	if err := s.ack(); err != nil {
+		return err
+	}
+	s.dirs = append(s.dirs, fi)
+	defer func() { s.dirs = s.dirs[:len(s.dirs)-1] }()
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read local directory:")
+	}
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		// Follow symlinks as scp does.
+		fi, err := os.Stat(p)
+		if err != nil {
+			return errors.Wrap(err, "failed to read local file:")
+		}
+		switch {
+		case fi.IsDir() && s.sending(fi):
+			continue
+		case fi.IsDir():
+			err = s.sendDir(p, fi)
+		case fi.Mode().IsRegular():
+			err = s.sendFile(p, fi)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprint(s.w, "E\n"); err != nil {
+		return errors.Wrap(err, "failed to send end of directory:")
+	}
+	return s.ack()
+}
+
+// scpReceiver receives files from scp -f.
+type scpReceiver struct {
+	w        io.Writer
+	r        *bufio.Reader
+	progress *scpProgress
+	// target is a local path to copy to.
+	target string
+	// dirs is a stack of local directories being received.
+	dirs []string
+}
+
+func (s *scpReceiver) ok() error {
+	if _, err := s.w.Write([]byte{0}); err != nil {
+		return errors.Wrap(err, "failed to send scp response:")
+	}
+	return nil
+}
+
+func (s *scpReceiver) receive() error {
+	if err := s.ok(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read scp message:")
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return errors.New("unexpected empty scp message")
+		}
+
+		switch line[0] {
+		case 1, 2:
+			return errors.Errorf("scp: %s", strings.TrimSpace(line[1:]))
+		case 'T':
+			// Times are ignored.
+			err = s.ok()
+		case 'C':
+			err = s.receiveFile(line)
+		case 'D':
+			err = s.receiveDir(line)
+		case 'E':
+			if len(s.dirs) == 0 {
+				return errors.New("unexpected end of directory from scp")
+			}
+			s.dirs = s.dirs[:len(s.dirs)-1]
+			err = s.ok()
+		default:
+			return errors.Errorf("unexpected scp message: %q", line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// path returns a local path of a received file or directory.
+func (s *scpReceiver) path(name string) (string, error) {
+	// Don't allow the remote host to write files outside of the target.
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", errors.Errorf("invalid file name from scp: %q", name)
+	}
+	if len(s.dirs) > 0 {
+		return filepath.Join(s.dirs[len(s.dirs)-1], name), nil
+	}
+	if fi, err := os.Stat(s.target); err == nil && fi.IsDir() {
+		return filepath.Join(s.target, name), nil
+	}
+	return s.target, nil
+}
+
+func (s *scpReceiver) receiveFile(line string) error {
+	mode, size, name, err := parseSCPHeader(line)
+	if err != nil {
+		return err
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Wrap(err, "failed to create local file:")
+	}
+	defer f.Close()
+
+	if err := s.ok(); err != nil {
+		return err
+	}
+	p := s.progress.file(name, size)
+	if _, err := io.CopyN(io.MultiWriter(f, p), s.r, size); err != nil {
+		return errors.Wrapf(err, "failed to receive %s:", name)
+	}
+	if err := readSCPAck(s.r); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to write local file:")
+	}
+	p.done()
+	return s.ok()
+}
+
+func (s *scpReceiver) receiveDir(line string) error {
+	mode, _, name, err := parseSCPHeader(line)
+	if err != nil {
+		return err
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, mode|0700); err != nil {
+		return errors.Wrap(err, "failed to create local directory:")
+	}
+	s.dirs = append(s.dirs, path)
+	return s.ok()
+}
+
+// parseSCPHeader parses a header of a file or a directory such as
+// "C0644 123 name".
+func parseSCPHeader(line string) (os.FileMode, int64, string, error) {
+	parts := strings.SplitN(line[1:], " ", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", errors.Errorf("invalid scp header: %q", line)
+	}
+	mode, err := strconv.ParseUint(parts[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", errors.Errorf("invalid mode in scp header: %q", line)
+	}
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", errors.Errorf("invalid size in scp header: %q", line)
+	}
+	return os.FileMode(mode).Perm(), size, parts[2], nil
+}
+
+// readSCPAck reads a response of scp. A response other than 0 has an error
+// message.
+func readSCPAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return errors.Wrap(err, "failed to read scp response:")
+	}
+	if b == 0 {
+		return nil
+	}
+	msg, _ := r.ReadString('\n')
+	return errors.Errorf("scp: %s", strings.TrimSpace(msg))
+}
+
+// scpProgress prints progress of files copied from or to a host. A nil
+// progress prints nothing.
+type scpProgress struct {
+	w      io.Writer
+	prefix string
+	// interval is a minimum interval to print progress of a file.
+	interval time.Duration
+}
+
+// newSCPProgress returns a progress of the host. w may be shared by hosts.
+func newSCPProgress(w io.Writer, host sshHost) *scpProgress {
+	return &scpProgress{w: w, prefix: host.hostname + " | ", interval: time.Second}
+}
+
+// file returns a writer to count bytes of a file.
+func (p *scpProgress) file(name string, size int64) *scpFileProgress {
+	return &scpFileProgress{progress: p, name: name, size: size, start: time.Now(), last: time.Now()}
+}
+
+// scpFileProgress counts bytes of a file and prints them periodically.
+type scpFileProgress struct {
+	progress *scpProgress
+	name     string
+	size     int64
+	written  int64
+	start    time.Time
+	last     time.Time
+}
+
+func (f *scpFileProgress) Write(b []byte) (int, error) {
+	f.written += int64(len(b))
+	if f.progress != nil && time.Since(f.last) >= f.progress.interval && f.written < f.size {
+		f.last = time.Now()
+		fmt.Fprintf(f.progress.w, "%s%s %s / %s (%d%%)\n", f.progress.prefix, f.name,
+			humanize.Bytes(uint64(f.written)), humanize.Bytes(uint64(f.size)), f.written*100/f.size)
+	}
+	return len(b), nil
+}
+
+// done prints that the file has been copied.
+func (f *scpFileProgress) done() {
+	if f.progress != nil {
+		fmt.Fprintf(f.progress.w, "%s%s %s (100%%) in %s\n", f.progress.prefix, f.name,
+			humanize.Bytes(uint64(f.size)), time.Since(f.start).Round(time.Millisecond))
+	}
+}
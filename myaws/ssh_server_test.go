@@ -0,0 +1,404 @@
+package myaws
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// testSSHServer is an SSH server for testing. Instead of executing commands,
+// it runs a fake shell which supports the following commands:
+//   - echo ARGS... prints ARGS to stdout.
+//   - warn ARGS... prints ARGS to stderr.
+//   - exit N exits with the status N.
+//   - sleep DURATION sleeps until the connection is closed.
+//   - agent prints the number of keys of the forwarded agent.
+//   - scp ARGS... runs the scp command of the local host. Single quotes of
+//     arguments are removed.
+//
+// It also supports connecting to other addresses (direct-tcpip) and remote
+// port forwarding (tcpip-forward).
+type testSSHServer struct {
+	addr    string
+	port    string
+	hostKey ssh.PublicKey
+	// clientConfig is a config to connect to the server without verifying the
+	// host key.
+	clientConfig *ssh.ClientConfig
+	// authorizedKey restricts the public key of clients if set.
+	authorizedKey ssh.PublicKey
+	// ignoreKeepAlive doesn't respond to keepalive messages as a dead host.
+	ignoreKeepAlive bool
+
+	mu          sync.Mutex
+	commands    []string
+	users       []string
+	dials       []string
+	ptyRequests int
+	keepAlives  int
+	windowSizes []string
+}
+
+// testSSHClientConfig returns a client config with a new key, which doesn't
+// verify the host key.
+func testSSHClientConfig(t *testing.T) *ssh.ClientConfig {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %s", err)
+	}
+	return &ssh.ClientConfig{
+		User:            "ec2-user",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+}
+
+// newTestSSHServer starts a new SSH server on a random local port. It accepts
+// any public key unless authorizedKey is set.
+func newTestSSHServer(t *testing.T) *testSSHServer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %s", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %s", err)
+	}
+
+	s := &testSSHServer{hostKey: signer.PublicKey(), clientConfig: testSSHClientConfig(t)}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.authorizedKey != nil && !bytes.Equal(key.Marshal(), s.authorizedKey.Marshal()) {
+				return nil, fmt.Errorf("unauthorized key for %s", conn.User())
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s.addr = l.Addr().String()
+	_, s.port, _ = net.SplitHostPort(s.addr)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+// host returns a host of the server.
+func (s *testSSHServer) host(instanceID string) sshHost {
+	return sshHost{instanceID: instanceID, name: "test-" + instanceID, hostname: "127.0.0.1", port: s.port, config: s.clientConfig}
+}
+
+// authorize restricts the public key of clients.
+func (s *testSSHServer) authorize(key ssh.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorizedKey = key
+}
+
+// loginUsers returns users logged in so far.
+func (s *testSSHServer) loginUsers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.users...)
+}
+
+// dialed returns addresses connected by clients through the server so far.
+func (s *testSSHServer) dialed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.dials...)
+}
+
+// executed returns commands executed so far.
+func (s *testSSHServer) executed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.users = append(s.users, serverConn.User())
+	s.mu.Unlock()
+	closed := make(chan struct{})
+	go func() {
+		serverConn.Wait()
+		close(closed)
+	}()
+	go s.serveGlobalRequests(serverConn, reqs)
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+		case "direct-tcpip":
+			go s.serveDirectTCPIP(newChannel)
+			continue
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.serveSession(serverConn, ch, requests, closed)
+	}
+}
+
+// serveDirectTCPIP connects a channel to the requested address.
+func (s *testSSHServer) serveDirectTCPIP(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	ssh.Unmarshal(newChannel.ExtraData(), &payload)
+	addr := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+	s.mu.Lock()
+	s.dials = append(s.dials, addr)
+	s.mu.Unlock()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := newChannel.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	pipeConns(conn, testSSHChannelConn{ch})
+}
+
+// serveGlobalRequests serves requests of remote port forwarding.
+func (s *testSSHServer) serveGlobalRequests(serverConn *ssh.ServerConn, reqs <-chan *ssh.Request) {
+	listeners := []net.Listener{}
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+
+	for req := range reqs {
+		if req.Type == "keepalive@openssh.com" {
+			s.mu.Lock()
+			s.keepAlives++
+			ignore := s.ignoreKeepAlive
+			s.mu.Unlock()
+			if !ignore {
+				req.Reply(false, nil)
+			}
+			continue
+		}
+		if req.Type != "tcpip-forward" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct {
+			Addr string
+			Port uint32
+		}
+		ssh.Unmarshal(req.Payload, &payload)
+		l, err := net.Listen("tcp", net.JoinHostPort(payload.Addr, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		listeners = append(listeners, l)
+		port := uint32(l.Addr().(*net.TCPAddr).Port)
+		req.Reply(true, ssh.Marshal(struct{ Port uint32 }{port}))
+
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				origin := conn.RemoteAddr().(*net.TCPAddr)
+				ch, reqs, err := serverConn.OpenChannel("forwarded-tcpip", ssh.Marshal(struct {
+					Addr       string
+					Port       uint32
+					OriginAddr string
+					OriginPort uint32
+				}{payload.Addr, port, origin.IP.String(), uint32(origin.Port)}))
+				if err != nil {
+					conn.Close()
+					continue
+				}
+				go ssh.DiscardRequests(reqs)
+				go pipeConns(conn, testSSHChannelConn{ch})
+			}
+		}()
+	}
+}
+
+// testSSHChannelConn adapts an ssh.Channel to net.Conn for pipeConns.
+type testSSHChannelConn struct {
+	ssh.Channel
+}
+
+func (c testSSHChannelConn) LocalAddr() net.Addr                { return nil }
+func (c testSSHChannelConn) RemoteAddr() net.Addr               { return nil }
+func (c testSSHChannelConn) SetDeadline(t time.Time) error      { return nil }
+func (c testSSHChannelConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c testSSHChannelConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func (s *testSSHServer) serveSession(serverConn *ssh.ServerConn, ch ssh.Channel, requests <-chan *ssh.Request, closed <-chan struct{}) {
+	for req := range requests {
+		switch req.Type {
+		case "auth-agent-req@openssh.com":
+			req.Reply(true, nil)
+		case "window-change":
+			var payload struct{ Width, Height, PixelWidth, PixelHeight uint32 }
+			ssh.Unmarshal(req.Payload, &payload)
+			s.mu.Lock()
+			s.windowSizes = append(s.windowSizes, fmt.Sprintf("%dx%d", payload.Width, payload.Height))
+			s.mu.Unlock()
+		case "pty-req":
+			s.mu.Lock()
+			s.ptyRequests++
+			s.mu.Unlock()
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			req.Reply(true, nil)
+			go func() {
+				status := s.run(serverConn, payload.Command, ch, ch, ch.Stderr(), closed)
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+				ch.Close()
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// stats returns the number of keepalive messages and window sizes changed so
+// far.
+func (s *testSSHServer) stats() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keepAlives, append([]string{}, s.windowSizes...)
+}
+
+// ptys returns the number of pty requests so far.
+func (s *testSSHServer) ptys() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ptyRequests
+}
+
+// run runs a command of the fake shell and returns its exit status.
+func (s *testSSHServer) run(serverConn *ssh.ServerConn, command string, stdin io.Reader, stdout io.Writer, stderr io.Writer, closed <-chan struct{}) int {
+	s.mu.Lock()
+	s.commands = append(s.commands, command)
+	s.mu.Unlock()
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return 0
+	}
+	switch args[0] {
+	case "echo":
+		fmt.Fprintln(stdout, strings.Join(args[1:], " "))
+		return 0
+	case "warn":
+		fmt.Fprintln(stderr, strings.Join(args[1:], " "))
+		return 0
+	case "exit":
+		status, _ := strconv.Atoi(args[1])
+		return status
+	case "sleep":
+		d, _ := time.ParseDuration(args[1])
+		select {
+		case <-time.After(d):
+		case <-closed:
+		}
+		return 0
+	case "agent":
+		ch, reqs, err := serverConn.OpenChannel("auth-agent@openssh.com", nil)
+		if err != nil {
+			fmt.Fprintf(stderr, "agent: %s\n", err)
+			return 1
+		}
+		go ssh.DiscardRequests(reqs)
+		defer ch.Close()
+		keys, err := agent.NewClient(ch).List()
+		if err != nil {
+			fmt.Fprintf(stderr, "agent: %s\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, len(keys))
+		return 0
+	case "scp":
+		for i := range args {
+			args[i] = strings.Trim(args[i], "'")
+		}
+		cmd := exec.Command("scp", args[1:]...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		// Don't wait for stdin to be closed after scp exits as sshd does.
+		w, err := cmd.StdinPipe()
+		if err != nil {
+			fmt.Fprintf(stderr, "scp: %s\n", err)
+			return 1
+		}
+		go func() {
+			io.Copy(w, stdin)
+			w.Close()
+		}()
+		if err := cmd.Run(); err != nil {
+			if e, ok := err.(*exec.ExitError); ok {
+				return e.ExitCode()
+			}
+			fmt.Fprintf(stderr, "scp: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "%s: command not found\n", args[0])
+	return 127
+}
@@ -92,6 +92,46 @@ func (client *Client) getSSMParametersPerChunk(names []*string, withDecryption b
 	return response.Parameters, nil
 }
 
+// findSSMParameter returns a parameter without decryption.
+// It returns nil if not found.
+func (client *Client) findSSMParameter(name string) (*ssm.Parameter, error) {
+	response, err := client.SSM.GetParameters(&ssm.GetParametersInput{
+		Names: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetParameters failed:")
+	}
+
+	if len(response.Parameters) == 0 {
+		return nil, nil
+	}
+	return response.Parameters[0], nil
+}
+
+// findSSMParameters returns existing parameters of the names. Missing
+// parameters are ignored.
+func (client *Client) findSSMParameters(names []string, withDecryption bool) ([]*ssm.Parameter, error) {
+	results := []*ssm.Parameter{}
+	// GetParameters can only get 10 parameters at once.
+	chunkSize := 10
+	for i := 0; i < len(names); i += chunkSize {
+		end := i + chunkSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		response, err := client.SSM.GetParameters(&ssm.GetParametersInput{
+			Names:          aws.StringSlice(names[i:end]),
+			WithDecryption: aws.Bool(withDecryption),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "GetParameters failed:")
+		}
+		results = append(results, response.Parameters...)
+	}
+	return results, nil
+}
+
 // GetParametersByPath returns a list of parameters that start with the specified path.
 func (client *Client) GetParametersByPath(path *string, withDecryption bool) ([]*ssm.Parameter, error) {
 	input := &ssm.GetParametersByPathInput{
@@ -111,3 +151,28 @@ func (client *Client) GetParametersByPath(path *string, withDecryption bool) ([]
 	}
 	return parameters, nil
 }
+
+// pickSSMParameters chooses parameters matching the name in a fuzzy finder.
+func (client *Client) pickSSMParameters(name string) ([]*string, error) {
+	metadata, err := client.FindSSMParameterMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{"Name", "Type", "KeyId"}
+	rows := [][]string{}
+	for _, m := range metadata {
+		rows = append(rows, formatSSMParameterMetadata(m))
+	}
+
+	indexes, err := client.pick(fields, rows, true)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []*string{}
+	for _, i := range indexes {
+		names = append(names, metadata[i].Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,180 @@
+package myaws
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterCpOptions customize the behavior of the ParameterCp command.
+type SSMParameterCpOptions struct {
+	Source      string
+	Destination string
+	// Recursive copies all parameters under the source path to the
+	// destination path.
+	Recursive bool
+	// SrcProfile and DstProfile are AWS profiles to copy between accounts.
+	// Empty means the profile of the client.
+	SrcProfile string
+	DstProfile string
+}
+
+// ssmParameterCopy is a parameter to copy with its metadata.
+type ssmParameterCopy struct {
+	parameter *ssm.Parameter
+	metadata  *ssm.ParameterMetadata
+	dstName   string
+}
+
+// SSMParameterCp copies a parameter, or parameters under a path with
+// Recursive. Types, KMS key IDs, descriptions and tiers are kept. Note that a
+// customer managed KMS key must be accessible from the destination account.
+// Overwriting existing parameters requires confirmation.
+func (client *Client) SSMParameterCp(options SSMParameterCpOptions) error {
+	src, err := client.newProfileClient(options.SrcProfile)
+	if err != nil {
+		return err
+	}
+	dst, err := client.newProfileClient(options.DstProfile)
+	if err != nil {
+		return err
+	}
+
+	copies, err := src.findSSMParameterCopies(options.Source, options.Destination, options.Recursive)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, c := range copies {
+		names = append(names, c.dstName)
+	}
+	if err := dst.guardSSMParameters(names...); err != nil {
+		return err
+	}
+
+	if !dst.guard.Yes && !dst.dryRun {
+		existing, err := dst.findSSMParameters(names, false)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			if ok, err := dst.confirmSSMParameterOverwrite(existing); !ok {
+				return err
+			}
+		}
+	}
+
+	for _, c := range copies {
+		input := &ssm.PutParameterInput{
+			Name:        aws.String(c.dstName),
+			Value:       c.parameter.Value,
+			Type:        c.parameter.Type,
+			Description: c.metadata.Description,
+			Overwrite:   aws.Bool(true),
+		}
+		if aws.StringValue(c.parameter.Type) == ssm.ParameterTypeSecureString {
+			input.KeyId = c.metadata.KeyId
+		}
+		if aws.StringValue(c.metadata.Tier) == ssm.ParameterTierAdvanced {
+			input.Tier = c.metadata.Tier
+		}
+
+		if dst.dryRun {
+			if err := dst.planSSMParameterPut(input); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := dst.SSM.PutParameter(input)
+		dst.auditCall("SSM.PutParameter", input, []string{c.dstName}, err)
+		if err != nil {
+			return errors.Wrapf(err, "PutParameter %s failed:", c.dstName)
+		}
+		fmt.Fprintf(client.stdout, "%s -> %s\n", *c.parameter.Name, c.dstName)
+	}
+
+	return nil
+}
+
+// findSSMParameterCopies returns decrypted parameters to copy with their
+// metadata and destination names.
+func (client *Client) findSSMParameterCopies(source string, destination string, recursive bool) ([]ssmParameterCopy, error) {
+	var parameters []*ssm.Parameter
+	var dstName func(name string) string
+	if recursive {
+		srcPath, err := normalizeSSMPath(source)
+		if err != nil {
+			return nil, err
+		}
+		dstPath, err := normalizeSSMPath(destination)
+		if err != nil {
+			return nil, err
+		}
+
+		parameters, err = client.GetParametersByPath(aws.String(srcPath), true)
+		if err != nil {
+			return nil, err
+		}
+		if len(parameters) == 0 {
+			return nil, errors.Errorf("no parameters found under %s", srcPath)
+		}
+		dstName = func(name string) string {
+			return strings.TrimSuffix(dstPath, "/") + "/" + relativeSSMName(srcPath, name)
+		}
+	} else {
+		var err error
+		parameters, err = client.GetSSMParameters([]*string{aws.String(source)}, true)
+		if err != nil {
+			return nil, err
+		}
+		dstName = func(name string) string {
+			// Copy into the path as cp does.
+			if strings.HasSuffix(destination, "/") {
+				return destination + path.Base(name)
+			}
+			return destination
+		}
+	}
+
+	metadata, err := client.FindSSMParameterMetadata(source)
+	if err != nil {
+		return nil, err
+	}
+	metadataByName := map[string]*ssm.ParameterMetadata{}
+	for _, m := range metadata {
+		metadataByName[*m.Name] = m
+	}
+
+	copies := []ssmParameterCopy{}
+	for _, p := range parameters {
+		m, ok := metadataByName[*p.Name]
+		if !ok {
+			return nil, errors.Errorf("no metadata found for %s", *p.Name)
+		}
+		copies = append(copies, ssmParameterCopy{parameter: p, metadata: m, dstName: dstName(*p.Name)})
+	}
+	return copies, nil
+}
+
+// confirmSSMParameterOverwrite asks for confirmation to overwrite existing
+// parameters. Overwriting many parameters at once requires typing the number
+// of them.
+func (client *Client) confirmSSMParameterOverwrite(existing []*ssm.Parameter) (bool, error) {
+	names := []string{}
+	for _, p := range existing {
+		names = append(names, fmt.Sprintf("%s (Version %d)", *p.Name, aws.Int64Value(p.Version)))
+	}
+
+	if len(existing) >= highImpactThreshold {
+		message := fmt.Sprintf("Are you sure want to overwrite %d parameters?\n  %s", len(existing), strings.Join(names, "\n  "))
+		return client.confirmName(message, strconv.Itoa(len(existing)))
+	}
+	return client.confirm(fmt.Sprintf("Are you sure want to overwrite %s?", strings.Join(names, ", ")))
+}
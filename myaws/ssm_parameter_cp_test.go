@@ -0,0 +1,120 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSMParameterCp(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options SSMParameterCpOptions
+		// want are names and values of copied parameters with types and key IDs.
+		want    []string
+		wantOut string
+		wantErr string
+	}{
+		{
+			desc:    "recursive",
+			options: SSMParameterCpOptions{Source: "/app/staging", Destination: "/app/qa/", Recursive: true},
+			want: []string{
+				"/app/qa/db/host=db.staging String",
+				"/app/qa/db/password=secret SecureString alias/app",
+			},
+			wantOut: "/app/staging/db/host -> /app/qa/db/host\n/app/staging/db/password -> /app/qa/db/password\n",
+		},
+		{
+			desc:    "a parameter",
+			options: SSMParameterCpOptions{Source: "/app/staging/db/password", Destination: "/app/qa/db/pass"},
+			want:    []string{"/app/qa/db/pass=secret SecureString alias/app"},
+			wantOut: "/app/staging/db/password -> /app/qa/db/pass\n",
+		},
+		{
+			desc:    "into a path",
+			options: SSMParameterCpOptions{Source: "/app/staging/db/host", Destination: "/app/qa/"},
+			want:    []string{"/app/qa/host=db.staging String"},
+			wantOut: "/app/staging/db/host -> /app/qa/host\n",
+		},
+		{
+			desc:    "no parameters",
+			options: SSMParameterCpOptions{Source: "/app/dev", Destination: "/app/qa", Recursive: true},
+			wantErr: "no parameters found under /app/dev",
+		},
+		{
+			desc:    "not found",
+			options: SSMParameterCpOptions{Source: "/app/staging/db", Destination: "/app/qa/db"},
+			wantErr: "InvalidParameters",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("/app/staging/db/host", "db.staging")
+			f.put("/app/staging/db/password", "secret", "SecureString", "alias/app")
+			client, stdout := newTestClient(t, Services{SSM: f})
+
+			err := client.SSMParameterCp(tc.options)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			got := []string{}
+			for _, p := range f.parameters {
+				if strings.HasPrefix(*p.Name, "/app/qa/") {
+					got = append(got, strings.TrimSpace(*p.Name+"="+*p.Value+" "+*p.Type+" "+f.keyIDs[*p.Name]))
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got = %v, want = %v", got, tc.want)
+			}
+			if got := stdout.String(); got != tc.wantOut {
+				t.Errorf("stdout = %q, want = %q", got, tc.wantOut)
+			}
+		})
+	}
+}
+
+func TestSSMParameterCpOverwrite(t *testing.T) {
+	f := newFakeSSM("/app/staging/key", "new", "/app/qa/key", "old")
+	client, stdout := newTestClient(t, Services{SSM: f})
+	client.guard.Yes = false
+	client.stdin = strings.NewReader("n\n")
+
+	if err := client.SSMParameterCp(SSMParameterCpOptions{Source: "/app/staging", Destination: "/app/qa", Recursive: true}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !strings.Contains(stdout.String(), "Are you sure want to overwrite /app/qa/key (Version 1)?") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+	if v := *f.find("/app/qa/key").Value; v != "old" {
+		t.Errorf("the parameter was overwritten: %s", v)
+	}
+}
+
+func TestSSMParameterCpProfiles(t *testing.T) {
+	src := newFakeSSM()
+	src.put("/app/key", "secret", "SecureString", "alias/aws/ssm")
+	dst := newFakeSSM()
+	client, _ := newTestClient(t, Services{SSM: newFakeSSM()})
+	setTestTargetServices(t, func(target Target) Services {
+		if target.Profile == "stg" {
+			return Services{SSM: src}
+		}
+		return Services{SSM: dst}
+	})
+
+	err := client.SSMParameterCp(SSMParameterCpOptions{Source: "/app", Destination: "/app", Recursive: true, SrcProfile: "stg", DstProfile: "prod"})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	p := dst.find("/app/key")
+	if p == nil || *p.Value != "secret" || *p.Type != "SecureString" || dst.keyIDs["/app/key"] != "alias/aws/ssm" {
+		t.Errorf("unexpected parameter: %v (KeyId: %s)", p, dst.keyIDs["/app/key"])
+	}
+}
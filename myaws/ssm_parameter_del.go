@@ -1,6 +1,10 @@
 package myaws
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/pkg/errors"
@@ -8,19 +12,64 @@ import (
 
 // SSMParameterDelOptions customize the behavior of the ParameterDel command.
 type SSMParameterDelOptions struct {
-	Name string
+	Names []string
 }
 
-// SSMParameterDel deletes SSM parameter.
+// SSMParameterDel deletes SSM parameters.
+// Deleting many parameters at once requires typing the number of them for
+// confirmation.
 func (client *Client) SSMParameterDel(options SSMParameterDelOptions) error {
-	input := &ssm.DeleteParameterInput{
-		Name: aws.String(options.Name),
+	if err := client.guardSSMParameters(options.Names...); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Are you sure want to delete %s?", strings.Join(options.Names, ", "))
+	var ok bool
+	var err error
+	if len(options.Names) >= highImpactThreshold {
+		message = fmt.Sprintf("Are you sure want to delete %d parameters?\n  %s", len(options.Names), strings.Join(options.Names, "\n  "))
+		ok, err = client.confirmName(message, strconv.Itoa(len(options.Names)))
+	} else {
+		ok, err = client.confirm(message)
+	}
+	if !ok {
+		return err
 	}
 
-	_, err := client.SSM.DeleteParameter(input)
+	for _, name := range options.Names {
+		input := &ssm.DeleteParameterInput{
+			Name: aws.String(name),
+		}
+
+		if client.dryRun {
+			if err := client.planSSMParameterDel(input); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := client.SSM.DeleteParameter(input)
+		client.auditCall("SSM.DeleteParameter", input, []string{name}, err)
+		if err != nil {
+			return errors.Wrapf(err, "DeleteParameter %s failed:", name)
+		}
+	}
+
+	return nil
+}
+
+// planSSMParameterDel prints a plan to delete the parameter.
+func (client *Client) planSSMParameterDel(input *ssm.DeleteParameterInput) error {
+	current, err := client.findSSMParameter(*input.Name)
 	if err != nil {
-		return errors.Wrap(err, "DeleteParameters failed:")
+		return err
+	}
+
+	if current == nil {
+		client.printPlan("SSM.DeleteParameter", "%s (not found)", *input.Name)
+		return nil
 	}
 
+	client.printPlan("SSM.DeleteParameter", "%s (Type %s, Version %d)", *input.Name, aws.StringValue(current.Type), aws.Int64Value(current.Version))
 	return nil
 }
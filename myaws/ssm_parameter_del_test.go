@@ -0,0 +1,23 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSMParameterDel(t *testing.T) {
+	f := newFakeSSM("foo", "1", "bar", "2")
+	client, _ := newTestClient(t, Services{SSM: f})
+
+	if err := client.SSMParameterDel(SSMParameterDelOptions{Names: []string{"foo"}}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if f.find("foo") != nil || f.find("bar") == nil {
+		t.Errorf("unexpected parameters: %v", f.parameters)
+	}
+
+	err := client.SSMParameterDel(SSMParameterDelOptions{Names: []string{"foo"}})
+	if err == nil || !strings.Contains(err.Error(), "ParameterNotFound") {
+		t.Errorf("expected an error, but got: %v", err)
+	}
+}
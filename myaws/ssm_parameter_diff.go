@@ -0,0 +1,106 @@
+package myaws
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// SSMParameterDiffOptions customize the behavior of the ParameterDiff command.
+type SSMParameterDiffOptions struct {
+	Source      string
+	Destination string
+	// SrcProfile and DstProfile are AWS profiles of the paths. Empty means
+	// the profile of the client.
+	SrcProfile string
+	DstProfile string
+}
+
+// SSMParameterDiff compares parameters under two paths by names relative to
+// the paths, and prints names missing on either side and differences of types
+// and values. Values of SecureString are compared but masked.
+// It returns an ExitError with code 1 if there are any differences as diff
+// does.
+func (client *Client) SSMParameterDiff(options SSMParameterDiffOptions) error {
+	src, err := client.getSSMParametersUnderPath(options.SrcProfile, options.Source)
+	if err != nil {
+		return err
+	}
+	dst, err := client.getSSMParametersUnderPath(options.DstProfile, options.Destination)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range src {
+		names = append(names, name)
+	}
+	for name := range dst {
+		if _, ok := src[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fields := []string{"Name", "Difference", "Source", "Destination"}
+	rows := [][]string{}
+	for _, name := range names {
+		s, d := src[name], dst[name]
+		var difference string
+		switch {
+		case d == nil:
+			difference = "source-only"
+		case s == nil:
+			difference = "destination-only"
+		case aws.StringValue(s.Type) != aws.StringValue(d.Type):
+			difference = "type"
+		case aws.StringValue(s.Value) != aws.StringValue(d.Value):
+			difference = "value"
+		default:
+			continue
+		}
+		rows = append(rows, []string{name, difference, formatSSMDiffValue(s), formatSSMDiffValue(d)})
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := client.printRows(fields, rows, true); err != nil {
+		return err
+	}
+	return &ExitError{Code: 1}
+}
+
+// getSSMParametersUnderPath returns decrypted parameters under the path of the
+// profile by names relative to the path.
+func (client *Client) getSSMParametersUnderPath(profile string, path string) (map[string]*ssm.Parameter, error) {
+	path, err := normalizeSSMPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.newProfileClient(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := c.GetParametersByPath(aws.String(path), true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*ssm.Parameter{}
+	for _, p := range parameters {
+		results[relativeSSMName(path, *p.Name)] = p
+	}
+	return results, nil
+}
+
+// formatSSMDiffValue formats a type and a masked value of a parameter.
+func formatSSMDiffValue(p *ssm.Parameter) string {
+	if p == nil {
+		return ""
+	}
+	return aws.StringValue(p.Type) + " " + formatSSMPlanValue(aws.StringValue(p.Type), aws.StringValue(p.Value))
+}
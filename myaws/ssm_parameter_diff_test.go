@@ -0,0 +1,68 @@
+package myaws
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSSMParameterDiff(t *testing.T) {
+	f := newFakeSSM(
+		"/app/staging/same", "1",
+		"/app/staging/value", "a",
+		"/app/staging/type", "x",
+		"/app/staging/only", "1",
+		"/app/production/same", "1",
+		"/app/production/value", "b",
+		"/app/production/extra/key", "2",
+	)
+	f.put("/app/production/type", "x", "SecureString", "alias/app")
+	f.put("/app/staging/secret", "s1", "SecureString", "alias/app")
+	f.put("/app/production/secret", "s2", "SecureString", "alias/app")
+	client, stdout := newTestClient(t, Services{SSM: f})
+
+	err := client.SSMParameterDiff(SSMParameterDiffOptions{Source: "/app/staging", Destination: "/app/production/"})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 || exitErr.Err != nil {
+		t.Fatalf("expected an exit error with code 1, but got: %v", err)
+	}
+
+	want := `Name	Difference	Source	Destination
+extra/key	destination-only		String "2"
+only	source-only	String "1"	
+secret	value	SecureString (secure)	SecureString (secure)
+type	type	String "x"	SecureString (secure)
+value	value	String "a"	String "b"
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// no differences
+	stdout.Reset()
+	if err := client.SSMParameterDiff(SSMParameterDiffOptions{Source: "/app/staging", Destination: "/app/staging"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got := stdout.String(); got != "" {
+		t.Errorf("unexpected output: %s", got)
+	}
+}
+
+func TestSSMParameterDiffProfiles(t *testing.T) {
+	profiles := map[string]*fakeSSM{
+		"stg":  newFakeSSM("/app/key", "a"),
+		"prod": newFakeSSM("/app/key", "b"),
+	}
+	client, stdout := newTestClient(t, Services{SSM: newFakeSSM()})
+	setTestTargetServices(t, func(target Target) Services {
+		return Services{SSM: profiles[target.Profile]}
+	})
+
+	err := client.SSMParameterDiff(SSMParameterDiffOptions{Source: "/app", Destination: "/app", SrcProfile: "stg", DstProfile: "prod"})
+	if err == nil {
+		t.Fatal("expected an exit error, but got nil")
+	}
+	want := "Name\tDifference\tSource\tDestination\nkey\tvalue\tString \"a\"\tString \"b\"\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package myaws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+func TestSSMParameterEnv(t *testing.T) {
+	f := newFakeSSM(
+		"myapp.prod.db_host", "db.example.com",
+		"myapp.prod.nested.key", "nested",
+		"/myapp/prod/db_host", "db.example.com",
+		"/myapp/prod/nested/key", "nested",
+		"/myapp/stg/db_host", "stg.example.com",
+	)
+	f.put("/myapp/prod/password", "secret", "SecureString", "alias/myapp")
+
+	cases := []struct {
+		desc    string
+		options SSMParameterEnvOptions
+		want    string
+	}{
+		{
+			desc:    "prefix",
+			options: SSMParameterEnvOptions{Name: "myapp.prod"},
+			want:    "DB_HOST=db.example.com NESTED_KEY=nested",
+		},
+		{
+			desc:    "path",
+			options: SSMParameterEnvOptions{Name: "/myapp/prod"},
+			want:    "DB_HOST=db.example.com NESTED_KEY=nested PASSWORD=secret",
+		},
+		{
+			desc:    "docker format",
+			options: SSMParameterEnvOptions{Name: "/myapp/stg", DockerFormat: true},
+			want:    "-e DB_HOST=stg.example.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{SSM: f})
+			if err := client.SSMParameterEnv(tc.options); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatSSMParameterAsEnv(t *testing.T) {
+	cases := []struct {
+		name   string
+		prefix string
+		docker bool
+		want   string
+	}{
+		{name: "foo.bar.baz", prefix: "foo", want: "BAR_BAZ=v"},
+		{name: "foo.bar-baz", prefix: "foo.", want: "BAR-BAZ=v"},
+		{name: "/foo/bar/baz", prefix: "/foo", docker: true, want: "-e BAR_BAZ=v"},
+	}
+
+	for _, tc := range cases {
+		parameter := &ssm.Parameter{Name: aws.String(tc.name), Value: aws.String("v")}
+		if got := formatSSMParameterAsEnv(parameter, tc.prefix, tc.docker); got != tc.want {
+			t.Errorf("formatSSMParameterAsEnv(%q, %q, %t) = %q, want %q", tc.name, tc.prefix, tc.docker, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package myaws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterExportOptions customize the behavior of the ParameterExport command.
+type SSMParameterExportOptions struct {
+	Path string
+	// Format is one of SSMParameterFormats.
+	Format string
+}
+
+// SSMParameterExport prints decrypted parameters under the path in a format
+// which can be imported by SSMParameterImport. Types and KMS key IDs of
+// parameters other than String are kept.
+func (client *Client) SSMParameterExport(options SSMParameterExportOptions) error {
+	path, err := normalizeSSMPath(options.Path)
+	if err != nil {
+		return err
+	}
+
+	parameters, err := client.GetParametersByPath(aws.String(path), true)
+	if err != nil {
+		return err
+	}
+	if len(parameters) == 0 {
+		return errors.Errorf("no parameters found under %s", path)
+	}
+	metadata, err := client.FindSSMParameterMetadata(path)
+	if err != nil {
+		return err
+	}
+	keyIDs := map[string]string{}
+	for _, m := range metadata {
+		keyIDs[*m.Name] = aws.StringValue(m.KeyId)
+	}
+
+	entries := []ssmParameterEntry{}
+	for _, p := range parameters {
+		e := ssmParameterEntry{
+			name:  relativeSSMName(path, *p.Name),
+			value: aws.StringValue(p.Value),
+			typ:   aws.StringValue(p.Type),
+		}
+		if e.typ == ssm.ParameterTypeSecureString {
+			e.keyID = keyIDs[*p.Name]
+		}
+		entries = append(entries, e)
+	}
+
+	b, err := encodeSSMParameterFile(options.Format, entries)
+	if err != nil {
+		return err
+	}
+	_, err = client.stdout.Write(b)
+	return err
+}
@@ -0,0 +1,117 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestSSMParameterExport(t *testing.T) {
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "dotenv",
+			want: `db/host=db.example.com
+# myaws type=SecureString key-id=alias/app
+db/password="p@ss word"
+# myaws type=StringList
+hosts=a,b
+`,
+		},
+		{
+			format: "json",
+			want: `{
+  "db": {
+    "host": "db.example.com",
+    "password": {
+      "Value": "p@ss word",
+      "Type": "SecureString",
+      "KeyId": "alias/app"
+    }
+  },
+  "hosts": [
+    "a",
+    "b"
+  ]
+}
+`,
+		},
+		{
+			format: "yaml",
+			want: `db:
+  host: db.example.com
+  password:
+    Value: p@ss word
+    Type: SecureString
+    KeyId: alias/app
+hosts:
+- a
+- b
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			f := newFakeSSM("/app/prod/db/host", "db.example.com", "/app/staging/db/host", "db.staging")
+			f.put("/app/prod/db/password", "p@ss word", "SecureString", "alias/app")
+			f.put("/app/prod/hosts", "a,b", "StringList", "")
+			client, stdout := newTestClient(t, Services{SSM: f})
+
+			if err := client.SSMParameterExport(SSMParameterExportOptions{Path: "/app/prod/", Format: tc.format}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("stdout = %q, want = %q", got, tc.want)
+			}
+
+			// The output can be imported as it is.
+			entries, err := parseSSMParameterFile(tc.format, stdout.Bytes())
+			if err != nil {
+				t.Fatalf("failed to parse the output: %s", err)
+			}
+			want := `db/host="db.example.com"
+db/password="p@ss word" SecureString alias/app
+hosts="a,b" StringList`
+			if got := formatTestSSMParameterEntries(entries); got != want {
+				t.Errorf("round trip = %s, want = %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSSMParameterExportError(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options SSMParameterExportOptions
+		wantErr string
+	}{
+		{
+			desc:    "no parameters",
+			options: SSMParameterExportOptions{Path: "/app/dev", Format: "dotenv"},
+			wantErr: "no parameters found under /app/dev",
+		},
+		{
+			desc:    "value and children",
+			options: SSMParameterExportOptions{Path: "/app", Format: "json"},
+			wantErr: "db has both a value and children, which can't be exported as json",
+		},
+		{
+			desc:    "unknown format",
+			options: SSMParameterExportOptions{Path: "/app", Format: "toml"},
+			wantErr: "unknown format: toml (expected dotenv|json|yaml)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("/app/db", "db", "/app/db/host", "db.example.com")
+			client, _ := newTestClient(t, Services{SSM: f})
+
+			err := client.SSMParameterExport(tc.options)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package myaws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Formats of parameter files.
+const (
+	SSMParameterFormatDotenv = "dotenv"
+	SSMParameterFormatJSON   = "json"
+	SSMParameterFormatYAML   = "yaml"
+)
+
+// SSMParameterFormats is a list of formats of parameter files.
+var SSMParameterFormats = []string{SSMParameterFormatDotenv, SSMParameterFormatJSON, SSMParameterFormatYAML}
+
+// ssmParameterEntry is a parameter in a file. The name is relative to a path
+// and separated by slashes. An empty type means the default.
+type ssmParameterEntry struct {
+	name  string
+	value string
+	typ   string
+	keyID string
+}
+
+// detectSSMParameterFormat returns the format of a file by its extension.
+func detectSSMParameterFormat(path string) (string, error) {
+	base := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(base, ".env") || strings.HasSuffix(base, ".env"):
+		return SSMParameterFormatDotenv, nil
+	case strings.HasSuffix(base, ".json"):
+		return SSMParameterFormatJSON, nil
+	case strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml"):
+		return SSMParameterFormatYAML, nil
+	}
+	return "", errors.Errorf("unknown format of %s (use --format %s)", path, strings.Join(SSMParameterFormats, "|"))
+}
+
+// parseSSMParameterFile parses parameters in the format.
+func parseSSMParameterFile(format string, b []byte) ([]ssmParameterEntry, error) {
+	var entries []ssmParameterEntry
+	switch format {
+	case SSMParameterFormatDotenv:
+		var err error
+		entries, err = parseSSMParameterDotenv(b)
+		if err != nil {
+			return nil, err
+		}
+	case SSMParameterFormatJSON:
+		d := json.NewDecoder(bytes.NewReader(b))
+		// Keep numbers as they are written.
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, errors.Wrap(err, "failed to parse json:")
+		}
+		if err := walkSSMParameterTree("", v, &entries); err != nil {
+			return nil, err
+		}
+	case SSMParameterFormatYAML:
+		var v ssmParameterYAMLValue
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return nil, errors.Wrap(err, "failed to parse yaml:")
+		}
+		if err := walkSSMParameterTree("", v.value, &entries); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unknown format: %s (expected %s)", format, strings.Join(SSMParameterFormats, "|"))
+	}
+
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.name] {
+			return nil, errors.Errorf("duplicated key: %s", e.name)
+		}
+		seen[e.name] = true
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+// ssmParameterDirective is a comment before a key in a dotenv file, which
+// sets the type and the KMS key ID of the key, such as
+// "# myaws type=SecureString key-id=alias/app".
+const ssmParameterDirective = "# myaws "
+
+var dotenvKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-/]+$`)
+
+// parseSSMParameterDotenv parses lines of KEY=VALUE. A value can be quoted
+// with double quotes with escapes, or single quotes without escapes. An
+// unquoted value ends at " #".
+func parseSSMParameterDotenv(b []byte) ([]ssmParameterEntry, error) {
+	entries := []ssmParameterEntry{}
+	var directive ssmParameterEntry
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, ssmParameterDirective):
+			var err error
+			directive, err = parseSSMParameterDirective(strings.TrimPrefix(line, ssmParameterDirective))
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", n)
+			}
+			continue
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, errors.Errorf("line %d: expected KEY=VALUE: %s", n, line)
+		}
+		key := strings.TrimSpace(line[:i])
+		if !dotenvKeyRegexp.MatchString(key) {
+			return nil, errors.Errorf("line %d: invalid key: %s", n, key)
+		}
+		value, err := parseDotenvValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+
+		directive.name, directive.value = key, value
+		entries = append(entries, directive)
+		directive = ssmParameterEntry{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read dotenv:")
+	}
+	return entries, nil
+}
+
+func parseSSMParameterDirective(s string) (ssmParameterEntry, error) {
+	e := ssmParameterEntry{}
+	for _, field := range strings.Fields(s) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return e, errors.Errorf("invalid directive: %s", field)
+		}
+		switch kv[0] {
+		case "type":
+			e.typ = kv[1]
+		case "key-id":
+			e.keyID = kv[1]
+		default:
+			return e, errors.Errorf("unknown directive: %s", kv[0])
+		}
+	}
+	return e, nil
+}
+
+// parseDotenvValue parses a value of dotenv. A quoted value can be followed
+// by a comment such as "a b" # note, while a comment of an unquoted value has
+// to be preceded by a space.
+func parseDotenvValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		// Find the closing quote, skipping escaped characters.
+		end := 1
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) || !isDotenvComment(s[end+1:]) {
+			return "", errors.Errorf("invalid quoted value: %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", errors.Errorf("invalid quoted value: %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'") + 1
+		if end == 0 || !isDotenvComment(s[end+1:]) {
+			return "", errors.Errorf("invalid quoted value: %s", s)
+		}
+		return s[1:end], nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// isDotenvComment returns true if the rest of a line after a quoted value is
+// empty or a comment.
+func isDotenvComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// ssmParameterLeaf is a parameter with its type in JSON or YAML, such as
+// {"Value": "secret", "Type": "SecureString", "KeyId": "alias/app"}.
+type ssmParameterLeaf struct {
+	Value interface{} `json:"Value" yaml:"Value"`
+	Type  string      `json:"Type" yaml:"Type"`
+	KeyID string      `json:"KeyId,omitempty" yaml:"KeyId,omitempty"`
+}
+
+// isSSMParameterLeaf returns true if an object is a parameter with its type,
+// which has Value and only Type and KeyId otherwise.
+func isSSMParameterLeaf(m map[string]interface{}) bool {
+	if _, ok := m["Value"]; !ok {
+		return false
+	}
+	for k := range m {
+		if k != "Value" && k != "Type" && k != "KeyId" {
+			return false
+		}
+	}
+	return true
+}
+
+// walkSSMParameterTree collects parameters from nested objects of JSON or
+// YAML. Keys of nested objects are joined with slashes. A list is a
+// StringList.
+func walkSSMParameterTree(name string, v interface{}, entries *[]ssmParameterEntry) error {
+	if m, ok := v.(map[string]interface{}); ok {
+		return walkSSMParameterObject(name, m, entries)
+	}
+
+	if name == "" {
+		return errors.New("parameters must be an object")
+	}
+	value, typ, err := formatSSMParameterTreeValue(name, v)
+	if err != nil {
+		return err
+	}
+	*entries = append(*entries, ssmParameterEntry{name: name, value: value, typ: typ})
+	return nil
+}
+
+func walkSSMParameterObject(name string, m map[string]interface{}, entries *[]ssmParameterEntry) error {
+	if name != "" && isSSMParameterLeaf(m) {
+		value, typ, err := formatSSMParameterTreeValue(name, m["Value"])
+		if err != nil {
+			return err
+		}
+		e := ssmParameterEntry{name: name, value: value, typ: typ}
+		if t, ok := m["Type"]; ok {
+			e.typ = fmt.Sprint(t)
+		}
+		if keyID, ok := m["KeyId"]; ok {
+			e.keyID = fmt.Sprint(keyID)
+		}
+		*entries = append(*entries, e)
+		return nil
+	}
+
+	for k, v := range m {
+		if k == "" {
+			return errors.Errorf("empty key in %s", name)
+		}
+		child := k
+		if name != "" {
+			child = name + "/" + k
+		}
+		if err := walkSSMParameterTree(child, v, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ssmParameterYAMLValue is a value of YAML which keeps scalars as written.
+// YAML 1.1 resolves unquoted scalars such as yes, 0755 and 1.10 to a bool or
+// a number, which would change values of parameters, so scalars and keys are
+// decoded as strings, which yaml.v2 keeps as they are.
+type ssmParameterYAMLValue struct {
+	value interface{}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Objects become
+// map[string]interface{}, lists become []interface{} and scalars become
+// strings. A null is nil.
+func (v *ssmParameterYAMLValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	switch raw.(type) {
+	case nil:
+		v.value = nil
+	case map[interface{}]interface{}:
+		m := map[string]*ssmParameterYAMLValue{}
+		if err := unmarshal(&m); err != nil {
+			return err
+		}
+		object := map[string]interface{}{}
+		for k, child := range m {
+			object[k] = child.interfaceValue()
+		}
+		v.value = object
+	case []interface{}:
+		items := []*ssmParameterYAMLValue{}
+		if err := unmarshal(&items); err != nil {
+			return err
+		}
+		list := []interface{}{}
+		for _, item := range items {
+			list = append(list, item.interfaceValue())
+		}
+		v.value = list
+	default:
+		var s string
+		if err := unmarshal(&s); err != nil {
+			return err
+		}
+		v.value = s
+	}
+	return nil
+}
+
+// interfaceValue returns the decoded value. A nil pointer is a null.
+func (v *ssmParameterYAMLValue) interfaceValue() interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.value
+}
+
+// formatSSMParameterTreeValue converts a value of JSON or YAML to a string.
+// A list is joined with commas as a StringList.
+func formatSSMParameterTreeValue(name string, v interface{}) (string, string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", "", errors.Errorf("null value: %s", name)
+	case map[string]interface{}:
+		return "", "", errors.Errorf("invalid value: %s", name)
+	case []interface{}:
+		items := []string{}
+		for _, item := range t {
+			s, typ, err := formatSSMParameterTreeValue(name, item)
+			if err != nil || typ != "" {
+				return "", "", errors.Errorf("a list must have scalar values: %s", name)
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), ssm.ParameterTypeStringList, nil
+	}
+	return fmt.Sprint(v), "", nil
+}
+
+// encodeSSMParameterFile encodes parameters in the format.
+func encodeSSMParameterFile(format string, entries []ssmParameterEntry) ([]byte, error) {
+	switch format {
+	case SSMParameterFormatDotenv:
+		return encodeSSMParameterDotenv(entries), nil
+	case SSMParameterFormatJSON:
+		tree, err := buildSSMParameterTree(format, entries)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal json:")
+		}
+		return append(b, '\n'), nil
+	case SSMParameterFormatYAML:
+		tree, err := buildSSMParameterTree(format, entries)
+		if err != nil {
+			return nil, err
+		}
+		b, err := yaml.Marshal(tree)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal yaml:")
+		}
+		return b, nil
+	}
+	return nil, errors.Errorf("unknown format: %s (expected %s)", format, strings.Join(SSMParameterFormats, "|"))
+}
+
+var dotenvPlainValueRegexp = regexp.MustCompile(`^[A-Za-z0-9_.,:/@+\-=]+$`)
+
+func encodeSSMParameterDotenv(entries []ssmParameterEntry) []byte {
+	var b bytes.Buffer
+	for _, e := range entries {
+		if e.typ != ssm.ParameterTypeString {
+			fmt.Fprintf(&b, "%stype=%s", ssmParameterDirective, e.typ)
+			if e.keyID != "" {
+				fmt.Fprintf(&b, " key-id=%s", e.keyID)
+			}
+			b.WriteString("\n")
+		}
+		value := e.value
+		if !dotenvPlainValueRegexp.MatchString(value) {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", e.name, value)
+	}
+	return b.Bytes()
+}
+
+// buildSSMParameterTree builds nested objects of parameters. A String is a
+// plain value, a StringList is a list, and a SecureString is an object with
+// its type and KMS key ID.
+func buildSSMParameterTree(format string, entries []ssmParameterEntry) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	for _, e := range entries {
+		keys := strings.Split(e.name, "/")
+		node := root
+		for i, k := range keys[:len(keys)-1] {
+			child, ok := node[k]
+			if !ok {
+				child = map[string]interface{}{}
+				node[k] = child
+			}
+			m, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("%s has both a value and children, which can't be exported as %s", strings.Join(keys[:i+1], "/"), format)
+			}
+			node = m
+		}
+
+		last := keys[len(keys)-1]
+		if _, ok := node[last]; ok {
+			return nil, errors.Errorf("%s has both a value and children, which can't be exported as %s", e.name, format)
+		}
+		switch e.typ {
+		case ssm.ParameterTypeString:
+			node[last] = e.value
+		case ssm.ParameterTypeStringList:
+			node[last] = strings.Split(e.value, ",")
+		default:
+			node[last] = ssmParameterLeaf{Value: e.value, Type: e.typ, KeyID: e.keyID}
+		}
+	}
+	return root, nil
+}
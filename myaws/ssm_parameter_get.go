@@ -10,10 +10,22 @@ import (
 type SSMParameterGetOptions struct {
 	Names          []*string
 	WithDecryption bool
+	// Select chooses parameters in a fuzzy finder. Path is a prefix of names
+	// of candidates.
+	Select bool
+	Path   string
 }
 
 // SSMParameterGet get values from SSM parameter store with KMS decryption.
 func (client *Client) SSMParameterGet(options SSMParameterGetOptions) error {
+	if options.Select {
+		names, err := client.pickSSMParameters(options.Path)
+		if err != nil {
+			return err
+		}
+		options.Names = names
+	}
+
 	parameters, err := client.GetSSMParameters(options.Names, options.WithDecryption)
 	if err != nil {
 		return err
@@ -0,0 +1,60 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSSMParameterGet(t *testing.T) {
+	f := newFakeSSM("foo", "1")
+	f.put("password", "secret", "SecureString", "alias/myapp")
+
+	cases := []struct {
+		desc           string
+		withDecryption bool
+		want           string
+	}{
+		{desc: "decrypt", withDecryption: true, want: "1\nsecret\n"},
+		{desc: "no decrypt", withDecryption: false, want: "1\nencrypted:secret\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client, stdout := newTestClient(t, Services{SSM: f})
+			err := client.SSMParameterGet(SSMParameterGetOptions{
+				Names:          aws.StringSlice([]string{"foo", "password"}),
+				WithDecryption: tc.withDecryption,
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSSMParameterGetSelector(t *testing.T) {
+	f := newFakeSSM("foo", "1", "foo", "2", "foo", "3")
+	f.findVersion("foo", "2").Labels = aws.StringSlice([]string{"stable"})
+	client, stdout := newTestClient(t, Services{SSM: f})
+
+	err := client.SSMParameterGet(SSMParameterGetOptions{
+		Names:          aws.StringSlice([]string{"foo", "foo:1", "foo:stable"}),
+		WithDecryption: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if got := stdout.String(); got != "3\n1\n2\n" {
+		t.Errorf("got = %q, want %q", got, "3\n1\n2\n")
+	}
+
+	err = client.SSMParameterGet(SSMParameterGetOptions{Names: aws.StringSlice([]string{"foo:4"})})
+	if err == nil || !strings.Contains(err.Error(), "foo:4") {
+		t.Errorf("expected error for a missing version, got: %v", err)
+	}
+}
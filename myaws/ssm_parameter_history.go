@@ -0,0 +1,64 @@
+package myaws
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterHistoryOptions customize the behavior of the ParameterHistory command.
+type SSMParameterHistoryOptions struct {
+	Name string
+	// WithDecryption shows values of SecureString, which are masked by
+	// default.
+	WithDecryption bool
+}
+
+// SSMParameterHistory prints versions of the parameter from the oldest.
+func (client *Client) SSMParameterHistory(options SSMParameterHistoryOptions) error {
+	history, err := client.getSSMParameterHistory(options.Name, options.WithDecryption)
+	if err != nil {
+		return err
+	}
+
+	fields := []string{"Version", "LastModifiedDate", "LastModifiedUser", "Labels", "Type", "Value"}
+	rows := [][]string{}
+	for _, h := range history {
+		value := aws.StringValue(h.Value)
+		if aws.StringValue(h.Type) == ssm.ParameterTypeSecureString && !options.WithDecryption {
+			value = formatSSMPlanValue(ssm.ParameterTypeSecureString, value)
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(aws.Int64Value(h.Version), 10),
+			client.FormatTime(h.LastModifiedDate),
+			aws.StringValue(h.LastModifiedUser),
+			strings.Join(aws.StringValueSlice(h.Labels), ","),
+			aws.StringValue(h.Type),
+			value,
+		})
+	}
+
+	return client.printRows(fields, rows, true)
+}
+
+// getSSMParameterHistory returns all versions of the parameter.
+func (client *Client) getSSMParameterHistory(name string, withDecryption bool) ([]*ssm.ParameterHistory, error) {
+	input := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(withDecryption),
+	}
+
+	history := []*ssm.ParameterHistory{}
+	err := client.SSM.GetParameterHistoryPages(input,
+		func(page *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+			history = append(history, page.Parameters...)
+			return true
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetParameterHistory failed:")
+	}
+	return history, nil
+}
@@ -0,0 +1,66 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSSMParameterHistory(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options SSMParameterHistoryOptions
+		want    string
+		wantErr string
+	}{
+		{
+			desc:    "masked",
+			options: SSMParameterHistoryOptions{Name: "password"},
+			want: `Version	LastModifiedDate	LastModifiedUser	Labels	Type	Value
+1	2021-04-01 01:00:00	arn:aws:iam::123456789012:user/alice		String	plain
+2	2021-04-01 02:00:00	arn:aws:iam::123456789012:user/alice	stable	SecureString	(secure)
+3	2021-04-01 03:00:00	arn:aws:iam::123456789012:user/alice		SecureString	(secure)
+`,
+		},
+		{
+			desc:    "decrypted",
+			options: SSMParameterHistoryOptions{Name: "password", WithDecryption: true},
+			want: `Version	LastModifiedDate	LastModifiedUser	Labels	Type	Value
+1	2021-04-01 01:00:00	arn:aws:iam::123456789012:user/alice		String	plain
+2	2021-04-01 02:00:00	arn:aws:iam::123456789012:user/alice	stable	SecureString	old
+3	2021-04-01 03:00:00	arn:aws:iam::123456789012:user/alice		SecureString	new
+`,
+		},
+		{
+			desc:    "not found",
+			options: SSMParameterHistoryOptions{Name: "foo"},
+			wantErr: "ParameterNotFound",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("password", "plain")
+			f.pageSize = 1
+			f.put("password", "old", "SecureString", "alias/myapp")
+			f.put("password", "new", "SecureString", "alias/myapp")
+			f.findVersion("password", "2").Labels = []*string{aws.String("stable")}
+			client, stdout := newTestClient(t, Services{SSM: f})
+
+			err := client.SSMParameterHistory(tc.options)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("got = %q, want = %q", got, tc.want)
+			}
+		})
+	}
+}
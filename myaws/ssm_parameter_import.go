@@ -0,0 +1,208 @@
+package myaws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterImportOptions customize the behavior of the ParameterImport command.
+type SSMParameterImportOptions struct {
+	File string
+	// Format is one of SSMParameterFormats. Empty means detecting it by the
+	// extension of the file.
+	Format string
+	// Prefix is a path to put parameters under.
+	Prefix string
+	// Type and KeyID are defaults for keys without their own type and key ID.
+	// Empty Type means String.
+	Type  string
+	KeyID string
+}
+
+// SSMParameterImport puts parameters in a file under the prefix. Nested keys
+// of JSON and YAML are separated by slashes. It prints a plan of changes
+// compared to current parameters and asks for confirmation before writing.
+// Unchanged parameters are skipped.
+func (client *Client) SSMParameterImport(options SSMParameterImportOptions) error {
+	prefix, err := normalizeSSMPath(options.Prefix)
+	if err != nil {
+		return err
+	}
+	format := options.Format
+	if format == "" {
+		format, err = detectSSMParameterFormat(options.File)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := ioutil.ReadFile(options.File)
+	if err != nil {
+		return errors.Wrap(err, "ReadFile failed:")
+	}
+	entries, err := parseSSMParameterFile(format, b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s:", options.File)
+	}
+	if len(entries) == 0 {
+		return errors.Errorf("no parameters found in %s", options.File)
+	}
+
+	inputs, err := buildSSMParameterImportInputs(prefix, entries, options.Type, options.KeyID)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, input := range inputs {
+		names = append(names, *input.Name)
+	}
+	if err := client.guardSSMParameters(names...); err != nil {
+		return err
+	}
+
+	changes, err := client.planSSMParameterImport(prefix, inputs)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 || client.dryRun {
+		return nil
+	}
+
+	if len(changes) >= highImpactThreshold {
+		if ok, err := client.confirmName(fmt.Sprintf("Are you sure want to put %d parameters?", len(changes)), strconv.Itoa(len(changes))); !ok {
+			return err
+		}
+	} else if ok, err := client.confirm("Are you sure want to put these parameters?"); !ok {
+		return err
+	}
+
+	for _, input := range changes {
+		_, err := client.SSM.PutParameter(input)
+		client.auditCall("SSM.PutParameter", input, []string{*input.Name}, err)
+		if err != nil {
+			return errors.Wrapf(err, "PutParameter %s failed:", *input.Name)
+		}
+	}
+	fmt.Fprintf(client.stdout, "Imported %d parameters.\n", len(changes))
+	return nil
+}
+
+// buildSSMParameterImportInputs returns inputs to put entries under the
+// prefix. Types and key IDs of entries take precedence over the defaults.
+func buildSSMParameterImportInputs(prefix string, entries []ssmParameterEntry, defaultType string, defaultKeyID string) ([]*ssm.PutParameterInput, error) {
+	inputs := []*ssm.PutParameterInput{}
+	for _, e := range entries {
+		name := strings.TrimSuffix(prefix, "/") + "/" + e.name
+		parameterType := e.typ
+		if parameterType == "" {
+			parameterType = defaultType
+		}
+		if parameterType == "" {
+			parameterType = ssm.ParameterTypeString
+		}
+
+		input := &ssm.PutParameterInput{
+			Name:      aws.String(name),
+			Value:     aws.String(e.value),
+			Type:      aws.String(parameterType),
+			Overwrite: aws.Bool(true),
+		}
+		switch parameterType {
+		case ssm.ParameterTypeString, ssm.ParameterTypeStringList:
+			// keyID must be nil when type is not SecureString.
+			if e.keyID != "" {
+				return nil, errors.Errorf("a key ID is only allowed for SecureString: %s", name)
+			}
+		case ssm.ParameterTypeSecureString:
+			keyID := e.keyID
+			if keyID == "" {
+				keyID = defaultKeyID
+			}
+			if keyID != "" {
+				input.KeyId = aws.String(keyID)
+			}
+		default:
+			return nil, errors.Errorf("unknown type of %s: %s (expected %s)", name, parameterType, strings.Join(ssm.ParameterType_Values(), "|"))
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// planSSMParameterImport prints changes of the inputs compared to current
+// parameters, and returns inputs to put. A SecureString without a key ID keeps
+// the current key.
+func (client *Client) planSSMParameterImport(prefix string, inputs []*ssm.PutParameterInput) ([]*ssm.PutParameterInput, error) {
+	names := []string{}
+	for _, input := range inputs {
+		names = append(names, *input.Name)
+	}
+	parameters, err := client.findSSMParameters(names, true)
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]*ssm.Parameter{}
+	for _, p := range parameters {
+		current[*p.Name] = p
+	}
+	metadata, err := client.FindSSMParameterMetadata(prefix)
+	if err != nil {
+		return nil, err
+	}
+	keyIDs := map[string]string{}
+	for _, m := range metadata {
+		keyIDs[*m.Name] = aws.StringValue(m.KeyId)
+	}
+
+	changes := []*ssm.PutParameterInput{}
+	added, unchanged := 0, 0
+	for _, input := range inputs {
+		p, ok := current[*input.Name]
+		if !ok {
+			fmt.Fprintf(client.stdout, "+ %s\n", formatSSMParameterChange(nil, input))
+			changes = append(changes, input)
+			added++
+			continue
+		}
+
+		currentKeyID := ""
+		if aws.StringValue(p.Type) == ssm.ParameterTypeSecureString {
+			currentKeyID = keyIDs[*input.Name]
+		}
+		if *input.Type == ssm.ParameterTypeSecureString && input.KeyId == nil && currentKeyID != "" {
+			input.KeyId = aws.String(currentKeyID)
+		}
+		keyID := aws.StringValue(input.KeyId)
+		if *input.Type == aws.StringValue(p.Type) && *input.Value == aws.StringValue(p.Value) && keyID == currentKeyID {
+			unchanged++
+			continue
+		}
+
+		change := formatSSMParameterChange(p, input)
+		if keyID != currentKeyID {
+			change += fmt.Sprintf(", KeyId %s => %s", formatSSMKeyID(currentKeyID), formatSSMKeyID(keyID))
+		}
+		fmt.Fprintf(client.stdout, "~ %s\n", change)
+		changes = append(changes, input)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(client.stdout, "No changes.")
+		return nil, nil
+	}
+	fmt.Fprintf(client.stdout, "Plan: %d to add, %d to change, %d unchanged.\n", added, len(changes)-added, unchanged)
+	return changes, nil
+}
+
+func formatSSMKeyID(keyID string) string {
+	if keyID == "" {
+		return "(none)"
+	}
+	return keyID
+}
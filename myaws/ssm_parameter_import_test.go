@@ -0,0 +1,339 @@
+package myaws
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSSMParameterFile(t *testing.T) {
+	cases := []struct {
+		desc    string
+		format  string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			desc:   "dotenv",
+			format: "dotenv",
+			input: `# comment
+export DB_HOST=db.example.com # host
+DB_NAME='app # prod'
+# myaws type=SecureString key-id=alias/app
+DB_PASSWORD="p@ss\nword"
+
+GREETING="hello world"
+NOTE="a \"b\" # c" # note
+TAG='x y'# note
+`,
+			want: `DB_HOST="db.example.com"
+DB_NAME="app # prod"
+DB_PASSWORD="p@ss\nword" SecureString alias/app
+GREETING="hello world"
+NOTE="a \"b\" # c"
+TAG="x y"`,
+		},
+		{
+			desc:   "json",
+			format: "json",
+			input: `{
+  "db": {"host": "db.example.com", "port": 5432},
+  "hosts": ["a", "b"],
+  "password": {"Value": "secret", "Type": "SecureString", "KeyId": "alias/app"}
+}`,
+			want: `db/host="db.example.com"
+db/port="5432"
+hosts="a,b" StringList
+password="secret" SecureString alias/app`,
+		},
+		{
+			desc:   "yaml",
+			format: "yaml",
+			input: `db:
+  host: db.example.com
+  port: 5432
+hosts: [a, b]
+password:
+  Value: secret
+  Type: SecureString
+`,
+			want: `db/host="db.example.com"
+db/port="5432"
+hosts="a,b" StringList
+password="secret" SecureString`,
+		},
+		{
+			desc:   "yaml scalars as written",
+			format: "yaml",
+			input: `enabled: yes
+mode: 0755
+version: 1.10
+mask: 0x1F
+0644: on
+quoted: "1.10"
+list: [no, 010]
+`,
+			want: `0644="on"
+enabled="yes"
+list="no,010" StringList
+mask="0x1F"
+mode="0755"
+quoted="1.10"
+version="1.10"`,
+		},
+		{
+			desc:    "invalid dotenv",
+			format:  "dotenv",
+			input:   "FOO\n",
+			wantErr: "line 1: expected KEY=VALUE: FOO",
+		},
+		{
+			desc:    "text after quoted value",
+			format:  "dotenv",
+			input:   "FOO=\"a b\" c\n",
+			wantErr: "line 1: invalid quoted value: \"a b\" c",
+		},
+		{
+			desc:    "unknown directive",
+			format:  "dotenv",
+			input:   "# myaws tier=Advanced\nFOO=bar\n",
+			wantErr: "line 1: unknown directive: tier",
+		},
+		{
+			desc:    "duplicated key",
+			format:  "dotenv",
+			input:   "FOO=a\nFOO=b\n",
+			wantErr: "duplicated key: FOO",
+		},
+		{
+			desc:    "null",
+			format:  "yaml",
+			input:   "db:\n  host:\n",
+			wantErr: "null value: db/host",
+		},
+		{
+			desc:    "not an object",
+			format:  "json",
+			input:   `["a"]`,
+			wantErr: "parameters must be an object",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			entries, err := parseSSMParameterFile(tc.format, []byte(tc.input))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := formatTestSSMParameterEntries(entries); got != tc.want {
+				t.Errorf("got = %s, want = %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func formatTestSSMParameterEntries(entries []ssmParameterEntry) string {
+	lines := []string{}
+	for _, e := range entries {
+		lines = append(lines, strings.TrimSpace(e.name+"="+formatSSMPlanValue("", e.value)+" "+e.typ+" "+e.keyID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDetectSSMParameterFormat(t *testing.T) {
+	cases := map[string]string{
+		".env":             "dotenv",
+		"config/.env.prod": "dotenv",
+		"prod.env":         "dotenv",
+		"params.json":      "json",
+		"params.yaml":      "yaml",
+		"params.yml":       "yaml",
+		"params.txt":       "",
+	}
+	for path, want := range cases {
+		got, err := detectSSMParameterFormat(path)
+		if got != want || (want == "") != (err != nil) {
+			t.Errorf("detectSSMParameterFormat(%s) = %q, %v, want = %q", path, got, err, want)
+		}
+	}
+}
+
+func TestSSMParameterImport(t *testing.T) {
+	cases := []struct {
+		desc    string
+		file    string
+		options SSMParameterImportOptions
+		dryRun  bool
+		want    []string
+		wantOut string
+		wantErr string
+	}{
+		{
+			desc: "add and change",
+			file: "params.env",
+			options: SSMParameterImportOptions{
+				Prefix: "/app/prod/",
+				KeyID:  "alias/default",
+			},
+			want: []string{
+				"/app/prod/DB_HOST=db.new String",
+				"/app/prod/DB_NAME=app String",
+				"/app/prod/DB_PASSWORD=secret SecureString alias/app",
+				"/app/prod/TOKEN=token SecureString alias/default",
+			},
+			wantOut: `~ /app/prod/DB_HOST (Version 1) Type String => String, Value "db.old" => "db.new"
+~ /app/prod/DB_PASSWORD (Version 1) Type SecureString => SecureString, Value (secure) => (secure)
++ /app/prod/TOKEN (new) Type SecureString, Value (secure)
+Plan: 1 to add, 2 to change, 1 unchanged.
+Imported 3 parameters.
+`,
+		},
+		{
+			desc:    "dry run",
+			file:    "params.env",
+			options: SSMParameterImportOptions{Prefix: "/app/prod"},
+			dryRun:  true,
+			want: []string{
+				"/app/prod/DB_HOST=db.old String",
+				"/app/prod/DB_NAME=app String",
+				"/app/prod/DB_PASSWORD=old SecureString alias/app",
+			},
+			wantOut: `~ /app/prod/DB_HOST (Version 1) Type String => String, Value "db.old" => "db.new"
+~ /app/prod/DB_PASSWORD (Version 1) Type SecureString => SecureString, Value (secure) => (secure)
++ /app/prod/TOKEN (new) Type SecureString, Value (secure)
+Plan: 1 to add, 2 to change, 1 unchanged.
+`,
+		},
+		{
+			desc:    "key id changed",
+			file:    "params.json",
+			options: SSMParameterImportOptions{Prefix: "/app/prod"},
+			want: []string{
+				"/app/prod/DB_HOST=db.old String",
+				"/app/prod/DB_NAME=app String",
+				"/app/prod/DB_PASSWORD=old SecureString alias/other",
+			},
+			wantOut: `~ /app/prod/DB_PASSWORD (Version 1) Type SecureString => SecureString, Value (secure) => (secure), KeyId alias/app => alias/other
+Plan: 0 to add, 1 to change, 2 unchanged.
+Imported 1 parameters.
+`,
+		},
+		{
+			desc:    "no changes",
+			file:    "params.yaml",
+			options: SSMParameterImportOptions{Prefix: "/app/prod"},
+			want: []string{
+				"/app/prod/DB_HOST=db.old String",
+				"/app/prod/DB_NAME=app String",
+				"/app/prod/DB_PASSWORD=old SecureString alias/app",
+			},
+			wantOut: "No changes.\n",
+		},
+		{
+			desc:    "key id for String",
+			file:    "invalid.json",
+			options: SSMParameterImportOptions{Prefix: "/app/prod"},
+			wantErr: "a key ID is only allowed for SecureString: /app/prod/DB_HOST",
+		},
+		{
+			desc:    "unknown type",
+			file:    "params.env",
+			options: SSMParameterImportOptions{Prefix: "/app/prod", Type: "Secret"},
+			wantErr: "unknown type of /app/prod/DB_HOST: Secret (expected String|StringList|SecureString)",
+		},
+		{
+			desc:    "relative prefix",
+			file:    "params.env",
+			options: SSMParameterImportOptions{Prefix: "app/prod"},
+			wantErr: "path must start with /: app/prod",
+		},
+		{
+			desc:    "unknown format",
+			file:    "params.txt",
+			options: SSMParameterImportOptions{Prefix: "/app/prod"},
+			wantErr: "unknown format of",
+		},
+	}
+
+	files := map[string]string{
+		"params.env": `DB_HOST=db.new
+DB_NAME=app
+# myaws type=SecureString key-id=alias/app
+DB_PASSWORD=secret
+# myaws type=SecureString
+TOKEN=token
+`,
+		"params.json":  `{"DB_HOST": "db.old", "DB_NAME": "app", "DB_PASSWORD": {"Value": "old", "Type": "SecureString", "KeyId": "alias/other"}}`,
+		"params.yaml":  "DB_HOST: db.old\nDB_NAME: app\nDB_PASSWORD:\n  Value: old\n  Type: SecureString\n",
+		"invalid.json": `{"DB_HOST": {"Value": "db.old", "KeyId": "alias/app"}}`,
+		"params.txt":   "DB_HOST=db.old\n",
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %s", err)
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("/app/prod/DB_HOST", "db.old", "/app/prod/DB_NAME", "app")
+			f.put("/app/prod/DB_PASSWORD", "old", "SecureString", "alias/app")
+			client, stdout := newTestClient(t, Services{SSM: f})
+			client.dryRun = tc.dryRun
+
+			options := tc.options
+			options.File = filepath.Join(dir, tc.file)
+			err := client.SSMParameterImport(options)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			got := []string{}
+			for _, p := range f.parameters {
+				got = append(got, strings.TrimSpace(*p.Name+"="+*p.Value+" "+*p.Type+" "+f.keyIDs[*p.Name]))
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got = %v, want = %v", got, tc.want)
+			}
+			if got := stdout.String(); got != tc.wantOut {
+				t.Errorf("stdout = %q, want = %q", got, tc.wantOut)
+			}
+		})
+	}
+}
+
+func TestSSMParameterImportConfirm(t *testing.T) {
+	f := newFakeSSM("/app/prod/DB_HOST", "db.old")
+	client, stdout := newTestClient(t, Services{SSM: f})
+	client.guard.Yes = false
+	client.stdin = strings.NewReader("n\n")
+
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := ioutil.WriteFile(file, []byte("DB_HOST=db.new\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	if err := client.SSMParameterImport(SSMParameterImportOptions{File: file, Prefix: "/app/prod"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !strings.Contains(stdout.String(), "Are you sure want to put these parameters?") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+	if v := *f.find("/app/prod/DB_HOST").Value; v != "db.old" {
+		t.Errorf("the parameter was overwritten: %s", v)
+	}
+}
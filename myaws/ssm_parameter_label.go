@@ -0,0 +1,50 @@
+package myaws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterLabelOptions customize the behavior of the ParameterLabel command.
+type SSMParameterLabelOptions struct {
+	Name    string
+	Version int64
+	Labels  []string
+}
+
+// SSMParameterLabel attaches labels to a version of the parameter. A label
+// attached to another version of the parameter is moved to the version.
+func (client *Client) SSMParameterLabel(options SSMParameterLabelOptions) error {
+	if len(options.Labels) == 0 {
+		return errors.New("label is required")
+	}
+	if err := client.guardSSMParameters(options.Name); err != nil {
+		return err
+	}
+
+	input := &ssm.LabelParameterVersionInput{
+		Name:             aws.String(options.Name),
+		ParameterVersion: aws.Int64(options.Version),
+		Labels:           aws.StringSlice(options.Labels),
+	}
+
+	if client.dryRun {
+		client.printPlan("SSM.LabelParameterVersion", "%s (Version %d) Labels %s", options.Name, options.Version, formatPlanValues(input.Labels))
+		return nil
+	}
+
+	response, err := client.SSM.LabelParameterVersion(input)
+	client.auditCall("SSM.LabelParameterVersion", input, []string{options.Name}, err)
+	if err != nil {
+		return errors.Wrap(err, "LabelParameterVersion failed:")
+	}
+	// Invalid labels are returned without an error.
+	if len(response.InvalidLabels) > 0 {
+		return errors.Errorf("invalid labels: %s", strings.Join(aws.StringValueSlice(response.InvalidLabels), ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package myaws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSSMParameterLabel(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options SSMParameterLabelOptions
+		dryRun  bool
+		// want are labels of versions.
+		want    string
+		wantOut string
+		wantErr string
+	}{
+		{
+			desc:    "attach",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 1, Labels: []string{"prod", "v1"}},
+			want:    "1:prod,v1 2:stable 3:",
+		},
+		{
+			desc:    "move",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 3, Labels: []string{"stable"}},
+			want:    "1: 2: 3:stable",
+		},
+		{
+			desc:    "dry run",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 3, Labels: []string{"stable"}},
+			dryRun:  true,
+			want:    "1: 2:stable 3:",
+			wantOut: "[dry-run] SSM.LabelParameterVersion: foo (Version 3) Labels [stable]\n",
+		},
+		{
+			desc:    "invalid labels",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 1, Labels: []string{"aws-prod", "1st"}},
+			want:    "1: 2:stable 3:",
+			wantErr: "invalid labels: aws-prod, 1st",
+		},
+		{
+			desc:    "no such version",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 4, Labels: []string{"prod"}},
+			wantErr: "ParameterVersionNotFound",
+		},
+		{
+			desc:    "no labels",
+			options: SSMParameterLabelOptions{Name: "foo", Version: 1},
+			wantErr: "label is required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("foo", "1", "foo", "2", "foo", "3")
+			f.findVersion("foo", "2").Labels = []*string{aws.String("stable")}
+			client, stdout := newTestClient(t, Services{SSM: f})
+			client.dryRun = tc.dryRun
+
+			err := client.SSMParameterLabel(tc.options)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			if tc.want != "" {
+				versions := []string{}
+				for _, h := range f.history["foo"] {
+					versions = append(versions, fmt.Sprintf("%d:%s", *h.Version, strings.Join(aws.StringValueSlice(h.Labels), ",")))
+				}
+				if got := strings.Join(versions, " "); got != tc.want {
+					t.Errorf("labels = %s, want = %s", got, tc.want)
+				}
+			}
+			if got := stdout.String(); got != tc.wantOut {
+				t.Errorf("stdout = %q, want = %q", got, tc.wantOut)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package myaws
 
 import (
-	"fmt"
-
 	"github.com/aws/aws-sdk-go/service/ssm"
 )
 
@@ -18,16 +16,17 @@ func (client *Client) SSMParameterLs(options SSMParameterLsOptions) error {
 		return err
 	}
 
+	fields := []string{"Name", "Type", "KeyId"}
+	rows := [][]string{}
 	for _, m := range metadata {
-		fmt.Fprintln(client.stdout, formatSSMParameterMetadata(m))
+		rows = append(rows, formatSSMParameterMetadata(m))
 	}
 
-	return nil
+	return client.printRows(fields, rows, false)
 }
 
-func formatSSMParameterMetadata(m *ssm.ParameterMetadata) string {
-	keyid := formatSSMParameterKeyID(m)
-	return fmt.Sprintf("%s\t%s\t%s", *m.Name, *m.Type, keyid)
+func formatSSMParameterMetadata(m *ssm.ParameterMetadata) []string {
+	return []string{*m.Name, *m.Type, formatSSMParameterKeyID(m)}
 }
 
 func formatSSMParameterKeyID(m *ssm.ParameterMetadata) string {
@@ -0,0 +1,20 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestSSMParameterLs(t *testing.T) {
+	f := newFakeSSM("app.foo", "1", "other", "2")
+	f.put("app.password", "secret", "SecureString", "alias/myapp")
+	client, stdout := newTestClient(t, Services{SSM: f})
+
+	if err := client.SSMParameterLs(SSMParameterLsOptions{Name: "app."}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "app.foo\tString\t\napp.password\tSecureString\talias/myapp\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
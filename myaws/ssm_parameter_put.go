@@ -1,6 +1,10 @@
 package myaws
 
 import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/pkg/errors"
 )
@@ -35,10 +39,63 @@ func (client *Client) SSMParameterPut(options SSMParameterPutOptions) error {
 		Overwrite: &overwrite,
 	}
 
+	if err := client.guardSSMParameters(options.Name); err != nil {
+		return err
+	}
+
+	if client.dryRun {
+		return client.planSSMParameterPut(input)
+	}
+
+	if !client.guard.Yes {
+		current, err := client.findSSMParameter(options.Name)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			if ok, err := client.confirm(fmt.Sprintf("Are you sure want to overwrite %s (Version %d)?", options.Name, aws.Int64Value(current.Version))); !ok {
+				return err
+			}
+		}
+	}
+
 	_, err := client.SSM.PutParameter(input)
+	client.auditCall("SSM.PutParameter", input, []string{options.Name}, err)
 	if err != nil {
 		return errors.Wrap(err, "PutParameter failed:")
 	}
 
 	return nil
 }
+
+// planSSMParameterPut prints a plan to put the parameter.
+// Values of SecureString are masked.
+func (client *Client) planSSMParameterPut(input *ssm.PutParameterInput) error {
+	current, err := client.findSSMParameter(*input.Name)
+	if err != nil {
+		return err
+	}
+
+	client.printPlan("SSM.PutParameter", "%s", formatSSMParameterChange(current, input))
+	return nil
+}
+
+// formatSSMParameterChange formats a change from the current parameter to the
+// input. The current parameter is nil for a new one.
+func formatSSMParameterChange(current *ssm.Parameter, input *ssm.PutParameterInput) string {
+	newValue := formatSSMPlanValue(*input.Type, *input.Value)
+	if current == nil {
+		return fmt.Sprintf("%s (new) Type %s, Value %s", *input.Name, *input.Type, newValue)
+	}
+
+	currentValue := formatSSMPlanValue(aws.StringValue(current.Type), aws.StringValue(current.Value))
+	return fmt.Sprintf("%s (Version %d) Type %s => %s, Value %s => %s",
+		*input.Name, aws.Int64Value(current.Version), aws.StringValue(current.Type), *input.Type, currentValue, newValue)
+}
+
+func formatSSMPlanValue(parameterType string, value string) string {
+	if parameterType == "SecureString" {
+		return "(secure)"
+	}
+	return strconv.Quote(value)
+}
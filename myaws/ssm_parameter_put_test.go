@@ -0,0 +1,26 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestSSMParameterPut(t *testing.T) {
+	f := newFakeSSM("foo", "old")
+	client, _ := newTestClient(t, Services{SSM: f})
+
+	// overwrite an existing parameter
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "foo", Value: "new"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if p := f.find("foo"); *p.Value != "new" || *p.Type != "String" || *p.Version != 2 {
+		t.Errorf("unexpected parameter: %s", p)
+	}
+
+	// a key id makes it a SecureString
+	if err := client.SSMParameterPut(SSMParameterPutOptions{Name: "bar", Value: "secret", KeyID: "alias/myapp"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if p := f.find("bar"); *p.Type != "SecureString" || f.keyIDs["bar"] != "alias/myapp" {
+		t.Errorf("unexpected parameter: %s (KeyId: %s)", p, f.keyIDs["bar"])
+	}
+}
@@ -0,0 +1,74 @@
+package myaws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterRollbackOptions customize the behavior of the ParameterRollback command.
+type SSMParameterRollbackOptions struct {
+	Name string
+	// To is a version to roll back to.
+	To int64
+}
+
+// SSMParameterRollback puts the value of an old version of the parameter as a
+// new version. Types, KMS key IDs, descriptions and tiers of the old version
+// are kept, and labels stay on their versions.
+func (client *Client) SSMParameterRollback(options SSMParameterRollbackOptions) error {
+	if err := client.guardSSMParameters(options.Name); err != nil {
+		return err
+	}
+
+	history, err := client.getSSMParameterHistory(options.Name, true)
+	if err != nil {
+		return err
+	}
+	var target *ssm.ParameterHistory
+	for _, h := range history {
+		if aws.Int64Value(h.Version) == options.To {
+			target = h
+		}
+	}
+	if target == nil {
+		return errors.Errorf("no such version of %s: %d", options.Name, options.To)
+	}
+	latest := aws.Int64Value(history[len(history)-1].Version)
+	if latest == options.To {
+		return errors.Errorf("%s is already Version %d", options.Name, options.To)
+	}
+
+	input := &ssm.PutParameterInput{
+		Name:        aws.String(options.Name),
+		Value:       target.Value,
+		Type:        target.Type,
+		Description: target.Description,
+		Overwrite:   aws.Bool(true),
+	}
+	if aws.StringValue(target.Type) == ssm.ParameterTypeSecureString {
+		input.KeyId = target.KeyId
+	}
+	if aws.StringValue(target.Tier) == ssm.ParameterTierAdvanced {
+		input.Tier = target.Tier
+	}
+
+	if client.dryRun {
+		return client.planSSMParameterPut(input)
+	}
+
+	if ok, err := client.confirm(fmt.Sprintf("Are you sure want to roll back %s (Version %d) to Version %d?", options.Name, latest, options.To)); !ok {
+		return err
+	}
+
+	response, err := client.SSM.PutParameter(input)
+	client.auditCall("SSM.PutParameter", input, []string{options.Name}, err)
+	if err != nil {
+		return errors.Wrap(err, "PutParameter failed:")
+	}
+	fmt.Fprintf(client.stdout, "Rolled back %s to Version %d as Version %d\n", options.Name, options.To, aws.Int64Value(response.Version))
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package myaws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSSMParameterRollback(t *testing.T) {
+	cases := []struct {
+		desc    string
+		options SSMParameterRollbackOptions
+		dryRun  bool
+		// want is the latest version with its type and key ID.
+		want    string
+		wantOut string
+		wantErr string
+	}{
+		{
+			desc:    "rollback",
+			options: SSMParameterRollbackOptions{Name: "password", To: 2},
+			want:    "4 old SecureString alias/old",
+			wantOut: "Rolled back password to Version 2 as Version 4\n",
+		},
+		{
+			desc:    "type changed",
+			options: SSMParameterRollbackOptions{Name: "password", To: 1},
+			want:    "4 plain String",
+			wantOut: "Rolled back password to Version 1 as Version 4\n",
+		},
+		{
+			desc:    "dry run",
+			options: SSMParameterRollbackOptions{Name: "password", To: 1},
+			dryRun:  true,
+			want:    "3 new SecureString alias/new",
+			wantOut: "[dry-run] SSM.PutParameter: password (Version 3) Type SecureString => String, Value (secure) => \"plain\"\n",
+		},
+		{
+			desc:    "latest",
+			options: SSMParameterRollbackOptions{Name: "password", To: 3},
+			want:    "3 new SecureString alias/new",
+			wantErr: "password is already Version 3",
+		},
+		{
+			desc:    "no such version",
+			options: SSMParameterRollbackOptions{Name: "password", To: 5},
+			want:    "3 new SecureString alias/new",
+			wantErr: "no such version of password: 5",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM("password", "plain")
+			f.put("password", "old", "SecureString", "alias/old")
+			f.put("password", "new", "SecureString", "alias/new")
+			client, stdout := newTestClient(t, Services{SSM: f})
+			client.dryRun = tc.dryRun
+
+			err := client.SSMParameterRollback(tc.options)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			p := f.find("password")
+			got := strings.TrimSpace(fmt.Sprintf("%d %s %s %s", *p.Version, *p.Value, *p.Type, f.keyIDs["password"]))
+			if got != tc.want {
+				t.Errorf("got = %s, want = %s", got, tc.want)
+			}
+			if got := stdout.String(); got != tc.wantOut {
+				t.Errorf("stdout = %q, want = %q", got, tc.wantOut)
+			}
+		})
+	}
+}
+
+func TestSSMParameterRollbackConfirm(t *testing.T) {
+	f := newFakeSSM("foo", "1", "foo", "2")
+	client, stdout := newTestClient(t, Services{SSM: f})
+	client.guard.Yes = false
+	client.stdin = strings.NewReader("n\n")
+
+	if err := client.SSMParameterRollback(SSMParameterRollbackOptions{Name: "foo", To: 1}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !strings.Contains(stdout.String(), "Are you sure want to roll back foo (Version 2) to Version 1?") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+	if v := *f.find("foo").Version; v != 2 {
+		t.Errorf("the parameter was rolled back: Version %d", v)
+	}
+}
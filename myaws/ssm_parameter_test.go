@@ -0,0 +1,70 @@
+package myaws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestGetSSMParameters(t *testing.T) {
+	// The GetParameters API can get up to 10 parameters at once.
+	f := newFakeSSM()
+	names := []*string{}
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("app.key%02d", i)
+		f.put(name, fmt.Sprintf("v%02d", i), "String", "")
+		names = append(names, aws.String(name))
+	}
+	client, _ := newTestClient(t, Services{SSM: f})
+
+	parameters, err := client.GetSSMParameters(names, false)
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	if got := len(parameters); got != 25 {
+		t.Errorf("len(parameters) = %d, want 25", got)
+	}
+	if f.getParametersCalls != 3 {
+		t.Errorf("getParametersCalls = %d, want 3", f.getParametersCalls)
+	}
+	if got := *parameters[24].Value; got != "v24" {
+		t.Errorf("value = %s, want v24", got)
+	}
+}
+
+func TestGetSSMParametersInvalidParameters(t *testing.T) {
+	client, _ := newTestClient(t, Services{SSM: newFakeSSM("foo", "1")})
+
+	_, err := client.GetSSMParameters(aws.StringSlice([]string{"foo", "bar"}), false)
+	if err == nil || !strings.Contains(err.Error(), "InvalidParameters") || !strings.Contains(err.Error(), "bar") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}
+
+func TestFindSSMParameterMetadata(t *testing.T) {
+	f := newFakeSSM("app.foo", "1", "app.bar", "2", "other", "3")
+	f.pageSize = 1
+	client, _ := newTestClient(t, Services{SSM: f})
+
+	cases := []struct {
+		name string
+		want int
+	}{
+		{name: "", want: 3},
+		{name: "app.", want: 2},
+		{name: "none", want: 0},
+	}
+
+	for _, tc := range cases {
+		metadata, err := client.FindSSMParameterMetadata(tc.name)
+		if err != nil {
+			t.Fatalf("unexpected err: %s", err)
+		}
+		if len(metadata) != tc.want {
+			t.Errorf("FindSSMParameterMetadata(%q) returns %d parameters, want %d", tc.name, len(metadata), tc.want)
+		}
+	}
+}
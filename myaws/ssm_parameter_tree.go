@@ -0,0 +1,103 @@
+package myaws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// SSMParameterTreeOptions customize the behavior of the ParameterTree command.
+type SSMParameterTreeOptions struct {
+	Path string
+}
+
+// SSMParameterTree prints a hierarchy of parameters under the path as a tree.
+// Values are not printed.
+func (client *Client) SSMParameterTree(options SSMParameterTreeOptions) error {
+	path, err := normalizeSSMPath(options.Path)
+	if err != nil {
+		return err
+	}
+
+	parameters, err := client.GetParametersByPath(aws.String(path), false)
+	if err != nil {
+		return err
+	}
+
+	root := &ssmParameterTreeNode{}
+	for _, p := range parameters {
+		root.add(strings.Split(relativeSSMName(path, *p.Name), "/"), p)
+	}
+
+	fmt.Fprintln(client.stdout, path)
+	root.print(client, "")
+	return nil
+}
+
+// normalizeSSMPath validates a path of the hierarchy and removes a trailing
+// slash.
+func normalizeSSMPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", errors.Errorf("path must start with /: %s", path)
+	}
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path, nil
+}
+
+// relativeSSMName returns a name of the parameter relative to the path.
+func relativeSSMName(path string, name string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(name, path), "/")
+}
+
+// ssmParameterTreeNode is a node of the hierarchy. A node can be both a
+// parameter and a parent of other parameters.
+type ssmParameterTreeNode struct {
+	parameter *ssm.Parameter
+	children  map[string]*ssmParameterTreeNode
+}
+
+func (n *ssmParameterTreeNode) add(keys []string, p *ssm.Parameter) {
+	if len(keys) == 0 {
+		n.parameter = p
+		return
+	}
+	if n.children == nil {
+		n.children = map[string]*ssmParameterTreeNode{}
+	}
+	child, ok := n.children[keys[0]]
+	if !ok {
+		child = &ssmParameterTreeNode{}
+		n.children[keys[0]] = child
+	}
+	child.add(keys[1:], p)
+}
+
+// print prints children of the node sorted by name with the indent.
+func (n *ssmParameterTreeNode) print(client *Client, indent string) {
+	keys := []string{}
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		child := n.children[k]
+		branch, next := "├── ", "│   "
+		if i == len(keys)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		label := k
+		if child.parameter != nil {
+			label += " (" + aws.StringValue(child.parameter.Type) + ")"
+		}
+		fmt.Fprintln(client.stdout, indent+branch+label)
+		child.print(client, indent+next)
+	}
+}
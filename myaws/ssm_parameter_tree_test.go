@@ -0,0 +1,41 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestSSMParameterTree(t *testing.T) {
+	f := newFakeSSM(
+		"/app/staging/db/host", "db.staging",
+		"/app/staging/db", "1",
+		"/app/production/db/host", "db.production",
+		"/app/production/api/url", "https://api",
+		"/other/foo", "1",
+	)
+	f.put("/app/production/db/password", "secret", "SecureString", "alias/app")
+	f.pageSize = 2
+	client, stdout := newTestClient(t, Services{SSM: f})
+
+	if err := client.SSMParameterTree(SSMParameterTreeOptions{Path: "/app/"}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := `/app
+├── production
+│   ├── api
+│   │   └── url (String)
+│   └── db
+│       ├── host (String)
+│       └── password (SecureString)
+└── staging
+    └── db (String)
+        └── host (String)
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := client.SSMParameterTree(SSMParameterTreeOptions{Path: "app"}); err == nil || err.Error() != "path must start with /: app" {
+		t.Errorf("expected an error, but got: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+// ssmRunMaxInstances is the maximum number of instances of SendCommand.
+// Commands on more instances are sent in batches.
+const ssmRunMaxInstances = 50
+
+// ssmRunPollInterval is an interval to poll results of a command.
+var ssmRunPollInterval = 2 * time.Second
+
+// SSMRunOptions customize the behavior of the Run command.
+type SSMRunOptions struct {
+	FilterTag string
+	// Command is a shell script run by AWS-RunShellScript.
+	Command string
+	// Timeout is a timeout of the command per instance. 0 means the default
+	// of the document (1 hour).
+	Timeout time.Duration
+	// MaxConcurrency is the number or percentage of instances to run the
+	// command at once, such as "10" or "10%". Empty means the default of SSM.
+	// It applies to each batch of ssmRunMaxInstances instances.
+	MaxConcurrency string
+	// MaxErrors is the number or percentage of errors to stop sending the
+	// command to the rest of instances. Empty means the default of SSM.
+	// It applies to each batch of ssmRunMaxInstances instances.
+	MaxErrors string
+}
+
+// ssmRunResult is a result of a command on an instance.
+type ssmRunResult struct {
+	instanceID string
+	name       string
+	status     string
+	exitCode   int64
+}
+
+// SSMRun runs a shell command on instances matching the filter by SSM Run
+// Command. Output of each instance is printed as soon as it completes, and a
+// summary is printed at the end. Interrupting it cancels the command.
+// The command is sent in batches of ssmRunMaxInstances instances, which run
+// at the same time.
+func (client *Client) SSMRun(options SSMRunOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return client.runSSMCommand(ctx, options)
+}
+
+func (client *Client) runSSMCommand(ctx context.Context, options SSMRunOptions) error {
+	if options.Command == "" {
+		return errors.New("command is required")
+	}
+	if options.Timeout != 0 && options.Timeout < time.Second {
+		return errors.Errorf("--timeout must be at least 1s: %s", options.Timeout)
+	}
+
+	instances, err := client.FindEC2Instances(options.FilterTag, false)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		return errors.Errorf("no such instance: %s", options.FilterTag)
+	}
+
+	instanceIds := []*string{}
+	names := map[string]string{}
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, instance.InstanceId)
+		names[*instance.InstanceId] = lookupTag(instance, "Name")
+	}
+	if err := client.guardEC2Instances(instanceIds); err != nil {
+		return err
+	}
+	if ok, err := client.confirmSSMRun(options.Command, instanceIds, names); !ok {
+		return err
+	}
+
+	commandIDs := []string{}
+	for i := 0; i < len(instanceIds); i += ssmRunMaxInstances {
+		end := i + ssmRunMaxInstances
+		if end > len(instanceIds) {
+			end = len(instanceIds)
+		}
+		batch := instanceIds[i:end]
+
+		input := &ssm.SendCommandInput{
+			DocumentName: aws.String("AWS-RunShellScript"),
+			InstanceIds:  batch,
+			Parameters: map[string][]*string{
+				"commands": {aws.String(options.Command)},
+			},
+		}
+		if options.Timeout > 0 {
+			input.Parameters["executionTimeout"] = []*string{aws.String(strconv.Itoa(int(options.Timeout / time.Second)))}
+		}
+		if options.MaxConcurrency != "" {
+			input.MaxConcurrency = aws.String(options.MaxConcurrency)
+		}
+		if options.MaxErrors != "" {
+			input.MaxErrors = aws.String(options.MaxErrors)
+		}
+
+		if client.dryRun {
+			client.printPlan("SSM.SendCommand", "AWS-RunShellScript %q on %s", options.Command, formatPlanValues(batch))
+			continue
+		}
+
+		response, err := client.SSM.SendCommandWithContext(ctx, input)
+		client.auditCall("SSM.SendCommand", input, aws.StringValueSlice(batch), err)
+		if err != nil {
+			// Don't leave the previous batches running unattended.
+			if len(commandIDs) > 0 {
+				fmt.Fprintln(client.stderr, client.cancelSSMCommands(commandIDs))
+			}
+			return errors.Wrap(err, "SendCommand failed:")
+		}
+		commandIDs = append(commandIDs, *response.Command.CommandId)
+	}
+	if client.dryRun {
+		return nil
+	}
+
+	results, err := client.waitSSMCommands(ctx, commandIDs, names)
+	if err != nil {
+		return err
+	}
+	return client.printSSMRunResults(results)
+}
+
+// confirmSSMRun asks for confirmation of running the command. The user has to
+// type the number of instances if it's run on many instances.
+func (client *Client) confirmSSMRun(command string, instanceIds []*string, names map[string]string) (bool, error) {
+	if len(instanceIds) >= highImpactThreshold {
+		hosts := []string{}
+		for _, id := range aws.StringValueSlice(instanceIds) {
+			hosts = append(hosts, strings.TrimSpace(id+" "+names[id]))
+		}
+		message := fmt.Sprintf("Are you sure want to run %q on %d instances?\n  %s", command, len(instanceIds), strings.Join(hosts, "\n  "))
+		return client.confirmName(message, strconv.Itoa(len(instanceIds)))
+	}
+	return client.confirm(fmt.Sprintf("Are you sure want to run %q on instances %s?", command, formatPlanValues(instanceIds)))
+}
+
+// waitSSMCommands polls invocations of the commands until all of them
+// complete, and prints output of each instance when it completes. If the
+// context is done, it cancels the commands.
+func (client *Client) waitSSMCommands(ctx context.Context, commandIDs []string, names map[string]string) ([]ssmRunResult, error) {
+	out := &sshOutput{stdout: client.stdout, stderr: client.stderr}
+	results := []ssmRunResult{}
+	completed := map[string]bool{}
+
+	for {
+		done := true
+		for _, commandID := range commandIDs {
+			input := &ssm.ListCommandInvocationsInput{CommandId: aws.String(commandID)}
+			invocations := []*ssm.CommandInvocation{}
+			err := client.SSM.ListCommandInvocationsPagesWithContext(ctx, input, func(page *ssm.ListCommandInvocationsOutput, lastPage bool) bool {
+				invocations = append(invocations, page.CommandInvocations...)
+				return true
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, client.cancelSSMCommands(commandIDs)
+				}
+				return nil, errors.Wrap(err, "ListCommandInvocations failed:")
+			}
+
+			// Invocations are created for all instances when the command is
+			// sent, but wait for at least one in case they are not visible yet.
+			if len(invocations) == 0 {
+				done = false
+			}
+			for _, invocation := range invocations {
+				instanceID := *invocation.InstanceId
+				if completed[instanceID] {
+					continue
+				}
+				if !isSSMCommandCompleted(*invocation.Status) {
+					done = false
+					continue
+				}
+				completed[instanceID] = true
+
+				result, err := client.printSSMInvocation(ctx, commandID, instanceID, names[instanceID], out)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, result)
+			}
+		}
+		if done {
+			return results, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, client.cancelSSMCommands(commandIDs)
+		case <-time.After(ssmRunPollInterval):
+		}
+	}
+}
+
+// isSSMCommandCompleted returns true if the status of an invocation is final.
+func isSSMCommandCompleted(status string) bool {
+	switch status {
+	case ssm.CommandInvocationStatusPending, ssm.CommandInvocationStatusInProgress,
+		ssm.CommandInvocationStatusDelayed, ssm.CommandInvocationStatusCancelling:
+		return false
+	}
+	return true
+}
+
+// printSSMInvocation gets output of the command on the instance and prints
+// it. Output is truncated by SSM to 24,000 characters for stdout and 8,000
+// for stderr.
+func (client *Client) printSSMInvocation(ctx context.Context, commandID string, instanceID string, name string, out *sshOutput) (ssmRunResult, error) {
+	input := &ssm.GetCommandInvocationInput{
+		CommandId:  aws.String(commandID),
+		InstanceId: aws.String(instanceID),
+	}
+	response, err := client.SSM.GetCommandInvocationWithContext(ctx, input)
+	if err != nil {
+		return ssmRunResult{}, errors.Wrapf(err, "GetCommandInvocation %s failed:", instanceID)
+	}
+
+	host := instanceID
+	if name != "" {
+		host += " (" + name + ")"
+	}
+	out.group(host, []byte(aws.StringValue(response.StandardOutputContent)))
+	if stderr := aws.StringValue(response.StandardErrorContent); stderr != "" {
+		w := out.prefixed(host + " | ").Stderr()
+		w.Write([]byte(stderr))
+		w.Flush()
+	}
+
+	return ssmRunResult{
+		instanceID: instanceID,
+		name:       name,
+		status:     aws.StringValue(response.Status),
+		exitCode:   aws.Int64Value(response.ResponseCode),
+	}, nil
+}
+
+// cancelSSMCommands cancels the commands after interrupted.
+func (client *Client) cancelSSMCommands(commandIDs []string) error {
+	for _, commandID := range commandIDs {
+		input := &ssm.CancelCommandInput{CommandId: aws.String(commandID)}
+		_, err := client.SSM.CancelCommand(input)
+		client.auditCall("SSM.CancelCommand", input, []string{commandID}, err)
+		if err != nil {
+			return errors.Wrapf(err, "CancelCommand %s failed:", commandID)
+		}
+	}
+	return errors.Errorf("command %s cancelled", strings.Join(commandIDs, ", "))
+}
+
+// printSSMRunResults prints a summary of the results, and returns an error if
+// the command failed on any instance.
+func (client *Client) printSSMRunResults(results []ssmRunResult) error {
+	fields := []string{"InstanceId", "Name", "Status", "ExitCode"}
+	rows := [][]string{}
+	failed := 0
+	for _, r := range results {
+		exitCode := ""
+		if r.exitCode >= 0 {
+			exitCode = strconv.FormatInt(r.exitCode, 10)
+		}
+		rows = append(rows, []string{r.instanceID, r.name, r.status, exitCode})
+		if r.status != ssm.CommandInvocationStatusSuccess {
+			failed++
+		}
+	}
+
+	if err := client.printRows(fields, rows, true); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.Errorf("command failed on %d of %d instances", failed, len(results))
+	}
+	return nil
+}
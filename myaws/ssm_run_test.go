@@ -0,0 +1,272 @@
+package myaws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// setTestSSMRunPollInterval polls results of commands without waiting.
+func setTestSSMRunPollInterval(t *testing.T) {
+	t.Helper()
+	orig := ssmRunPollInterval
+	ssmRunPollInterval = 0
+	t.Cleanup(func() { ssmRunPollInterval = orig })
+}
+
+func TestSSMRun(t *testing.T) {
+	setTestSSMRunPollInterval(t)
+
+	cases := []struct {
+		desc        string
+		options     SSMRunOptions
+		invocations map[string]*fakeSSMInvocation
+		dryRun      bool
+		want        string
+		wantStderr  string
+		wantParams  string
+		wantErr     string
+	}{
+		{
+			desc:    "success",
+			options: SSMRunOptions{FilterTag: "Name:web", Command: "uptime"},
+			invocations: map[string]*fakeSSMInvocation{
+				"i-0001": {polls: 2, status: "Success", stdout: "up 3 days\n"},
+				"i-0002": {polls: 1, status: "Success", stdout: "up 1 day\n"},
+			},
+			// Output is printed in order of completion.
+			want: `========== Start output on host: i-0002 (web-stg-1) ==========
+up 1 day
+
+========== End   output on host: i-0002 (web-stg-1) ==========
+========== Start output on host: i-0001 (web-prod-1) ==========
+up 3 days
+
+========== End   output on host: i-0001 (web-prod-1) ==========
+InstanceId	Name	Status	ExitCode
+i-0002	web-stg-1	Success	0
+i-0001	web-prod-1	Success	0
+`,
+			wantParams: "commands=[uptime]",
+		},
+		{
+			desc:    "failure",
+			options: SSMRunOptions{FilterTag: "Name:web", Command: "false", Timeout: 90 * time.Second, MaxConcurrency: "1", MaxErrors: "0"},
+			invocations: map[string]*fakeSSMInvocation{
+				"i-0001": {polls: 1, status: "Success"},
+				"i-0002": {polls: 3, status: "Failed", code: 1, stderr: "boom\n"},
+			},
+			want: `========== Start output on host: i-0001 (web-prod-1) ==========
+
+========== End   output on host: i-0001 (web-prod-1) ==========
+========== Start output on host: i-0002 (web-stg-1) ==========
+
+========== End   output on host: i-0002 (web-stg-1) ==========
+InstanceId	Name	Status	ExitCode
+i-0001	web-prod-1	Success	0
+i-0002	web-stg-1	Failed	1
+`,
+			wantStderr: "i-0002 (web-stg-1) | boom\n",
+			wantParams: "commands=[false] executionTimeout=[90] MaxConcurrency=1 MaxErrors=0",
+			wantErr:    "command failed on 1 of 2 instances",
+		},
+		{
+			desc:    "not run",
+			options: SSMRunOptions{FilterTag: "Name:web", Command: "uptime", MaxErrors: "0"},
+			invocations: map[string]*fakeSSMInvocation{
+				"i-0001": {polls: 1, status: "Failed", code: 1},
+				"i-0002": {polls: 1, status: "Cancelled", code: -1},
+			},
+			want: `========== Start output on host: i-0001 (web-prod-1) ==========
+
+========== End   output on host: i-0001 (web-prod-1) ==========
+========== Start output on host: i-0002 (web-stg-1) ==========
+
+========== End   output on host: i-0002 (web-stg-1) ==========
+InstanceId	Name	Status	ExitCode
+i-0001	web-prod-1	Failed	1
+i-0002	web-stg-1	Cancelled	
+`,
+			wantParams: "commands=[uptime] MaxErrors=0",
+			wantErr:    "command failed on 2 of 2 instances",
+		},
+		{
+			desc:    "dry run",
+			options: SSMRunOptions{FilterTag: "Name:web", Command: "uptime"},
+			dryRun:  true,
+			want:    "[dry-run] SSM.SendCommand: AWS-RunShellScript \"uptime\" on [i-0001, i-0002]\n",
+		},
+		{
+			desc:    "no such instance",
+			options: SSMRunOptions{FilterTag: "Name:app", Command: "uptime"},
+			wantErr: "no such instance: Name:app",
+		},
+		{
+			desc:    "no command",
+			options: SSMRunOptions{FilterTag: "Name:web"},
+			wantErr: "command is required",
+		},
+		{
+			desc:    "invalid timeout",
+			options: SSMRunOptions{FilterTag: "Name:web", Command: "uptime", Timeout: 500 * time.Millisecond},
+			wantErr: "--timeout must be at least 1s: 500ms",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeSSM()
+			f.pageSize = 1
+			f.invocations = tc.invocations
+			client, stdout := newTestClient(t, Services{EC2: newFakeEC2WithInstances(), SSM: f})
+			client.dryRun = tc.dryRun
+
+			err := client.runSSMCommand(context.Background(), tc.options)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got: %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+
+			if got := stdout.String(); got != tc.want {
+				t.Errorf("stdout = %q, want = %q", got, tc.want)
+			}
+			if got := stderrOf(client).String(); got != tc.wantStderr {
+				t.Errorf("stderr = %q, want = %q", got, tc.wantStderr)
+			}
+			if tc.wantParams == "" {
+				if len(f.commandInputs) != 0 {
+					t.Errorf("unexpected SendCommand: %s", f.commandInputs)
+				}
+				return
+			}
+
+			input := f.commandInputs[0]
+			params := []string{}
+			for _, k := range []string{"commands", "executionTimeout"} {
+				if v, ok := input.Parameters[k]; ok {
+					params = append(params, k+"="+formatPlanValues(v))
+				}
+			}
+			if input.MaxConcurrency != nil {
+				params = append(params, "MaxConcurrency="+*input.MaxConcurrency)
+			}
+			if input.MaxErrors != nil {
+				params = append(params, "MaxErrors="+*input.MaxErrors)
+			}
+			if got := strings.Join(params, " "); got != tc.wantParams {
+				t.Errorf("params = %s, want = %s", got, tc.wantParams)
+			}
+			if *input.DocumentName != "AWS-RunShellScript" || strings.Join(aws.StringValueSlice(input.InstanceIds), ",") != "i-0001,i-0002" {
+				t.Errorf("unexpected SendCommand input: %s", input)
+			}
+		})
+	}
+}
+
+func TestSSMRunCancel(t *testing.T) {
+	setTestSSMRunPollInterval(t)
+
+	f := newFakeSSM()
+	f.invocations = map[string]*fakeSSMInvocation{
+		"i-0001": {polls: 1, status: "Success"},
+		"i-0002": {polls: 1 << 30, status: "Success"},
+	}
+	client, _ := newTestClient(t, Services{EC2: newFakeEC2WithInstances(), SSM: f})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	err := client.runSSMCommand(ctx, SSMRunOptions{FilterTag: "Name:web", Command: "sleep 3600"})
+	if err == nil || err.Error() != "command cmd-1 cancelled" {
+		t.Fatalf("expected cancelled error, got: %v", err)
+	}
+	if strings.Join(f.cancelled, ",") != "cmd-1" {
+		t.Errorf("cancelled = %v", f.cancelled)
+	}
+}
+
+func TestSSMRunConfirm(t *testing.T) {
+	cases := []struct {
+		desc      string
+		instances int
+		stdin     string
+		want      string
+	}{
+		{
+			desc:      "few instances",
+			instances: 2,
+			stdin:     "n\n",
+			want:      `Are you sure want to run "uptime" on instances [i-0001, i-0002]?`,
+		},
+		{
+			desc:      "many instances",
+			instances: highImpactThreshold,
+			stdin:     "y\n",
+			want:      "Are you sure want to run \"uptime\" on 5 instances?\n  i-0001 web-1\n  i-0002 web-2\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ec2Fake := &fakeEC2{}
+			for i := 1; i <= tc.instances; i++ {
+				ec2Fake.instances = append(ec2Fake.instances, newFakeEC2Instance(fmt.Sprintf("i-%04d", i), "running", fmt.Sprintf("web-%d", i), "", ""))
+			}
+			f := newFakeSSM()
+			client, stdout := newTestClient(t, Services{EC2: ec2Fake, SSM: f})
+			client.guard.Yes = false
+			client.stdin = strings.NewReader(tc.stdin)
+
+			if err := client.runSSMCommand(context.Background(), SSMRunOptions{FilterTag: "Name:web", Command: "uptime"}); err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !strings.Contains(stdout.String(), tc.want) {
+				t.Errorf("stdout = %q, want = %q", stdout.String(), tc.want)
+			}
+			if len(f.commandInputs) != 0 {
+				t.Errorf("unexpected SendCommand: %s", f.commandInputs)
+			}
+		})
+	}
+}
+
+func TestSSMRunBatches(t *testing.T) {
+	setTestSSMRunPollInterval(t)
+
+	ec2Fake := &fakeEC2{}
+	f := newFakeSSM()
+	f.invocations = map[string]*fakeSSMInvocation{}
+	for i := 1; i <= ssmRunMaxInstances+2; i++ {
+		id := fmt.Sprintf("i-%04d", i)
+		ec2Fake.instances = append(ec2Fake.instances, newFakeEC2Instance(id, "running", fmt.Sprintf("web-%d", i), "", ""))
+		f.invocations[id] = &fakeSSMInvocation{polls: 1, status: "Success"}
+	}
+	// The last instance of the second batch fails.
+	f.invocations["i-0052"] = &fakeSSMInvocation{polls: 3, status: "Failed", code: 1}
+	client, stdout := newTestClient(t, Services{EC2: ec2Fake, SSM: f})
+
+	err := client.runSSMCommand(context.Background(), SSMRunOptions{FilterTag: "Name:web", Command: "uptime"})
+	if err == nil || err.Error() != "command failed on 1 of 52 instances" {
+		t.Fatalf("expected an error, got: %v", err)
+	}
+
+	sizes := []int{}
+	for _, input := range f.commandInputs {
+		sizes = append(sizes, len(input.InstanceIds))
+	}
+	if fmt.Sprint(sizes) != "[50 2]" {
+		t.Errorf("instances of SendCommand = %v, want = [50 2]", sizes)
+	}
+	if got := strings.Count(stdout.String(), "\tSuccess\t0\n"); got != 51 {
+		t.Errorf("succeeded = %d, want = 51\n%s", got, stdout)
+	}
+	if !strings.HasSuffix(stdout.String(), "i-0052\tweb-52\tFailed\t1\n") {
+		t.Errorf("unexpected summary:\n%s", stdout)
+	}
+}
@@ -0,0 +1,640 @@
+package myaws
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Message types of the data channel of Session Manager.
+const (
+	ssmMessageInput            = "input_stream_data"
+	ssmMessageOutput           = "output_stream_data"
+	ssmMessageAcknowledge      = "acknowledge"
+	ssmMessageChannelClosed    = "channel_closed"
+	ssmMessageStartPublication = "start_publication"
+	ssmMessagePausePublication = "pause_publication"
+)
+
+// Payload types of stream data messages.
+const (
+	ssmPayloadOutput            uint32 = 1
+	ssmPayloadError             uint32 = 2
+	ssmPayloadSize              uint32 = 3
+	ssmPayloadHandshakeRequest  uint32 = 5
+	ssmPayloadHandshakeResponse uint32 = 6
+	ssmPayloadHandshakeComplete uint32 = 7
+	ssmPayloadFlag              uint32 = 10
+	ssmPayloadStdErr            uint32 = 11
+)
+
+// Flags sent as a payload of the flag type in port sessions.
+const (
+	ssmFlagDisconnectToPort uint32 = 1
+)
+
+// Session types requested by the agent in the handshake.
+const (
+	ssmSessionTypeShell = "Standard_Stream"
+	ssmSessionTypePort  = "Port"
+)
+
+// Status of client actions in the handshake response.
+const (
+	ssmActionSuccess     = 1
+	ssmActionUnsupported = 3
+)
+
+// ssmClientVersion is a version of the client reported to the agent. It's a
+// version of session-manager-plugin which supports the same features.
+const ssmClientVersion = "1.2.0.0"
+
+// ssmStreamDataSize is a maximum size of a payload of input stream data.
+const ssmStreamDataSize = 1024
+
+// ssmResendInterval is an interval to resend input which has not been
+// acknowledged by the agent.
+const ssmResendInterval = 200 * time.Millisecond
+
+// ssmAgentMessage is a binary message of the data channel. All integers are
+// big endian, and the header has the following fields:
+//
+//	HeaderLength   uint32
+//	MessageType    32 bytes of a string padded with spaces
+//	SchemaVersion  uint32
+//	CreatedDate    uint64 (milliseconds since epoch)
+//	SequenceNumber int64
+//	Flags          uint64
+//	MessageId      16 bytes of UUID (the least significant half first)
+//	PayloadDigest  32 bytes of SHA-256
+//	PayloadType    uint32
+//	PayloadLength  uint32
+type ssmAgentMessage struct {
+	MessageType    string
+	SchemaVersion  uint32
+	CreatedDate    uint64
+	SequenceNumber int64
+	Flags          uint64
+	MessageID      string
+	PayloadType    uint32
+	Payload        []byte
+}
+
+// ssmAgentMessageHeaderLength is the length of the header except the
+// PayloadLength.
+const ssmAgentMessageHeaderLength = 116
+
+// marshal encodes the message to binary.
+func (m *ssmAgentMessage) marshal() ([]byte, error) {
+	id, err := parseUUID(m.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, ssmAgentMessageHeaderLength+4+len(m.Payload))
+	binary.BigEndian.PutUint32(b[0:], ssmAgentMessageHeaderLength)
+	copy(b[4:36], []byte(fmt.Sprintf("%-32s", m.MessageType)))
+	binary.BigEndian.PutUint32(b[36:], m.SchemaVersion)
+	binary.BigEndian.PutUint64(b[40:], m.CreatedDate)
+	binary.BigEndian.PutUint64(b[48:], uint64(m.SequenceNumber))
+	binary.BigEndian.PutUint64(b[56:], m.Flags)
+	copy(b[64:72], id[8:])
+	copy(b[72:80], id[:8])
+	digest := sha256.Sum256(m.Payload)
+	copy(b[80:112], digest[:])
+	binary.BigEndian.PutUint32(b[112:], m.PayloadType)
+	binary.BigEndian.PutUint32(b[116:], uint32(len(m.Payload)))
+	copy(b[120:], m.Payload)
+	return b, nil
+}
+
+// unmarshalSSMAgentMessage decodes a binary message and validates its payload
+// digest.
+func unmarshalSSMAgentMessage(b []byte) (*ssmAgentMessage, error) {
+	if len(b) < ssmAgentMessageHeaderLength+4 {
+		return nil, errors.Errorf("too short agent message: %d bytes", len(b))
+	}
+	headerLength := binary.BigEndian.Uint32(b[0:])
+	if int(headerLength)+4 > len(b) || headerLength < ssmAgentMessageHeaderLength {
+		return nil, errors.Errorf("invalid header length of agent message: %d", headerLength)
+	}
+
+	var id [16]byte
+	copy(id[8:], b[64:72])
+	copy(id[:8], b[72:80])
+	m := &ssmAgentMessage{
+		MessageType:    strings.TrimRight(string(b[4:36]), " \x00"),
+		SchemaVersion:  binary.BigEndian.Uint32(b[36:]),
+		CreatedDate:    binary.BigEndian.Uint64(b[40:]),
+		SequenceNumber: int64(binary.BigEndian.Uint64(b[48:])),
+		Flags:          binary.BigEndian.Uint64(b[56:]),
+		MessageID:      formatUUID(id),
+		PayloadType:    binary.BigEndian.Uint32(b[112:]),
+	}
+
+	payloadLength := binary.BigEndian.Uint32(b[headerLength:])
+	start := int(headerLength) + 4
+	if start+int(payloadLength) > len(b) {
+		return nil, errors.Errorf("invalid payload length of agent message: %d", payloadLength)
+	}
+	m.Payload = b[start : start+int(payloadLength)]
+	if digest := sha256.Sum256(m.Payload); !bytes.Equal(digest[:], b[80:112]) {
+		return nil, errors.New("payload digest of agent message mismatched")
+	}
+	return m, nil
+}
+
+// newUUID returns a new random UUID.
+func newUUID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return formatUUID(id)
+}
+
+func formatUUID(id [16]byte) string {
+	h := hex.EncodeToString(id[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	b, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil || len(b) != 16 {
+		return id, errors.Errorf("invalid UUID: %s", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// ssmDataChannel is a client of the data channel of Session Manager, which
+// streams input and output of a session over a websocket. Output of the
+// agent is written to the output in order, and input is sent by Write.
+type ssmDataChannel struct {
+	conn   *websocket.Conn
+	output io.Writer
+	stderr io.Writer
+
+	// writeMu serializes writes to the websocket, the sequence number of
+	// input and outgoing, which keeps input not acknowledged yet by its
+	// sequence number to resend it.
+	writeMu  sync.Mutex
+	seq      int64
+	outgoing map[int64]*ssmOutgoingInput
+
+	// expected is a sequence number of the next output, and buffered keeps
+	// output which arrived out of order.
+	expected int64
+	buffered map[int64]*ssmAgentMessage
+
+	// ready is closed when the handshake completes, and closed is closed when
+	// the channel is closed.
+	ready     chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// paused is true while the agent asks to pause sending input.
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+
+	// sessionType is a type of the session requested by the agent.
+	sessionType string
+}
+
+// ssmOutgoingInput is input sent to the agent.
+type ssmOutgoingInput struct {
+	message []byte
+	sentAt  time.Time
+}
+
+// openSSMDataChannel connects to the stream URL of a session and opens the
+// data channel with the token.
+func openSSMDataChannel(ctx context.Context, streamURL string, token string, output io.Writer, stderr io.Writer) (*ssmDataChannel, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, streamURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the data channel:")
+	}
+
+	open := map[string]string{
+		"MessageSchemaVersion": "1.0",
+		"RequestId":            newUUID(),
+		"TokenValue":           token,
+		"ClientId":             newUUID(),
+		"ClientVersion":        ssmClientVersion,
+	}
+	if err := conn.WriteJSON(open); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to open the data channel:")
+	}
+
+	c := &ssmDataChannel{
+		conn:     conn,
+		output:   output,
+		stderr:   stderr,
+		outgoing: map[int64]*ssmOutgoingInput{},
+		buffered: map[int64]*ssmAgentMessage{},
+		ready:    make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c, nil
+}
+
+// run reads messages until the channel is closed by the agent. It returns nil
+// if the channel is closed normally. Input not acknowledged is resent while
+// running.
+func (c *ssmDataChannel) run() error {
+	defer c.markClosed()
+	go c.resendLoop()
+	for {
+		messageType, b, err := c.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-c.closed:
+				return nil
+			default:
+			}
+			return errors.Wrap(err, "failed to read the data channel:")
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		m, err := unmarshalSSMAgentMessage(b)
+		if err != nil {
+			return err
+		}
+
+		switch m.MessageType {
+		case ssmMessageOutput:
+			if err := c.handleOutput(m); err != nil {
+				return err
+			}
+		case ssmMessageChannelClosed:
+			var payload struct{ Output string }
+			json.Unmarshal(m.Payload, &payload)
+			if payload.Output != "" {
+				fmt.Fprintln(c.stderr, payload.Output)
+			}
+			return nil
+		case ssmMessagePausePublication:
+			c.setPaused(true)
+		case ssmMessageStartPublication:
+			c.setPaused(false)
+		case ssmMessageAcknowledge:
+			c.handleAcknowledge(m)
+		}
+	}
+}
+
+// handleOutput acknowledges output and processes it in order of the sequence
+// number. Duplicated output is ignored.
+func (c *ssmDataChannel) handleOutput(m *ssmAgentMessage) error {
+	// The agent may close the connection right after the last output, so a
+	// failure of the acknowledgement is ignored. A broken connection is
+	// reported by reading it anyway.
+	c.acknowledge(m)
+	if m.SequenceNumber < c.expected {
+		return nil
+	}
+	c.buffered[m.SequenceNumber] = m
+
+	for {
+		next, ok := c.buffered[c.expected]
+		if !ok {
+			return nil
+		}
+		delete(c.buffered, c.expected)
+		c.expected++
+		if err := c.processOutput(next); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *ssmDataChannel) processOutput(m *ssmAgentMessage) error {
+	switch m.PayloadType {
+	case ssmPayloadOutput:
+		if _, err := c.output.Write(m.Payload); err != nil {
+			return errors.Wrap(err, "failed to write output:")
+		}
+	case ssmPayloadError, ssmPayloadStdErr:
+		c.stderr.Write(m.Payload)
+	case ssmPayloadHandshakeRequest:
+		return c.handshake(m.Payload)
+	case ssmPayloadHandshakeComplete:
+		var payload struct{ CustomerMessage string }
+		json.Unmarshal(m.Payload, &payload)
+		if payload.CustomerMessage != "" {
+			fmt.Fprintln(c.stderr, payload.CustomerMessage)
+		}
+		select {
+		case <-c.ready:
+		default:
+			close(c.ready)
+		}
+	}
+	return nil
+}
+
+// handshake responds to the handshake request of the agent. Only the session
+// type is supported, and other actions such as KMS encryption are reported as
+// unsupported.
+func (c *ssmDataChannel) handshake(b []byte) error {
+	var request struct {
+		AgentVersion           string
+		RequestedClientActions []struct {
+			ActionType       string
+			ActionParameters json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(b, &request); err != nil {
+		return errors.Wrap(err, "failed to parse handshake request:")
+	}
+
+	type processedAction struct {
+		ActionType   string
+		ActionStatus int
+		ActionResult interface{}
+		Error        string
+	}
+	response := struct {
+		ClientVersion          string
+		ProcessedClientActions []processedAction
+		Errors                 []string
+	}{ClientVersion: ssmClientVersion, ProcessedClientActions: []processedAction{}, Errors: []string{}}
+
+	for _, action := range request.RequestedClientActions {
+		switch action.ActionType {
+		case "SessionType":
+			var params struct{ SessionType string }
+			json.Unmarshal(action.ActionParameters, &params)
+			c.sessionType = params.SessionType
+			response.ProcessedClientActions = append(response.ProcessedClientActions, processedAction{ActionType: action.ActionType, ActionStatus: ssmActionSuccess})
+		default:
+			msg := fmt.Sprintf("%s is not supported", action.ActionType)
+			response.ProcessedClientActions = append(response.ProcessedClientActions, processedAction{ActionType: action.ActionType, ActionStatus: ssmActionUnsupported, Error: msg})
+			response.Errors = append(response.Errors, msg)
+		}
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode handshake response:")
+	}
+	return c.sendInput(ssmPayloadHandshakeResponse, payload)
+}
+
+// handleAcknowledge forgets input acknowledged by the agent.
+func (c *ssmDataChannel) handleAcknowledge(m *ssmAgentMessage) {
+	var payload struct{ AcknowledgedMessageSequenceNumber int64 }
+	if err := json.Unmarshal(m.Payload, &payload); err != nil {
+		return
+	}
+
+	c.writeMu.Lock()
+	delete(c.outgoing, payload.AcknowledgedMessageSequenceNumber)
+	c.writeMu.Unlock()
+}
+
+// resendLoop resends input not acknowledged until the channel is closed.
+func (c *ssmDataChannel) resendLoop() {
+	ticker := time.NewTicker(ssmResendInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.resend()
+		}
+	}
+}
+
+// resend resends input which has not been acknowledged for the resend
+// interval in order of the sequence number. A failure is ignored because a
+// broken connection is reported by reading it.
+func (c *ssmDataChannel) resend() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	seqs := make([]int64, 0, len(c.outgoing))
+	for seq := range c.outgoing {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	now := time.Now()
+	for _, seq := range seqs {
+		o := c.outgoing[seq]
+		if now.Sub(o.sentAt) < ssmResendInterval {
+			continue
+		}
+		if err := c.write(o.message); err != nil {
+			return
+		}
+		o.sentAt = now
+	}
+}
+
+// acknowledge sends an acknowledgement of a message.
+func (c *ssmDataChannel) acknowledge(m *ssmAgentMessage) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"AcknowledgedMessageType":           m.MessageType,
+		"AcknowledgedMessageId":             m.MessageID,
+		"AcknowledgedMessageSequenceNumber": m.SequenceNumber,
+		"IsSequentialMessage":               true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode acknowledgement:")
+	}
+
+	b, err := c.marshalMessage(&ssmAgentMessage{
+		MessageType: ssmMessageAcknowledge,
+		Flags:       3,
+		Payload:     payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.write(b)
+}
+
+// sendInput sends a payload as input stream data, and keeps it until the
+// agent acknowledges it.
+func (c *ssmDataChannel) sendInput(payloadType uint32, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	b, err := c.marshalMessage(&ssmAgentMessage{
+		MessageType:    ssmMessageInput,
+		SequenceNumber: c.seq,
+		PayloadType:    payloadType,
+		Payload:        payload,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.write(b); err != nil {
+		return err
+	}
+	c.outgoing[c.seq] = &ssmOutgoingInput{message: b, sentAt: time.Now()}
+	c.seq++
+	return nil
+}
+
+// marshalMessage fills the common fields of a message to send and encodes it.
+func (c *ssmDataChannel) marshalMessage(m *ssmAgentMessage) ([]byte, error) {
+	m.SchemaVersion = 1
+	m.CreatedDate = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	m.MessageID = newUUID()
+	return m.marshal()
+}
+
+// write writes an encoded message to the websocket. The caller should hold
+// writeMu.
+func (c *ssmDataChannel) write(b []byte) error {
+	if err := c.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return errors.Wrap(err, "failed to write the data channel:")
+	}
+	return nil
+}
+
+// waitReady waits until the handshake completes.
+func (c *ssmDataChannel) waitReady() error {
+	select {
+	case <-c.ready:
+		return nil
+	case <-c.closed:
+		return errors.New("data channel closed before handshake")
+	}
+}
+
+// Write sends input to the session after the handshake completes. It blocks
+// while the agent pauses publication.
+func (c *ssmDataChannel) Write(p []byte) (int, error) {
+	if err := c.waitReady(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		c.waitPublication()
+		size := len(p)
+		if size > ssmStreamDataSize {
+			size = ssmStreamDataSize
+		}
+		if err := c.sendInput(ssmPayloadOutput, p[:size]); err != nil {
+			return n, err
+		}
+		n += size
+		p = p[size:]
+	}
+	return n, nil
+}
+
+// resize sends a size of the terminal.
+func (c *ssmDataChannel) resize(width int, height int) error {
+	if err := c.waitReady(); err != nil {
+		return err
+	}
+	payload, _ := json.Marshal(map[string]int{"cols": width, "rows": height})
+	return c.sendInput(ssmPayloadSize, payload)
+}
+
+// disconnectPort tells the agent that the local connection of a port session
+// has been closed.
+func (c *ssmDataChannel) disconnectPort() error {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, ssmFlagDisconnectToPort)
+	return c.sendInput(ssmPayloadFlag, payload)
+}
+
+func (c *ssmDataChannel) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+func (c *ssmDataChannel) waitPublication() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.paused {
+		select {
+		case <-c.closed:
+			return
+		default:
+		}
+		c.cond.Wait()
+	}
+}
+
+func (c *ssmDataChannel) markClosed() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.setPaused(false)
+	})
+}
+
+// close closes the websocket.
+func (c *ssmDataChannel) close() {
+	c.markClosed()
+	c.writeMu.Lock()
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	c.conn.Close()
+}
+
+// startSSMSession starts a session on the instance and opens its data
+// channel. An empty document means a shell session. The caller should
+// terminate the session.
+func (client *Client) startSSMSession(ctx context.Context, instanceID string, document string, parameters map[string][]*string, output io.Writer) (string, *ssmDataChannel, error) {
+	input := &ssm.StartSessionInput{Target: aws.String(instanceID)}
+	if document != "" {
+		input.DocumentName = aws.String(document)
+		input.Parameters = parameters
+	}
+	res, err := client.SSM.StartSessionWithContext(ctx, input)
+	client.auditCall("SSM.StartSession", input, []string{instanceID}, err)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "StartSession failed:")
+	}
+
+	c, err := openSSMDataChannel(ctx, *res.StreamUrl, *res.TokenValue, output, client.stderr)
+	if err != nil {
+		client.terminateSSMSession(*res.SessionId)
+		return "", nil, err
+	}
+	return *res.SessionId, c, nil
+}
+
+// terminateSSMSession terminates a session. An error is only reported because
+// the session will be terminated by timeout anyway.
+func (client *Client) terminateSSMSession(sessionID string) {
+	input := &ssm.TerminateSessionInput{SessionId: aws.String(sessionID)}
+	_, err := client.SSM.TerminateSession(input)
+	client.auditCall("SSM.TerminateSession", input, []string{sessionID}, err)
+	if err != nil {
+		fmt.Fprintf(client.stderr, "TerminateSession failed: %s\n", err)
+	}
+}
@@ -0,0 +1,352 @@
+package myaws
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testSSMAgent is a stand-in of the SSM agent which serves the data channel
+// of Session Manager by websocket.
+//
+// In a shell session, it runs input lines as commands: "echo ARGS" prints
+// ARGS and "exit" closes the channel. In a port session, it echoes input back.
+// Input is acknowledged and processed in order of the sequence number, and
+// input out of order is ignored until it's resent.
+type testSSMAgent struct {
+	server      *httptest.Server
+	sessionType string
+	// shuffle sends output out of order and duplicated.
+	shuffle bool
+	// drop drops the first input of output payload without acknowledgement.
+	drop bool
+
+	mu        sync.Mutex
+	tokens    []string
+	responses []string
+	sizes     []string
+	flags     []uint32
+	acks      int
+	dropped   int
+}
+
+func newTestSSMAgent(t *testing.T, sessionType string) *testSSMAgent {
+	t.Helper()
+	a := &testSSMAgent{sessionType: sessionType}
+	a.server = httptest.NewServer(http.HandlerFunc(a.serve))
+	t.Cleanup(a.server.Close)
+	return a
+}
+
+// url returns a URL of the data channel.
+func (a *testSSMAgent) url() string {
+	return "ws" + strings.TrimPrefix(a.server.URL, "http")
+}
+
+// stats returns tokens, handshake responses, sizes and flags received so far.
+func (a *testSSMAgent) stats() ([]string, []string, []string, []uint32, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string{}, a.tokens...), append([]string{}, a.responses...),
+		append([]string{}, a.sizes...), append([]uint32{}, a.flags...), a.acks
+}
+
+func (a *testSSMAgent) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var open struct{ TokenValue string }
+	if err := conn.ReadJSON(&open); err != nil {
+		return
+	}
+	a.mu.Lock()
+	a.tokens = append(a.tokens, open.TokenValue)
+	a.mu.Unlock()
+
+	s := &testSSMAgentSession{conn: conn}
+	s.send(ssmMessageOutput, ssmPayloadHandshakeRequest, fmt.Sprintf(`{"AgentVersion":"3.1.0.0","RequestedClientActions":[`+
+		`{"ActionType":"KMSEncryption","ActionParameters":{"KMSKeyId":"alias/session"}},`+
+		`{"ActionType":"SessionType","ActionParameters":{"SessionType":%q,"Properties":null}}]}`, a.sessionType))
+
+	var line string
+	var expected int64
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		m, err := unmarshalSSMAgentMessage(b)
+		if err != nil {
+			return
+		}
+		if m.MessageType == ssmMessageAcknowledge {
+			a.mu.Lock()
+			a.acks++
+			a.mu.Unlock()
+			continue
+		}
+
+		a.mu.Lock()
+		if a.drop && a.dropped == 0 && m.PayloadType == ssmPayloadOutput {
+			a.dropped++
+			a.mu.Unlock()
+			continue
+		}
+		a.mu.Unlock()
+		if m.SequenceNumber > expected {
+			continue
+		}
+		s.acknowledge(m)
+		if m.SequenceNumber < expected {
+			continue
+		}
+		expected++
+
+		a.mu.Lock()
+		switch m.PayloadType {
+		case ssmPayloadHandshakeResponse:
+			a.responses = append(a.responses, string(m.Payload))
+		case ssmPayloadSize:
+			a.sizes = append(a.sizes, string(m.Payload))
+		case ssmPayloadFlag:
+			a.flags = append(a.flags, binary.BigEndian.Uint32(m.Payload))
+		}
+		a.mu.Unlock()
+
+		switch m.PayloadType {
+		case ssmPayloadHandshakeResponse:
+			s.send(ssmMessageOutput, ssmPayloadHandshakeComplete, `{"HandshakeTimeToComplete":1000000,"CustomerMessage":"Session is encrypted"}`)
+		case ssmPayloadFlag:
+			s.send(ssmMessageChannelClosed, 0, `{"MessageType":"channel_closed","Output":""}`)
+			return
+		case ssmPayloadOutput:
+			if a.sessionType == ssmSessionTypePort {
+				s.send(ssmMessageOutput, ssmPayloadOutput, string(m.Payload))
+				continue
+			}
+			line += string(m.Payload)
+			for strings.Contains(line, "\n") {
+				i := strings.Index(line, "\n")
+				command := line[:i]
+				line = line[i+1:]
+				if command == "exit" {
+					s.send(ssmMessageChannelClosed, 0, `{"MessageType":"channel_closed","Output":"Exiting session"}`)
+					return
+				}
+				s.output(strings.TrimPrefix(command, "echo ")+"\n", a.shuffle)
+			}
+		}
+	}
+}
+
+// testSSMAgentSession sends messages of the stand-in agent.
+type testSSMAgentSession struct {
+	conn *websocket.Conn
+	seq  int64
+}
+
+func (s *testSSMAgentSession) message(messageType string, payloadType uint32, payload string) []byte {
+	m := &ssmAgentMessage{
+		MessageType:    messageType,
+		SchemaVersion:  1,
+		SequenceNumber: s.seq,
+		MessageID:      newUUID(),
+		PayloadType:    payloadType,
+		Payload:        []byte(payload),
+	}
+	s.seq++
+	b, err := m.marshal()
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// acknowledge sends an acknowledgement of input, which has no sequence
+// number of output.
+func (s *testSSMAgentSession) acknowledge(m *ssmAgentMessage) {
+	ack := &ssmAgentMessage{
+		MessageType: ssmMessageAcknowledge,
+		MessageID:   newUUID(),
+		Payload:     []byte(fmt.Sprintf(`{"AcknowledgedMessageSequenceNumber":%d}`, m.SequenceNumber)),
+	}
+	b, err := ack.marshal()
+	if err != nil {
+		panic(err)
+	}
+	s.conn.WriteMessage(websocket.BinaryMessage, b)
+}
+
+func (s *testSSMAgentSession) send(messageType string, payloadType uint32, payload string) {
+	s.conn.WriteMessage(websocket.BinaryMessage, s.message(messageType, payloadType, payload))
+}
+
+// output sends output. If shuffle is true, it's split into two messages,
+// which are sent in reverse order and the first one is sent twice.
+func (s *testSSMAgentSession) output(payload string, shuffle bool) {
+	if !shuffle || len(payload) < 2 {
+		s.send(ssmMessageOutput, ssmPayloadOutput, payload)
+		return
+	}
+	first := s.message(ssmMessageOutput, ssmPayloadOutput, payload[:1])
+	second := s.message(ssmMessageOutput, ssmPayloadOutput, payload[1:])
+	for _, b := range [][]byte{second, first, first} {
+		s.conn.WriteMessage(websocket.BinaryMessage, b)
+	}
+}
+
+func TestSSMAgentMessage(t *testing.T) {
+	m := &ssmAgentMessage{
+		MessageType:    ssmMessageInput,
+		SchemaVersion:  1,
+		CreatedDate:    1600000000000,
+		SequenceNumber: 7,
+		Flags:          3,
+		MessageID:      "00112233-4455-6677-8899-aabbccddeeff",
+		PayloadType:    ssmPayloadOutput,
+		Payload:        []byte("ls\n"),
+	}
+	b, err := m.marshal()
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+
+	if len(b) != 123 {
+		t.Fatalf("len = %d, want = 123", len(b))
+	}
+	if got := binary.BigEndian.Uint32(b[0:]); got != 116 {
+		t.Errorf("HeaderLength = %d, want = 116", got)
+	}
+	if got := string(b[4:36]); got != "input_stream_data               " {
+		t.Errorf("MessageType = %q", got)
+	}
+	if got := binary.BigEndian.Uint64(b[48:]); got != 7 {
+		t.Errorf("SequenceNumber = %d, want = 7", got)
+	}
+	wantID := []byte{0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}
+	if got := b[64:80]; !bytes.Equal(got, wantID) {
+		t.Errorf("MessageId = %x, want = %x", got, wantID)
+	}
+	if got := binary.BigEndian.Uint32(b[116:]); got != 3 {
+		t.Errorf("PayloadLength = %d, want = 3", got)
+	}
+
+	got, err := unmarshalSSMAgentMessage(b)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", m) {
+		t.Errorf("got = %+v, want = %+v", got, m)
+	}
+
+	b[len(b)-1] = 'x'
+	if _, err := unmarshalSSMAgentMessage(b); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("expected digest error, got: %v", err)
+	}
+	if _, err := unmarshalSSMAgentMessage(b[:100]); err == nil {
+		t.Error("expected error for a short message")
+	}
+}
+
+func TestSSMDataChannelHandshake(t *testing.T) {
+	agent := newTestSSMAgent(t, ssmSessionTypeShell)
+	client, _ := newTestClient(t, Services{})
+	c, err := openSSMDataChannel(context.Background(), agent.url(), "token", &bytes.Buffer{}, client.stderr)
+	if err != nil {
+		t.Fatalf("openSSMDataChannel failed: %s", err)
+	}
+	defer c.close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.run() }()
+	if err := c.resize(120, 40); err != nil {
+		t.Fatalf("resize failed: %s", err)
+	}
+	c.Write([]byte("exit\n"))
+	if err := <-done; err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	tokens, responses, sizes, _, acks := agent.stats()
+	if len(tokens) != 1 || tokens[0] != "token" {
+		t.Errorf("tokens = %v", tokens)
+	}
+	if len(responses) != 1 {
+		t.Fatalf("responses = %v", responses)
+	}
+	var response struct {
+		ProcessedClientActions []struct {
+			ActionType   string
+			ActionStatus int
+		}
+	}
+	json.Unmarshal([]byte(responses[0]), &response)
+	statuses := fmt.Sprintf("%+v", response.ProcessedClientActions)
+	if statuses != "[{ActionType:KMSEncryption ActionStatus:3} {ActionType:SessionType ActionStatus:1}]" {
+		t.Errorf("processed actions = %s", statuses)
+	}
+	if c.sessionType != ssmSessionTypeShell {
+		t.Errorf("sessionType = %s", c.sessionType)
+	}
+	if len(sizes) != 1 || sizes[0] != `{"cols":120,"rows":40}` {
+		t.Errorf("sizes = %v", sizes)
+	}
+	// The handshake request and complete are acknowledged.
+	if acks != 2 {
+		t.Errorf("acks = %d, want = 2", acks)
+	}
+	if got := stderrOf(client).String(); got != "Session is encrypted\nExiting session\n" {
+		t.Errorf("stderr = %q", got)
+	}
+}
+
+func TestSSMDataChannelResend(t *testing.T) {
+	agent := newTestSSMAgent(t, ssmSessionTypeShell)
+	agent.drop = true
+	client, _ := newTestClient(t, Services{})
+	output := &bytes.Buffer{}
+	c, err := openSSMDataChannel(context.Background(), agent.url(), "token", output, client.stderr)
+	if err != nil {
+		t.Fatalf("openSSMDataChannel failed: %s", err)
+	}
+	defer c.close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.run() }()
+	// The first line is dropped, and the second one is ignored because it's
+	// out of order, so both of them are resent.
+	c.Write([]byte("echo hello\n"))
+	c.Write([]byte("exit\n"))
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run failed: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("input was not resent")
+	}
+
+	agent.mu.Lock()
+	dropped := agent.dropped
+	agent.mu.Unlock()
+	if dropped != 1 {
+		t.Errorf("dropped = %d, want = 1", dropped)
+	}
+	if got := output.String(); got != "hello\n" {
+		t.Errorf("output = %q, want = %q", got, "hello\n")
+	}
+}
@@ -0,0 +1,93 @@
+package myaws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+const (
+	// STSFormatEnv prints credentials as export lines of environment variables.
+	STSFormatEnv = "env"
+	// STSFormatJSON prints credentials in the credential_process format.
+	STSFormatJSON = "json"
+)
+
+// STSFormats is a list of available formats of credentials.
+var STSFormats = []string{STSFormatEnv, STSFormatJSON}
+
+func validateSTSFormat(format string) error {
+	for _, f := range STSFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown format: %s (must be one of %s)", format, strings.Join(STSFormats, ", "))
+}
+
+// credentialProcessOutput is the output format of credential_process in
+// the shared config.
+// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html
+type credentialProcessOutput struct {
+	Version         int        `json:"Version"`
+	AccessKeyID     string     `json:"AccessKeyId"`
+	SecretAccessKey string     `json:"SecretAccessKey"`
+	SessionToken    string     `json:"SessionToken,omitempty"`
+	Expiration      *time.Time `json:"Expiration,omitempty"`
+}
+
+// credentialsEnv returns a list of environment variables for credentials
+// such as AWS_ACCESS_KEY_ID=XXX.
+func credentialsEnv(v credentials.Value, region string) []string {
+	env := []string{
+		"AWS_ACCESS_KEY_ID=" + v.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + v.SecretAccessKey,
+	}
+	if v.SessionToken != "" {
+		env = append(env, "AWS_SESSION_TOKEN="+v.SessionToken)
+	}
+	if region != "" {
+		env = append(env, "AWS_REGION="+region, "AWS_DEFAULT_REGION="+region)
+	}
+	return env
+}
+
+// printCredentials prints credentials in a given format.
+// The expiration is nil if the credentials don't expire.
+func (client *Client) printCredentials(v credentials.Value, expiration *time.Time, format string) error {
+	switch format {
+	case STSFormatJSON:
+		b, err := json.MarshalIndent(credentialProcessOutput{
+			Version:         1,
+			AccessKeyID:     v.AccessKeyID,
+			SecretAccessKey: v.SecretAccessKey,
+			SessionToken:    v.SessionToken,
+			Expiration:      expiration,
+		}, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal credentials:")
+		}
+		fmt.Fprintln(client.stdout, string(b))
+	default:
+		for _, e := range credentialsEnv(v, aws.StringValue(client.config.Region)) {
+			fmt.Fprintf(client.stdout, "export %s\n", e)
+		}
+	}
+	return nil
+}
+
+// printSTSCredentials prints temporary credentials returned by STS.
+func (client *Client) printSTSCredentials(c *sts.Credentials, format string) error {
+	v := credentials.Value{
+		AccessKeyID:     aws.StringValue(c.AccessKeyId),
+		SecretAccessKey: aws.StringValue(c.SecretAccessKey),
+		SessionToken:    aws.StringValue(c.SessionToken),
+	}
+	return client.printCredentials(v, c.Expiration, format)
+}
@@ -0,0 +1,56 @@
+package myaws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// STSAssumeRoleOptions customize the behavior of the AssumeRole command.
+type STSAssumeRoleOptions struct {
+	RoleArn         string
+	RoleSessionName string
+	ExternalID      string
+	MFASerial       string
+	Duration        time.Duration
+	Format          string
+}
+
+// STSAssumeRole assumes a role and prints its temporary credentials.
+func (client *Client) STSAssumeRole(options STSAssumeRoleOptions) error {
+	if err := validateSTSFormat(options.Format); err != nil {
+		return err
+	}
+
+	sessionName := options.RoleSessionName
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("myaws-%d", time.Now().Unix())
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(options.RoleArn),
+		RoleSessionName: aws.String(sessionName),
+		DurationSeconds: aws.Int64(int64(options.Duration / time.Second)),
+	}
+	if options.ExternalID != "" {
+		input.ExternalId = aws.String(options.ExternalID)
+	}
+	if options.MFASerial != "" {
+		code, err := newMFATokenProvider(client.stdin, client.stderr)()
+		if err != nil {
+			return err
+		}
+		input.SerialNumber = aws.String(options.MFASerial)
+		input.TokenCode = aws.String(code)
+	}
+
+	response, err := client.STS.AssumeRole(input)
+	if err != nil {
+		return errors.Wrap(err, "AssumeRole failed:")
+	}
+
+	return client.printSTSCredentials(response.Credentials, options.Format)
+}
@@ -0,0 +1,65 @@
+package myaws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSTSAssumeRole(t *testing.T) {
+	f := newFakeSTS()
+	client, stdout := newTestClient(t, Services{STS: f})
+	client.stdin = strings.NewReader("123456\n")
+
+	err := client.STSAssumeRole(STSAssumeRoleOptions{
+		RoleArn:    "arn:aws:iam::123456789012:role/admin",
+		ExternalID: "ext",
+		MFASerial:  "arn:aws:iam::123456789012:mfa/alice",
+		Duration:   time.Hour,
+		Format:     STSFormatEnv,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	input := f.assumeRoleInput
+	if got := aws.StringValue(input.TokenCode); got != "123456" {
+		t.Errorf("TokenCode = %q, want 123456", got)
+	}
+	if got := aws.StringValue(input.ExternalId); got != "ext" {
+		t.Errorf("ExternalId = %q, want ext", got)
+	}
+	if got := aws.Int64Value(input.DurationSeconds); got != 3600 {
+		t.Errorf("DurationSeconds = %d, want 3600", got)
+	}
+	if got := aws.StringValue(input.RoleSessionName); !strings.HasPrefix(got, "myaws-") {
+		t.Errorf("RoleSessionName = %q, want a default name", got)
+	}
+
+	want := "export AWS_ACCESS_KEY_ID=ASIAEXAMPLE\n" +
+		"export AWS_SECRET_ACCESS_KEY=tempsecret\n" +
+		"export AWS_SESSION_TOKEN=token\n" +
+		"export AWS_REGION=ap-northeast-1\n" +
+		"export AWS_DEFAULT_REGION=ap-northeast-1\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := stderrOf(client).String(); got != "Enter MFA code: " {
+		t.Errorf("stderr = %q", got)
+	}
+}
+
+func TestSTSAssumeRoleInvalidFormat(t *testing.T) {
+	f := newFakeSTS()
+	client, _ := newTestClient(t, Services{STS: f})
+
+	err := client.STSAssumeRole(STSAssumeRoleOptions{RoleArn: "arn:aws:iam::123456789012:role/admin", Format: "xml"})
+	if err == nil || !strings.Contains(err.Error(), "unknown format: xml") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+	if f.assumeRoleInput != nil {
+		t.Error("AssumeRole should not be called")
+	}
+}
@@ -0,0 +1,30 @@
+package myaws
+
+import (
+	"github.com/pkg/errors"
+)
+
+// STSEnvOptions customize the behavior of the Env command.
+type STSEnvOptions struct {
+	Format string
+}
+
+// STSEnv prints the current credentials resolved from the profile, role and
+// environment, so that other tools can use them.
+func (client *Client) STSEnv(options STSEnvOptions) error {
+	if err := validateSTSFormat(options.Format); err != nil {
+		return err
+	}
+
+	v, err := client.config.Credentials.Get()
+	if err != nil {
+		return errors.Wrap(err, "failed to get credentials:")
+	}
+
+	expiration, err := client.config.Credentials.ExpiresAt()
+	if err != nil || expiration.IsZero() {
+		// The credentials don't expire.
+		return client.printCredentials(v, nil, options.Format)
+	}
+	return client.printCredentials(v, &expiration, options.Format)
+}
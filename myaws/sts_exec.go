@@ -0,0 +1,92 @@
+package myaws
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+// STSExecOptions customize the behavior of the Exec command.
+type STSExecOptions struct {
+	Command []string
+}
+
+// STSExec runs a command with the current credentials injected as
+// environment variables. It returns an ExitError with the exit status of the
+// command if the command fails.
+func (client *Client) STSExec(options STSExecOptions) error {
+	if len(options.Command) == 0 {
+		return errors.New("COMMAND is required")
+	}
+
+	v, err := client.config.Credentials.Get()
+	if err != nil {
+		return errors.Wrap(err, "failed to get credentials:")
+	}
+
+	cmd := exec.Command(options.Command[0], options.Command[1:]...)
+	cmd.Stdin = client.stdin
+	cmd.Stdout = client.stdout
+	cmd.Stderr = client.stderr
+	region := aws.StringValue(client.config.Region)
+	cmd.Env = append(filterCredentialsEnv(os.Environ(), region != ""), credentialsEnv(v, region)...)
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "failed to run %s:", options.Command[0])
+	}
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return execExitError(exitErr)
+		}
+		return errors.Wrapf(err, "failed to run %s:", options.Command[0])
+	}
+	return nil
+}
+
+// execExitError converts an exit error of a local command to an ExitError
+// with its exit status. As with shells, it exits with 128 plus the signal
+// number if the command is killed by a signal.
+func execExitError(err *exec.ExitError) error {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return &ExitError{Code: 128 + int(status.Signal()), Err: errors.Errorf("terminated by signal: %s", status.Signal())}
+	}
+	// The command has already printed the reason if any.
+	return &ExitError{Code: err.ExitCode()}
+}
+
+// filterCredentialsEnv removes environment variables which may conflict with
+// the injected credentials, such as AWS_PROFILE. If withRegion is true,
+// variables of the region are also removed.
+func filterCredentialsEnv(environ []string, withRegion bool) []string {
+	conflicts := []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"AWS_SECURITY_TOKEN",
+		"AWS_PROFILE",
+		"AWS_DEFAULT_PROFILE",
+	}
+	if withRegion {
+		conflicts = append(conflicts, "AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+
+	env := []string{}
+	for _, e := range environ {
+		name := strings.SplitN(e, "=", 2)[0]
+		conflict := false
+		for _, c := range conflicts {
+			if name == c {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			env = append(env, e)
+		}
+	}
+	return env
+}
@@ -0,0 +1,18 @@
+package myaws
+
+import (
+	"testing"
+)
+
+func TestSTSID(t *testing.T) {
+	client, stdout := newTestClient(t, Services{STS: newFakeSTS()})
+
+	if err := client.STSID(); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	want := "Account: 123456789012\nUserId: AIDAEXAMPLE\nArn: arn:aws:iam::123456789012:user/alice\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
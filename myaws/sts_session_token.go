@@ -0,0 +1,70 @@
+package myaws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// STSSessionTokenOptions customize the behavior of the SessionToken command.
+type STSSessionTokenOptions struct {
+	MFA       bool
+	MFASerial string
+	Duration  time.Duration
+	Format    string
+}
+
+// STSSessionToken gets a session token and prints its temporary credentials.
+// If MFA is true and no MFASerial is given, the MFA device of the current
+// IAM user is used.
+func (client *Client) STSSessionToken(options STSSessionTokenOptions) error {
+	if err := validateSTSFormat(options.Format); err != nil {
+		return err
+	}
+
+	input := &sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int64(int64(options.Duration / time.Second)),
+	}
+
+	if options.MFA || options.MFASerial != "" {
+		serial := options.MFASerial
+		if serial == "" {
+			var err error
+			serial, err = client.findMFASerial()
+			if err != nil {
+				return err
+			}
+		}
+
+		code, err := newMFATokenProvider(client.stdin, client.stderr)()
+		if err != nil {
+			return err
+		}
+		input.SerialNumber = aws.String(serial)
+		input.TokenCode = aws.String(code)
+	}
+
+	response, err := client.STS.GetSessionToken(input)
+	if err != nil {
+		return errors.Wrap(err, "GetSessionToken failed:")
+	}
+
+	return client.printSTSCredentials(response.Credentials, options.Format)
+}
+
+// findMFASerial returns a serial number of the MFA device of the current IAM user.
+func (client *Client) findMFASerial() (string, error) {
+	response, err := client.IAM.ListMFADevices(&iam.ListMFADevicesInput{})
+	if err != nil {
+		return "", errors.Wrap(err, "ListMFADevices failed:")
+	}
+
+	if len(response.MFADevices) == 0 {
+		return "", errors.New("MFA device not found. Use --mfa-serial to specify it")
+	}
+
+	return *response.MFADevices[0].SerialNumber, nil
+}
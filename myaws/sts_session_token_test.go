@@ -0,0 +1,48 @@
+package myaws
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestSTSSessionToken(t *testing.T) {
+	f := newFakeSTS()
+	i := &fakeIAM{mfaDevices: []*iam.MFADevice{{SerialNumber: aws.String("arn:aws:iam::123456789012:mfa/alice")}}}
+	client, stdout := newTestClient(t, Services{STS: f, IAM: i})
+	client.stdin = strings.NewReader("654321\n")
+
+	err := client.STSSessionToken(STSSessionTokenOptions{MFA: true, Duration: 12 * time.Hour, Format: STSFormatJSON})
+	if err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	input := f.getSessionTokenInput
+	if got := aws.StringValue(input.SerialNumber); got != "arn:aws:iam::123456789012:mfa/alice" {
+		t.Errorf("SerialNumber = %q", got)
+	}
+	if got := aws.StringValue(input.TokenCode); got != "654321" {
+		t.Errorf("TokenCode = %q", got)
+	}
+
+	var output credentialProcessOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("failed to parse output: %s\n%s", err, stdout)
+	}
+	if output.Version != 1 || output.AccessKeyID != "ASIAEXAMPLE" || output.Expiration == nil || !output.Expiration.Equal(testTime.Add(time.Hour)) {
+		t.Errorf("unexpected output: %+v", output)
+	}
+}
+
+func TestSTSSessionTokenNoMFADevice(t *testing.T) {
+	client, _ := newTestClient(t, Services{STS: newFakeSTS(), IAM: &fakeIAM{}})
+
+	err := client.STSSessionToken(STSSessionTokenOptions{MFA: true, Format: STSFormatEnv})
+	if err == nil || !strings.Contains(err.Error(), "MFA device not found") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package myaws
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+)
+
+// TargetOptions customize querying multiple profiles and regions.
+type TargetOptions struct {
+	// Profiles is a list of AWS profiles to query.
+	// If empty, the profile of the client is used.
+	Profiles []string
+	// Regions is a list of AWS regions to query. "all" means all regions
+	// enabled for the account. If empty, the region of the client is used.
+	Regions []string
+	// Parallel is the maximum number of targets queried concurrently.
+	Parallel int
+}
+
+// Target is a pair of a profile and a region to query.
+type Target struct {
+	Profile string
+	Region  string
+}
+
+// String returns a human readable representation of the target.
+func (t Target) String() string {
+	return t.profileName() + "/" + t.Region
+}
+
+func (t Target) profileName() string {
+	if t.Profile == "" {
+		return "default"
+	}
+	return t.Profile
+}
+
+const (
+	// targetFieldProfile is a field name of the profile of a target.
+	targetFieldProfile = "Profile"
+	// targetFieldRegion is a field name of the region of a target.
+	targetFieldRegion = "Region"
+)
+
+// enabled returns true if multiple profiles or regions are requested.
+func (options TargetOptions) enabled() bool {
+	return len(options.Profiles) > 0 || len(options.Regions) > 0
+}
+
+// outputFields prepends Profile and Region fields if enabled.
+func (options TargetOptions) outputFields(fields []string) []string {
+	if !options.enabled() {
+		return fields
+	}
+	return append([]string{targetFieldProfile, targetFieldRegion}, fields...)
+}
+
+// formatRow prepends the profile and region of the target to a row if enabled.
+func (options TargetOptions) formatRow(target Target, row []string) []string {
+	if !options.enabled() {
+		return row
+	}
+	return append([]string{target.profileName(), target.Region}, row...)
+}
+
+// targetResource is a resource with the target where it was found.
+type targetResource struct {
+	target   Target
+	resource interface{}
+}
+
+// targetsError is returned when some of the targets failed.
+type targetsError struct {
+	failed int
+	total  int
+}
+
+func (e *targetsError) Error() string {
+	return fmt.Sprintf("%d of %d targets failed", e.failed, e.total)
+}
+
+// isTargetsError returns true if the error is reported for each target.
+// The found resources are still available, so we should print them.
+func isTargetsError(err error) bool {
+	_, ok := err.(*targetsError)
+	return ok
+}
+
+// target returns the profile and region of the client.
+func (client *Client) target() Target {
+	return Target{
+		Profile: getenv(client.profile, "AWS_DEFAULT_PROFILE"),
+		Region:  aws.StringValue(client.config.Region),
+	}
+}
+
+// unwrapTargetResource returns the resource and the target where it was
+// found. If the resource is not found by fanOut, the target of the client is
+// returned.
+func (client *Client) unwrapTargetResource(resource interface{}) (interface{}, Target) {
+	if r, ok := resource.(*targetResource); ok {
+		return r.resource, r.target
+	}
+	return resource, client.target()
+}
+
+// newTargetClient returns a new client for the target, which shares the other
+// settings with the client.
+func (client *Client) newTargetClient(target Target) (*Client, error) {
+	return newTargetClientFunc(client, target)
+}
+
+// newTargetClientFunc creates a client for the target with real AWS clients.
+// It's a variable so that tests can inject fakes.
+var newTargetClientFunc = func(client *Client, target Target) (*Client, error) {
+	return NewClient(client.stdin, client.stdout, client.stderr, target.Profile, target.Region, client.timezone, client.humanize, client.debug, client.output, client.credential, client.endpoint, client.dryRun, client.guard, client.audit)
+}
+
+// newProfileClient returns a new client for the profile in the same region.
+// An empty profile means the client itself.
+func (client *Client) newProfileClient(profile string) (*Client, error) {
+	if profile == "" {
+		return client, nil
+	}
+	return client.newTargetClient(Target{Profile: profile, Region: aws.StringValue(client.config.Region)})
+}
+
+// resolveTargets returns a list of targets, which is a product of profiles
+// and regions.
+func (client *Client) resolveTargets(options TargetOptions) ([]Target, error) {
+	profiles := options.Profiles
+	if len(profiles) == 0 {
+		profiles = []string{client.profile}
+	}
+
+	targets := []Target{}
+	for _, profile := range profiles {
+		regions := options.Regions
+		if len(regions) == 0 {
+			regions = []string{aws.StringValue(client.config.Region)}
+		}
+
+		for _, region := range regions {
+			if region != "all" {
+				targets = append(targets, Target{Profile: profile, Region: region})
+				continue
+			}
+
+			enabled, err := client.describeEnabledRegions(profile)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range enabled {
+				targets = append(targets, Target{Profile: profile, Region: r})
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// describeEnabledRegions returns a sorted list of regions enabled for the
+// account of the profile.
+func (client *Client) describeEnabledRegions(profile string) ([]string, error) {
+	c, err := client.newTargetClient(Target{Profile: profile, Region: aws.StringValue(client.config.Region)})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.EC2.DescribeRegionsWithContext(aws.BackgroundContext(), &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "DescribeRegions failed for profile %s:", Target{Profile: profile}.profileName())
+	}
+
+	regions := []string{}
+	for _, r := range response.Regions {
+		regions = append(regions, *r.RegionName)
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// fanOut calls find for each target concurrently with bounded parallelism and
+// returns the found resources in the order of targets. Each resource is
+// wrapped with the target where it was found, so use unwrapTargetResource to
+// get it.
+// If no profiles and regions are requested, it simply calls find with the
+// client itself.
+// An error of each target is reported to stderr and doesn't abort the others.
+// If some targets failed, it returns the found resources with a targetsError.
+func (client *Client) fanOut(options TargetOptions, find func(c *Client) ([]interface{}, error)) ([]interface{}, error) {
+	if !options.enabled() {
+		return find(client)
+	}
+
+	if options.Parallel <= 0 {
+		return nil, errors.Errorf("--parallel must be a positive number: %d", options.Parallel)
+	}
+
+	targets, err := client.resolveTargets(options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]interface{}, len(targets))
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, options.Parallel)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			c, err := client.newTargetClient(target)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = find(c)
+		}(i, target)
+	}
+	wg.Wait()
+
+	resources := []interface{}{}
+	failed := 0
+	for i, target := range targets {
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintf(client.stderr, "%s: %s\n", target, errs[i])
+			continue
+		}
+		for _, resource := range results[i] {
+			resources = append(resources, &targetResource{target: target, resource: resource})
+		}
+	}
+
+	if failed > 0 {
+		return resources, &targetsError{failed: failed, total: len(targets)}
+	}
+	return resources, nil
+}
@@ -0,0 +1,85 @@
+package myaws
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setTestTargetServices makes clients for targets use services returned by fn
+// instead of real AWS clients.
+func setTestTargetServices(t *testing.T, fn func(target Target) Services) {
+	t.Helper()
+	orig := newTargetClientFunc
+	newTargetClientFunc = func(client *Client, target Target) (*Client, error) {
+		config := client.config.Copy().WithRegion(target.Region)
+		c, err := NewClientWithServices(client.stdin, client.stdout, client.stderr, config, client.timezone, client.humanize, client.output, fn(target))
+		if err != nil {
+			return nil, err
+		}
+		c.profile = target.Profile
+		c.waiterOptions = client.waiterOptions
+		c.dryRun = client.dryRun
+		c.guard = client.guard
+		c.audit = client.audit
+		return c, nil
+	}
+	t.Cleanup(func() { newTargetClientFunc = orig })
+}
+
+func TestResolveTargets(t *testing.T) {
+	client, _ := newTestClient(t, Services{EC2: &fakeEC2{regions: []string{"us-east-1", "ap-northeast-1"}}})
+	setTestTargetServices(t, func(target Target) Services {
+		return Services{EC2: &fakeEC2{regions: []string{"us-east-1", "ap-northeast-1"}}}
+	})
+
+	cases := []struct {
+		desc    string
+		options TargetOptions
+		want    []Target
+	}{
+		{
+			desc:    "default",
+			options: TargetOptions{},
+			want:    []Target{{Profile: "", Region: testRegion}},
+		},
+		{
+			desc:    "profiles",
+			options: TargetOptions{Profiles: []string{"dev", "prd"}},
+			want:    []Target{{Profile: "dev", Region: testRegion}, {Profile: "prd", Region: testRegion}},
+		},
+		{
+			desc:    "all regions",
+			options: TargetOptions{Profiles: []string{"dev"}, Regions: []string{"us-west-2", "all"}},
+			want: []Target{
+				{Profile: "dev", Region: "us-west-2"},
+				{Profile: "dev", Region: "ap-northeast-1"},
+				{Profile: "dev", Region: "us-east-1"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := client.resolveTargets(tc.options)
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFanOutInvalidParallel(t *testing.T) {
+	client, _ := newTestClient(t, Services{})
+
+	_, err := client.fanOut(TargetOptions{Regions: []string{"us-east-1"}}, func(c *Client) ([]interface{}, error) {
+		t.Fatal("find should not be called")
+		return nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "--parallel must be a positive number") {
+		t.Fatalf("expected an error, but got: %v", err)
+	}
+}